@@ -32,6 +32,8 @@ export function createContract(data: {
   content: string
   contractType: string
   creatorCitizenID: string
+  buyerCitizenID: string
+  sellerCitizenID: string
 }) {
   return request({
     url: '/contracts/createContract',
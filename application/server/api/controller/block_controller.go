@@ -4,10 +4,15 @@ import (
 	blockDto "grets_server/dto/block_dto"
 	"grets_server/pkg/utils"
 	"grets_server/service"
+	"io"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// 区块推送心跳间隔，防止代理断开空闲连接
+const _StreamHeartbeatInterval = 30 * time.Second
+
 // GlobalBlockController 全局区块控制器实例
 var GlobalBlockController *BlockController
 
@@ -59,6 +64,169 @@ func (c *BlockController) QueryBlockList(ctx *gin.Context) {
 	utils.ResponseSuccess(ctx, "获取区块成功", block)
 }
 
+// QueryChainTransactionList 按创建者、链码函数、时间等条件查询链上交易
+func (c *BlockController) QueryChainTransactionList(ctx *gin.Context) {
+	var queryChainTransactionDTO blockDto.QueryChainTransactionDTO
+	if err := ctx.ShouldBindJSON(&queryChainTransactionDTO); err != nil {
+		utils.ResponseBadRequest(ctx, err.Error())
+		return
+	}
+
+	result, err := c.blockService.QueryChainTransactionList(queryChainTransactionDTO)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+	utils.ResponseSuccess(ctx, "获取链上交易列表成功", result)
+}
+
+// GetTransactionByID 根据交易ID查询链上交易
+func (c *BlockController) GetTransactionByID(ctx *gin.Context) {
+	txID := ctx.Param("txID")
+	if txID == "" {
+		utils.ResponseBadRequest(ctx, "交易ID不能为空")
+		return
+	}
+
+	transaction, err := c.blockService.GetTransactionByID(txID)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+	utils.ResponseSuccess(ctx, "获取链上交易成功", transaction)
+}
+
+// QueryBlockInvocationList 解析区块中各交易的链码调用、背书组织和读写集
+func (c *BlockController) QueryBlockInvocationList(ctx *gin.Context) {
+	var queryBlockTransactionDTO blockDto.QueryBlockTransactionDTO
+	if err := ctx.ShouldBindJSON(&queryBlockTransactionDTO); err != nil {
+		utils.ResponseBadRequest(ctx, err.Error())
+		return
+	}
+	if queryBlockTransactionDTO.ChannelName == "" {
+		utils.ResponseBadRequest(ctx, "通道名称不能为空")
+		return
+	}
+
+	invocationList, err := c.blockService.QueryBlockInvocationList(queryBlockTransactionDTO)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+	utils.ResponseSuccess(ctx, "解析区块交易成功", gin.H{
+		"invocationList": invocationList,
+	})
+}
+
+// GetTransactionInvocation 根据交易ID解析链码调用、背书组织和读写集
+func (c *BlockController) GetTransactionInvocation(ctx *gin.Context) {
+	txID := ctx.Param("txID")
+	if txID == "" {
+		utils.ResponseBadRequest(ctx, "交易ID不能为空")
+		return
+	}
+
+	invocationList, err := c.blockService.GetTransactionInvocation(txID)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+	utils.ResponseSuccess(ctx, "解析链上交易成功", gin.H{
+		"invocationList": invocationList,
+	})
+}
+
+// GetTransactionInclusionProof 根据交易ID获取可离线校验的交易包含证明
+func (c *BlockController) GetTransactionInclusionProof(ctx *gin.Context) {
+	txID := ctx.Param("txID")
+	if txID == "" {
+		utils.ResponseBadRequest(ctx, "交易ID不能为空")
+		return
+	}
+
+	proof, err := c.blockService.GetTransactionInclusionProof(txID)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+	utils.ResponseSuccess(ctx, "获取交易包含证明成功", proof)
+}
+
+// GetChainTxStatus 查询链上交易提交状态，可通过waitSeconds等待交易确认
+func (c *BlockController) GetChainTxStatus(ctx *gin.Context) {
+	txID := ctx.Param("txID")
+	if txID == "" {
+		utils.ResponseBadRequest(ctx, "交易ID不能为空")
+		return
+	}
+	var query blockDto.QueryChainTxStatusDTO
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		utils.ResponseBadRequest(ctx, err.Error())
+		return
+	}
+
+	status, err := c.blockService.GetChainTxStatus(txID, query.WaitSeconds)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+	utils.ResponseSuccess(ctx, "查询交易状态成功", status)
+}
+
+// StreamBlocks 通过Server-Sent Events推送新区块和新交易，可按通道、省份、链码函数过滤
+// 订阅者来不及接收时丢弃其事件，并通过lagged事件告知丢弃数量，客户端可重新查询区块列表补齐
+func (c *BlockController) StreamBlocks(ctx *gin.Context) {
+	// EventSource无法设置请求头，从URL参数获取token
+	token := ctx.Query("token")
+	if token == "" {
+		utils.ResponseUnauthorized(ctx, "未提供认证令牌")
+		return
+	}
+	if _, err := utils.ParseToken(token); err != nil {
+		utils.ResponseUnauthorized(ctx, "认证令牌无效，请重新登录")
+		return
+	}
+
+	var streamBlockDTO blockDto.StreamBlockDTO
+	if err := ctx.ShouldBindQuery(&streamBlockDTO); err != nil {
+		utils.ResponseBadRequest(ctx, err.Error())
+		return
+	}
+
+	subscription, err := c.blockService.SubscribeBlockFeed(streamBlockDTO)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+	defer c.blockService.UnsubscribeBlockFeed(subscription)
+
+	heartbeat := time.NewTicker(_StreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return false
+			}
+			if dropped := subscription.TakeDropped(); dropped > 0 {
+				ctx.SSEvent("lagged", gin.H{"dropped": dropped})
+			}
+			ctx.SSEvent(event.Type, event)
+		case <-heartbeat.C:
+			if dropped := subscription.TakeDropped(); dropped > 0 {
+				ctx.SSEvent("lagged", gin.H{"dropped": dropped})
+			}
+			ctx.SSEvent("ping", time.Now().Unix())
+		}
+		return true
+	})
+}
+
 // QueryBlockList 查询区块列表
 func QueryBlockList(ctx *gin.Context) {
 	GlobalBlockController.QueryBlockList(ctx)
@@ -67,3 +235,31 @@ func QueryBlockList(ctx *gin.Context) {
 func QueryBlockTransactionList(ctx *gin.Context) {
 	GlobalBlockController.QueryBlockTransactionList(ctx)
 }
+
+func QueryChainTransactionList(ctx *gin.Context) {
+	GlobalBlockController.QueryChainTransactionList(ctx)
+}
+
+func GetTransactionByID(ctx *gin.Context) {
+	GlobalBlockController.GetTransactionByID(ctx)
+}
+
+func QueryBlockInvocationList(ctx *gin.Context) {
+	GlobalBlockController.QueryBlockInvocationList(ctx)
+}
+
+func GetTransactionInvocation(ctx *gin.Context) {
+	GlobalBlockController.GetTransactionInvocation(ctx)
+}
+
+func GetTransactionInclusionProof(ctx *gin.Context) {
+	GlobalBlockController.GetTransactionInclusionProof(ctx)
+}
+
+func StreamBlocks(ctx *gin.Context) {
+	GlobalBlockController.StreamBlocks(ctx)
+}
+
+func GetChainTxStatus(ctx *gin.Context) {
+	GlobalBlockController.GetChainTxStatus(ctx)
+}
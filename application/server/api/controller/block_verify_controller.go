@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"grets_server/constants"
+	blockDto "grets_server/dto/block_dto"
+	"grets_server/pkg/utils"
+	"grets_server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BlockVerifyController 区块哈希链校验控制器结构体
+type BlockVerifyController struct {
+	blockVerifyService service.BlockVerifyService
+}
+
+// NewBlockVerifyController 创建区块哈希链校验控制器实例
+func NewBlockVerifyController() *BlockVerifyController {
+	return &BlockVerifyController{
+		blockVerifyService: service.GlobalBlockVerifyService,
+	}
+}
+
+// RunBlockVerify 手动校验区块哈希链
+func (c *BlockVerifyController) RunBlockVerify(ctx *gin.Context) {
+	var req blockDto.RunBlockVerifyDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ResponseBadRequest(ctx, "无效的请求参数")
+		return
+	}
+
+	resultList, err := c.blockVerifyService.Run(constants.BlockVerifyTriggerManual, req.ChannelName)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "区块哈希链校验完成", gin.H{
+		"resultList": resultList,
+	})
+}
+
+// GetBlockVerifyResultList 查询各通道最近一次的校验结果
+func (c *BlockVerifyController) GetBlockVerifyResultList(ctx *gin.Context) {
+	utils.ResponseSuccess(ctx, "查询区块哈希链校验结果成功", gin.H{
+		"resultList": c.blockVerifyService.GetLatestResultList(),
+	})
+}
+
+// GlobalBlockVerifyController 全局区块哈希链校验控制器实例
+var GlobalBlockVerifyController *BlockVerifyController
+
+// InitBlockVerifyController 初始化区块哈希链校验控制器
+func InitBlockVerifyController() {
+	GlobalBlockVerifyController = NewBlockVerifyController()
+}
+
+func RunBlockVerify(c *gin.Context) {
+	GlobalBlockVerifyController.RunBlockVerify(c)
+}
+
+func GetBlockVerifyResultList(c *gin.Context) {
+	GlobalBlockVerifyController.GetBlockVerifyResultList(c)
+}
@@ -100,7 +100,7 @@ func (ctrl *ContractController) SignContract(c *gin.Context) {
 	}
 
 	// 调用服务签署合同
-	if err := ctrl.contractService.SignContract(id, &req); err != nil {
+	if err := ctrl.contractService.SignContract(id, c.GetString("citizenID"), c.GetString("organization"), &req); err != nil {
 		utils.ResponseInternalServerError(c, err.Error())
 		return
 	}
@@ -109,6 +109,33 @@ func (ctrl *ContractController) SignContract(c *gin.Context) {
 	utils.ResponseSuccess(c, "合同签署成功", nil)
 }
 
+// SignContractOffline 签署人用DID私钥签名链上交易签署合同
+func (ctrl *ContractController) SignContractOffline(c *gin.Context) {
+	// 获取路径参数
+	id := c.Param("id")
+	if id == "" {
+		utils.ResponseBadRequest(c, "合同ID不能为空")
+		return
+	}
+
+	// 解析请求参数
+	var req contractDto.SignContractDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseBadRequest(c, "无效的请求参数")
+		return
+	}
+
+	// 调用服务创建签署合同提案
+	session, err := ctrl.contractService.SignContractOffline(id, c.GetString("citizenID"), c.GetString("organization"), &req)
+	if err != nil {
+		utils.ResponseInternalServerError(c, err.Error())
+		return
+	}
+
+	// 返回待签名的提案
+	utils.ResponseSuccess(c, "创建签署合同提案成功", session)
+}
+
 // AuditContract 审核合同
 func (ctrl *ContractController) AuditContract(c *gin.Context) {
 	// 获取路径参数
@@ -126,13 +153,16 @@ func (ctrl *ContractController) AuditContract(c *gin.Context) {
 	}
 
 	// 调用服务审核合同
-	if err := ctrl.contractService.AuditContract(id, &req); err != nil {
+	txID, err := ctrl.contractService.AuditContract(id, c.GetString("organization"), &req)
+	if err != nil {
 		utils.ResponseInternalServerError(c, err.Error())
 		return
 	}
 
-	// 返回成功结果
-	utils.ResponseSuccess(c, "合同审核完成", nil)
+	// 返回链上交易ID，上链状态可通过交易ID查询
+	utils.ResponseSuccess(c, "合同审核已提交", gin.H{
+		"txID": txID,
+	})
 }
 
 // GetContractByUUID 根据UUID获取合同信息
@@ -183,13 +213,16 @@ func (ctrl *ContractController) BindTransaction(c *gin.Context) {
 	}
 
 	// 调用服务绑定交易
-	if err := ctrl.contractService.BindTransaction(&req); err != nil {
+	txID, err := ctrl.contractService.BindTransaction(&req)
+	if err != nil {
 		utils.ResponseInternalServerError(c, err.Error())
 		return
 	}
 
-	// 返回成功结果
-	utils.ResponseSuccess(c, "交易绑定成功", nil)
+	// 返回链上交易ID，上链状态可通过交易ID查询
+	utils.ResponseSuccess(c, "交易绑定已提交", gin.H{
+		"txID": txID,
+	})
 }
 
 // 创建全局合同控制器实例
@@ -217,6 +250,10 @@ func SignContract(c *gin.Context) {
 	GlobalContractController.SignContract(c)
 }
 
+func SignContractOffline(c *gin.Context) {
+	GlobalContractController.SignContractOffline(c)
+}
+
 func AuditContract(c *gin.Context) {
 	GlobalContractController.AuditContract(c)
 }
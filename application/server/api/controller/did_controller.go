@@ -213,6 +213,39 @@ func (c *DIDController) GetDIDByUser(ctx *gin.Context) {
 	utils.ResponseSuccess(ctx, "获取用户DID成功", gin.H{"did": did})
 }
 
+// PrepareOfflineEnroll 为当前用户的DID公钥生成离线签名证书请求
+func (c *DIDController) PrepareOfflineEnroll(ctx *gin.Context) {
+	session, err := c.didService.PrepareOfflineEnroll(ctx.GetString("citizenID"), ctx.GetString("organization"))
+	if err != nil {
+		utils.ResponseError(ctx, constants.ServiceError, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "生成证书请求成功", session)
+}
+
+// SubmitOfflineSignature 提交离线签名会话当前阶段的签名
+func (c *DIDController) SubmitOfflineSignature(ctx *gin.Context) {
+	sessionID := ctx.Param("sessionID")
+	if sessionID == "" {
+		utils.ResponseError(ctx, constants.ParamError, "会话ID不能为空")
+		return
+	}
+	var req didDto.OfflineSignatureRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(ctx, constants.ParamError, "参数错误: "+err.Error())
+		return
+	}
+
+	session, err := c.didService.SubmitOfflineSignature(sessionID, ctx.GetString("citizenID"), ctx.GetString("organization"), req.Signature)
+	if err != nil {
+		utils.ResponseError(ctx, constants.ServiceError, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "提交签名成功", session)
+}
+
 // 创建全局DID控制器实例
 var GlobalDIDController *DIDController
 
@@ -265,3 +298,11 @@ func DIDRegister(c *gin.Context) {
 func GetDIDByUser(c *gin.Context) {
 	GlobalDIDController.GetDIDByUser(c)
 }
+
+func PrepareOfflineEnroll(c *gin.Context) {
+	GlobalDIDController.PrepareOfflineEnroll(c)
+}
+
+func SubmitOfflineSignature(c *gin.Context) {
+	GlobalDIDController.SubmitOfflineSignature(c)
+}
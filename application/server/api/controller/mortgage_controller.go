@@ -0,0 +1,145 @@
+package controller
+
+import (
+	mortgageDto "grets_server/dto/mortgage_dto"
+	"grets_server/pkg/utils"
+	"grets_server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MortgageController 抵押控制器结构体
+type MortgageController struct {
+	mortgageService service.MortgageService
+}
+
+// NewMortgageController 创建抵押控制器实例
+func NewMortgageController() *MortgageController {
+	return &MortgageController{
+		mortgageService: service.NewMortgageService(),
+	}
+}
+
+// CreateMortgage 创建抵押申请
+func (c *MortgageController) CreateMortgage(ctx *gin.Context) {
+	var req mortgageDto.CreateMortgageDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ResponseBadRequest(ctx, "无效的请求参数")
+		return
+	}
+
+	mortgageUUID, txID, err := c.mortgageService.CreateMortgage(&req)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "抵押申请已提交", gin.H{
+		"mortgageUUID": mortgageUUID,
+		"txID":         txID,
+	})
+}
+
+// ApproveMortgage 批准抵押
+func (c *MortgageController) ApproveMortgage(ctx *gin.Context) {
+	var req mortgageDto.MortgageActionDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ResponseBadRequest(ctx, "无效的请求参数")
+		return
+	}
+
+	txID, err := c.mortgageService.ApproveMortgage(&req)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "抵押批准已提交", gin.H{
+		"txID": txID,
+	})
+}
+
+// AssumeMortgage 同意买方承接抵押
+func (c *MortgageController) AssumeMortgage(ctx *gin.Context) {
+	var req mortgageDto.AssumeMortgageDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ResponseBadRequest(ctx, "无效的请求参数")
+		return
+	}
+
+	txID, err := c.mortgageService.AssumeMortgage(&req)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "抵押承接已提交", gin.H{
+		"txID": txID,
+	})
+}
+
+// ReleaseMortgage 解除抵押
+func (c *MortgageController) ReleaseMortgage(ctx *gin.Context) {
+	var req mortgageDto.MortgageActionDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ResponseBadRequest(ctx, "无效的请求参数")
+		return
+	}
+
+	txID, err := c.mortgageService.ReleaseMortgage(&req)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "抵押解除已提交", gin.H{
+		"txID": txID,
+	})
+}
+
+// QueryMortgagesByRealty 查询房产上的抵押
+func (c *MortgageController) QueryMortgagesByRealty(ctx *gin.Context) {
+	realtyCertHash := ctx.Param("realtyCertHash")
+	if realtyCertHash == "" {
+		utils.ResponseBadRequest(ctx, "房产ID不能为空")
+		return
+	}
+
+	mortgageList, err := c.mortgageService.QueryMortgagesByRealty(realtyCertHash)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "查询抵押信息成功", gin.H{
+		"mortgageList": mortgageList,
+	})
+}
+
+// GlobalMortgageController 全局抵押控制器实例
+var GlobalMortgageController *MortgageController
+
+// InitMortgageController 初始化抵押控制器
+func InitMortgageController() {
+	GlobalMortgageController = NewMortgageController()
+}
+
+func CreateMortgage(c *gin.Context) {
+	GlobalMortgageController.CreateMortgage(c)
+}
+
+func ApproveMortgage(c *gin.Context) {
+	GlobalMortgageController.ApproveMortgage(c)
+}
+
+func AssumeMortgage(c *gin.Context) {
+	GlobalMortgageController.AssumeMortgage(c)
+}
+
+func ReleaseMortgage(c *gin.Context) {
+	GlobalMortgageController.ReleaseMortgage(c)
+}
+
+func QueryMortgagesByRealty(c *gin.Context) {
+	GlobalMortgageController.QueryMortgagesByRealty(c)
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"grets_server/pkg/utils"
+	"grets_server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OperationController 业务操作控制器结构体
+type OperationController struct {
+	operationService service.OperationService
+}
+
+// NewOperationController 创建业务操作控制器实例
+func NewOperationController() *OperationController {
+	return &OperationController{
+		operationService: service.GlobalOperationService,
+	}
+}
+
+// GetOperationByUUID 查询业务操作及各步骤的执行状态
+func (c *OperationController) GetOperationByUUID(ctx *gin.Context) {
+	operationUUID := ctx.Param("id")
+	if operationUUID == "" {
+		utils.ResponseBadRequest(ctx, "业务操作UUID不能为空")
+		return
+	}
+
+	operation, err := c.operationService.GetOperationByUUID(operationUUID)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "查询业务操作成功", operation)
+}
+
+// GlobalOperationController 全局业务操作控制器实例
+var GlobalOperationController *OperationController
+
+// InitOperationController 初始化业务操作控制器
+func InitOperationController() {
+	GlobalOperationController = NewOperationController()
+}
+
+func GetOperationByUUID(c *gin.Context) {
+	GlobalOperationController.GetOperationByUUID(c)
+}
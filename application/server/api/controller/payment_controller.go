@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"grets_server/dao"
 	paymentDto "grets_server/dto/payment_dto"
 	"grets_server/pkg/utils"
@@ -29,15 +30,22 @@ func (c *PaymentController) CreatePayment(ctx *gin.Context) {
 		utils.ResponseBadRequest(ctx, "无效的请求参数")
 		return
 	}
+	// 付款人为当前用户，支付以付款人本人的身份签名
+	req.PayerCitizenID = ctx.GetString("citizenID")
+	req.PayerOrganization = ctx.GetString("organization")
 
 	// 调用服务创建支付
-	if err := c.paymentService.CreatePayment(&req); err != nil {
+	paymentUUID, operationUUID, err := c.paymentService.CreatePayment(&req)
+	if err != nil {
 		utils.ResponseInternalServerError(ctx, err.Error())
 		return
 	}
 
 	// 返回成功结果
-	utils.ResponseSuccess(ctx, "支付创建成功", nil)
+	utils.ResponseSuccess(ctx, "支付创建成功", gin.H{
+		"paymentUUID":   paymentUUID,
+		"operationUUID": operationUUID,
+	})
 }
 
 // PayForTransaction 支付交易
@@ -48,15 +56,44 @@ func (c *PaymentController) PayForTransaction(ctx *gin.Context) {
 		utils.ResponseBadRequest(ctx, "无效的请求参数")
 		return
 	}
+	// 付款人为当前用户，支付以付款人本人的身份签名
+	req.PayerCitizenID = ctx.GetString("citizenID")
+	req.PayerOrganization = ctx.GetString("organization")
 
 	// 调用服务支付交易
-	if err := c.paymentService.PayForTransaction(&req); err != nil {
+	paymentUUID, operationUUID, err := c.paymentService.PayForTransaction(&req)
+	if err != nil {
 		utils.ResponseInternalServerError(ctx, err.Error())
 		return
 	}
 
 	// 返回成功结果
-	utils.ResponseSuccess(ctx, "支付交易成功", nil)
+	utils.ResponseSuccess(ctx, "支付交易成功", gin.H{
+		"paymentUUID":   paymentUUID,
+		"operationUUID": operationUUID,
+	})
+}
+
+// PayForTransactionOffline 付款人用DID私钥签名链上交易支付，付款人为当前用户
+func (c *PaymentController) PayForTransactionOffline(ctx *gin.Context) {
+	// 解析请求参数
+	var req paymentDto.PayForTransactionDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ResponseBadRequest(ctx, "无效的请求参数")
+		return
+	}
+	req.PayerCitizenID = ctx.GetString("citizenID")
+	req.PayerOrganization = ctx.GetString("organization")
+
+	// 调用服务创建支付提案
+	session, err := c.paymentService.PayForTransactionOffline(&req)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	// 返回待签名的提案
+	utils.ResponseSuccess(ctx, "创建支付提案成功", session)
 }
 
 // QueryPaymentList 查询支付列表
@@ -102,6 +139,23 @@ func (c *PaymentController) GetPaymentByUUID(ctx *gin.Context) {
 	utils.ResponseSuccess(ctx, "查询支付成功", payment)
 }
 
+// GetEscrowByTransactionUUID 查询交易资金托管账户
+func (c *PaymentController) GetEscrowByTransactionUUID(ctx *gin.Context) {
+	transactionUUID := ctx.Param("transactionUUID")
+	if transactionUUID == "" {
+		utils.ResponseBadRequest(ctx, "交易UUID不能为空")
+		return
+	}
+
+	escrow, err := c.paymentService.GetEscrowByTransactionUUID(transactionUUID)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "查询托管账户成功", escrow)
+}
+
 // VerifyPayment 验证支付
 func (c *PaymentController) VerifyPayment(ctx *gin.Context) {
 	// 获取路径参数
@@ -112,13 +166,16 @@ func (c *PaymentController) VerifyPayment(ctx *gin.Context) {
 	}
 
 	// 调用服务验证支付
-	if err := c.paymentService.VerifyPayment(id); err != nil {
+	txID, err := c.paymentService.VerifyPayment(id)
+	if err != nil {
 		utils.ResponseInternalServerError(ctx, err.Error())
 		return
 	}
 
-	// 返回成功结果
-	utils.ResponseSuccess(ctx, "支付验证成功", nil)
+	// 返回链上交易ID，上链状态可通过交易ID查询
+	utils.ResponseSuccess(ctx, "支付验证已提交", gin.H{
+		"txID": txID,
+	})
 }
 
 // CompletePayment 完成支付
@@ -131,13 +188,48 @@ func (c *PaymentController) CompletePayment(ctx *gin.Context) {
 	}
 
 	// 调用服务完成支付
-	if err := c.paymentService.CompletePayment(id); err != nil {
+	txID, err := c.paymentService.CompletePayment(id)
+	if err != nil {
+		if errors.Is(err, service.ErrEscrowPaymentSettle) {
+			utils.ResponseBadRequest(ctx, err.Error())
+			return
+		}
 		utils.ResponseInternalServerError(ctx, err.Error())
 		return
 	}
 
-	// 返回成功结果
-	utils.ResponseSuccess(ctx, "支付完成成功", nil)
+	// 返回链上交易ID，上链状态可通过交易ID查询
+	utils.ResponseSuccess(ctx, "支付结算已提交", gin.H{
+		"txID": txID,
+	})
+}
+
+// ReversePayment 冲正支付
+func (c *PaymentController) ReversePayment(ctx *gin.Context) {
+	// 获取路径参数
+	id := ctx.Param("id")
+	if id == "" {
+		utils.ResponseBadRequest(ctx, "支付ID不能为空")
+		return
+	}
+
+	var req paymentDto.ReversePaymentDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ResponseBadRequest(ctx, "无效的请求参数")
+		return
+	}
+
+	// 调用服务冲正支付
+	txID, err := c.paymentService.ReversePayment(id, &req)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	// 返回链上交易ID，上链状态可通过交易ID查询
+	utils.ResponseSuccess(ctx, "支付冲正已提交", gin.H{
+		"txID": txID,
+	})
 }
 
 // GetTotalPaymentAmount 获取总支付金额
@@ -184,10 +276,22 @@ func ConfirmPayment(c *gin.Context) {
 	GlobalPaymentController.CompletePayment(c)
 }
 
+func ReversePayment(c *gin.Context) {
+	GlobalPaymentController.ReversePayment(c)
+}
+
 func PayForTransaction(c *gin.Context) {
 	GlobalPaymentController.PayForTransaction(c)
 }
 
+func PayForTransactionOffline(c *gin.Context) {
+	GlobalPaymentController.PayForTransactionOffline(c)
+}
+
 func GetTotalPaymentAmount(c *gin.Context) {
 	GlobalPaymentController.GetTotalPaymentAmount(c)
 }
+
+func GetEscrowByTransactionUUID(c *gin.Context) {
+	GlobalPaymentController.GetEscrowByTransactionUUID(c)
+}
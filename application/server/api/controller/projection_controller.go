@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"grets_server/pkg/utils"
+	"grets_server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProjectionController 投影控制器结构体
+type ProjectionController struct {
+	projectionService service.ProjectionService
+}
+
+// NewProjectionController 创建投影控制器实例
+func NewProjectionController() *ProjectionController {
+	return &ProjectionController{
+		projectionService: service.GlobalProjectionService,
+	}
+}
+
+// GetProjectionStatus 查询投影状态和各通道检查点
+func (c *ProjectionController) GetProjectionStatus(ctx *gin.Context) {
+	status, err := c.projectionService.GetProjectionStatus()
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "查询投影状态成功", status)
+}
+
+// RebuildProjection 从0号区块重建MySQL投影
+func (c *ProjectionController) RebuildProjection(ctx *gin.Context) {
+	if err := c.projectionService.Rebuild(); err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "已开始重建投影", nil)
+}
+
+// GlobalProjectionController 全局投影控制器实例
+var GlobalProjectionController *ProjectionController
+
+// InitProjectionController 初始化投影控制器
+func InitProjectionController() {
+	GlobalProjectionController = NewProjectionController()
+}
+
+func GetProjectionStatus(c *gin.Context) {
+	GlobalProjectionController.GetProjectionStatus(c)
+}
+
+func RebuildProjection(c *gin.Context) {
+	GlobalProjectionController.RebuildProjection(c)
+}
@@ -4,6 +4,7 @@ import (
 	realtyDto "grets_server/dto/realty_dto"
 	"grets_server/pkg/utils"
 	"grets_server/service"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -151,6 +152,111 @@ func (ctrl *RealtyController) QueryRealtyByOrganizationAndCitizenID(c *gin.Conte
 	})
 }
 
+// QueryRealtyHistory 分页查询房产链上历史版本
+func (ctrl *RealtyController) QueryRealtyHistory(c *gin.Context) {
+	realtyCertHash := c.Param("realtyCertHash")
+	if realtyCertHash == "" {
+		utils.ResponseBadRequest(c, "不动产证哈希不能为空")
+		return
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if err != nil || pageSize <= 0 {
+		utils.ResponseBadRequest(c, "无效的分页参数")
+		return
+	}
+	pageNumber, err := strconv.Atoi(c.DefaultQuery("pageNumber", "1"))
+	if err != nil || pageNumber <= 0 {
+		utils.ResponseBadRequest(c, "无效的分页参数")
+		return
+	}
+
+	historyList, total, err := ctrl.realtyService.QueryRealtyHistory(realtyCertHash, pageSize, pageNumber)
+	if err != nil {
+		utils.ResponseInternalServerError(c, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(c, "查询房产历史成功", gin.H{
+		"historyList": historyList,
+		"total":       total,
+		"pageSize":    pageSize,
+		"pageNumber":  pageNumber,
+	})
+}
+
+// FreezeRealty 司法冻结房产
+func (ctrl *RealtyController) FreezeRealty(c *gin.Context) {
+	realtyCertHash := c.Param("realtyCertHash")
+	if realtyCertHash == "" {
+		utils.ResponseBadRequest(c, "不动产证哈希不能为空")
+		return
+	}
+
+	var req realtyDto.FreezeRealtyDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseBadRequest(c, "无效的请求参数")
+		return
+	}
+
+	txID, err := ctrl.realtyService.FreezeRealty(realtyCertHash, c.GetString("organization"), &req)
+	if err != nil {
+		utils.ResponseInternalServerError(c, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(c, "房产冻结已提交", gin.H{
+		"txID": txID,
+	})
+}
+
+// SetRealtyOwnerList 登记房产共有人
+func (ctrl *RealtyController) SetRealtyOwnerList(c *gin.Context) {
+	realtyCertHash := c.Param("realtyCertHash")
+	if realtyCertHash == "" {
+		utils.ResponseBadRequest(c, "不动产证哈希不能为空")
+		return
+	}
+
+	var req realtyDto.SetRealtyOwnerListDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseBadRequest(c, "无效的请求参数")
+		return
+	}
+
+	if err := ctrl.realtyService.SetRealtyOwnerList(realtyCertHash, &req); err != nil {
+		utils.ResponseInternalServerError(c, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(c, "房产共有人登记成功", nil)
+}
+
+// UnfreezeRealty 解冻房产
+func (ctrl *RealtyController) UnfreezeRealty(c *gin.Context) {
+	realtyCertHash := c.Param("realtyCertHash")
+	if realtyCertHash == "" {
+		utils.ResponseBadRequest(c, "不动产证哈希不能为空")
+		return
+	}
+
+	var req realtyDto.UnfreezeRealtyDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseBadRequest(c, "无效的请求参数")
+		return
+	}
+
+	txID, err := ctrl.realtyService.UnfreezeRealty(realtyCertHash, c.GetString("organization"), &req)
+	if err != nil {
+		utils.ResponseInternalServerError(c, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(c, "房产解冻已提交", gin.H{
+		"txID": txID,
+	})
+}
+
 // GlobalRealtyController 创建全局房产控制器实例
 var GlobalRealtyController *RealtyController
 
@@ -183,3 +289,19 @@ func GetRealtyByRealtyCertHash(c *gin.Context) {
 func QueryRealtyByOrganizationAndCitizenID(c *gin.Context) {
 	GlobalRealtyController.QueryRealtyByOrganizationAndCitizenID(c)
 }
+
+func QueryRealtyHistory(c *gin.Context) {
+	GlobalRealtyController.QueryRealtyHistory(c)
+}
+
+func FreezeRealty(c *gin.Context) {
+	GlobalRealtyController.FreezeRealty(c)
+}
+
+func UnfreezeRealty(c *gin.Context) {
+	GlobalRealtyController.UnfreezeRealty(c)
+}
+
+func SetRealtyOwnerList(c *gin.Context) {
+	GlobalRealtyController.SetRealtyOwnerList(c)
+}
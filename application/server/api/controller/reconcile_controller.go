@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"grets_server/constants"
+	reconcileDto "grets_server/dto/reconcile_dto"
+	"grets_server/pkg/utils"
+	"grets_server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReconcileController 对账控制器结构体
+type ReconcileController struct {
+	reconcileService service.ReconcileService
+}
+
+// NewReconcileController 创建对账控制器实例
+func NewReconcileController() *ReconcileController {
+	return &ReconcileController{
+		reconcileService: service.GlobalReconcileService,
+	}
+}
+
+// RunReconcile 手动发起对账
+func (c *ReconcileController) RunReconcile(ctx *gin.Context) {
+	var req reconcileDto.RunReconcileDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ResponseBadRequest(ctx, "无效的请求参数")
+		return
+	}
+
+	report, err := c.reconcileService.Run(constants.ReconcileTriggerManual, req.AutoRepair)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "已开始对账", report)
+}
+
+// QueryReconcileReportList 查询对账报告列表
+func (c *ReconcileController) QueryReconcileReportList(ctx *gin.Context) {
+	var query reconcileDto.QueryReconcileReportDTO
+	if err := ctx.ShouldBindJSON(&query); err != nil {
+		utils.ResponseBadRequest(ctx, "无效的请求参数")
+		return
+	}
+
+	reportList, total, err := c.reconcileService.QueryReportList(&query)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "查询对账报告列表成功", gin.H{
+		"reportList": reportList,
+		"total":      total,
+	})
+}
+
+// GetReconcileReport 查询对账报告及差异明细
+func (c *ReconcileController) GetReconcileReport(ctx *gin.Context) {
+	reportUUID := ctx.Param("id")
+	if reportUUID == "" {
+		utils.ResponseBadRequest(ctx, "报告ID不能为空")
+		return
+	}
+
+	report, err := c.reconcileService.GetReportByUUID(reportUUID)
+	if err != nil {
+		utils.ResponseInternalServerError(ctx, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "查询对账报告成功", report)
+}
+
+// GlobalReconcileController 全局对账控制器实例
+var GlobalReconcileController *ReconcileController
+
+// InitReconcileController 初始化对账控制器
+func InitReconcileController() {
+	GlobalReconcileController = NewReconcileController()
+}
+
+func RunReconcile(c *gin.Context) {
+	GlobalReconcileController.RunReconcile(c)
+}
+
+func QueryReconcileReportList(c *gin.Context) {
+	GlobalReconcileController.QueryReconcileReportList(c)
+}
+
+func GetReconcileReport(c *gin.Context) {
+	GlobalReconcileController.GetReconcileReport(c)
+}
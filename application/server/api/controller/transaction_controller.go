@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"grets_server/constants"
 	transactionDto "grets_server/dto/transaction_dto"
 	"grets_server/pkg/utils"
@@ -29,14 +30,25 @@ func (c *TransactionController) CreateTransaction(ctx *gin.Context) {
 		utils.ResponseError(ctx, constants.ParamError, "参数错误: "+err.Error())
 		return
 	}
+	// 买方为当前用户，创建交易以买方本人的身份签名
+	req.BuyerCitizenID = ctx.GetString("citizenID")
+	req.BuyerOrganization = ctx.GetString("organization")
 
 	// 调用服务层创建交易
-	if err := c.transactionService.CreateTransaction(&req); err != nil {
+	transactionUUID, operationUUID, err := c.transactionService.CreateTransaction(&req)
+	if err != nil {
+		if errors.Is(err, service.ErrRealtyInSale) {
+			utils.ResponseError(ctx, constants.RealtyInSaleError, err.Error())
+			return
+		}
 		utils.ResponseError(ctx, constants.ServiceError, err.Error())
 		return
 	}
 
-	utils.ResponseSuccess(ctx, "交易创建成功", nil)
+	utils.ResponseSuccess(ctx, "交易创建成功", gin.H{
+		"transactionUUID": transactionUUID,
+		"operationUUID":   operationUUID,
+	})
 }
 
 // GetTransactionByUUID 根据ID获取交易
@@ -82,24 +94,6 @@ func (c *TransactionController) QueryTransactionList(ctx *gin.Context) {
 	})
 }
 
-// UpdateTransaction 更新交易
-func (c *TransactionController) UpdateTransaction(ctx *gin.Context) {
-	// 绑定请求参数
-	var req transactionDto.UpdateTransactionDTO
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		utils.ResponseError(ctx, constants.ParamError, "参数错误: "+err.Error())
-		return
-	}
-
-	// 调用服务层更新交易
-	if err := c.transactionService.UpdateTransaction(&req); err != nil {
-		utils.ResponseError(ctx, constants.ServiceError, err.Error())
-		return
-	}
-
-	utils.ResponseSuccess(ctx, "交易更新成功", nil)
-}
-
 // AuditTransaction 审计交易
 // func (c *TransactionController) AuditTransaction(ctx *gin.Context) {
 // 	// 获取交易ID
@@ -135,7 +129,7 @@ func (c *TransactionController) UpdateTransaction(ctx *gin.Context) {
 // 	utils.ResponseSuccess(ctx, nil)
 // }
 
-// CompleteTransaction 完成交易
+// CompleteTransaction 完成交易（过户）
 func (c *TransactionController) CompleteTransaction(ctx *gin.Context) {
 	// 绑定请求参数
 	var req transactionDto.CompleteTransactionDTO
@@ -144,13 +138,217 @@ func (c *TransactionController) CompleteTransaction(ctx *gin.Context) {
 		return
 	}
 
-	// 调用服务层完成交易
-	if err := c.transactionService.CompleteTransaction(&req); err != nil {
+	c.confirmTransactionStep(ctx, req.TransactionUUID, constants.TxStepTitleTransferred, "过户已提交")
+}
+
+// BuyerSignTransaction 买方签署交易
+func (c *TransactionController) BuyerSignTransaction(ctx *gin.Context) {
+	c.confirmTransactionStep(ctx, ctx.Param("transactionUUID"), constants.TxStepBuyerSigned, "买方签署已提交")
+}
+
+// SellerSignTransaction 卖方签署交易
+func (c *TransactionController) SellerSignTransaction(ctx *gin.Context) {
+	c.confirmTransactionStep(ctx, ctx.Param("transactionUUID"), constants.TxStepSellerSigned, "卖方签署已提交")
+}
+
+// BuyerSignTransactionOffline 买方用DID私钥签名链上交易签署交易
+func (c *TransactionController) BuyerSignTransactionOffline(ctx *gin.Context) {
+	c.confirmTransactionStepOffline(ctx, ctx.Param("transactionUUID"), constants.TxStepBuyerSigned)
+}
+
+// SellerSignTransactionOffline 卖方用DID私钥签名链上交易签署交易
+func (c *TransactionController) SellerSignTransactionOffline(ctx *gin.Context) {
+	c.confirmTransactionStepOffline(ctx, ctx.Param("transactionUUID"), constants.TxStepSellerSigned)
+}
+
+// GovernmentApproveTransaction 政府审批交易
+func (c *TransactionController) GovernmentApproveTransaction(ctx *gin.Context) {
+	c.confirmTransactionStep(ctx, ctx.Param("transactionUUID"), constants.TxStepGovernmentApproved, "交易审批已提交")
+}
+
+// ConfirmFundsEscrowed 银行确认房款已托管
+func (c *TransactionController) ConfirmFundsEscrowed(ctx *gin.Context) {
+	c.confirmTransactionStep(ctx, ctx.Param("transactionUUID"), constants.TxStepFundsEscrowed, "房款托管确认已提交")
+}
+
+// ConfirmTaxPaid 政府确认税费已缴清
+func (c *TransactionController) ConfirmTaxPaid(ctx *gin.Context) {
+	c.confirmTransactionStep(ctx, ctx.Param("transactionUUID"), constants.TxStepTaxPaid, "税费缴清确认已提交")
+}
+
+// TransferTitle 政府办理过户
+func (c *TransactionController) TransferTitle(ctx *gin.Context) {
+	c.confirmTransactionStep(ctx, ctx.Param("transactionUUID"), constants.TxStepTitleTransferred, "过户已提交")
+}
+
+// ConsentTransaction 共有人同意出售
+func (c *TransactionController) ConsentTransaction(ctx *gin.Context) {
+	transactionUUID := ctx.Param("transactionUUID")
+	if transactionUUID == "" {
+		utils.ResponseError(ctx, constants.ParamError, "交易UUID不能为空")
+		return
+	}
+
+	txID, err := c.transactionService.ConsentTransaction(
+		transactionUUID,
+		ctx.GetString("citizenID"),
+		ctx.GetString("organization"),
+	)
+	if err != nil {
+		utils.ResponseError(ctx, constants.ServiceError, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "共有人同意出售已提交", gin.H{
+		"txID": txID,
+	})
+}
+
+// ConsentTransactionOffline 共有人用DID私钥签名链上交易同意出售
+func (c *TransactionController) ConsentTransactionOffline(ctx *gin.Context) {
+	transactionUUID := ctx.Param("transactionUUID")
+	if transactionUUID == "" {
+		utils.ResponseError(ctx, constants.ParamError, "交易UUID不能为空")
+		return
+	}
+
+	session, err := c.transactionService.ConsentTransactionOffline(
+		transactionUUID,
+		ctx.GetString("citizenID"),
+		ctx.GetString("organization"),
+	)
+	if err != nil {
+		utils.ResponseError(ctx, constants.ServiceError, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "创建交易提案成功", session)
+}
+
+// GetTransactionTimeline 查询交易时间线
+func (c *TransactionController) GetTransactionTimeline(ctx *gin.Context) {
+	transactionUUID := ctx.Param("transactionUUID")
+	if transactionUUID == "" {
+		utils.ResponseError(ctx, constants.ParamError, "交易UUID不能为空")
+		return
+	}
+
+	timeline, err := c.transactionService.GetTransactionTimeline(transactionUUID)
+	if err != nil {
+		utils.ResponseError(ctx, constants.ServiceError, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "查询交易时间线成功", gin.H{
+		"timeline": timeline,
+	})
+}
+
+// confirmTransactionStep 以当前登录用户的身份确认交易步骤
+func (c *TransactionController) confirmTransactionStep(ctx *gin.Context, transactionUUID string, step string, message string) {
+	if transactionUUID == "" {
+		utils.ResponseError(ctx, constants.ParamError, "交易UUID不能为空")
+		return
+	}
+
+	txID, err := c.transactionService.ConfirmTransactionStep(
+		transactionUUID,
+		step,
+		ctx.GetString("citizenID"),
+		ctx.GetString("organization"),
+	)
+	if err != nil {
+		utils.ResponseError(ctx, constants.ServiceError, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, message, gin.H{
+		"txID": txID,
+	})
+}
+
+// confirmTransactionStepOffline 创建确认交易步骤的提案，由用户签名后提交
+func (c *TransactionController) confirmTransactionStepOffline(ctx *gin.Context, transactionUUID string, step string) {
+	if transactionUUID == "" {
+		utils.ResponseError(ctx, constants.ParamError, "交易UUID不能为空")
+		return
+	}
+
+	session, err := c.transactionService.ConfirmTransactionStepOffline(
+		transactionUUID,
+		step,
+		ctx.GetString("citizenID"),
+		ctx.GetString("organization"),
+	)
+	if err != nil {
+		utils.ResponseError(ctx, constants.ServiceError, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "创建交易提案成功", session)
+}
+
+// RejectTransaction 拒绝交易
+func (c *TransactionController) RejectTransaction(ctx *gin.Context) {
+	// 绑定请求参数
+	var req transactionDto.RejectTransactionDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(ctx, constants.ParamError, "参数错误: "+err.Error())
+		return
+	}
+
+	// 调用服务层拒绝交易
+	txID, err := c.transactionService.RejectTransaction(&req, ctx.GetString("citizenID"), ctx.GetString("organization"))
+	if err != nil {
 		utils.ResponseError(ctx, constants.ServiceError, err.Error())
 		return
 	}
 
-	utils.ResponseSuccess(ctx, "交易完成成功", nil)
+	utils.ResponseSuccess(ctx, "拒绝交易已提交，上链后退还托管资金", gin.H{
+		"txID": txID,
+	})
+}
+
+// CancelTransaction 取消交易
+func (c *TransactionController) CancelTransaction(ctx *gin.Context) {
+	// 绑定请求参数
+	var req transactionDto.CancelTransactionDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(ctx, constants.ParamError, "参数错误: "+err.Error())
+		return
+	}
+
+	// 调用服务层取消交易
+	txID, err := c.transactionService.CancelTransaction(&req, ctx.GetString("citizenID"), ctx.GetString("organization"))
+	if err != nil {
+		utils.ResponseError(ctx, constants.ServiceError, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "取消交易已提交，上链后退还托管资金", gin.H{
+		"txID": txID,
+	})
+}
+
+// ExpireTransaction 终止超时交易
+func (c *TransactionController) ExpireTransaction(ctx *gin.Context) {
+	// 绑定请求参数
+	var req transactionDto.ExpireTransactionDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(ctx, constants.ParamError, "参数错误: "+err.Error())
+		return
+	}
+
+	// 调用服务层终止超时交易
+	txID, err := c.transactionService.ExpireTransaction(&req)
+	if err != nil {
+		utils.ResponseError(ctx, constants.ServiceError, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "终止超时交易已提交，上链后退还托管资金", gin.H{
+		"txID": txID,
+	})
 }
 
 // QueryTransactionStatistics 查询交易统计
@@ -178,6 +376,28 @@ func (c *TransactionController) QueryTransactionStatistics(ctx *gin.Context) {
 	})
 }
 
+// PreviewTransactionTax 预估交易税费
+func (c *TransactionController) PreviewTransactionTax(ctx *gin.Context) {
+	// 绑定请求参数
+	var req transactionDto.PreviewTransactionTaxDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(ctx, constants.ParamError, "参数错误: "+err.Error())
+		return
+	}
+
+	// 调用服务层核定税费
+	taxList, totalTax, err := c.transactionService.PreviewTransactionTax(&req)
+	if err != nil {
+		utils.ResponseError(ctx, constants.ServiceError, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(ctx, "税费核定成功", gin.H{
+		"taxList":  taxList,
+		"totalTax": totalTax,
+	})
+}
+
 // 创建全局交易控制器实例
 var GlobalTxController *TransactionController
 
@@ -199,10 +419,6 @@ func QueryTransactionList(c *gin.Context) {
 	GlobalTxController.QueryTransactionList(c)
 }
 
-func UpdateTransaction(c *gin.Context) {
-	GlobalTxController.UpdateTransaction(c)
-}
-
 // func AuditTransaction(c *gin.Context) {
 // 	GlobalTxController.AuditTransaction(c)
 // }
@@ -214,3 +430,63 @@ func CompleteTransaction(c *gin.Context) {
 func QueryTransactionStatistics(c *gin.Context) {
 	GlobalTxController.QueryTransactionStatistics(c)
 }
+
+func PreviewTransactionTax(c *gin.Context) {
+	GlobalTxController.PreviewTransactionTax(c)
+}
+
+func RejectTransaction(c *gin.Context) {
+	GlobalTxController.RejectTransaction(c)
+}
+
+func CancelTransaction(c *gin.Context) {
+	GlobalTxController.CancelTransaction(c)
+}
+
+func ExpireTransaction(c *gin.Context) {
+	GlobalTxController.ExpireTransaction(c)
+}
+
+func BuyerSignTransaction(c *gin.Context) {
+	GlobalTxController.BuyerSignTransaction(c)
+}
+
+func SellerSignTransaction(c *gin.Context) {
+	GlobalTxController.SellerSignTransaction(c)
+}
+
+func BuyerSignTransactionOffline(c *gin.Context) {
+	GlobalTxController.BuyerSignTransactionOffline(c)
+}
+
+func SellerSignTransactionOffline(c *gin.Context) {
+	GlobalTxController.SellerSignTransactionOffline(c)
+}
+
+func GovernmentApproveTransaction(c *gin.Context) {
+	GlobalTxController.GovernmentApproveTransaction(c)
+}
+
+func ConfirmFundsEscrowed(c *gin.Context) {
+	GlobalTxController.ConfirmFundsEscrowed(c)
+}
+
+func ConfirmTaxPaid(c *gin.Context) {
+	GlobalTxController.ConfirmTaxPaid(c)
+}
+
+func TransferTitle(c *gin.Context) {
+	GlobalTxController.TransferTitle(c)
+}
+
+func GetTransactionTimeline(c *gin.Context) {
+	GlobalTxController.GetTransactionTimeline(c)
+}
+
+func ConsentTransaction(c *gin.Context) {
+	GlobalTxController.ConsentTransaction(c)
+}
+
+func ConsentTransactionOffline(c *gin.Context) {
+	GlobalTxController.ConsentTransactionOffline(c)
+}
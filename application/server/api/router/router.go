@@ -1,10 +1,14 @@
 package router
 
 import (
+	"fmt"
 	"grets_server/api/controller"
+	"grets_server/constants"
 	"grets_server/dao"
 	"grets_server/middleware"
+	"grets_server/pkg/blockchain"
 	"grets_server/service"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,6 +22,9 @@ func InitServices() error {
 	contractDAO := dao.NewContractDAO()
 	paymentDAO := dao.NewPaymentDAO()
 	didDAO := dao.NewDIDDAO()
+	projectionDAO := dao.NewProjectionDAO()
+	operationDAO := dao.NewOperationDAO()
+	reconcileDAO := dao.NewReconcileDAO()
 
 	// 初始化服务
 	service.InitUserService(userDAO)
@@ -29,6 +36,11 @@ func InitServices() error {
 	service.InitPictureService()
 	service.InitChatService()
 	service.InitDIDService(didDAO)
+	service.InitMortgageService()
+	service.InitProjectionService(projectionDAO, paymentDAO)
+	service.InitOperationService(operationDAO)
+	service.InitReconcileService(reconcileDAO)
+	service.InitBlockVerifyService()
 
 	// 初始化控制器
 	controller.InitUserController()
@@ -40,6 +52,32 @@ func InitServices() error {
 	controller.InitPictureController()
 	controller.InitChatController()
 	controller.InitDIDController()
+	controller.InitMortgageController()
+	controller.InitProjectionController()
+	controller.InitOperationController()
+	controller.InitReconcileController()
+	controller.InitBlockVerifyController()
+
+	// 定期解冻冻结期限届满的房产
+	service.StartFreezeExpiryWatcher(10 * time.Minute)
+
+	// 重试未完成的业务操作，补偿失败的业务操作
+	service.StartOperationWorker(5 * time.Second)
+
+	// 定期核对链上账本与MySQL
+	service.StartReconcileJob()
+
+	// 定期校验本地区块哈希链并与节点比对
+	service.StartBlockVerifyJob()
+
+	// 注册链码事件处理函数后再开始订阅，避免启动时的事件没有处理函数
+	service.InitChaincodeEventHandlers()
+	if err := blockchain.StartChaincodeEvents(); err != nil {
+		return fmt.Errorf("订阅链码事件失败: %v", err)
+	}
+
+	// 根据链码事件同步MySQL中的房产、交易、支付
+	service.GlobalProjectionService.Start()
 	return nil
 }
 
@@ -57,6 +95,18 @@ func SetupRouter() *gin.Engine {
 		})
 	})
 
+	// 就绪检查：每个组织当前使用的网关节点都可用时返回200，否则返回503，并给出各网关节点的状态
+	r.GET("/ready", func(c *gin.Context) {
+		code, status := 200, "ready"
+		if !blockchain.IsReady() {
+			code, status = 503, "unavailable"
+		}
+		c.JSON(code, gin.H{
+			"status": status,
+			"peers":  blockchain.GetPeerStateList(),
+		})
+	})
+
 	// API 路由组
 	api := r.Group("/api/v1")
 	{
@@ -118,10 +168,25 @@ func SetupRouter() *gin.Engine {
 		{
 			transactions.POST("/createTransaction", controller.CreateTransaction)
 			transactions.POST("/queryTransactionList", controller.QueryTransactionList)
-			transactions.POST("/updateTransaction", controller.UpdateTransaction)
 			transactions.GET("/:transactionUUID", controller.GetTransactionByUUID)
 			transactions.POST("/completeTransaction", controller.CompleteTransaction)
 			transactions.POST("/queryTransactionStatistics", controller.QueryTransactionStatistics)
+			transactions.POST("/previewTax", controller.PreviewTransactionTax)
+			transactions.POST("/rejectTransaction", controller.RejectTransaction)
+			transactions.POST("/cancelTransaction", controller.CancelTransaction)
+			transactions.POST("/expireTransaction", controller.ExpireTransaction)
+			transactions.GET("/:transactionUUID/timeline", controller.GetTransactionTimeline)
+			transactions.POST("/:transactionUUID/ownerConsent", controller.ConsentTransaction)
+			transactions.POST("/:transactionUUID/buyerSign", controller.BuyerSignTransaction)
+			transactions.POST("/:transactionUUID/sellerSign", controller.SellerSignTransaction)
+			// 用户自己签名链上交易（非托管模式），返回待签名的提案
+			transactions.POST("/:transactionUUID/ownerConsent/offline", controller.ConsentTransactionOffline)
+			transactions.POST("/:transactionUUID/buyerSign/offline", controller.BuyerSignTransactionOffline)
+			transactions.POST("/:transactionUUID/sellerSign/offline", controller.SellerSignTransactionOffline)
+			transactions.POST("/:transactionUUID/governmentApprove", controller.GovernmentApproveTransaction)
+			transactions.POST("/:transactionUUID/confirmFundsEscrowed", controller.ConfirmFundsEscrowed)
+			transactions.POST("/:transactionUUID/confirmTaxPaid", controller.ConfirmTaxPaid)
+			transactions.POST("/:transactionUUID/transferTitle", controller.TransferTitle)
 		}
 
 		// 房产相关接口
@@ -135,7 +200,16 @@ func SetupRouter() *gin.Engine {
 			realEstates.POST("/queryRealtyList", controller.QueryRealtyList)
 			realEstates.PUT("/:id", controller.UpdateRealty)
 			realEstates.GET("/:realtyCertHash", controller.GetRealtyByRealtyCertHash)
+			realEstates.GET("/:realtyCertHash/history", controller.QueryRealtyHistory)
 			realEstates.GET("/queryRealtyByOrganizationAndCitizenID", controller.QueryRealtyByOrganizationAndCitizenID)
+			// 司法冻结、解冻（仅政府、审计机构）
+			realEstatesJudicial := realEstates.Group("", middleware.OrganizationAuth(constants.GovernmentOrganization, constants.AuditOrganization))
+			{
+				realEstatesJudicial.POST("/:realtyCertHash/freeze", controller.FreezeRealty)
+				realEstatesJudicial.POST("/:realtyCertHash/unfreeze", controller.UnfreezeRealty)
+			}
+			// 登记共有人（仅政府）
+			realEstates.POST("/:realtyCertHash/owners", middleware.OrganizationAuth(constants.GovernmentOrganization), controller.SetRealtyOwnerList)
 			// 暂时注释审核接口，等待实现
 			// realEstates.POST("/:id/audit", controller.AuditRealEstate)
 		}
@@ -147,9 +221,24 @@ func SetupRouter() *gin.Engine {
 			payments.POST("/createPayment", controller.CreatePayment)
 			payments.POST("/queryPaymentList", controller.QueryPaymentList)
 			payments.POST("/payForTransaction", controller.PayForTransaction)
+			payments.POST("/payForTransaction/offline", controller.PayForTransactionOffline)
 			payments.GET("/:id", controller.GetPaymentByUUID)
-			payments.POST("/:id/verify", controller.VerifyPayment)
+			payments.POST("/:id/verify", middleware.OrganizationAuth(constants.BankOrganization), controller.VerifyPayment)
+			payments.POST("/:id/settle", middleware.OrganizationAuth(constants.BankOrganization), controller.ConfirmPayment)
+			payments.POST("/:id/reverse", middleware.OrganizationAuth(constants.BankOrganization), controller.ReversePayment)
 			payments.GET("/getTotalPaymentAmount", controller.GetTotalPaymentAmount)
+			payments.GET("/escrow/:transactionUUID", controller.GetEscrowByTransactionUUID)
+		}
+
+		// 抵押相关接口（仅银行可以操作）
+		mortgages := api.Group("/mortgages")
+		mortgages.Use(middleware.JWTAuth(), middleware.OrganizationAuth(constants.BankOrganization))
+		{
+			mortgages.POST("/createMortgage", controller.CreateMortgage)
+			mortgages.POST("/approveMortgage", controller.ApproveMortgage)
+			mortgages.POST("/assumeMortgage", controller.AssumeMortgage)
+			mortgages.POST("/releaseMortgage", controller.ReleaseMortgage)
+			mortgages.GET("/realty/:realtyCertHash", controller.QueryMortgagesByRealty)
 		}
 
 		// 合同相关接口
@@ -161,17 +250,69 @@ func SetupRouter() *gin.Engine {
 			contracts.GET("/:id", controller.GetContractByID)
 			contracts.GET("/getContractByUUID/:contractUUID", controller.GetContractByUUID)
 			contracts.POST("/:id/sign", controller.SignContract)
-			contracts.POST("/:id/audit", controller.AuditContract)
+			contracts.POST("/:id/sign/offline", controller.SignContractOffline)
+			contracts.POST("/:id/audit", middleware.OrganizationAuth(constants.AuditOrganization), controller.AuditContract)
 			contracts.POST("/updateContractStatus", controller.UpdateContractStatus)
 			contracts.POST("/bindTransaction", controller.BindTransaction)
 		}
 
+		// 业务操作状态查询接口
+		operations := api.Group("/operations")
+		operations.Use(middleware.JWTAuth())
+		{
+			operations.GET("/:id", controller.GetOperationByUUID)
+		}
+
+		// 链上数据投影接口（仅政府）
+		projection := api.Group("/projection")
+		projection.Use(middleware.JWTAuth(), middleware.OrganizationAuth(constants.GovernmentOrganization))
+		{
+			projection.GET("/status", controller.GetProjectionStatus)
+			projection.POST("/rebuild", controller.RebuildProjection)
+		}
+
+		// 链上与数据库对账接口（仅政府）
+		reconcile := api.Group("/reconcile")
+		reconcile.Use(middleware.JWTAuth(), middleware.OrganizationAuth(constants.GovernmentOrganization))
+		{
+			reconcile.POST("/run", controller.RunReconcile)
+			reconcile.POST("/reports", controller.QueryReconcileReportList)
+			reconcile.GET("/reports/:id", controller.GetReconcileReport)
+		}
+
+		// 链上交易提交状态接口
+		chain := api.Group("/chain")
+		chain.Use(middleware.JWTAuth())
+		{
+			chain.GET("/tx/:txID/status", controller.GetChainTxStatus)
+			// 离线签名：登记绑定DID公钥的证书，逐阶段提交用户签名
+			chain.POST("/offline/enroll", controller.PrepareOfflineEnroll)
+			chain.POST("/offline/sessions/:sessionID/signature", controller.SubmitOfflineSignature)
+		}
+
+		// 区块哈希链校验接口（仅审计机构）
+		blockVerify := api.Group("/blockVerify")
+		blockVerify.Use(middleware.JWTAuth(), middleware.OrganizationAuth(constants.AuditOrganization))
+		{
+			blockVerify.POST("/run", controller.RunBlockVerify)
+			blockVerify.GET("/results", controller.GetBlockVerifyResultList)
+		}
+
 		// 区块相关接口
 		blocks := api.Group("/blocks")
 		blocks.Use(middleware.JWTAuth())
 		{
 			blocks.POST("/queryBlockList", controller.QueryBlockList)
 			blocks.POST("/queryBlockTransactionList", controller.QueryBlockTransactionList)
+			blocks.POST("/queryTransactionList", controller.QueryChainTransactionList)
+			blocks.GET("/tx/:txID", controller.GetTransactionByID)
+
+			// 链码调用参数和读写集仅政府和审计机构可见
+			blocksDecode := blocks.Group("", middleware.OrganizationAuth(constants.GovernmentOrganization, constants.AuditOrganization))
+			blocksDecode.POST("/queryBlockInvocationList", controller.QueryBlockInvocationList)
+			blocksDecode.GET("/tx/:txID/invocation", controller.GetTransactionInvocation)
+			// 包含证明中有区块全部交易信封的原始字节（含未脱敏的参数），同样仅政府和审计机构可获取
+			blocksDecode.GET("/tx/:txID/proof", controller.GetTransactionInclusionProof)
 		}
 
 		picture := api.Group("/picture")
@@ -194,6 +335,9 @@ func SetupRouter() *gin.Engine {
 
 		// WebSocket连接路由（不使用JWT中间件，自行处理认证）
 		api.GET("/chat/ws/:roomUUID", controller.WebSocketHandler)
+
+		// 新区块和新交易推送（Server-Sent Events，自行处理认证）
+		api.GET("/blocks/stream", controller.StreamBlocks)
 	}
 
 	return r
@@ -24,14 +24,56 @@ type OrganizationConfig struct {
 	TlsCertPath  string `mapstructure:"tlsCertPath"`
 	GatewayPeer  string `mapstructure:"gatewayPeer"`
 	PeerEndpoint string `mapstructure:"peerEndpoint"`
+	// 网关节点列表，按优先级排列，为空时只使用上面的单个节点
+	Peers []PeerConfig `mapstructure:"peers"`
+	// 为用户登记身份的CA，未配置时用户的交易使用组织身份签名
+	CA CAConfig `mapstructure:"ca"`
+}
+
+// CAConfig 用户身份登记配置
+type CAConfig struct {
+	Type            string `mapstructure:"type"`            // fabric-ca：通过Fabric CA登记；local：用组织CA证书和私钥在本地签发，仅用于测试
+	URL             string `mapstructure:"url"`             // fabric-ca：CA地址
+	CAName          string `mapstructure:"caName"`          // fabric-ca：CA名称
+	TlsCertPath     string `mapstructure:"tlsCertPath"`     // fabric-ca：CA的TLS根证书
+	Registrar       string `mapstructure:"registrar"`       // fabric-ca：登记员用户名
+	RegistrarSecret string `mapstructure:"registrarSecret"` // fabric-ca：登记员密码
+	CertPath        string `mapstructure:"certPath"`        // local：组织CA证书文件
+	KeyPath         string `mapstructure:"keyPath"`         // local：组织CA私钥文件
+}
+
+// PeerConfig 网关节点配置
+type PeerConfig struct {
+	PeerEndpoint string `mapstructure:"peerEndpoint"`
+	GatewayPeer  string `mapstructure:"gatewayPeer"`
+	TlsCertPath  string `mapstructure:"tlsCertPath"` // 为空时使用组织的tlsCertPath
+}
+
+// PeerList 组织的网关节点列表，按优先级排列
+func (c OrganizationConfig) PeerList() []PeerConfig {
+	if len(c.Peers) == 0 {
+		return []PeerConfig{{PeerEndpoint: c.PeerEndpoint, GatewayPeer: c.GatewayPeer, TlsCertPath: c.TlsCertPath}}
+	}
+	peerList := make([]PeerConfig, 0, len(c.Peers))
+	for _, peer := range c.Peers {
+		if peer.TlsCertPath == "" {
+			peer.TlsCertPath = c.TlsCertPath
+		}
+		peerList = append(peerList, peer)
+	}
+	return peerList
 }
 
 type Fabric struct {
-	MainChannelName   string                        `mapstructure:"mainChannelName"`
-	MainChainCodeName string                        `mapstructure:"mainChainCodeName"`
-	SubChannelName    []string                      `mapstructure:"subChannelName"`
-	SubChainCodeName  []string                      `mapstructure:"subChainCodeName"`
-	Organizations     map[string]OrganizationConfig `mapstructure:"organizations"`
+	MainChannelName   string   `mapstructure:"mainChannelName"`
+	MainChainCodeName string   `mapstructure:"mainChainCodeName"`
+	SubChannelName    []string `mapstructure:"subChannelName"`
+	SubChainCodeName  []string `mapstructure:"subChainCodeName"`
+	// 主通道上的审计日志链码，为空时不上链记录审计结果
+	AuditChainCodeName string `mapstructure:"auditChainCodeName"`
+	// 网关节点健康检查间隔（秒），0表示使用默认的10秒
+	PeerHealthInterval int                           `mapstructure:"peerHealthInterval"`
+	Organizations      map[string]OrganizationConfig `mapstructure:"organizations"`
 }
 
 type Log struct {
@@ -57,12 +99,23 @@ type Database struct {
 	} `mapstructure:"mysql"`
 }
 
+type Reconcile struct {
+	Interval   int  `mapstructure:"interval"`   // 定时对账间隔（分钟），0表示不定时对账
+	AutoRepair bool `mapstructure:"autoRepair"` // 定时对账时是否用链上数据修复MySQL
+}
+
+type BlockVerify struct {
+	Interval int `mapstructure:"interval"` // 定时校验区块哈希链间隔（分钟），0表示不定时校验
+}
+
 type Config struct {
-	Server   Server   `mapstructure:"server"`
-	Jwt      Jwt      `mapstructure:"jwt"`
-	Fabric   Fabric   `mapstructure:"fabric"`
-	Log      Log      `mapstructure:"log"`
-	Database Database `mapstructure:"database"`
+	Server      Server      `mapstructure:"server"`
+	Jwt         Jwt         `mapstructure:"jwt"`
+	Fabric      Fabric      `mapstructure:"fabric"`
+	Log         Log         `mapstructure:"log"`
+	Database    Database    `mapstructure:"database"`
+	Reconcile   Reconcile   `mapstructure:"reconcile"`
+	BlockVerify BlockVerify `mapstructure:"blockVerify"`
 }
 
 var GlobalConfig *Config
@@ -0,0 +1,15 @@
+package constants
+
+// 区块哈希链校验触发方式
+const (
+	BlockVerifyTriggerScheduled = "SCHEDULED" // 定时校验
+	BlockVerifyTriggerManual    = "MANUAL"    // 审计人员手动校验
+)
+
+// 区块哈希链校验结果上链时的审计记录字段
+const (
+	BlockVerifyAuditTargetType     = "BLOCK_CHAIN" // 审计目标类型
+	BlockVerifyAuditResultTampered = "TAMPERED"    // 审计结果：区块被篡改或与节点不一致
+	BlockVerifyStatusIntact        = "INTACT"      // 哈希链完整
+	BlockVerifyStatusBroken        = "BROKEN"      // 哈希链断裂
+)
@@ -1,5 +1,7 @@
 package constants
 
+import "time"
+
 // 资产状态枚举
 const (
 	StatusNormal        = "NORMAL"         // 正常状态
@@ -26,12 +28,90 @@ const (
 	RealtyTypeOther      = "OTHER"      // 其他
 )
 
+// 司法冻结状态枚举
+const (
+	FreezeStatusActive   = "ACTIVE"   // 冻结中
+	FreezeStatusReleased = "RELEASED" // 已解冻
+	FreezeStatusExpired  = "EXPIRED"  // 到期解冻
+)
+
 // 交易状态枚举
 const (
 	TxStatusPending   = "PENDING"     // 待处理
 	TxStatusInProcess = "IN_PROGRESS" // 处理中
 	TxStatusRejected  = "REJECTED"    // 已拒绝
 	TxStatusCompleted = "COMPLETED"   // 已完成
+	TxStatusCancelled = "CANCELLED"   // 已取消
+	TxStatusExpired   = "EXPIRED"     // 已超时
+)
+
+// TransactionTimeout 交易超时时间，与链码保持一致，超时未推进的交易可被终止并释放房产
+const TransactionTimeout = 30 * 24 * time.Hour
+
+// TransactionExpireWait 创建交易前终止超时交易时等待终止交易上链的时间
+const TransactionExpireWait = 30 * time.Second
+
+// 交易步骤枚举
+const (
+	TxStepBuyerSigned        = "BUYER_SIGNED"        // 买方签署
+	TxStepSellerSigned       = "SELLER_SIGNED"       // 卖方签署
+	TxStepGovernmentApproved = "GOVERNMENT_APPROVED" // 政府审批
+	TxStepFundsEscrowed      = "FUNDS_ESCROWED"      // 房款已托管
+	TxStepTaxPaid            = "TAX_PAID"            // 税费已缴清
+	TxStepTitleTransferred   = "TITLE_TRANSFERRED"   // 已过户
+)
+
+// 抵押状态枚举
+const (
+	MortgageStatusPending  = "PENDING"  // 待审批
+	MortgageStatusActive   = "ACTIVE"   // 生效中
+	MortgageStatusAssumed  = "ASSUMED"  // 已同意买方承接
+	MortgageStatusReleased = "RELEASED" // 已解除
+)
+
+// 税费类型枚举
+const (
+	TaxTypeDeedTax   = "DEED_TAX"   // 契税（买方缴纳）
+	TaxTypeVAT       = "VAT"        // 增值税（卖方缴纳）
+	TaxTypeIncomeTax = "INCOME_TAX" // 个人所得税（卖方缴纳）
+)
+
+// 纳税人角色枚举
+const (
+	TaxPayerBuyer  = "BUYER"  // 买方
+	TaxPayerSeller = "SELLER" // 卖方
+)
+
+// 资金托管状态枚举
+const (
+	EscrowStatusHolding  = "HOLDING"  // 托管中
+	EscrowStatusReleased = "RELEASED" // 已放款
+	EscrowStatusRefunded = "REFUNDED" // 已退款
+)
+
+// 链码事件类型
+const (
+	EventRealtyCreated         = "RealtyCreated"         // 房产登记
+	EventRealtyUpdated         = "RealtyUpdated"         // 房产信息变更
+	EventTransactionCreated    = "TransactionCreated"    // 交易创建
+	EventTransactionUpdated    = "TransactionUpdated"    // 交易步骤确认或终止
+	EventTransactionCompleted  = "TransactionCompleted"  // 交易完成过户
+	EventPaymentMade           = "PaymentMade"           // 支付发起
+	EventPaymentUpdated        = "PaymentUpdated"        // 支付核验、结算、冲正
+	EventContractUpdated       = "ContractUpdated"       // 合同变更
+	EventMortgageUpdated       = "MortgageUpdated"       // 抵押创建、批准、承接、解除
+	EventDIDRegistered         = "DIDRegistered"         // DID注册
+	EventCredentialRevoked     = "CredentialRevoked"     // 凭证撤销
+	EventRealtyIndexRegistered = "RealtyIndexRegistered" // 主通道房产索引注册
+	EventChannelRegistered     = "ChannelRegistered"     // 主通道子通道注册
+)
+
+// 支付状态枚举
+const (
+	PaymentStatusInitiated    = "INITIATED"     // 已发起
+	PaymentStatusBankVerified = "BANK_VERIFIED" // 银行已核验
+	PaymentStatusSettled      = "SETTLED"       // 已结算
+	PaymentStatusReversed     = "REVERSED"      // 已冲正
 )
 
 // 组织MSP ID
@@ -51,7 +131,7 @@ const (
 	DocTypeMortgage    = "MG" // 抵押信息
 	DocTypeAudit       = "AD" // 审计记录
 	DocTypeUser        = "US" // 用户信息
-	DocTypeTax         = "TX" // 税费信息
+	DocTypeTax         = "TA" // 税费信息
 	DocTypePayment     = "PT" // 支付信息
 )
 
@@ -17,6 +17,9 @@ const (
 	// 资源不存在
 	NotFoundError = 404
 
+	// 房产已有进行中的交易
+	RealtyInSaleError = 409
+
 	// 服务层错误
 	ServiceError = 500
 
@@ -0,0 +1,40 @@
+package constants
+
+import "time"
+
+// 业务操作类型（由操作工作器按步骤执行的链上+数据库双写流程）
+const (
+	OperationCreateTransaction      = "CREATE_TRANSACTION"       // 创建交易
+	OperationPayForTransaction      = "PAY_FOR_TRANSACTION"      // 支付交易
+	OperationCreatePayment          = "CREATE_PAYMENT"           // 创建独立支付
+	OperationConfirmTransactionStep = "CONFIRM_TRANSACTION_STEP" // 交易步骤（审批、过户）上链后同步主通道索引和数据库
+	OperationCloseTransaction       = "CLOSE_TRANSACTION"        // 交易终止（拒绝、取消、超时）上链后同步数据库
+)
+
+// 业务操作状态枚举
+const (
+	OperationStatusPending      = "PENDING"      // 待执行或等待重试
+	OperationStatusSucceeded    = "SUCCEEDED"    // 全部步骤已完成
+	OperationStatusCompensating = "COMPENSATING" // 正在补偿已完成的步骤
+	OperationStatusCompensated  = "COMPENSATED"  // 已补偿
+	OperationStatusFailed       = "FAILED"       // 失败且无法补偿，需要人工处理
+)
+
+// 业务操作步骤状态枚举
+const (
+	OperationStepPending     = "PENDING"     // 未执行
+	OperationStepSucceeded   = "SUCCEEDED"   // 已完成
+	OperationStepFailed      = "FAILED"      // 执行失败
+	OperationStepCompensated = "COMPENSATED" // 已补偿
+	OperationStepSkipped     = "SKIPPED"     // 无需补偿或无法补偿
+)
+
+// 业务操作重试策略
+const (
+	OperationMaxAttempts  = 5               // 单个步骤最多执行次数
+	OperationRetryBackoff = 2 * time.Second // 首次重试间隔，之后每次翻倍
+	OperationMaxBackoff   = 5 * time.Minute // 最大重试间隔
+
+	OperationWaitInterval = 5 * time.Second  // 等待链上交易上链时的检查间隔，等待不计入执行次数
+	OperationWaitTimeout  = 30 * time.Minute // 等待链上交易上链的最长时间，离线签名的交易从创建提案开始计时
+)
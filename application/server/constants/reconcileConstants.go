@@ -0,0 +1,31 @@
+package constants
+
+// 对账触发方式
+const (
+	ReconcileTriggerScheduled = "SCHEDULED" // 定时对账
+	ReconcileTriggerManual    = "MANUAL"    // 管理员手动对账
+)
+
+// 对账状态枚举
+const (
+	ReconcileStatusRunning   = "RUNNING"   // 对账中
+	ReconcileStatusCompleted = "COMPLETED" // 已完成
+	ReconcileStatusFailed    = "FAILED"    // 对账中断
+)
+
+// 对账对象类型
+const (
+	ReconcileEntityRealty      = "REALTY"      // 房产
+	ReconcileEntityTransaction = "TRANSACTION" // 交易
+	ReconcileEntityPayment     = "PAYMENT"     // 支付
+)
+
+// 对账差异类型
+const (
+	DiscrepancyMissingIndex      = "MISSING_INDEX"       // 子通道有记录，主通道缺少索引
+	DiscrepancyMissingSubChannel = "MISSING_SUB_CHANNEL" // 主通道有索引，子通道缺少记录
+	DiscrepancyMissingDatabase   = "MISSING_DATABASE"    // 链上有记录，MySQL缺少记录
+	DiscrepancyMissingChain      = "MISSING_CHAIN"       // MySQL有记录，链上缺少记录
+	DiscrepancyIndexMismatch     = "INDEX_MISMATCH"      // 主通道索引与子通道字段不一致
+	DiscrepancyDatabaseMismatch  = "DATABASE_MISMATCH"   // MySQL与链上字段不一致
+)
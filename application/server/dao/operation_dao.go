@@ -0,0 +1,84 @@
+package dao
+
+import (
+	"fmt"
+	"grets_server/constants"
+	"grets_server/db"
+	"grets_server/db/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OperationDAO 业务操作（事务发件箱）数据访问对象
+type OperationDAO struct {
+	mysqlDB *gorm.DB
+}
+
+// 创建新的OperationDAO实例
+func NewOperationDAO() *OperationDAO {
+	return &OperationDAO{
+		mysqlDB: db.GlobalMysql,
+	}
+}
+
+// CreateOperation 在同一个数据库事务中创建业务操作及其全部步骤
+func (dao *OperationDAO) CreateOperation(operation *models.Operation, stepList []*models.OperationStep) error {
+	return dao.mysqlDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(operation).Error; err != nil {
+			return fmt.Errorf("创建业务操作失败: %v", err)
+		}
+		if len(stepList) > 0 {
+			if err := tx.Create(&stepList).Error; err != nil {
+				return fmt.Errorf("创建业务操作步骤失败: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetOperationByUUID 根据UUID获取业务操作
+func (dao *OperationDAO) GetOperationByUUID(operationUUID string) (*models.Operation, error) {
+	var operation models.Operation
+	if err := dao.mysqlDB.First(&operation, "operation_uuid = ?", operationUUID).Error; err != nil {
+		return nil, fmt.Errorf("根据UUID查询业务操作失败: %v", err)
+	}
+	return &operation, nil
+}
+
+// GetOperationStepList 获取业务操作的步骤列表，按步骤序号排序
+func (dao *OperationDAO) GetOperationStepList(operationUUID string) ([]*models.OperationStep, error) {
+	var stepList []*models.OperationStep
+	if err := dao.mysqlDB.Where("operation_uuid = ?", operationUUID).Order("step_index").Find(&stepList).Error; err != nil {
+		return nil, fmt.Errorf("查询业务操作步骤失败: %v", err)
+	}
+	return stepList, nil
+}
+
+// QueryDueOperationList 查询到达执行时间的待执行、待补偿业务操作
+func (dao *OperationDAO) QueryDueOperationList(now time.Time, limit int) ([]*models.Operation, error) {
+	var operationList []*models.Operation
+	if err := dao.mysqlDB.
+		Where("status IN ? AND next_retry_time <= ?", []string{constants.OperationStatusPending, constants.OperationStatusCompensating}, now).
+		Order("next_retry_time").
+		Limit(limit).
+		Find(&operationList).Error; err != nil {
+		return nil, fmt.Errorf("查询待执行业务操作失败: %v", err)
+	}
+	return operationList, nil
+}
+
+// SaveOperation 在同一个数据库事务中保存业务操作和步骤的执行进度
+func (dao *OperationDAO) SaveOperation(operation *models.Operation, step *models.OperationStep) error {
+	return dao.mysqlDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(operation).Error; err != nil {
+			return fmt.Errorf("保存业务操作失败: %v", err)
+		}
+		if step != nil {
+			if err := tx.Save(step).Error; err != nil {
+				return fmt.Errorf("保存业务操作步骤失败: %v", err)
+			}
+		}
+		return nil
+	})
+}
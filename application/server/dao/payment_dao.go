@@ -41,8 +41,14 @@ func NewPaymentDAO() *PaymentDAO {
 
 // CreatePayment 创建支付记录
 func (dao *PaymentDAO) CreatePayment(payment *models.Payment) error {
+	// 链码事件投影可能已先写入该支付，此时覆盖投影记录
+	var existing models.Payment
+	if err := dao.mysqlDB.Select("id").First(&existing, "payment_uuid = ?", payment.PaymentUUID).Error; err == nil {
+		payment.ID = existing.ID
+	}
+
 	// 保存到MySQL数据库
-	if err := dao.mysqlDB.Create(payment).Error; err != nil {
+	if err := dao.mysqlDB.Save(payment).Error; err != nil {
 		return fmt.Errorf("创建支付记录失败: %v", err)
 	}
 
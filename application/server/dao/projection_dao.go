@@ -0,0 +1,155 @@
+package dao
+
+import (
+	"errors"
+	"fmt"
+	"grets_server/db"
+	"grets_server/db/models"
+
+	"gorm.io/gorm"
+)
+
+// ProjectionDAO 链上数据投影访问对象，按链码事件幂等地更新房产、交易、支付表
+type ProjectionDAO struct {
+	mysqlDB *gorm.DB
+}
+
+// 创建新的ProjectionDAO实例
+func NewProjectionDAO() *ProjectionDAO {
+	return &ProjectionDAO{
+		mysqlDB: db.GlobalMysql,
+	}
+}
+
+// GetCheckpoint 获取通道的投影检查点，不存在时返回nil
+func (dao *ProjectionDAO) GetCheckpoint(channelName string) (*models.ProjectionCheckpoint, error) {
+	var checkpoint models.ProjectionCheckpoint
+	if err := dao.mysqlDB.First(&checkpoint, "channel_name = ?", channelName).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询投影检查点失败: %v", err)
+	}
+	return &checkpoint, nil
+}
+
+// QueryCheckpointList 查询全部通道的投影检查点
+func (dao *ProjectionDAO) QueryCheckpointList() ([]*models.ProjectionCheckpoint, error) {
+	var checkpointList []*models.ProjectionCheckpoint
+	if err := dao.mysqlDB.Order("channel_name").Find(&checkpointList).Error; err != nil {
+		return nil, fmt.Errorf("查询投影检查点失败: %v", err)
+	}
+	return checkpointList, nil
+}
+
+// DeleteCheckpointList 删除全部投影检查点，下次投影从0号区块开始
+func (dao *ProjectionDAO) DeleteCheckpointList() error {
+	if err := dao.mysqlDB.Where("1 = 1").Delete(&models.ProjectionCheckpoint{}).Error; err != nil {
+		return fmt.Errorf("删除投影检查点失败: %v", err)
+	}
+	return nil
+}
+
+// SaveProjection 在同一个数据库事务中写入投影数据和检查点，保证重放事件时不会重复或遗漏
+// 已存在的记录只更新链上字段，房产地址、图片等链下信息保持不变
+func (dao *ProjectionDAO) SaveProjection(
+	checkpoint *models.ProjectionCheckpoint,
+	realtyList []*models.Realty,
+	transactionList []*models.Transaction,
+	paymentList []*models.Payment,
+) error {
+	return dao.mysqlDB.Transaction(func(tx *gorm.DB) error {
+		for _, realty := range realtyList {
+			if err := upsertChainRealty(tx, realty); err != nil {
+				return err
+			}
+		}
+		for _, transaction := range transactionList {
+			if err := upsertChainTransaction(tx, transaction); err != nil {
+				return err
+			}
+		}
+		for _, payment := range paymentList {
+			if err := upsertChainPayment(tx, payment); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where(models.ProjectionCheckpoint{ChannelName: checkpoint.ChannelName}).
+			Assign(map[string]interface{}{"block_number": checkpoint.BlockNumber, "transaction_id": checkpoint.TransactionID}).
+			FirstOrCreate(&models.ProjectionCheckpoint{}).Error; err != nil {
+			return fmt.Errorf("保存投影检查点失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// upsertChainRealty 按房产ID写入链上房产，已存在时只更新类型、状态和所有者中非空的字段
+// 交易、抵押事件只带房产状态等部分字段，房产尚未投影时跳过，由房产创建事件写入完整记录
+func upsertChainRealty(tx *gorm.DB, realty *models.Realty) error {
+	var existing models.Realty
+	err := tx.Select("id").First(&existing, "realty_cert_hash = ?", realty.RealtyCertHash).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if realty.RealtyCert == "" {
+			return nil
+		}
+		err = tx.Create(realty).Error
+	} else if err == nil {
+		err = tx.Model(&existing).Updates(models.Realty{
+			RealtyType:                realty.RealtyType,
+			Status:                    realty.Status,
+			CurrentOwnerCitizenIDHash: realty.CurrentOwnerCitizenIDHash,
+			CurrentOwnerOrganization:  realty.CurrentOwnerOrganization,
+		}).Error
+	}
+	if err != nil {
+		return fmt.Errorf("写入房产[%s]失败: %v", realty.RealtyCertHash, err)
+	}
+	return nil
+}
+
+// upsertChainTransaction 按交易UUID写入链上交易，已存在时只更新买卖双方和状态
+func upsertChainTransaction(tx *gorm.DB, transaction *models.Transaction) error {
+	var existing models.Transaction
+	err := tx.Select("id").First(&existing, "transaction_uuid = ?", transaction.TransactionUUID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = tx.Create(transaction).Error
+	} else if err == nil {
+		err = tx.Model(&existing).Updates(models.Transaction{
+			RealtyCertHash:      transaction.RealtyCertHash,
+			SellerCitizenIDHash: transaction.SellerCitizenIDHash,
+			SellerOrganization:  transaction.SellerOrganization,
+			BuyerCitizenIDHash:  transaction.BuyerCitizenIDHash,
+			BuyerOrganization:   transaction.BuyerOrganization,
+			Status:              transaction.Status,
+		}).Error
+	}
+	if err != nil {
+		return fmt.Errorf("写入交易[%s]失败: %v", transaction.TransactionUUID, err)
+	}
+	return nil
+}
+
+// upsertChainPayment 按支付UUID写入链上支付，已存在时更新金额、收付款人和状态
+func upsertChainPayment(tx *gorm.DB, payment *models.Payment) error {
+	var existing models.Payment
+	err := tx.Select("id").First(&existing, "payment_uuid = ?", payment.PaymentUUID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = tx.Create(payment).Error
+	} else if err == nil {
+		err = tx.Model(&existing).Updates(models.Payment{
+			TransactionUUID:       payment.TransactionUUID,
+			PaymentType:           payment.PaymentType,
+			Amount:                payment.Amount,
+			PayerCitizenIDHash:    payment.PayerCitizenIDHash,
+			PayerOrganization:     payment.PayerOrganization,
+			ReceiverCitizenIDHash: payment.ReceiverCitizenIDHash,
+			ReceiverOrganization:  payment.ReceiverOrganization,
+			Status:                payment.Status,
+		}).Error
+	}
+	if err != nil {
+		return fmt.Errorf("写入支付[%s]失败: %v", payment.PaymentUUID, err)
+	}
+	return nil
+}
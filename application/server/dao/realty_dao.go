@@ -46,8 +46,14 @@ func (dao *RealEstateDAO) CreateRealEstate(re *models.Realty) error {
 		return fmt.Errorf("开启事务失败: %v", tx.Error)
 	}
 
+	// 链码事件投影可能已先写入该房产，此时覆盖投影记录
+	var existing models.Realty
+	if err := tx.Select("id").First(&existing, "realty_cert_hash = ?", re.RealtyCertHash).Error; err == nil {
+		re.ID = existing.ID
+	}
+
 	// 保存到MySQL数据库
-	if err := tx.Create(re).Error; err != nil {
+	if err := tx.Save(re).Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("创建房产记录失败: %v", err)
 	}
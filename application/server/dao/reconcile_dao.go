@@ -0,0 +1,106 @@
+package dao
+
+import (
+	"fmt"
+	"grets_server/db"
+	"grets_server/db/models"
+
+	"gorm.io/gorm"
+)
+
+// ReconcileDAO 对账数据访问对象
+type ReconcileDAO struct {
+	mysqlDB *gorm.DB
+}
+
+// 创建新的ReconcileDAO实例
+func NewReconcileDAO() *ReconcileDAO {
+	return &ReconcileDAO{
+		mysqlDB: db.GlobalMysql,
+	}
+}
+
+// QueryRealtyPage 按ID顺序分页读取房产，lastID为上一页最后一条记录的ID
+func (dao *ReconcileDAO) QueryRealtyPage(lastID int64, pageSize int) ([]*models.Realty, error) {
+	var realtyList []*models.Realty
+	if err := dao.mysqlDB.Where("id > ?", lastID).Order("id").Limit(pageSize).Find(&realtyList).Error; err != nil {
+		return nil, fmt.Errorf("分页查询房产失败: %v", err)
+	}
+	return realtyList, nil
+}
+
+// QueryTransactionPage 按ID顺序分页读取交易，lastID为上一页最后一条记录的ID
+func (dao *ReconcileDAO) QueryTransactionPage(lastID int64, pageSize int) ([]*models.Transaction, error) {
+	var transactionList []*models.Transaction
+	if err := dao.mysqlDB.Where("id > ?", lastID).Order("id").Limit(pageSize).Find(&transactionList).Error; err != nil {
+		return nil, fmt.Errorf("分页查询交易失败: %v", err)
+	}
+	return transactionList, nil
+}
+
+// QueryPaymentPage 按ID顺序分页读取支付，lastID为上一页最后一条记录的ID
+func (dao *ReconcileDAO) QueryPaymentPage(lastID int64, pageSize int) ([]*models.Payment, error) {
+	var paymentList []*models.Payment
+	if err := dao.mysqlDB.Where("id > ?", lastID).Order("id").Limit(pageSize).Find(&paymentList).Error; err != nil {
+		return nil, fmt.Errorf("分页查询支付失败: %v", err)
+	}
+	return paymentList, nil
+}
+
+// RepairRealty 用链上房产修复MySQL记录，只更新链上字段
+func (dao *ReconcileDAO) RepairRealty(realty *models.Realty) error {
+	return upsertChainRealty(dao.mysqlDB, realty)
+}
+
+// RepairTransaction 用链上交易修复MySQL记录，只更新链上字段
+func (dao *ReconcileDAO) RepairTransaction(transaction *models.Transaction) error {
+	return upsertChainTransaction(dao.mysqlDB, transaction)
+}
+
+// RepairPayment 用链上支付修复MySQL记录，只更新链上字段
+func (dao *ReconcileDAO) RepairPayment(payment *models.Payment) error {
+	return upsertChainPayment(dao.mysqlDB, payment)
+}
+
+// CreateReport 创建对账报告
+func (dao *ReconcileDAO) CreateReport(report *models.ReconcileReport) error {
+	if err := dao.mysqlDB.Create(report).Error; err != nil {
+		return fmt.Errorf("创建对账报告失败: %v", err)
+	}
+	return nil
+}
+
+// UpdateReport 更新对账报告
+func (dao *ReconcileDAO) UpdateReport(report *models.ReconcileReport) error {
+	if err := dao.mysqlDB.Save(report).Error; err != nil {
+		return fmt.Errorf("更新对账报告失败: %v", err)
+	}
+	return nil
+}
+
+// GetReportByUUID 根据UUID获取对账报告
+func (dao *ReconcileDAO) GetReportByUUID(reportUUID string) (*models.ReconcileReport, error) {
+	var report models.ReconcileReport
+	if err := dao.mysqlDB.First(&report, "report_uuid = ?", reportUUID).Error; err != nil {
+		return nil, fmt.Errorf("根据UUID查询对账报告失败: %v", err)
+	}
+	return &report, nil
+}
+
+// QueryReportList 分页查询对账报告（不含差异明细），按开始时间倒序
+func (dao *ReconcileDAO) QueryReportList(pageSize int, pageNumber int) ([]*models.ReconcileReport, int64, error) {
+	var reportList []*models.ReconcileReport
+	var total int64
+	query := dao.mysqlDB.Model(&models.ReconcileReport{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询对账报告总数失败: %v", err)
+	}
+	if err := query.Omit("discrepancy_list").
+		Order("start_time DESC").
+		Offset((pageNumber - 1) * pageSize).
+		Limit(pageSize).
+		Find(&reportList).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询对账报告失败: %v", err)
+	}
+	return reportList, total, nil
+}
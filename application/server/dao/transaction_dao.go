@@ -94,8 +94,14 @@ func NewTransactionDAO() *TransactionDAO {
 
 // CreateTransaction 创建交易记录
 func (dao *TransactionDAO) CreateTransaction(tx *models.Transaction) error {
+	// 链码事件投影可能已先写入该交易，此时覆盖投影记录
+	var existing models.Transaction
+	if err := dao.mysqlDB.Select("id").First(&existing, "transaction_uuid = ?", tx.TransactionUUID).Error; err == nil {
+		tx.ID = existing.ID
+	}
+
 	// 保存到MySQL数据库
-	if err := dao.mysqlDB.Create(tx).Error; err != nil {
+	if err := dao.mysqlDB.Save(tx).Error; err != nil {
 		return fmt.Errorf("创建交易记录失败: %v", err)
 	}
 
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Operation 业务操作（事务发件箱），记录需要依次写入链上和数据库的多步流程，由操作工作器执行、重试和补偿
+type Operation struct {
+	ID            int64     `gorm:"primaryKey;autoIncrement:true;size:64" json:"id"`   // 操作ID
+	OperationUUID string    `gorm:"size:64;uniqueIndex;not null" json:"operationUUID"` // 操作UUID
+	OperationType string    `gorm:"size:50;index;not null" json:"operationType"`       // 操作类型
+	BusinessKey   string    `gorm:"size:100;index" json:"businessKey"`                 // 业务主键，如交易UUID、支付UUID
+	Payload       string    `gorm:"type:text;not null" json:"payload"`                 // 操作参数JSON，重试时保持不变
+	Status        string    `gorm:"size:30;index;not null" json:"status"`              // 操作状态
+	CurrentStep   int       `gorm:"not null" json:"currentStep"`                       // 下一个待执行的步骤序号
+	Attempts      int       `gorm:"not null" json:"attempts"`                          // 当前步骤已执行次数
+	LastError     string    `gorm:"type:text" json:"lastError"`                        // 最近一次错误
+	NextRetryTime time.Time `gorm:"index;not null" json:"nextRetryTime"`               // 下次执行时间
+	CreateTime    time.Time `gorm:"autoCreateTime" json:"createTime"`                  // 创建时间
+	UpdateTime    time.Time `gorm:"autoUpdateTime" json:"updateTime"`                  // 更新时间
+}
+
+// OperationStep 业务操作步骤执行记录
+type OperationStep struct {
+	ID            int64      `gorm:"primaryKey;autoIncrement:true;size:64" json:"id"` // 步骤ID
+	OperationUUID string     `gorm:"size:64;index;not null" json:"operationUUID"`     // 操作UUID
+	StepIndex     int        `gorm:"not null" json:"stepIndex"`                       // 步骤序号
+	StepName      string     `gorm:"size:100;not null" json:"stepName"`               // 步骤名称
+	Status        string     `gorm:"size:30;not null" json:"status"`                  // 步骤状态
+	Attempts      int        `gorm:"not null" json:"attempts"`                        // 执行次数
+	LastError     string     `gorm:"type:text" json:"lastError"`                      // 最近一次错误
+	FinishTime    *time.Time `gorm:"null" json:"finishTime"`                          // 完成或补偿时间
+	CreateTime    time.Time  `gorm:"autoCreateTime" json:"createTime"`                // 创建时间
+	UpdateTime    time.Time  `gorm:"autoUpdateTime" json:"updateTime"`                // 更新时间
+}
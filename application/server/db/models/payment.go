@@ -13,6 +13,7 @@ type Payment struct {
 	PayerOrganization     string    `gorm:"size:50" json:"payerOrganization"`                // 付款人组织机构代码
 	ReceiverCitizenIDHash string    `gorm:"size:255" json:"receiverCitizenIDHash"`           // 收款人身份证号哈希
 	ReceiverOrganization  string    `gorm:"size:50" json:"receiverOrganization"`             // 收款人组织机构代码
+	Status                string    `gorm:"size:30;index" json:"status"`                     // 支付状态
 	CreateTime            time.Time `gorm:"autoCreateTime" json:"createTime"`                // 创建时间
 	Remarks               string    `gorm:"type:text" json:"remarks"`                        // 备注
 }
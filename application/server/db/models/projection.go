@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// ProjectionCheckpoint 链上数据投影检查点，记录每个通道最后写入MySQL的链码事件
+type ProjectionCheckpoint struct {
+	ID            int64     `gorm:"primaryKey;autoIncrement:true;size:64" json:"id"`  // 检查点ID
+	ChannelName   string    `gorm:"size:100;uniqueIndex;not null" json:"channelName"` // 通道名
+	BlockNumber   uint64    `gorm:"not null" json:"blockNumber"`                      // 最后处理的区块号
+	TransactionID string    `gorm:"size:100" json:"transactionID"`                    // 最后处理的交易ID
+	UpdateTime    time.Time `gorm:"autoUpdateTime" json:"updateTime"`                 // 更新时间
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// ReconcileReport 链上与数据库对账报告
+type ReconcileReport struct {
+	ID                 int64      `gorm:"primaryKey;autoIncrement:true;size:64" json:"id"` // 报告ID
+	ReportUUID         string     `gorm:"size:64;uniqueIndex;not null" json:"reportUUID"`  // 报告UUID
+	TriggerType        string     `gorm:"size:30;not null" json:"triggerType"`             // 触发方式：定时、手动
+	AutoRepair         bool       `gorm:"not null" json:"autoRepair"`                      // 是否用链上数据修复MySQL
+	Status             string     `gorm:"size:30;index;not null" json:"status"`            // 对账状态
+	RealtyChecked      int        `gorm:"not null" json:"realtyChecked"`                   // 已核对房产数
+	TransactionChecked int        `gorm:"not null" json:"transactionChecked"`              // 已核对交易数
+	PaymentChecked     int        `gorm:"not null" json:"paymentChecked"`                  // 已核对支付数
+	DiscrepancyCount   int        `gorm:"not null" json:"discrepancyCount"`                // 差异数
+	RepairedCount      int        `gorm:"not null" json:"repairedCount"`                   // 已修复差异数
+	DiscrepancyList    string     `gorm:"type:longtext" json:"discrepancyList"`            // 差异明细JSON
+	ErrorMessage       string     `gorm:"type:text" json:"errorMessage"`                   // 对账中断原因
+	StartTime          time.Time  `gorm:"not null" json:"startTime"`                       // 开始时间
+	EndTime            *time.Time `gorm:"null" json:"endTime"`                             // 结束时间
+}
@@ -83,6 +83,10 @@ func autoMigrate(db *gorm.DB) error {
 		&models.DIDAuthChallenge{},
 		&models.DIDKeyPair{},
 		&models.UserDIDMapping{},
+		&models.ProjectionCheckpoint{},
+		&models.Operation{},
+		&models.OperationStep{},
+		&models.ReconcileReport{},
 	)
 
 	if err != nil {
@@ -4,7 +4,9 @@ package block_dto
 type QueryBlockDTO struct {
 	BlockHash    string `json:"blockHash"`
 	ProvinceName string `json:"provinceName"`
-	Creator      string `json:"creator"`
+	Creator      string `json:"creator"`   // 创建者地址或"MSPID/CN"
+	StartDate    string `json:"startDate"` // 开始日期
+	EndDate      string `json:"endDate"`   // 结束日期
 	Organization string `json:"organization"`
 	PageSize     int    `json:"pageSize"`
 	PageNumber   int    `json:"pageNumber"`
@@ -16,6 +18,37 @@ type QueryBlockTransactionDTO struct {
 	ChannelName string `json:"channelName"`
 }
 
+// QueryChainTransactionDTO 查询链上交易列表
+type QueryChainTransactionDTO struct {
+	ChannelName   string `json:"channelName"`
+	ProvinceName  string `json:"provinceName"`
+	Creator       string `json:"creator"`       // 创建者地址或"MSPID/CN"
+	ChaincodeName string `json:"chaincodeName"` // 链码名称
+	FunctionName  string `json:"functionName"`  // 链码函数名称
+	StartDate     string `json:"startDate"`     // 开始日期
+	EndDate       string `json:"endDate"`       // 结束日期
+	Organization  string `json:"organization"`
+	PageSize      int    `json:"pageSize"`
+	PageNumber    int    `json:"pageNumber"`
+}
+
+// StreamBlockDTO 订阅新区块和新交易推送，条件为空表示不限
+type StreamBlockDTO struct {
+	ChannelName  string `form:"channelName"`  // 通道名
+	ProvinceName string `form:"provinceName"` // 省份名称，对应省份的子通道
+	FunctionName string `form:"functionName"` // 链码函数名称
+}
+
+// QueryChainTxStatusDTO 查询链上交易提交状态
+type QueryChainTxStatusDTO struct {
+	WaitSeconds int `form:"waitSeconds"` // 交易尚未确认时最多等待的秒数，0表示立即返回
+}
+
+// RunBlockVerifyDTO 手动校验区块哈希链请求
+type RunBlockVerifyDTO struct {
+	ChannelName string `json:"channelName"` // 通道名，为空时校验所有通道
+}
+
 // ChannelInfo 子通道信息结构
 type ChannelInfo struct {
 	ChannelName   string   `json:"channelName"`   // 通道名
@@ -46,3 +79,10 @@ type TransactionIndex struct {
 	Status          string `json:"status"`          // 状态
 	CreateTime      int64  `json:"createTime"`      // 创建时间
 }
+
+// PaymentIndex 独立支付索引，记录不属于房产交易的支付所在的子通道
+type PaymentIndex struct {
+	PaymentUUID string `json:"paymentUUID"` // 支付UUID
+	ChannelName string `json:"channelName"` // 所在子通道名
+	CreateTime  int64  `json:"createTime"`  // 创建时间
+}
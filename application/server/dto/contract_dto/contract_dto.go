@@ -4,17 +4,41 @@ import "time"
 
 // ContractDTO 合同结构体
 type ContractDTO struct {
-	ID                   int64     `json:"id"`
-	ContractUUID         string    `json:"contractUUID"`
-	TransactionUUID      string    `json:"transactionUUID"`
-	Title                string    `json:"title"`
-	DocHash              string    `json:"docHash"`
-	Content              string    `json:"content"`
-	Status               string    `json:"status"`
-	ContractType         string    `json:"contractType"`
-	CreatorCitizenIDHash string    `json:"creatorCitizenIDHash"`
-	CreateTime           time.Time `json:"createTime"`
-	UpdateTime           time.Time `json:"updateTime"`
+	ID                   int64                   `json:"id"`
+	ContractUUID         string                  `json:"contractUUID"`
+	TransactionUUID      string                  `json:"transactionUUID"`
+	Title                string                  `json:"title"`
+	DocHash              string                  `json:"docHash"`
+	Content              string                  `json:"content"`
+	Status               string                  `json:"status"`
+	ContractType         string                  `json:"contractType"`
+	CreatorCitizenIDHash string                  `json:"creatorCitizenIDHash"`
+	RequiredPartyList    []string                `json:"requiredPartyList"` // 须签署的合同方
+	SignatureList        []*ContractSignatureDTO `json:"signatureList"`     // 链上签署记录
+	AuditList            []*ContractAuditDTO     `json:"auditList"`         // 链上审核记录
+	CreateTime           time.Time               `json:"createTime"`
+	UpdateTime           time.Time               `json:"updateTime"`
+}
+
+// ContractSignatureDTO 合同签署记录
+type ContractSignatureDTO struct {
+	PartyRole           string    `json:"partyRole"`           // 签署方角色
+	SignerCitizenIDHash string    `json:"signerCitizenIDHash"` // 签署人
+	SignerOrganization  string    `json:"signerOrganization"`  // 签署人组织
+	DID                 string    `json:"did"`                 // 签署人DID
+	DocHash             string    `json:"docHash"`             // 签署时的文档哈希
+	Signature           string    `json:"signature"`           // 签名
+	SignTime            time.Time `json:"signTime"`            // 签署时间
+}
+
+// ContractAuditDTO 合同审核记录
+type ContractAuditDTO struct {
+	Result               string    `json:"result"`               // 审核结果
+	Comments             string    `json:"comments"`             // 审核意见
+	RevisionRequirements string    `json:"revisionRequirements"` // 修改要求
+	RejectionReason      string    `json:"rejectionReason"`      // 拒绝理由
+	DocHash              string    `json:"docHash"`              // 审核时的文档哈希
+	AuditTime            time.Time `json:"auditTime"`            // 审核时间
 }
 
 // CreateContractDTO 合同请求和响应结构体
@@ -24,6 +48,8 @@ type CreateContractDTO struct {
 	Content          string `json:"content"`
 	ContractType     string `json:"contractType"`
 	CreatorCitizenID string `json:"creatorCitizenID"`
+	BuyerCitizenID   string `json:"buyerCitizenID" binding:"required"`  // 买方签署人身份证号
+	SellerCitizenID  string `json:"sellerCitizenID" binding:"required"` // 卖方签署人身份证号
 }
 
 type QueryContractDTO struct {
@@ -39,14 +65,15 @@ type QueryContractDTO struct {
 }
 
 type SignContractDTO struct {
-	SignerType string `json:"signerType"`
+	SignerType string `json:"signerType" binding:"required"` // 签署方：buyer/seller
+	Signature  string `json:"signature" binding:"required"`  // 签署人DID私钥对docHash的签名
 }
 
 type AuditContractDTO struct {
-	Result               string `json:"result"`               // 审核结果：approved/rejected/needRevision
-	Comments             string `json:"comments"`             // 审核意见
-	RevisionRequirements string `json:"revisionRequirements"` // 修改要求
-	RejectionReason      string `json:"rejectionReason"`      // 拒绝理由
+	Result               string `json:"result" binding:"required"` // 审核结果：approved/rejected/needRevision
+	Comments             string `json:"comments"`                  // 审核意见
+	RevisionRequirements string `json:"revisionRequirements"`      // 修改要求
+	RejectionReason      string `json:"rejectionReason"`           // 拒绝理由
 }
 
 type UpdateContractDTO struct {
@@ -150,3 +150,8 @@ type DIDRegistrationResponse struct {
 	Credentials []did.VerifiableCredential `json:"credentials"`
 	Message     string                     `json:"message"`
 }
+
+// OfflineSignatureRequest 离线签名请求
+type OfflineSignatureRequest struct {
+	Signature string `json:"signature" binding:"required"` // 用DID私钥对会话待签名内容的签名，十六进制r||s或DER编码
+}
@@ -0,0 +1,49 @@
+package mortgage_dto
+
+import "time"
+
+// MortgageDTO 抵押DTO
+type MortgageDTO struct {
+	MortgageUUID           string    `json:"mortgageUUID"`           // 抵押UUID
+	RealtyCertHash         string    `json:"realtyCertHash"`         // 抵押房产ID
+	MortgagorCitizenIDHash string    `json:"mortgagorCitizenIDHash"` // 抵押人
+	MortgagorOrganization  string    `json:"mortgagorOrganization"`  // 抵押人组织机构代码
+	AssumeCitizenIDHash    string    `json:"assumeCitizenIDHash"`    // 承接人
+	AssumeOrganization     string    `json:"assumeOrganization"`     // 承接人组织机构代码
+	LoanAmount             float64   `json:"loanAmount"`             // 贷款金额
+	InterestRate           float64   `json:"interestRate"`           // 利率
+	Term                   int       `json:"term"`                   // 期限(月)
+	CollateralValue        float64   `json:"collateralValue"`        // 抵押物估值
+	PaymentPlan            string    `json:"paymentPlan"`            // 还款计划
+	Status                 string    `json:"status"`                 // 抵押状态
+	CreateTime             time.Time `json:"createTime"`             // 创建时间
+	ApprovedTime           time.Time `json:"approvedTime"`           // 批准时间
+	ReleasedTime           time.Time `json:"releasedTime"`           // 解除时间
+	LastUpdateTime         time.Time `json:"lastUpdateTime"`         // 最后更新时间
+}
+
+// CreateMortgageDTO 创建抵押请求
+type CreateMortgageDTO struct {
+	RealtyCert            string  `json:"realtyCert" binding:"required"`            // 不动产证号
+	MortgagorCitizenID    string  `json:"mortgagorCitizenID" binding:"required"`    // 抵押人身份证号
+	MortgagorOrganization string  `json:"mortgagorOrganization" binding:"required"` // 抵押人组织机构代码
+	LoanAmount            float64 `json:"loanAmount" binding:"required"`            // 贷款金额
+	InterestRate          float64 `json:"interestRate"`                             // 利率
+	Term                  int     `json:"term"`                                     // 期限(月)
+	CollateralValue       float64 `json:"collateralValue"`                          // 抵押物估值
+	PaymentPlan           string  `json:"paymentPlan"`                              // 还款计划
+}
+
+// MortgageActionDTO 批准/解除抵押请求
+type MortgageActionDTO struct {
+	MortgageUUID   string `json:"mortgageUUID" binding:"required"`   // 抵押UUID
+	RealtyCertHash string `json:"realtyCertHash" binding:"required"` // 抵押房产ID
+}
+
+// AssumeMortgageDTO 买方承接抵押请求
+type AssumeMortgageDTO struct {
+	MortgageUUID       string `json:"mortgageUUID" binding:"required"`       // 抵押UUID
+	RealtyCertHash     string `json:"realtyCertHash" binding:"required"`     // 抵押房产ID
+	AssumeCitizenID    string `json:"assumeCitizenID" binding:"required"`    // 承接人身份证号
+	AssumeOrganization string `json:"assumeOrganization" binding:"required"` // 承接人组织机构代码
+}
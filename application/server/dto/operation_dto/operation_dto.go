@@ -0,0 +1,27 @@
+package operation_dto
+
+import "time"
+
+// OperationDTO 业务操作执行状态
+type OperationDTO struct {
+	OperationUUID string              `json:"operationUUID"` // 操作UUID
+	OperationType string              `json:"operationType"` // 操作类型
+	BusinessKey   string              `json:"businessKey"`   // 业务主键，如交易UUID、支付UUID
+	Status        string              `json:"status"`        // 操作状态
+	CurrentStep   int                 `json:"currentStep"`   // 下一个待执行的步骤序号
+	LastError     string              `json:"lastError"`     // 最近一次错误
+	NextRetryTime time.Time           `json:"nextRetryTime"` // 下次执行时间
+	CreateTime    time.Time           `json:"createTime"`    // 创建时间
+	UpdateTime    time.Time           `json:"updateTime"`    // 更新时间
+	StepList      []*OperationStepDTO `json:"stepList"`      // 步骤列表
+}
+
+// OperationStepDTO 业务操作步骤执行状态
+type OperationStepDTO struct {
+	StepIndex  int        `json:"stepIndex"`  // 步骤序号
+	StepName   string     `json:"stepName"`   // 步骤名称
+	Status     string     `json:"status"`     // 步骤状态
+	Attempts   int        `json:"attempts"`   // 执行次数
+	LastError  string     `json:"lastError"`  // 最近一次错误
+	FinishTime *time.Time `json:"finishTime"` // 完成或补偿时间
+}
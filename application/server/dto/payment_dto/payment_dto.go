@@ -13,10 +13,37 @@ type PaymentDTO struct {
 	PayerOrganization     string    `json:"payerOrganization"`     // 付款人组织机构代码
 	ReceiverCitizenIDHash string    `json:"receiverCitizenIDHash"` // 收款人身份证号哈希
 	ReceiverOrganization  string    `json:"receiverOrganization"`  // 收款人组织机构代码
+	Status                string    `json:"status"`                // 支付状态
 	CreateTime            time.Time `json:"createTime"`            // 创建时间
 	Remarks               string    `json:"remarks"`               // 备注
 }
 
+// EscrowDTO 交易资金托管账户
+type EscrowDTO struct {
+	TransactionUUID     string              `json:"transactionUUID"`     // 关联交易UUID
+	BuyerCitizenIDHash  string              `json:"buyerCitizenIDHash"`  // 买方
+	BuyerOrganization   string              `json:"buyerOrganization"`   // 买方组织机构代码
+	SellerCitizenIDHash string              `json:"sellerCitizenIDHash"` // 卖方
+	SellerOrganization  string              `json:"sellerOrganization"`  // 卖方组织机构代码
+	TransferAmount      float64             `json:"transferAmount"`      // 托管房款
+	TaxAmount           float64             `json:"taxAmount"`           // 托管税费
+	DepositList         []*EscrowDepositDTO `json:"depositList"`         // 托管明细
+	Status              string              `json:"status"`              // 托管状态
+	CreateTime          time.Time           `json:"createTime"`          // 创建时间
+	SettleTime          time.Time           `json:"settleTime"`          // 放款/退款时间
+	LastUpdateTime      time.Time           `json:"lastUpdateTime"`      // 最后更新时间
+}
+
+// EscrowDepositDTO 托管明细
+type EscrowDepositDTO struct {
+	PaymentUUID        string    `json:"paymentUUID"`        // 支付ID
+	PaymentType        string    `json:"paymentType"`        // 支付类型
+	PayerCitizenIDHash string    `json:"payerCitizenIDHash"` // 付款人身份证号哈希
+	PayerOrganization  string    `json:"payerOrganization"`  // 付款人组织机构代码
+	Amount             float64   `json:"amount"`             // 金额
+	CreateTime         time.Time `json:"createTime"`         // 托管时间
+}
+
 // PayForTransactionDTO 支付交易请求
 type PayForTransactionDTO struct {
 	TransactionUUID       string  `json:"transactionUUID"`       // 交易ID
@@ -40,6 +67,11 @@ type CreatePaymentDTO struct {
 	Remarks              string  `json:"remarks"`              // 备注
 }
 
+// ReversePaymentDTO 冲正支付请求
+type ReversePaymentDTO struct {
+	Reason string `json:"reason" binding:"required"` // 冲正原因
+}
+
 type QueryPaymentDTO struct {
 	PaymentUUID       string `json:"paymentUUID"`
 	TransactionUUID   string `json:"transactionUUID"`
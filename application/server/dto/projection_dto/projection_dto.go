@@ -0,0 +1,17 @@
+package projection_dto
+
+import "time"
+
+// ProjectionStatusDTO 投影运行状态
+type ProjectionStatusDTO struct {
+	Running        bool                       `json:"running"`        // 是否正在投影
+	CheckpointList []*ProjectionCheckpointDTO `json:"checkpointList"` // 各通道检查点
+}
+
+// ProjectionCheckpointDTO 通道投影检查点
+type ProjectionCheckpointDTO struct {
+	ChannelName   string    `json:"channelName"`   // 通道名
+	BlockNumber   uint64    `json:"blockNumber"`   // 最后处理的区块号
+	TransactionID string    `json:"transactionID"` // 最后处理的交易ID
+	UpdateTime    time.Time `json:"updateTime"`    // 更新时间
+}
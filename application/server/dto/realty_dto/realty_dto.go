@@ -4,31 +4,34 @@ import "time"
 
 // RealtyDTO 房产基本DTO
 type RealtyDTO struct {
-	ID                              int64     `json:"id"`                              // 房产ID
-	RealtyCertHash                  string    `json:"realtyCertHash"`                  // 不动产证ID
-	RealtyCert                      string    `json:"realtyCert"`                      // 不动产证ID
-	RealtyType                      string    `json:"realtyType"`                      // 建筑类型
-	Price                           float64   `json:"price"`                           // 房产价格
-	Area                            float64   `json:"area"`                            // 房产面积
-	Province                        string    `json:"province"`                        // 省
-	City                            string    `json:"city"`                            // 市
-	District                        string    `json:"district"`                        // 区
-	Street                          string    `json:"street"`                          // 街道
-	Community                       string    `json:"community"`                       // 小区
-	Unit                            string    `json:"unit"`                            // 单元
-	Floor                           string    `json:"floor"`                           // 楼层
-	Room                            string    `json:"room"`                            // 房号
-	HouseType                       string    `json:"houseType"`                       // 户型
-	IsNewHouse                      bool      `json:"isNewHouse"`                      // 是否为新房
-	Images                          []string  `json:"images"`                          // 图片链接JSON数组
-	CurrentOwnerCitizenIDHash       string    `json:"currentOwnerCitizenIDHash"`       // 当前所有者
-	CurrentOwnerOrganization        string    `json:"currentOwnerOrganization"`        // 当前持有者组织
-	PreviousOwnersCitizenIDHashList []string  `json:"previousOwnersCitizenIDHashList"` // 历史所有者
-	RelContractUUID                 string    `json:"relContractUUID"`                 // 关联合同UUID
-	CreateTime                      time.Time `json:"createTime"`                      // 创建时间
-	Status                          string    `json:"status"`                          // 房产当前状态
-	LastUpdateTime                  time.Time `json:"lastUpdateTime"`                  // 最后更新时间
-	Description                     string    `json:"description"`                     // 房产描述
+	ID                              int64              `json:"id"`                              // 房产ID
+	RealtyCertHash                  string             `json:"realtyCertHash"`                  // 不动产证ID
+	RealtyCert                      string             `json:"realtyCert"`                      // 不动产证ID
+	RealtyType                      string             `json:"realtyType"`                      // 建筑类型
+	Price                           float64            `json:"price"`                           // 房产价格
+	Area                            float64            `json:"area"`                            // 房产面积
+	Province                        string             `json:"province"`                        // 省
+	City                            string             `json:"city"`                            // 市
+	District                        string             `json:"district"`                        // 区
+	Street                          string             `json:"street"`                          // 街道
+	Community                       string             `json:"community"`                       // 小区
+	Unit                            string             `json:"unit"`                            // 单元
+	Floor                           string             `json:"floor"`                           // 楼层
+	Room                            string             `json:"room"`                            // 房号
+	HouseType                       string             `json:"houseType"`                       // 户型
+	IsNewHouse                      bool               `json:"isNewHouse"`                      // 是否为新房
+	Images                          []string           `json:"images"`                          // 图片链接JSON数组
+	CurrentOwnerCitizenIDHash       string             `json:"currentOwnerCitizenIDHash"`       // 当前所有者
+	CurrentOwnerOrganization        string             `json:"currentOwnerOrganization"`        // 当前持有者组织
+	PreviousOwnersCitizenIDHashList []string           `json:"previousOwnersCitizenIDHashList"` // 历史所有者
+	OwnerList                       []*RealtyOwnerDTO  `json:"ownerList"`                       // 共有人及份额
+	RelContractUUID                 string             `json:"relContractUUID"`                 // 关联合同UUID
+	CreateTime                      time.Time          `json:"createTime"`                      // 创建时间
+	Status                          string             `json:"status"`                          // 房产当前状态
+	ActiveTransactionUUID           string             `json:"activeTransactionUUID"`           // 进行中的交易UUID
+	FreezeHistory                   []*RealtyFreezeDTO `json:"freezeHistory"`                   // 司法冻结历史
+	LastUpdateTime                  time.Time          `json:"lastUpdateTime"`                  // 最后更新时间
+	Description                     string             `json:"description"`                     // 房产描述
 }
 
 // CreateRealtyDTO 创建房产请求
@@ -96,3 +99,59 @@ type QueryRealtyListDTO struct {
 	PageNumber int     `json:"pageNumber"` // 页码
 	Status     string  `json:"status"`     // 状态
 }
+
+// RealtyFreezeDTO 房产司法冻结记录
+type RealtyFreezeDTO struct {
+	FreezeID         string    `json:"freezeID"`         // 冻结记录ID
+	CourtOrderHash   string    `json:"courtOrderHash"`   // 法律文书哈希
+	Authority        string    `json:"authority"`        // 冻结机关
+	PreviousStatus   string    `json:"previousStatus"`   // 冻结前的房产状态
+	Status           string    `json:"status"`           // 冻结状态
+	FreezeClientID   string    `json:"freezeClientID"`   // 冻结操作人
+	FreezeMSPID      string    `json:"freezeMSPID"`      // 冻结操作人所属MSP
+	FreezeTime       time.Time `json:"freezeTime"`       // 冻结时间
+	ExpireTime       time.Time `json:"expireTime"`       // 冻结到期时间
+	UnfreezeClientID string    `json:"unfreezeClientID"` // 解冻操作人
+	UnfreezeMSPID    string    `json:"unfreezeMSPID"`    // 解冻操作人所属MSP
+	UnfreezeReason   string    `json:"unfreezeReason"`   // 解冻原因
+	UnfreezeTime     time.Time `json:"unfreezeTime"`     // 解冻时间
+}
+
+// FreezeRealtyDTO 司法冻结房产请求
+type FreezeRealtyDTO struct {
+	CourtOrderHash string    `json:"courtOrderHash" binding:"required"` // 法律文书哈希
+	Authority      string    `json:"authority" binding:"required"`      // 冻结机关
+	ExpireTime     time.Time `json:"expireTime" binding:"required"`     // 冻结到期时间
+}
+
+// RealtyHistoryDTO 房产链上历史版本
+type RealtyHistoryDTO struct {
+	TxID      string     `json:"txID"`      // 写入该版本的Fabric交易ID
+	Timestamp time.Time  `json:"timestamp"` // 写入时间
+	IsDelete  bool       `json:"isDelete"`  // 该版本是否为删除
+	Realty    *RealtyDTO `json:"realty"`    // 该版本的房产公开信息
+}
+
+// RealtyOwnerDTO 房产共有人
+type RealtyOwnerDTO struct {
+	CitizenIDHash string  `json:"citizenIDHash"` // 共有人
+	Organization  string  `json:"organization"`  // 共有人组织
+	Share         float64 `json:"share"`         // 所有权份额（百分比）
+}
+
+// SetRealtyOwnerDTO 登记的共有人
+type SetRealtyOwnerDTO struct {
+	CitizenID    string  `json:"citizenID" binding:"required"`    // 共有人身份证号
+	Organization string  `json:"organization" binding:"required"` // 共有人组织
+	Share        float64 `json:"share" binding:"required,gt=0"`   // 所有权份额（百分比）
+}
+
+// SetRealtyOwnerListDTO 登记房产共有人请求，第一位为主登记所有者
+type SetRealtyOwnerListDTO struct {
+	OwnerList []*SetRealtyOwnerDTO `json:"ownerList" binding:"required,min=1,dive"` // 共有人列表，份额合计须为100
+}
+
+// UnfreezeRealtyDTO 解冻房产请求
+type UnfreezeRealtyDTO struct {
+	Reason string `json:"reason" binding:"required"` // 解冻原因
+}
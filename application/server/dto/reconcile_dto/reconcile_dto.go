@@ -0,0 +1,44 @@
+package reconcile_dto
+
+import "time"
+
+// RunReconcileDTO 手动对账请求
+type RunReconcileDTO struct {
+	AutoRepair bool `json:"autoRepair"` // 是否用链上数据修复MySQL
+}
+
+// QueryReconcileReportDTO 查询对账报告列表请求
+type QueryReconcileReportDTO struct {
+	PageSize   int `json:"pageSize"`
+	PageNumber int `json:"pageNumber"`
+}
+
+// ReconcileReportDTO 对账报告
+type ReconcileReportDTO struct {
+	ReportUUID         string            `json:"reportUUID"`         // 报告UUID
+	TriggerType        string            `json:"triggerType"`        // 触发方式
+	AutoRepair         bool              `json:"autoRepair"`         // 是否修复MySQL
+	Status             string            `json:"status"`             // 对账状态
+	RealtyChecked      int               `json:"realtyChecked"`      // 已核对房产数
+	TransactionChecked int               `json:"transactionChecked"` // 已核对交易数
+	PaymentChecked     int               `json:"paymentChecked"`     // 已核对支付数
+	DiscrepancyCount   int               `json:"discrepancyCount"`   // 差异数
+	RepairedCount      int               `json:"repairedCount"`      // 已修复差异数
+	DiscrepancyList    []*DiscrepancyDTO `json:"discrepancyList"`    // 差异明细，列表查询时为空
+	ErrorMessage       string            `json:"errorMessage"`       // 对账中断原因
+	StartTime          time.Time         `json:"startTime"`          // 开始时间
+	EndTime            *time.Time        `json:"endTime"`            // 结束时间
+}
+
+// DiscrepancyDTO 对账差异
+type DiscrepancyDTO struct {
+	EntityType  string `json:"entityType"`  // 对象类型：房产、交易、支付
+	EntityKey   string `json:"entityKey"`   // 对象主键：房产证哈希、交易UUID、支付UUID
+	ChannelName string `json:"channelName"` // 所在子通道
+	Kind        string `json:"kind"`        // 差异类型
+	Field       string `json:"field"`       // 不一致的字段，缺失类差异为空
+	ExpectValue string `json:"expectValue"` // 子通道上的值
+	ActualValue string `json:"actualValue"` // 主通道索引或MySQL中的值
+	Repaired    bool   `json:"repaired"`    // 是否已修复
+	RepairError string `json:"repairError"` // 修复失败原因
+}
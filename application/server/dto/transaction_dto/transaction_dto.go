@@ -14,6 +14,8 @@ type TransactionDTO struct {
 	Status              string    `json:"status"`              // 交易状态
 	Price               float64   `json:"price"`               // 成交价格
 	Tax                 float64   `json:"tax"`                 // 税费
+	CompletedStepList   []string  `json:"completedStepList"`   // 已完成的交易步骤
+	SellerConsentList   []string  `json:"sellerConsentList"`   // 已同意出售的共有人
 	CreateTime          time.Time `json:"createTime"`          // 创建时间
 	UpdateTime          time.Time `json:"updateTime"`          // 更新时间
 }
@@ -24,14 +26,29 @@ type CreateTransactionDTO struct {
 	BuyerCitizenID    string   `json:"buyerCitizenID"`    // 买方身份证号
 	BuyerOrganization string   `json:"buyerOrganization"` // 买方组织机构代码
 	PaymentUUIDList   []string `json:"paymentUUIDList"`   // 支付ID列表
-	Tax               float64  `json:"tax"`               // 税费
 	Price             float64  `json:"price"`             // 成交价格
 }
 
-// CheckTransactionDTO 检查交易请求
-type CheckTransactionDTO struct {
-	TransactionUUID string `json:"transactionUUID"` // 交易ID
-	Status          string `json:"status"`          // 交易状态
+// PreviewTransactionTaxDTO 预估交易税费请求
+type PreviewTransactionTaxDTO struct {
+	RealtyCert        string  `json:"realtyCert" binding:"required"`        // 不动产证号
+	BuyerCitizenID    string  `json:"buyerCitizenID" binding:"required"`    // 买方身份证号
+	BuyerOrganization string  `json:"buyerOrganization" binding:"required"` // 买方组织机构代码
+	Price             float64 `json:"price" binding:"required"`             // 成交价格
+}
+
+// TaxDTO 税费明细DTO
+type TaxDTO struct {
+	TransactionUUID string    `json:"transactionUUID"` // 关联交易UUID
+	TaxType         string    `json:"taxType"`         // 税费类型
+	PayerRole       string    `json:"payerRole"`       // 纳税人角色（买方/卖方）
+	TaxBase         float64   `json:"taxBase"`         // 计税依据
+	TaxRate         float64   `json:"taxRate"`         // 税率
+	TaxAmount       float64   `json:"taxAmount"`       // 税额
+	Status          string    `json:"status"`          // 状态（已缴/未缴）
+	PaymentUUID     string    `json:"paymentUUID"`     // 缴税支付ID
+	PaidTime        time.Time `json:"paidTime"`        // 缴纳时间
+	CreateTime      time.Time `json:"createTime"`      // 核定时间
 }
 
 // CompleteTransactionDTO 完成交易请求
@@ -39,6 +56,21 @@ type CompleteTransactionDTO struct {
 	TransactionUUID string `json:"transactionUUID" binding:"required"` // 交易ID
 }
 
+// RejectTransactionDTO 拒绝交易请求
+type RejectTransactionDTO struct {
+	TransactionUUID string `json:"transactionUUID" binding:"required"` // 交易ID
+}
+
+// CancelTransactionDTO 取消交易请求
+type CancelTransactionDTO struct {
+	TransactionUUID string `json:"transactionUUID" binding:"required"` // 交易ID
+}
+
+// ExpireTransactionDTO 终止超时交易请求
+type ExpireTransactionDTO struct {
+	TransactionUUID string `json:"transactionUUID" binding:"required"` // 交易ID
+}
+
 // QueryTransactionDTO 查询交易请求
 type QueryTransactionDTO struct {
 	TransactionUUID string `json:"transactionUUID" binding:"required"` // 交易ID
@@ -57,10 +89,12 @@ type QueryTransactionListDTO struct {
 	PageNumber         int    `json:"pageNumber"`         // 页码
 }
 
-// UpdateTransactionDTO 更新交易请求
-type UpdateTransactionDTO struct {
-	TransactionUUID string `json:"transactionUUID"` // 交易ID
-	Status          string `json:"status"`          // 交易状态
+// TransactionRecordDTO 交易时间线记录
+type TransactionRecordDTO struct {
+	ClientID string    `json:"clientID"` // 操作人
+	MSPID    string    `json:"mspID"`    // 操作人所属MSP
+	Action   string    `json:"action"`   // 操作
+	Time     time.Time `json:"time"`     // 操作时间
 }
 
 // QueryTransactionStatisticsDTO 查询交易统计请求
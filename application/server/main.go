@@ -1,17 +1,28 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"grets_server/api/router"
 	"grets_server/config"
 	"grets_server/db"
 	"grets_server/pkg/blockchain"
+	"grets_server/pkg/did"
 	"grets_server/pkg/utils"
+	"os"
 	"path/filepath"
 	"strconv"
 )
 
 func main() {
+	// 离线校验交易包含证明，不加载配置、不连接数据库和区块链网络
+	verifyProof := flag.String("verify-proof", "", "离线校验交易包含证明JSON文件")
+	flag.Parse()
+	if *verifyProof != "" {
+		os.Exit(runVerifyProof(*verifyProof))
+	}
+
 	// 打印服务名称
 	fmt.Println("======== 政府房地产交易系统后端服务 ========")
 
@@ -63,3 +74,19 @@ func main() {
 		return
 	}
 }
+
+// runVerifyProof 离线校验交易包含证明并输出结果，证明成立时返回0
+func runVerifyProof(path string) int {
+	result, err := did.VerifyInclusionProofFile(path)
+	if err != nil {
+		fmt.Printf("校验交易包含证明失败: %v\n", err)
+		return 2
+	}
+	output, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(output))
+	if !result.Valid {
+		return 1
+	}
+	fmt.Println("证明成立，请将blockHash与独立获取的区块哈希比对后确认交易已上链")
+	return 0
+}
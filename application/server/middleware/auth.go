@@ -120,3 +120,33 @@ func RoleAuth(roles ...string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OrganizationAuth 组织认证中间件
+func OrganizationAuth(organizations ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 获取用户组织
+		userOrganization := c.GetString("organization")
+		if userOrganization == "" {
+			utils.ResponseUnauthorized(c, "未获取到用户组织信息")
+			c.Abort()
+			return
+		}
+
+		// 检查用户是否属于所需组织
+		hasOrganization := false
+		for _, organization := range organizations {
+			if userOrganization == organization {
+				hasOrganization = true
+				break
+			}
+		}
+
+		if !hasOrganization {
+			utils.ResponseForbidden(c, "所属组织无权执行该操作")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,337 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// 脱敏后的参数值
+const _MaskedArgument = "******"
+
+// privateArgList 链码函数中写入私有数据集合的明文参数位置（不含函数名，从0开始），区块浏览器中脱敏显示
+var privateArgList = map[string][]int{
+	"Register":           {1, 2, 3, 4, 5, 9}, // 身份证号、姓名、手机号、邮箱、密码哈希、余额
+	"UpdateUser":         {2, 3},             // 手机号、邮箱
+	"CreateRealty":       {6},                // 历史所有者
+	"UpdateRealty":       {5},                // 历史所有者
+	"SetRealtyOwnerList": {1},                // 共有人及份额
+	"CreateTransaction":  {6, 7, 8},          // 合同UUID、支付UUID列表、成交价格
+	"CreateMortgage":     {4, 5, 6, 7, 8},    // 贷款金额、利率、期限、抵押物价值、还款计划
+}
+
+// TransactionInvocationDetail 交易的链码调用详情，包含校验结果、背书组织和读写集
+type TransactionInvocationDetail struct {
+	BlockTransactionDetail
+	TxIndex        int               `json:"txIndex"`        // 交易在区块中的序号
+	ValidationCode string            `json:"validationCode"` // 交易校验结果，VALID表示已生效
+	ArgumentList   []string          `json:"argumentList"`   // 调用参数（不含函数名），私有参数已脱敏
+	EndorserList   []string          `json:"endorserList"`   // 背书组织MSP
+	ResponseStatus int32             `json:"responseStatus"` // 链码返回状态码
+	EventName      string            `json:"eventName"`      // 链码事件名
+	NamespaceList  []*NamespaceRWSet `json:"namespaceList"`  // 各命名空间的读写集
+}
+
+// NamespaceRWSet 命名空间（链码）的读写集
+type NamespaceRWSet struct {
+	Namespace      string                   `json:"namespace"`      // 命名空间
+	ReadList       []*KeyRead               `json:"readList"`       // 公开数据读集
+	WriteList      []*KeyWrite              `json:"writeList"`      // 公开数据写集
+	CollectionList []*CollectionHashedRWSet `json:"collectionList"` // 私有数据集合读写集，账本中只有哈希
+}
+
+// KeyRead 读取的键及读取时的版本
+type KeyRead struct {
+	Key      string `json:"key"`      // 键名，复合键以":"分隔
+	BlockNum uint64 `json:"blockNum"` // 读取版本所在区块
+	TxNum    uint64 `json:"txNum"`    // 读取版本所在交易
+}
+
+// KeyWrite 写入的键和值
+type KeyWrite struct {
+	Key      string `json:"key"`      // 键名，复合键以":"分隔
+	IsDelete bool   `json:"isDelete"` // 是否删除
+	Value    string `json:"value"`    // 写入的值，非文本时为十六进制
+}
+
+// CollectionHashedRWSet 私有数据集合的读写集哈希
+type CollectionHashedRWSet struct {
+	CollectionName  string         `json:"collectionName"`  // 集合名
+	PvtRWSetHash    string         `json:"pvtRWSetHash"`    // 私有读写集哈希
+	HashedReadList  []string       `json:"hashedReadList"`  // 读取的键哈希
+	HashedWriteList []*HashedWrite `json:"hashedWriteList"` // 写入的键哈希和值哈希
+}
+
+// HashedWrite 私有数据写入的哈希
+type HashedWrite struct {
+	KeyHash   string `json:"keyHash"`   // 键哈希
+	IsDelete  bool   `json:"isDelete"`  // 是否删除
+	ValueHash string `json:"valueHash"` // 值哈希
+}
+
+// GetTransactionInvocationList 解析区块中每笔交易的链码调用、校验结果和读写集
+func (l *blockListener) GetTransactionInvocationList(channelName string, blockNum uint64) ([]*TransactionInvocationDetail, error) {
+	blockData, err := l.GetBlockByNumber(channelName, "", blockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*TransactionInvocationDetail, 0, len(blockData.Data))
+	for txIndex := range blockData.Data {
+		detailList, err := decodeTransactionInvocation(blockData, txIndex)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, detailList...)
+	}
+	return result, nil
+}
+
+// GetTransactionInvocation 根据交易ID解析交易的链码调用、校验结果和读写集
+func (l *blockListener) GetTransactionInvocation(txID string) ([]*TransactionInvocationDetail, error) {
+	blockData, txIndex, err := l.GetTransactionBlock(txID)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTransactionInvocation(blockData, txIndex)
+}
+
+// decodeTransactionInvocation 解析区块中的单笔交易，背书交易每个链码调用返回一条记录
+func decodeTransactionInvocation(blockData *BlockData, txIndex int) ([]*TransactionInvocationDetail, error) {
+	env := &common.Envelope{}
+	if err := proto.Unmarshal(blockData.Data[txIndex], env); err != nil {
+		return nil, fmt.Errorf("解析交易信封失败: %v", err)
+	}
+	detailList, err := transactionDetailListFromEnvelope(env)
+	if err != nil {
+		return nil, err
+	}
+
+	validationCode := blockData.TxValidationCode(txIndex)
+
+	baseInvocation := func(detail *BlockTransactionDetail) *TransactionInvocationDetail {
+		detail.ChannelName = blockData.ChannelName
+		detail.BlockNumber = blockData.BlockNumber
+		return &TransactionInvocationDetail{
+			BlockTransactionDetail: *detail,
+			TxIndex:                txIndex,
+			ValidationCode:         validationCode,
+			ArgumentList:           []string{},
+			EndorserList:           []string{},
+			NamespaceList:          []*NamespaceRWSet{},
+		}
+	}
+
+	// 配置交易没有链码调用
+	actionList, err := transactionActionList(env)
+	if err != nil {
+		return nil, err
+	}
+	if len(actionList) == 0 {
+		result := make([]*TransactionInvocationDetail, 0, len(detailList))
+		for _, detail := range detailList {
+			result = append(result, baseInvocation(detail))
+		}
+		return result, nil
+	}
+
+	result := make([]*TransactionInvocationDetail, 0, len(actionList))
+	for i, action := range actionList {
+		if i >= len(detailList) {
+			break
+		}
+		invocation := baseInvocation(detailList[i])
+		if err := decodeChaincodeAction(invocation, action); err != nil {
+			return nil, err
+		}
+		result = append(result, invocation)
+	}
+	return result, nil
+}
+
+// TxValidationCode 获取区块中第txIndex笔交易的校验结果，区块元数据中没有校验结果时返回空字符串
+func (b *BlockData) TxValidationCode(txIndex int) string {
+	if txIndex < 0 || txIndex >= len(b.TxValidationCodes) {
+		return ""
+	}
+	return peer.TxValidationCode(b.TxValidationCodes[txIndex]).String()
+}
+
+// transactionActionList 获取背书交易中的链码调用，其他类型交易返回空列表
+func transactionActionList(env *common.Envelope) ([]*peer.TransactionAction, error) {
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(env.Payload, payload); err != nil {
+		return nil, fmt.Errorf("解析payload失败: %v", err)
+	}
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader); err != nil {
+		return nil, fmt.Errorf("解析channelHeader失败: %v", err)
+	}
+	if common.HeaderType(channelHeader.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return nil, nil
+	}
+
+	txPayload := &peer.Transaction{}
+	if err := proto.Unmarshal(payload.Data, txPayload); err != nil {
+		return nil, fmt.Errorf("解析TransactionAction失败: %v", err)
+	}
+	return txPayload.Actions, nil
+}
+
+// decodeChaincodeAction 解析链码调用的参数、背书组织、返回结果和读写集
+func decodeChaincodeAction(invocation *TransactionInvocationDetail, action *peer.TransactionAction) error {
+	chaincodeActionPayload := &peer.ChaincodeActionPayload{}
+	if err := proto.Unmarshal(action.Payload, chaincodeActionPayload); err != nil {
+		return fmt.Errorf("解析ChaincodeActionPayload失败: %v", err)
+	}
+
+	// 调用参数
+	chaincodeProposalPayload := &peer.ChaincodeProposalPayload{}
+	if err := proto.Unmarshal(chaincodeActionPayload.ChaincodeProposalPayload, chaincodeProposalPayload); err != nil {
+		return fmt.Errorf("解析ChaincodeProposalPayload失败: %v", err)
+	}
+	chaincodeInvocationSpec := &peer.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(chaincodeProposalPayload.Input, chaincodeInvocationSpec); err != nil {
+		return fmt.Errorf("解析ChaincodeInvocationSpec失败: %v", err)
+	}
+	args := chaincodeInvocationSpec.GetChaincodeSpec().GetInput().GetArgs()
+	if len(args) > 1 {
+		invocation.ArgumentList = maskArgumentList(invocation.ChainCodeFunctionName, args[1:])
+	}
+
+	endorsedAction := chaincodeActionPayload.GetAction()
+	if endorsedAction == nil {
+		return nil
+	}
+
+	// 背书组织
+	for _, endorsement := range endorsedAction.Endorsements {
+		endorser := &msp.SerializedIdentity{}
+		if err := proto.Unmarshal(endorsement.Endorser, endorser); err != nil {
+			return fmt.Errorf("解析背书者失败: %v", err)
+		}
+		invocation.EndorserList = append(invocation.EndorserList, endorser.Mspid)
+	}
+
+	// 链码返回结果和读写集
+	proposalResponsePayload := &peer.ProposalResponsePayload{}
+	if err := proto.Unmarshal(endorsedAction.ProposalResponsePayload, proposalResponsePayload); err != nil {
+		return fmt.Errorf("解析ProposalResponsePayload失败: %v", err)
+	}
+	chaincodeAction := &peer.ChaincodeAction{}
+	if err := proto.Unmarshal(proposalResponsePayload.Extension, chaincodeAction); err != nil {
+		return fmt.Errorf("解析ChaincodeAction失败: %v", err)
+	}
+	invocation.ResponseStatus = chaincodeAction.GetResponse().GetStatus()
+	if len(chaincodeAction.Events) > 0 {
+		chaincodeEvent := &peer.ChaincodeEvent{}
+		if err := proto.Unmarshal(chaincodeAction.Events, chaincodeEvent); err == nil {
+			invocation.EventName = chaincodeEvent.EventName
+		}
+	}
+
+	txRWSet := &rwset.TxReadWriteSet{}
+	if err := proto.Unmarshal(chaincodeAction.Results, txRWSet); err != nil {
+		return fmt.Errorf("解析读写集失败: %v", err)
+	}
+	for _, nsRWSet := range txRWSet.NsRwset {
+		namespace, err := decodeNamespaceRWSet(nsRWSet)
+		if err != nil {
+			return err
+		}
+		invocation.NamespaceList = append(invocation.NamespaceList, namespace)
+	}
+	return nil
+}
+
+// decodeNamespaceRWSet 解析命名空间的公开读写集和私有数据集合哈希
+func decodeNamespaceRWSet(nsRWSet *rwset.NsReadWriteSet) (*NamespaceRWSet, error) {
+	namespace := &NamespaceRWSet{
+		Namespace:      nsRWSet.Namespace,
+		ReadList:       []*KeyRead{},
+		WriteList:      []*KeyWrite{},
+		CollectionList: []*CollectionHashedRWSet{},
+	}
+
+	kvRWSet := &kvrwset.KVRWSet{}
+	if err := proto.Unmarshal(nsRWSet.Rwset, kvRWSet); err != nil {
+		return nil, fmt.Errorf("解析命名空间[%s]读写集失败: %v", nsRWSet.Namespace, err)
+	}
+	for _, read := range kvRWSet.Reads {
+		namespace.ReadList = append(namespace.ReadList, &KeyRead{
+			Key:      readableKey(read.Key),
+			BlockNum: read.GetVersion().GetBlockNum(),
+			TxNum:    read.GetVersion().GetTxNum(),
+		})
+	}
+	for _, write := range kvRWSet.Writes {
+		namespace.WriteList = append(namespace.WriteList, &KeyWrite{
+			Key:      readableKey(write.Key),
+			IsDelete: write.IsDelete,
+			Value:    readableValue(write.Value),
+		})
+	}
+
+	for _, collection := range nsRWSet.CollectionHashedRwset {
+		hashedRWSet := &kvrwset.HashedRWSet{}
+		if err := proto.Unmarshal(collection.HashedRwset, hashedRWSet); err != nil {
+			return nil, fmt.Errorf("解析私有数据集合[%s]读写集失败: %v", collection.CollectionName, err)
+		}
+		collectionRWSet := &CollectionHashedRWSet{
+			CollectionName:  collection.CollectionName,
+			PvtRWSetHash:    hex.EncodeToString(collection.PvtRwsetHash),
+			HashedReadList:  []string{},
+			HashedWriteList: []*HashedWrite{},
+		}
+		for _, read := range hashedRWSet.HashedReads {
+			collectionRWSet.HashedReadList = append(collectionRWSet.HashedReadList, hex.EncodeToString(read.KeyHash))
+		}
+		for _, write := range hashedRWSet.HashedWrites {
+			collectionRWSet.HashedWriteList = append(collectionRWSet.HashedWriteList, &HashedWrite{
+				KeyHash:   hex.EncodeToString(write.KeyHash),
+				IsDelete:  write.IsDelete,
+				ValueHash: hex.EncodeToString(write.ValueHash),
+			})
+		}
+		namespace.CollectionList = append(namespace.CollectionList, collectionRWSet)
+	}
+	return namespace, nil
+}
+
+// maskArgumentList 参数转为文本，写入私有数据集合的参数脱敏
+func maskArgumentList(functionName string, args [][]byte) []string {
+	argumentList := make([]string, 0, len(args))
+	for _, arg := range args {
+		argumentList = append(argumentList, readableValue(arg))
+	}
+	for _, index := range privateArgList[functionName] {
+		if index < len(argumentList) {
+			argumentList[index] = _MaskedArgument
+		}
+	}
+	return argumentList
+}
+
+// readableKey 复合键（\x00类型\x00属性1\x00...）转为"类型:属性1:..."
+func readableKey(key string) string {
+	if !strings.HasPrefix(key, "\x00") {
+		return key
+	}
+	return strings.Join(strings.Split(strings.Trim(key, "\x00"), "\x00"), ":")
+}
+
+// readableValue 文本原样返回，二进制转为十六进制
+func readableValue(value []byte) string {
+	if !utf8.Valid(value) {
+		return hex.EncodeToString(value)
+	}
+	return string(value)
+}
@@ -0,0 +1,204 @@
+package blockchain
+
+import (
+	"fmt"
+	"grets_server/pkg/utils"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	_FeedQueueSize          = 256 // 等待推送的新区块队列长度，队列满时丢弃推送，不阻塞区块保存
+	_SubscriptionBufferSize = 64  // 每个订阅者的事件缓冲长度，缓冲满时丢弃该订阅者的事件
+)
+
+// 推送事件类型
+const (
+	FeedEventBlock       = "block"       // 新区块
+	FeedEventTransaction = "transaction" // 新交易
+)
+
+// FeedEvent 推送给订阅者的新区块或新交易事件
+type FeedEvent struct {
+	Type        string                   `json:"type"`                  // 事件类型
+	Block       *BlockNotification       `json:"block,omitempty"`       // 新区块
+	Transaction *TransactionNotification `json:"transaction,omitempty"` // 新交易
+}
+
+// BlockNotification 新区块通知
+type BlockNotification struct {
+	ChannelName string    `json:"channelName"` // 通道名
+	BlockNumber uint64    `json:"blockNumber"` // 区块号
+	BlockHash   string    `json:"blockHash"`   // 区块哈希
+	TxCount     int       `json:"txCount"`     // 交易数
+	SaveTime    time.Time `json:"saveTime"`    // 区块时间
+}
+
+// TransactionNotification 新交易通知
+type TransactionNotification struct {
+	BlockTransactionDetail
+	ValidationCode string `json:"validationCode"` // 交易校验结果
+}
+
+// FeedFilter 订阅条件，为空表示不限
+type FeedFilter struct {
+	ChannelList  []string // 通道
+	FunctionName string   // 链码函数，设置后只推送包含该函数调用的区块和交易
+}
+
+// FeedSubscription 区块推送订阅
+type FeedSubscription struct {
+	filter  FeedFilter
+	events  chan *FeedEvent
+	dropped atomic.Int64
+}
+
+// Events 订阅的事件，取消订阅后关闭
+func (s *FeedSubscription) Events() <-chan *FeedEvent {
+	return s.events
+}
+
+// TakeDropped 返回上次调用以来因缓冲已满而丢弃的事件数，订阅者可据此重新查询区块列表
+func (s *FeedSubscription) TakeDropped() int64 {
+	return s.dropped.Swap(0)
+}
+
+// blockFeed 将新保存的区块推送给订阅者，推送在独立协程中进行，慢订阅者只会丢失自己的事件
+type blockFeed struct {
+	mu            sync.RWMutex
+	subscriptions map[*FeedSubscription]struct{}
+	queue         chan *BlockData
+}
+
+func newBlockFeed() *blockFeed {
+	return &blockFeed{
+		subscriptions: make(map[*FeedSubscription]struct{}),
+		queue:         make(chan *BlockData, _FeedQueueSize),
+	}
+}
+
+// Subscribe 订阅新区块和新交易
+func (l *blockListener) Subscribe(filter FeedFilter) *FeedSubscription {
+	subscription := &FeedSubscription{
+		filter: filter,
+		events: make(chan *FeedEvent, _SubscriptionBufferSize),
+	}
+	l.feed.mu.Lock()
+	l.feed.subscriptions[subscription] = struct{}{}
+	l.feed.mu.Unlock()
+	return subscription
+}
+
+// Unsubscribe 取消订阅
+func (l *blockListener) Unsubscribe(subscription *FeedSubscription) {
+	l.feed.mu.Lock()
+	defer l.feed.mu.Unlock()
+	if _, ok := l.feed.subscriptions[subscription]; ok {
+		delete(l.feed.subscriptions, subscription)
+		close(subscription.events)
+	}
+}
+
+// publishBlock 新区块保存后加入推送队列，队列已满时丢弃，不阻塞区块保存协程
+func (l *blockListener) publishBlock(blockData *BlockData) {
+	select {
+	case l.feed.queue <- blockData:
+	default:
+		utils.Log.Warn(fmt.Sprintf("推送队列已满，通道[%s]区块[%d]未推送", blockData.ChannelName, blockData.BlockNumber))
+	}
+}
+
+// runBlockFeed 解析待推送区块中的交易，按订阅条件分发给订阅者
+func (l *blockListener) runBlockFeed() {
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case blockData := <-l.feed.queue:
+			l.feed.mu.RLock()
+			if len(l.feed.subscriptions) > 0 {
+				block, transactionList := feedEventList(blockData)
+				for subscription := range l.feed.subscriptions {
+					subscription.dispatch(block, transactionList)
+				}
+			}
+			l.feed.mu.RUnlock()
+		}
+	}
+}
+
+// feedEventList 生成区块事件和区块中各交易的事件
+func feedEventList(blockData *BlockData) (*FeedEvent, []*FeedEvent) {
+	block := &FeedEvent{
+		Type: FeedEventBlock,
+		Block: &BlockNotification{
+			ChannelName: blockData.ChannelName,
+			BlockNumber: blockData.BlockNumber,
+			BlockHash:   blockData.BlockHash,
+			TxCount:     blockData.TxCount,
+			SaveTime:    blockData.SaveTime,
+		},
+	}
+
+	var transactionList []*FeedEvent
+	for txIndex, data := range blockData.Data {
+		env := &common.Envelope{}
+		if err := proto.Unmarshal(data, env); err != nil {
+			utils.Log.Warn(fmt.Sprintf("解析通道[%s]区块[%d]交易信封失败: %v", blockData.ChannelName, blockData.BlockNumber, err))
+			continue
+		}
+		detailList, err := transactionDetailListFromEnvelope(env)
+		if err != nil {
+			utils.Log.Warn(fmt.Sprintf("解析通道[%s]区块[%d]交易失败: %v", blockData.ChannelName, blockData.BlockNumber, err))
+			continue
+		}
+		for _, detail := range detailList {
+			detail.ChannelName = blockData.ChannelName
+			detail.BlockNumber = blockData.BlockNumber
+			transactionList = append(transactionList, &FeedEvent{
+				Type: FeedEventTransaction,
+				Transaction: &TransactionNotification{
+					BlockTransactionDetail: *detail,
+					ValidationCode:         blockData.TxValidationCode(txIndex),
+				},
+			})
+		}
+	}
+	return block, transactionList
+}
+
+// dispatch 按订阅条件发送事件，指定链码函数时只发送包含该函数调用的区块
+func (s *FeedSubscription) dispatch(block *FeedEvent, transactionList []*FeedEvent) {
+	if len(s.filter.ChannelList) > 0 && !slices.Contains(s.filter.ChannelList, block.Block.ChannelName) {
+		return
+	}
+
+	var matchedList []*FeedEvent
+	for _, transaction := range transactionList {
+		if s.filter.FunctionName == "" || transaction.Transaction.ChainCodeFunctionName == s.filter.FunctionName {
+			matchedList = append(matchedList, transaction)
+		}
+	}
+	if s.filter.FunctionName != "" && len(matchedList) == 0 {
+		return
+	}
+
+	s.send(block)
+	for _, transaction := range matchedList {
+		s.send(transaction)
+	}
+}
+
+// send 订阅者缓冲已满时丢弃事件并计数
+func (s *FeedSubscription) send(event *FeedEvent) {
+	select {
+	case s.events <- event:
+	default:
+		s.dropped.Add(1)
+	}
+}
@@ -0,0 +1,510 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// 区块索引bucket
+// 交易ID、区块哈希索引直接定位区块；时间、创建者、链码函数索引按通道分bucket，
+// 键为 索引值 + 0x00 + 区块时间(8字节) + 区块号(8字节) + 交易序号(4字节)，同一索引值下按时间排序，游标倒序遍历即为最新优先
+const (
+	_TxIndexBucket        = "txIndex"
+	_BlockHashIndexBucket = "blockHashIndex"
+	_TimeIndexBucket      = "timeIndex"
+	_CreatorIndexBucket   = "creatorIndex"
+	_FunctionIndexBucket  = "functionIndex"
+)
+
+// 需要按通道分bucket的索引
+var channelIndexBucketList = []string{_TimeIndexBucket, _CreatorIndexBucket, _FunctionIndexBucket}
+
+// BlockLocation 区块或交易在区块存储中的位置
+type BlockLocation struct {
+	ChannelName string `json:"channelName"` // 通道名
+	BlockNumber uint64 `json:"blockNumber"` // 区块号
+	TxIndex     int    `json:"txIndex"`     // 交易在区块中的序号
+}
+
+// blockIndexEntry 时间、创建者、链码函数索引的值，按多个条件查询时不需要读取区块即可过滤
+type blockIndexEntry struct {
+	TxID           string `json:"txID"`
+	Creator        string `json:"creator"`
+	CreatorMSPID   string `json:"creatorMSPID"`
+	CreatorName    string `json:"creatorName"`
+	ChaincodeName  string `json:"chaincodeName"`
+	ChaincodeFunc  string `json:"chaincodeFunc"`
+	BlockTimestamp int64  `json:"blockTimestamp"`
+}
+
+// BlockIndexFilter 按索引查询区块或交易的条件，空值表示不过滤
+type BlockIndexFilter struct {
+	ChannelList   []string  // 查询的通道
+	OrgName       string    // 只返回该组织已同步的区块，为空时不限制
+	Creator       string    // 创建者地址或"MSPID/CN"
+	ChaincodeName string    // 链码名
+	FunctionName  string    // 链码函数名，需同时指定链码名
+	StartTime     time.Time // 区块时间下限（含）
+	EndTime       time.Time // 区块时间上限（含）
+}
+
+// TransactionQueryResult 交易查询结果
+type TransactionQueryResult struct {
+	TransactionList []*BlockTransactionDetail `json:"transactionList"` // 交易列表
+	Total           int                       `json:"total"`           // 总记录数
+	PageSize        int                       `json:"pageSize"`        // 每页大小
+	PageNum         int                       `json:"pageNum"`         // 当前页码
+	HasMore         bool                      `json:"hasMore"`         // 是否还有更多数据
+}
+
+// creatorKey 创建者的"MSPID/CN"标识
+func creatorKey(mspID string, commonName string) string {
+	return mspID + "/" + commonName
+}
+
+// functionKey 链码函数索引值
+func functionKey(chaincodeName string, functionName string) string {
+	return chaincodeName + "." + functionName
+}
+
+// indexKey 通道索引的键
+func indexKey(value string, timestamp int64, blockNum uint64, txIndex int) []byte {
+	key := make([]byte, 0, len(value)+1+8+8+4)
+	key = append(key, value...)
+	key = append(key, 0)
+	key = binary.BigEndian.AppendUint64(key, uint64(timestamp))
+	key = binary.BigEndian.AppendUint64(key, blockNum)
+	key = binary.BigEndian.AppendUint32(key, uint32(txIndex))
+	return key
+}
+
+// parseIndexKey 解析通道索引键中的区块时间、区块号和交易序号
+func parseIndexKey(key []byte) (int64, uint64, int) {
+	suffix := key[len(key)-20:]
+	return int64(binary.BigEndian.Uint64(suffix[0:8])), binary.BigEndian.Uint64(suffix[8:16]), int(binary.BigEndian.Uint32(suffix[16:20]))
+}
+
+// createBlockIndexBuckets 创建索引bucket
+func createBlockIndexBuckets(tx *bolt.Tx) error {
+	for _, bucketName := range append([]string{_TxIndexBucket, _BlockHashIndexBucket}, channelIndexBucketList...) {
+		if _, err := tx.CreateBucketIfNotExists([]byte(bucketName)); err != nil {
+			return fmt.Errorf("创建索引bucket[%s]失败: %v", bucketName, err)
+		}
+	}
+	return nil
+}
+
+// indexBlock 为新保存的区块写入交易ID、区块哈希、时间、创建者和链码函数索引，与区块在同一个事务中提交
+func (l *blockListener) indexBlock(tx *bolt.Tx, blockData *BlockData) error {
+	location, err := json.Marshal(BlockLocation{ChannelName: blockData.ChannelName, BlockNumber: blockData.BlockNumber})
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket([]byte(_BlockHashIndexBucket)).Put([]byte(blockData.BlockHash), location); err != nil {
+		return fmt.Errorf("保存区块哈希索引失败: %v", err)
+	}
+
+	channelBucketList := make(map[string]*bolt.Bucket, len(channelIndexBucketList))
+	for _, bucketName := range channelIndexBucketList {
+		channelBucket, err := tx.Bucket([]byte(bucketName)).CreateBucketIfNotExists([]byte(blockData.ChannelName))
+		if err != nil {
+			return fmt.Errorf("创建通道[%s]索引bucket[%s]失败: %v", blockData.ChannelName, bucketName, err)
+		}
+		channelBucketList[bucketName] = channelBucket
+	}
+
+	timestamp := blockData.SaveTime.UnixNano()
+	envelopeList, err := l.GetEnvelopeListFromBoltBlockData(blockData)
+	if err != nil {
+		return err
+	}
+	for txIndex, env := range envelopeList {
+		detailList, err := transactionDetailListFromEnvelope(env)
+		if err != nil {
+			// 无法解析的交易不建立索引，不影响区块保存
+			continue
+		}
+		for _, detail := range detailList {
+			location, err := json.Marshal(BlockLocation{
+				ChannelName: blockData.ChannelName,
+				BlockNumber: blockData.BlockNumber,
+				TxIndex:     txIndex,
+			})
+			if err != nil {
+				return err
+			}
+			if err := tx.Bucket([]byte(_TxIndexBucket)).Put([]byte(detail.TransactionID), location); err != nil {
+				return fmt.Errorf("保存交易索引失败: %v", err)
+			}
+
+			entry, err := json.Marshal(blockIndexEntry{
+				TxID:           detail.TransactionID,
+				Creator:        detail.Creator,
+				CreatorMSPID:   detail.CreatorMSPID,
+				CreatorName:    detail.CreatorName,
+				ChaincodeName:  detail.ChaincodeName,
+				ChaincodeFunc:  detail.ChainCodeFunctionName,
+				BlockTimestamp: timestamp,
+			})
+			if err != nil {
+				return err
+			}
+
+			putList := map[string][]string{_TimeIndexBucket: {""}}
+			if detail.Creator != "" {
+				putList[_CreatorIndexBucket] = []string{detail.Creator, creatorKey(detail.CreatorMSPID, detail.CreatorName)}
+			}
+			if detail.ChaincodeName != "" {
+				putList[_FunctionIndexBucket] = []string{functionKey(detail.ChaincodeName, detail.ChainCodeFunctionName)}
+			}
+			for bucketName, valueList := range putList {
+				for _, value := range valueList {
+					if err := channelBucketList[bucketName].Put(indexKey(value, timestamp, blockData.BlockNumber, txIndex), entry); err != nil {
+						return fmt.Errorf("保存索引[%s]失败: %v", bucketName, err)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// transactionDetailListFromEnvelope 解析交易信封，背书交易每个链码调用返回一条记录，配置交易只返回交易ID和时间
+func transactionDetailListFromEnvelope(env *common.Envelope) ([]*BlockTransactionDetail, error) {
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(env.Payload, payload); err != nil {
+		return nil, fmt.Errorf("解析payload失败: %v", err)
+	}
+	if payload.Header == nil {
+		return nil, fmt.Errorf("交易缺少header")
+	}
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.Header.ChannelHeader, channelHeader); err != nil {
+		return nil, fmt.Errorf("解析channelHeader失败: %v", err)
+	}
+	baseDetail := BlockTransactionDetail{
+		TransactionID:        channelHeader.TxId,
+		TransactionTimestamp: channelHeader.Timestamp.AsTime().Format(time.RFC3339),
+	}
+	if common.HeaderType(channelHeader.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return []*BlockTransactionDetail{&baseDetail}, nil
+	}
+
+	signatureHeader := &common.SignatureHeader{}
+	if err := proto.Unmarshal(payload.Header.SignatureHeader, signatureHeader); err != nil {
+		return nil, fmt.Errorf("解析signatureHeader失败: %v", err)
+	}
+	creator := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(signatureHeader.Creator, creator); err != nil {
+		return nil, fmt.Errorf("解析creator失败: %v", err)
+	}
+	creatorInfo, err := parseCreatorCertificate(creator.IdBytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析creator证书失败: %v", err)
+	}
+	baseDetail.Creator = creatorInfo
+	baseDetail.CreatorMSPID = creator.Mspid
+	if block, _ := pem.Decode(creator.IdBytes); block != nil {
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			baseDetail.CreatorName = cert.Subject.CommonName
+		}
+	}
+
+	txPayload := &peer.Transaction{}
+	if err := proto.Unmarshal(payload.Data, txPayload); err != nil {
+		return nil, fmt.Errorf("解析TransactionAction失败: %v", err)
+	}
+	detailList := make([]*BlockTransactionDetail, 0, len(txPayload.Actions))
+	for _, action := range txPayload.Actions {
+		chaincodeActionPayload := &peer.ChaincodeActionPayload{}
+		if err := proto.Unmarshal(action.Payload, chaincodeActionPayload); err != nil {
+			return nil, fmt.Errorf("解析ChaincodeActionPayload失败: %v", err)
+		}
+		chaincodeProposalPayload := &peer.ChaincodeProposalPayload{}
+		if err := proto.Unmarshal(chaincodeActionPayload.ChaincodeProposalPayload, chaincodeProposalPayload); err != nil {
+			return nil, fmt.Errorf("解析ChaincodeProposalPayload失败: %v", err)
+		}
+		chaincodeInvocationSpec := &peer.ChaincodeInvocationSpec{}
+		if err := proto.Unmarshal(chaincodeProposalPayload.Input, chaincodeInvocationSpec); err != nil {
+			return nil, fmt.Errorf("解析ChaincodeInvocationSpec失败: %v", err)
+		}
+
+		detail := baseDetail
+		if spec := chaincodeInvocationSpec.ChaincodeSpec; spec != nil {
+			detail.ChaincodeName = spec.GetChaincodeId().GetName()
+			if args := spec.GetInput().GetArgs(); len(args) > 0 {
+				detail.ChainCodeFunctionName = string(args[0])
+			}
+		}
+		detailList = append(detailList, &detail)
+	}
+	return detailList, nil
+}
+
+// getTransactionLocation 根据交易ID查询交易在区块存储中的位置
+func (l *blockListener) getTransactionLocation(txID string) (*BlockLocation, error) {
+	var location BlockLocation
+	err := l.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket([]byte(_TxIndexBucket)).Get([]byte(txID))
+		if data == nil {
+			return fmt.Errorf("交易[%s]不存在", txID)
+		}
+		return json.Unmarshal(data, &location)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
+
+// GetTransactionByID 根据交易ID查询交易所在区块及交易详情
+func (l *blockListener) GetTransactionByID(txID string) (*BlockTransactionDetail, error) {
+	blockData, txIndex, err := l.GetTransactionBlock(txID)
+	if err != nil {
+		return nil, err
+	}
+	env := &common.Envelope{}
+	if err := proto.Unmarshal(blockData.Data[txIndex], env); err != nil {
+		return nil, fmt.Errorf("解析交易信封失败: %v", err)
+	}
+	detailList, err := transactionDetailListFromEnvelope(env)
+	if err != nil {
+		return nil, err
+	}
+	if len(detailList) == 0 {
+		return nil, fmt.Errorf("交易[%s]没有链码调用", txID)
+	}
+
+	detail := detailList[0]
+	detail.ChannelName = blockData.ChannelName
+	detail.BlockNumber = blockData.BlockNumber
+	return detail, nil
+}
+
+// GetTransactionBlock 根据交易ID查询交易所在区块及交易在区块中的序号
+func (l *blockListener) GetTransactionBlock(txID string) (*BlockData, int, error) {
+	location, err := l.getTransactionLocation(txID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	blockData, err := l.GetBlockByNumber(location.ChannelName, "", location.BlockNumber)
+	if err != nil {
+		return nil, 0, err
+	}
+	if location.TxIndex >= len(blockData.Data) {
+		return nil, 0, fmt.Errorf("交易[%s]索引与区块数据不一致", txID)
+	}
+	return blockData, location.TxIndex, nil
+}
+
+// GetBlockByHash 根据区块哈希查询区块
+func (l *blockListener) GetBlockByHash(blockHash string) (*BlockData, error) {
+	var location BlockLocation
+	err := l.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket([]byte(_BlockHashIndexBucket)).Get([]byte(blockHash))
+		if data == nil {
+			return fmt.Errorf("区块[%s]不存在", blockHash)
+		}
+		return json.Unmarshal(data, &location)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l.GetBlockByNumber(location.ChannelName, "", location.BlockNumber)
+}
+
+// QueryBlockPage 按索引分页查询区块（按区块时间降序），只读取当前页的区块数据
+func (l *blockListener) QueryBlockPage(filter *BlockIndexFilter, pageNum int, pageSize int) (*BlockQueryResult, error) {
+	if pageNum <= 0 {
+		pageNum = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	result := &BlockQueryResult{PageNum: pageNum, PageSize: pageSize, Blocks: make([]*BlockData, 0, pageSize)}
+	offset := (pageNum - 1) * pageSize
+
+	err := l.db.View(func(tx *bolt.Tx) error {
+		var lastLocation *BlockLocation
+		return walkBlockIndex(tx, filter, func(channelName string, blockNum uint64, txIndex int, entry *blockIndexEntry) error {
+			// 同一区块的多条交易在索引中相邻，只计一次
+			if lastLocation != nil && lastLocation.ChannelName == channelName && lastLocation.BlockNumber == blockNum {
+				return nil
+			}
+			lastLocation = &BlockLocation{ChannelName: channelName, BlockNumber: blockNum}
+
+			if result.Total >= offset && len(result.Blocks) < pageSize {
+				data := tx.Bucket([]byte(_BlocksBucket)).Bucket([]byte(channelName)).Get(blockKey(blockNum))
+				if data != nil {
+					var block BlockData
+					if err := json.Unmarshal(data, &block); err != nil {
+						return fmt.Errorf("区块数据反序列化失败: %v", err)
+					}
+					result.Blocks = append(result.Blocks, &block)
+				}
+			}
+			result.Total++
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询区块失败: %v", err)
+	}
+
+	result.HasMore = offset+len(result.Blocks) < result.Total
+	return result, nil
+}
+
+// QueryTransactionPage 按索引分页查询交易（按区块时间降序），只解析当前页的交易
+func (l *blockListener) QueryTransactionPage(filter *BlockIndexFilter, pageNum int, pageSize int) (*TransactionQueryResult, error) {
+	if pageNum <= 0 {
+		pageNum = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	result := &TransactionQueryResult{PageNum: pageNum, PageSize: pageSize, TransactionList: make([]*BlockTransactionDetail, 0, pageSize)}
+	offset := (pageNum - 1) * pageSize
+
+	err := l.db.View(func(tx *bolt.Tx) error {
+		return walkBlockIndex(tx, filter, func(channelName string, blockNum uint64, txIndex int, entry *blockIndexEntry) error {
+			if result.Total >= offset && len(result.TransactionList) < pageSize {
+				result.TransactionList = append(result.TransactionList, &BlockTransactionDetail{
+					TransactionID:         entry.TxID,
+					Creator:               entry.Creator,
+					CreatorMSPID:          entry.CreatorMSPID,
+					CreatorName:           entry.CreatorName,
+					TransactionTimestamp:  time.Unix(0, entry.BlockTimestamp).Format(time.RFC3339),
+					ChaincodeName:         entry.ChaincodeName,
+					ChainCodeFunctionName: entry.ChaincodeFunc,
+					ChannelName:           channelName,
+					BlockNumber:           blockNum,
+				})
+			}
+			result.Total++
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询交易失败: %v", err)
+	}
+
+	result.HasMore = offset+len(result.TransactionList) < result.Total
+	return result, nil
+}
+
+// channelIndexCursor 单个通道索引的倒序游标
+type channelIndexCursor struct {
+	channelName string
+	cursor      *bolt.Cursor
+	prefix      []byte
+	latest      uint64
+	key         []byte
+	value       []byte
+}
+
+// next 移动到上一条满足时间条件的索引，没有时key为nil
+func (c *channelIndexCursor) next(first bool, filter *BlockIndexFilter) {
+	var k, v []byte
+	if first {
+		seekKey := append(append([]byte{}, c.prefix...), 0xff)
+		if !filter.EndTime.IsZero() {
+			seekKey = binary.BigEndian.AppendUint64(append([]byte{}, c.prefix...), uint64(filter.EndTime.UnixNano()+1))
+		}
+		k, v = c.cursor.Seek(seekKey)
+		if k == nil {
+			k, v = c.cursor.Last()
+		} else {
+			k, v = c.cursor.Prev()
+		}
+	} else {
+		k, v = c.cursor.Prev()
+	}
+
+	for ; k != nil && bytes.HasPrefix(k, c.prefix) && len(k) == len(c.prefix)+20; k, v = c.cursor.Prev() {
+		timestamp, blockNum, _ := parseIndexKey(k)
+		if !filter.StartTime.IsZero() && timestamp < filter.StartTime.UnixNano() {
+			break
+		}
+		if !filter.EndTime.IsZero() && timestamp > filter.EndTime.UnixNano() {
+			continue
+		}
+		if blockNum > c.latest {
+			continue
+		}
+		c.key, c.value = k, v
+		return
+	}
+	c.key, c.value = nil, nil
+}
+
+// walkBlockIndex 按区块时间降序合并遍历各通道的索引，优先使用创建者索引，其次链码函数索引，否则使用时间索引
+func walkBlockIndex(tx *bolt.Tx, filter *BlockIndexFilter, visit func(channelName string, blockNum uint64, txIndex int, entry *blockIndexEntry) error) error {
+	bucketName, value := _TimeIndexBucket, ""
+	if filter.Creator != "" {
+		bucketName, value = _CreatorIndexBucket, filter.Creator
+	} else if filter.ChaincodeName != "" && filter.FunctionName != "" {
+		bucketName, value = _FunctionIndexBucket, functionKey(filter.ChaincodeName, filter.FunctionName)
+	}
+	prefix := append([]byte(value), 0)
+
+	cursorList := make([]*channelIndexCursor, 0, len(filter.ChannelList))
+	for _, channelName := range filter.ChannelList {
+		channelBucket := tx.Bucket([]byte(bucketName)).Bucket([]byte(channelName))
+		if channelBucket == nil {
+			continue
+		}
+		cursor := &channelIndexCursor{channelName: channelName, cursor: channelBucket.Cursor(), prefix: prefix, latest: math.MaxUint64}
+		if filter.OrgName != "" {
+			latest, err := getLatestBlock(tx, channelName, filter.OrgName)
+			if err != nil {
+				continue
+			}
+			cursor.latest = latest.BlockNum
+		}
+		cursor.next(true, filter)
+		cursorList = append(cursorList, cursor)
+	}
+
+	for {
+		var current *channelIndexCursor
+		for _, cursor := range cursorList {
+			if cursor.key == nil {
+				continue
+			}
+			if current == nil || bytes.Compare(cursor.key[len(cursor.prefix):], current.key[len(current.prefix):]) > 0 {
+				current = cursor
+			}
+		}
+		if current == nil {
+			return nil
+		}
+
+		var entry blockIndexEntry
+		if err := json.Unmarshal(current.value, &entry); err != nil {
+			return fmt.Errorf("索引反序列化失败: %v", err)
+		}
+		_, blockNum, txIndex := parseIndexKey(current.key)
+		if (filter.Creator == "" || entry.Creator == filter.Creator || creatorKey(entry.CreatorMSPID, entry.CreatorName) == filter.Creator) &&
+			(filter.ChaincodeName == "" || entry.ChaincodeName == filter.ChaincodeName) &&
+			(filter.FunctionName == "" || entry.ChaincodeFunc == filter.FunctionName) {
+			if err := visit(current.channelName, blockNum, txIndex, &entry); err != nil {
+				return err
+			}
+		}
+		current.next(false, filter)
+	}
+}
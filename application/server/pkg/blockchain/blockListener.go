@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/asn1"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"encoding/pem"
@@ -23,13 +24,14 @@ import (
 	"github.com/boltdb/bolt"
 	"github.com/hyperledger/fabric-gateway/pkg/client"
 	"github.com/hyperledger/fabric-protos-go-apiv2/common"
-	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
-	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
 )
 
 const (
 	_BlocksBucket      = "blocks"
 	_LatestBucket      = "latestBucket"
+	_MetaBucket        = "meta"
+	_StoreVersionKey   = "storeVersion"
+	_StoreVersion      = "4" // 区块存储结构版本，版本不一致时清空旧数据后重新同步
 	_RetryInterval     = 3 * time.Second
 	_BlockQueueSize    = 1000                   // 内部区块处理队列大小
 	_BlockBatchSize    = 100                    // 一次数据库事务保存的区块数量
@@ -43,6 +45,13 @@ type blockToSave struct {
 	block       *common.Block
 }
 
+// 区块流中缺少的区块
+type blockGap struct {
+	channelName string
+	orgName     string
+	blockNum    uint64
+}
+
 // BlockHeader 区块头
 type BlockHeader struct {
 	Number       *big.Int
@@ -60,25 +69,35 @@ type BlockData struct {
 	SaveTime    time.Time `json:"saveTime"`
 	Data        [][]byte  `json:"data"`
 	ChannelName string    `json:"channelName"`
+	// 区块元数据中各交易的校验结果（peer.TxValidationCode），与Data一一对应
+	TxValidationCodes []byte `json:"txValidationCodes"`
 }
 
-// LatestBlock 最新区块信息
+// LatestBlock 区块流（通道+组织）已连续保存到的区块号，之前的区块均已保存
 type LatestBlock struct {
 	BlockNum uint64    `json:"blockNum"`
 	SaveTime time.Time `json:"saveTime"`
 }
 
+// blockStream 正在监听的区块流
+type blockStream struct {
+	cancel    context.CancelFunc // 断开当前订阅，监听协程会从已保存的区块号之后重新订阅
+	nextBlock uint64             // 当前订阅下一个要入队的区块号
+	resync    bool               // 是否因区块缺口而断开订阅
+}
+
 // BlockListener 区块监听器
 type blockListener struct {
 	db *bolt.DB
 	sync.RWMutex
-	mainNetworks      map[string]*client.Network
-	subNetworks       map[string]map[string]*client.Network
+	gateways          map[string]map[string]*orgGateway // 通道-组织-网关，区块流每次订阅时使用组织当前的网关节点
 	ctx               context.Context
 	cancel            context.CancelFunc
 	dataDir           string
-	blockProcessQueue chan blockToSave // 区块处理队列
-	wg                sync.WaitGroup   // 用于等待保存协程完成
+	blockProcessQueue chan blockToSave        // 区块处理队列
+	wg                sync.WaitGroup          // 用于等待保存协程完成
+	streams           map[string]*blockStream // 区块流，键为streamKey
+	feed              *blockFeed              // 新区块推送
 }
 
 var (
@@ -96,35 +115,15 @@ func initBlockchainListener(dataDir string) error {
 			return
 		}
 
-		// 打开数据库
+		// 打开数据库，保留已保存的区块，重启后各区块流从已保存的区块号之后继续同步
 		dbPath := filepath.Join(dataDir, "blocks.db")
-
-		if _, err := os.Stat(dbPath); err == nil {
-			// 文件存在，删除它
-			if err := os.Remove(dbPath); err != nil {
-				initErr = fmt.Errorf("删除已存在的数据库文件失败: %v", err)
-				return
-			}
-		}
-
 		db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 10 * time.Second})
 		if err != nil {
 			initErr = fmt.Errorf("打开数据库失败: %v", err)
 			return
 		}
 
-		// 创建bucket
-		if err := db.Update(func(tx *bolt.Tx) error {
-			_, err := tx.CreateBucketIfNotExists([]byte(_BlocksBucket))
-			if err != nil {
-				return fmt.Errorf("创建bucket失败: %v", err)
-			}
-			_, err = tx.CreateBucketIfNotExists([]byte(_LatestBucket))
-			if err != nil {
-				return fmt.Errorf("创建latest bucket失败: %v", err)
-			}
-			return nil
-		}); err != nil {
+		if err := db.Update(initBlockStore); err != nil {
 			db.Close()
 			initErr = fmt.Errorf("初始化数据库失败: %v", err)
 			return
@@ -132,24 +131,86 @@ func initBlockchainListener(dataDir string) error {
 
 		ctx, cancel := context.WithCancel(context.Background())
 		listener = &blockListener{
-			mainNetworks:      make(map[string]*client.Network),
-			subNetworks:       make(map[string]map[string]*client.Network),
+			gateways:          make(map[string]map[string]*orgGateway),
 			db:                db,
 			dataDir:           dataDir,
 			ctx:               ctx,
 			cancel:            cancel,
 			blockProcessQueue: make(chan blockToSave, _BlockQueueSize),
+			streams:           make(map[string]*blockStream),
+			feed:              newBlockFeed(),
 		}
 
 		// 启动专门的区块保存协程
 		listener.wg.Add(1)
 		go listener.runBlockSaver()
+		go listener.runBlockFeed()
 	})
 	utils.Log.Info("初始化区块链监听器完成")
 
 	return initErr
 }
 
+// initBlockStore 创建区块存储的bucket，旧版本的存储（区块按组织重复保存、最新区块号不区分通道）直接清空
+func initBlockStore(tx *bolt.Tx) error {
+	metaBucket, err := tx.CreateBucketIfNotExists([]byte(_MetaBucket))
+	if err != nil {
+		return fmt.Errorf("创建meta bucket失败: %v", err)
+	}
+
+	if string(metaBucket.Get([]byte(_StoreVersionKey))) != _StoreVersion {
+		for _, bucketName := range append([]string{_BlocksBucket, _LatestBucket, _TxIndexBucket, _BlockHashIndexBucket}, channelIndexBucketList...) {
+			if tx.Bucket([]byte(bucketName)) == nil {
+				continue
+			}
+			if err := tx.DeleteBucket([]byte(bucketName)); err != nil {
+				return fmt.Errorf("清空旧版本bucket[%s]失败: %v", bucketName, err)
+			}
+		}
+		if err := metaBucket.Put([]byte(_StoreVersionKey), []byte(_StoreVersion)); err != nil {
+			return fmt.Errorf("保存区块存储版本失败: %v", err)
+		}
+		utils.Log.Info(fmt.Sprintf("区块存储升级到版本%s，重新同步全部区块", _StoreVersion))
+	}
+
+	if _, err := tx.CreateBucketIfNotExists([]byte(_BlocksBucket)); err != nil {
+		return fmt.Errorf("创建bucket失败: %v", err)
+	}
+	if _, err := tx.CreateBucketIfNotExists([]byte(_LatestBucket)); err != nil {
+		return fmt.Errorf("创建latest bucket失败: %v", err)
+	}
+	if _, err := tx.CreateBucketIfNotExists([]byte(_TxStatusBucket)); err != nil {
+		return fmt.Errorf("创建交易状态bucket失败: %v", err)
+	}
+	return createBlockIndexBuckets(tx)
+}
+
+// blockHeaderHash 计算区块头哈希：区块号、前一区块哈希、数据哈希的ASN.1编码的SHA256
+func blockHeaderHash(blockNum uint64, previousHash []byte, dataHash []byte) ([]byte, error) {
+	headerBytes, err := asn1.Marshal(BlockHeader{
+		Number:       new(big.Int).SetUint64(blockNum),
+		PreviousHash: previousHash,
+		DataHash:     dataHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	blockHash := sha256.Sum256(headerBytes)
+	return blockHash[:], nil
+}
+
+// blockKey 区块在通道bucket中的键，区块号按大端编码，游标遍历时按区块号排序
+func blockKey(blockNum uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, blockNum)
+	return key
+}
+
+// streamKey 区块流在latest bucket中的键，同一组织在主通道和各子通道的进度分别保存
+func streamKey(channelName string, orgName string) string {
+	return channelName + "_" + orgName
+}
+
 // GetBlockListener 获取区块监听器实例
 func GetBlockListener() *blockListener {
 	return listener
@@ -168,22 +229,17 @@ func (l *blockListener) Stop() {
 }
 
 // addMainNetwork 添加主通道网络
-func addMainNetwork(orgName string, network *client.Network) error {
-	if listener == nil {
-		return fmt.Errorf("区块监听器未初始化")
-	}
-
-	listener.Lock()
-	defer listener.Unlock()
-
-	listener.mainNetworks[orgName] = network
-	go listener.startMainBlockListener(orgName)
-
-	return nil
+func addMainNetwork(orgName string, gateway *orgGateway) error {
+	return addNetwork(config.GlobalConfig.Fabric.MainChannelName, orgName, gateway)
 }
 
 // addSubNetwork 添加子通道网络
-func addSubNetwork(subChannelName string, orgName string, network *client.Network) error {
+func addSubNetwork(subChannelName string, orgName string, gateway *orgGateway) error {
+	return addNetwork(subChannelName, orgName, gateway)
+}
+
+// addNetwork 添加组织在通道上的网络并开始监听区块
+func addNetwork(channelName string, orgName string, gateway *orgGateway) error {
 	if listener == nil {
 		return fmt.Errorf("区块监听器未初始化")
 	}
@@ -191,172 +247,113 @@ func addSubNetwork(subChannelName string, orgName string, network *client.Networ
 	listener.Lock()
 	defer listener.Unlock()
 
-	// 确保subChannelName对应的map已初始化
-	if listener.subNetworks[subChannelName] == nil {
-		listener.subNetworks[subChannelName] = make(map[string]*client.Network)
+	// 确保channelName对应的map已初始化
+	if listener.gateways[channelName] == nil {
+		listener.gateways[channelName] = make(map[string]*orgGateway)
 	}
 
-	listener.subNetworks[subChannelName][orgName] = network
-	go listener.startSubBlockListener(subChannelName, orgName)
+	listener.gateways[channelName][orgName] = gateway
+	utils.Log.Info(fmt.Sprintf("开始监听组织[%s]的通道[%s]区块", orgName, channelName))
+	go listener.startNetworkListener(gateway, channelName, orgName)
 
 	return nil
 }
 
-// getLastBlockNum 获取最后保存的区块号
-func (l *blockListener) getLastBlockNum(orgName string) (uint64, bool) {
+// getLastBlockNum 获取区块流已连续保存到的区块号，返回false表示尚未保存过该通道的区块
+func (l *blockListener) getLastBlockNum(channelName string, orgName string) (uint64, bool) {
 	var lastBlock LatestBlock
+	var exists bool
 
 	err := l.db.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(_LatestBucket))
-		data := b.Get([]byte(orgName))
+		data := b.Get([]byte(streamKey(channelName, orgName)))
 		if data == nil {
 			return nil
 		}
+		exists = true
 		return json.Unmarshal(data, &lastBlock)
 	})
 
 	if err != nil {
-		fmt.Printf("获取最后区块号失败：%v\n", err)
-		return 0, false
-	}
-
-	// 如果没有数据，返回false表示是首次启动
-	if lastBlock.BlockNum == 0 && lastBlock.SaveTime.IsZero() {
+		utils.Log.Error(fmt.Sprintf("获取通道[%s]组织[%s]最后区块号失败: %v", channelName, orgName, err))
 		return 0, false
 	}
 
-	return lastBlock.BlockNum, true
+	return lastBlock.BlockNum, exists
 }
 
-// startMainNetworkListener 开始监听主通道区块
-func (l *blockListener) startMainNetworkListener(mainNetwork *client.Network, orgName string) {
+// startNetworkListener 监听通道区块，从已连续保存的区块号之后开始订阅，订阅中断或发现缺口后重新订阅
+// 每次订阅使用组织当前的网关节点，订阅中断时报告节点不可用，重新订阅时切换到其他可用节点
+func (l *blockListener) startNetworkListener(gateway *orgGateway, channelName string, orgName string) {
+	key := streamKey(channelName, orgName)
 	retryCount := 0
 
 	for {
-		lastBlockNum, exists := l.getLastBlockNum(orgName)
+		lastBlockNum, exists := l.getLastBlockNum(channelName, orgName)
 		var startBlock uint64
-		if !exists {
-			// 首次启动，从0开始
-			startBlock = 0
-		} else {
-			// 已有数据，从下一个开始
+		if exists {
 			startBlock = lastBlockNum + 1
 		}
 
-		events, err := mainNetwork.BlockEvents(l.ctx, client.WithStartBlock(startBlock))
-		if err != nil {
-			fmt.Printf("创建区块事件请求失败（已重试%d次）：%v\n", retryCount, err)
-			retryCount++
-			select {
-			case <-l.ctx.Done():
-				return
-			case <-time.After(_RetryInterval):
-				continue
+		streamCtx, cancel := context.WithCancel(l.ctx)
+		stream := &blockStream{cancel: cancel, nextBlock: startBlock}
+		l.Lock()
+		l.streams[key] = stream
+		l.Unlock()
+
+		network, peer := gateway.network(channelName)
+		events, err := network.BlockEvents(streamCtx, client.WithStartBlock(startBlock))
+		if err == nil {
+			utils.Log.Info(fmt.Sprintf("通道[%s]组织[%s]从节点[%s]的区块[%d]开始同步", channelName, orgName, peer.config.PeerEndpoint, startBlock))
+			for block := range events {
+				l.enqueueBlockForSaving(channelName, orgName, block)
+				l.Lock()
+				stream.nextBlock = block.GetHeader().GetNumber() + 1
+				l.Unlock()
 			}
 		}
+		cancel()
 
-		for {
-			select {
-			case <-l.ctx.Done():
-				return
-			case block, ok := <-events:
-				if !ok {
-					fmt.Printf("通道[%s]组织[%s]的区块事件监听中断（已重试%d次），准备重试...\n", config.GlobalConfig.Fabric.MainChannelName, orgName, retryCount)
-					retryCount++
-					select {
-					case <-l.ctx.Done():
-						return
-					case <-time.After(_RetryInterval):
-						break
-					}
-					goto RETRY
-				}
-				l.enqueueBlockForSaving(config.GlobalConfig.Fabric.MainChannelName, orgName, block)
-			}
+		if l.ctx.Err() != nil {
+			return
 		}
 
-	RETRY:
-		continue
-	}
-}
-
-// startSubNetworkListener 开始监听子通道区块
-func (l *blockListener) startSubNetworkListener(subNetwork *client.Network, orgName string, subChannelName string) {
-	utils.Log.Info(fmt.Sprintf("开始监听组织[%s]的子通道[%s]区块", orgName, subChannelName))
-
-	retryCount := 0
-
-	for {
-		lastBlockNum, exists := l.getLastBlockNum(orgName)
-		var startBlock uint64
-		if !exists {
-			// 首次启动，从0开始
-			startBlock = 0
-		} else {
-			// 已有数据，从下一个开始
-			startBlock = lastBlockNum + 1
+		l.RLock()
+		resync := stream.resync
+		l.RUnlock()
+		if resync {
+			continue
 		}
 
-		events, err := subNetwork.BlockEvents(l.ctx, client.WithStartBlock(startBlock))
 		if err != nil {
-			fmt.Printf("创建区块事件请求失败（已重试%d次）：%v\n", retryCount, err)
-			retryCount++
-			select {
-			case <-l.ctx.Done():
-				return
-			case <-time.After(_RetryInterval):
-				continue
-			}
+			utils.Log.Error(fmt.Sprintf("通道[%s]组织[%s]创建区块事件请求失败（已重试%d次）: %v", channelName, orgName, retryCount, err))
+		} else {
+			utils.Log.Warn(fmt.Sprintf("通道[%s]组织[%s]的区块事件监听中断（已重试%d次），准备重试...", channelName, orgName, retryCount))
+			err = fmt.Errorf("通道[%s]的区块事件订阅中断", channelName)
 		}
-
-		for {
-			select {
-			case <-l.ctx.Done():
-				return
-			case block, ok := <-events:
-				if !ok {
-					fmt.Printf("通道[%s]组织[%s]的区块事件监听中断（已重试%d次），准备重试...\n", subChannelName, orgName, retryCount)
-					retryCount++
-					select {
-					case <-l.ctx.Done():
-						return
-					case <-time.After(_RetryInterval):
-						break
-					}
-					goto RETRY
-				}
-				l.enqueueBlockForSaving(subChannelName, orgName, block)
-			}
+		gateway.reportFailure(peer, err)
+		retryCount++
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-time.After(_RetryInterval):
 		}
-
-	RETRY:
-		continue
 	}
 }
 
-// startBlockListener 开始监听区块
-func (l *blockListener) startMainBlockListener(orgName string) {
-	utils.Log.Info(fmt.Sprintf("开始监听组织[%s]的区块", orgName))
+// resyncStream 区块流出现缺口（区块入队失败或保存失败）时断开订阅，监听协程从缺口处重新订阅
+// 当前订阅尚未越过缺口时，缺少的区块可能还在队列中或来自上一次订阅，不重复断开
+func (l *blockListener) resyncStream(channelName string, orgName string, fromBlock uint64) {
+	l.Lock()
+	defer l.Unlock()
 
-	mainNetwork := l.mainNetworks[orgName]
-	if mainNetwork == nil {
-		fmt.Printf("组织[%s]的主通道网络未找到\n", orgName)
+	stream, ok := l.streams[streamKey(channelName, orgName)]
+	if !ok || stream.resync || stream.nextBlock <= fromBlock+1 {
 		return
 	}
-
-	go l.startMainNetworkListener(mainNetwork, orgName)
-}
-
-func (l *blockListener) startSubBlockListener(subChannelName string, orgName string) {
-	utils.Log.Info(fmt.Sprintf("开始监听组织[%s]的子通道[%s]区块", orgName, subChannelName))
-
-	subNetwork := l.subNetworks[subChannelName][orgName]
-	if subNetwork == nil {
-		fmt.Printf("组织[%s]的子通道[%s]网络未找到\n", orgName, subChannelName)
-		return
-	}
-
-	go l.startSubNetworkListener(subNetwork, orgName, subChannelName)
+	stream.resync = true
+	stream.cancel()
+	utils.Log.Warn(fmt.Sprintf("通道[%s]组织[%s]缺少区块[%d]，从该区块重新同步", channelName, orgName, fromBlock))
 }
 
 // saveBlock 保存区块 (已废弃，保留以兼容旧代码，应使用enqueueBlockForSaving)
@@ -365,14 +362,16 @@ func (l *blockListener) saveBlock(channelName string, orgName string, block *com
 	l.enqueueBlockForSaving(channelName, orgName, block)
 }
 
-// GetBlockByNumber 根据组织名和区块号查询区块
+// GetBlockByNumber 根据组织名和区块号查询区块，同一通道各组织收到的区块相同，只保存一份
 func (l *blockListener) GetBlockByNumber(channelName string, orgName string, blockNum uint64) (*BlockData, error) {
 	var blockData BlockData
 
 	err := l.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(_BlocksBucket))
-		blockKey := fmt.Sprintf("%s_%s_%d", channelName, orgName, blockNum)
-		data := b.Get([]byte(blockKey))
+		b := tx.Bucket([]byte(_BlocksBucket)).Bucket([]byte(channelName))
+		if b == nil {
+			return fmt.Errorf("区块不存在")
+		}
+		data := b.Get(blockKey(blockNum))
 		if data == nil {
 			return fmt.Errorf("区块不存在")
 		}
@@ -399,8 +398,13 @@ type BlockQueryResult struct {
 type BlockTransactionDetail struct {
 	TransactionID         string `json:"transactionID"`         // 交易ID
 	Creator               string `json:"creator"`               // 创建者地址
+	CreatorMSPID          string `json:"creatorMSPID"`          // 创建者所属MSP
+	CreatorName           string `json:"creatorName"`           // 创建者证书CN
 	TransactionTimestamp  string `json:"transactionTimestamp"`  // 交易时间戳
+	ChaincodeName         string `json:"chaincodeName"`         // 链码名称
 	ChainCodeFunctionName string `json:"chainCodeFunctionName"` // 链码函数名称
+	ChannelName           string `json:"channelName,omitempty"` // 所在通道，按索引查询时返回
+	BlockNumber           uint64 `json:"blockNumber,omitempty"` // 所在区块，按索引查询时返回
 }
 
 // GetBlocksByChannelAndOrg 分页查询组织的区块列表（按区块号降序）
@@ -416,24 +420,13 @@ func (l *blockListener) GetBlocksByChannelAndOrg(channelName string, orgName str
 	result.PageSize = pageSize
 
 	err := l.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(_BlocksBucket))
-		if b == nil {
-			return fmt.Errorf("区块bucket不存在")
-		}
-
-		// 获取组织的最新区块号
-		_LatestBucket := tx.Bucket([]byte(_LatestBucket))
-		if _LatestBucket == nil {
-			return fmt.Errorf("latest_blocks bucket不存在")
-		}
-
-		var latestBlock LatestBlock
-		latestData := _LatestBucket.Get([]byte(orgName))
-		if latestData == nil {
-			return fmt.Errorf("组织[%s]没有区块数据", orgName)
+		latestBlock, err := getLatestBlock(tx, channelName, orgName)
+		if err != nil {
+			return err
 		}
-		if err := json.Unmarshal(latestData, &latestBlock); err != nil {
-			return fmt.Errorf("最新区块信息反序列化失败: %v", err)
+		b := tx.Bucket([]byte(_BlocksBucket)).Bucket([]byte(channelName))
+		if b == nil {
+			return fmt.Errorf("通道[%s]没有区块数据", channelName)
 		}
 
 		// 计算总记录数
@@ -454,8 +447,7 @@ func (l *blockListener) GetBlocksByChannelAndOrg(channelName string, orgName str
 		// 收集区块数据
 		blocks := make([]*BlockData, 0, pageSize)
 		for i := endIndex - 1; i >= startIndex; i-- {
-			blockKey := fmt.Sprintf("%s_%s_%d", channelName, orgName, i)
-			data := b.Get([]byte(blockKey))
+			data := b.Get(blockKey(uint64(i)))
 			if data != nil {
 				var block BlockData
 				if err := json.Unmarshal(data, &block); err != nil {
@@ -476,46 +468,36 @@ func (l *blockListener) GetBlocksByChannelAndOrg(channelName string, orgName str
 	return &result, nil
 }
 
-// GetAllBlocksByChannelAndOrg 获取所有区块
+// GetAllBlocksByChannelAndOrg 获取组织已同步的所有区块（按区块号降序）
 func (l *blockListener) GetAllBlocksByChannelAndOrg(channelName string, orgName string) ([]*BlockData, error) {
 	var result []*BlockData
 
 	err := l.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(_BlocksBucket))
-		if b == nil {
-			return fmt.Errorf("区块bucket不存在")
-		}
-
-		// 获取组织的最新区块号
-		_LatestBucket := tx.Bucket([]byte(_LatestBucket))
-		if _LatestBucket == nil {
-			return fmt.Errorf("latest_blocks bucket不存在")
-		}
-
-		var latestBlock LatestBlock
-		latestData := _LatestBucket.Get([]byte(orgName))
-		if latestData == nil {
-			return fmt.Errorf("组织[%s]没有区块数据", orgName)
+		latestBlock, err := getLatestBlock(tx, channelName, orgName)
+		if err != nil {
+			return err
 		}
-		if err := json.Unmarshal(latestData, &latestBlock); err != nil {
-			return fmt.Errorf("最新区块信息反序列化失败: %v", err)
+		b := tx.Bucket([]byte(_BlocksBucket)).Bucket([]byte(channelName))
+		if b == nil {
+			return fmt.Errorf("通道[%s]没有区块数据", channelName)
 		}
 
-		// 计算总记录数
-		total := int(latestBlock.BlockNum) + 1
-
 		// 收集区块数据
-		blocks := make([]*BlockData, 0, total)
-		for i := total - 1; i >= 0; i-- {
-			blockKey := fmt.Sprintf("%s_%s_%d", channelName, orgName, i)
-			data := b.Get([]byte(blockKey))
-			if data != nil {
-				var block BlockData
-				if err := json.Unmarshal(data, &block); err != nil {
-					return fmt.Errorf("区块数据反序列化失败: %v", err)
-				}
-				blocks = append(blocks, &block)
+		blocks := make([]*BlockData, 0, latestBlock.BlockNum+1)
+		cursor := b.Cursor()
+		k, v := cursor.Seek(blockKey(latestBlock.BlockNum))
+		if k == nil {
+			k, v = cursor.Last()
+		}
+		for ; k != nil; k, v = cursor.Prev() {
+			if binary.BigEndian.Uint64(k) > latestBlock.BlockNum {
+				continue
 			}
+			var block BlockData
+			if err := json.Unmarshal(v, &block); err != nil {
+				return fmt.Errorf("区块数据反序列化失败: %v", err)
+			}
+			blocks = append(blocks, &block)
 		}
 
 		result = blocks
@@ -530,6 +512,19 @@ func (l *blockListener) GetAllBlocksByChannelAndOrg(channelName string, orgName
 	return result, nil
 }
 
+// getLatestBlock 读取区块流已连续保存到的区块号
+func getLatestBlock(tx *bolt.Tx, channelName string, orgName string) (*LatestBlock, error) {
+	latestData := tx.Bucket([]byte(_LatestBucket)).Get([]byte(streamKey(channelName, orgName)))
+	if latestData == nil {
+		return nil, fmt.Errorf("通道[%s]组织[%s]没有区块数据", channelName, orgName)
+	}
+	var latestBlock LatestBlock
+	if err := json.Unmarshal(latestData, &latestBlock); err != nil {
+		return nil, fmt.Errorf("最新区块信息反序列化失败: %v", err)
+	}
+	return &latestBlock, nil
+}
+
 func (l *blockListener) GetEnvelopeListFromBlock(block *common.Block) ([]*common.Envelope, error) {
 	var envelopes []*common.Envelope
 	for _, envBytes := range block.Data.Data {
@@ -600,54 +595,11 @@ func (l *blockListener) GetTransactionDetailListFromEnvelopeList(envList []*comm
 	transactionDetailList := make([]*BlockTransactionDetail, 0)
 	// 遍历envList
 	for _, env := range envList {
-		// 首先获取channelHeader来拿到交易时间和txID
-		channelHeader, err := l.GetChannelHeaderFromEnvelope(env)
+		detailList, err := transactionDetailListFromEnvelope(env)
 		if err != nil {
-			return nil, fmt.Errorf("获取channelHeader失败: %v", err)
-		}
-
-		signatureHeader, err := l.GetSignatureHeaderFromEnvelope(env)
-		if err != nil {
-			return nil, fmt.Errorf("获取signatureHeader失败: %v", err)
-		}
-		creator := &msp.SerializedIdentity{}
-		if err := proto.Unmarshal(signatureHeader.Creator, creator); err != nil {
-			return nil, fmt.Errorf("解析creator失败: %v", err)
-		}
-		creatorInfo, err := parseCreatorCertificate(creator.IdBytes)
-		if err != nil {
-			return nil, fmt.Errorf("解析creator证书失败: %v", err)
-		}
-
-		payload := &common.Payload{}
-		if err := proto.Unmarshal(env.Payload, payload); err != nil {
-			return nil, fmt.Errorf("解析payload失败: %v", err)
-		}
-		txPayload := &peer.Transaction{}
-		if err := proto.Unmarshal(payload.Data, txPayload); err != nil {
-			return nil, fmt.Errorf("解析TransactionAction失败: %v", err)
-		}
-		for _, action := range txPayload.Actions {
-			chaincodeActionPayload := &peer.ChaincodeActionPayload{}
-			if err := proto.Unmarshal(action.Payload, chaincodeActionPayload); err != nil {
-				return nil, fmt.Errorf("解析ChaincodeActionPayload失败: %v", err)
-			}
-			chaincodeProposalPayload := &peer.ChaincodeProposalPayload{}
-			if err := proto.Unmarshal(chaincodeActionPayload.ChaincodeProposalPayload, chaincodeProposalPayload); err != nil {
-				return nil, fmt.Errorf("解析ChaincodeProposalPayload失败: %v", err)
-			}
-			chaincodeInvocationSpec := &peer.ChaincodeInvocationSpec{}
-			if err := proto.Unmarshal(chaincodeProposalPayload.Input, chaincodeInvocationSpec); err != nil {
-				return nil, fmt.Errorf("解析ChaincodeInvocationSpec失败: %v", err)
-			}
-			transactionDetail := &BlockTransactionDetail{
-				TransactionID:         channelHeader.TxId,
-				Creator:               creatorInfo,
-				TransactionTimestamp:  channelHeader.Timestamp.AsTime().Format(time.RFC3339),
-				ChainCodeFunctionName: string(chaincodeInvocationSpec.ChaincodeSpec.Input.Args[0]),
-			}
-			transactionDetailList = append(transactionDetailList, transactionDetail)
+			return nil, err
 		}
+		transactionDetailList = append(transactionDetailList, detailList...)
 	}
 	return transactionDetailList, nil
 }
@@ -712,7 +664,9 @@ func (l *blockListener) persistBatch(batch []blockToSave) {
 		return
 	}
 
-	latestBlockUpdates := make(map[string]LatestBlock) // orgName -> LatestBlock
+	streamList := make(map[string]*blockToSave) // streamKey -> 批处理中该区块流的最后一个区块
+	var gapList []blockGap                      // 保存后仍有缺口的区块流
+	var newBlockList []*BlockData               // 批处理中首次保存的区块，提交后推送给订阅者
 
 	err := l.db.Update(func(tx *bolt.Tx) error {
 		blocksBucket := tx.Bucket([]byte(_BlocksBucket))
@@ -724,7 +678,7 @@ func (l *blockListener) persistBatch(batch []blockToSave) {
 			return fmt.Errorf("bucket %s not found", _LatestBucket)
 		}
 
-		for _, item := range batch {
+		for i, item := range batch {
 			if item.block == nil {
 				utils.Log.Warn("尝试保存空区块，已跳过")
 				continue
@@ -732,17 +686,11 @@ func (l *blockListener) persistBatch(batch []blockToSave) {
 			blockNum := item.block.GetHeader().GetNumber()
 
 			// 计算区块哈希
-			blockHeader := BlockHeader{
-				Number:       new(big.Int).SetUint64(blockNum),
-				PreviousHash: item.block.GetHeader().GetPreviousHash(),
-				DataHash:     item.block.GetHeader().GetDataHash(),
-			}
-			headerBytes, err := asn1.Marshal(blockHeader)
+			blockHash, err := blockHeaderHash(blockNum, item.block.GetHeader().GetPreviousHash(), item.block.GetHeader().GetDataHash())
 			if err != nil {
 				utils.Log.Error(fmt.Sprintf("区块头序列化失败 (区块 %d): %v", blockNum, err))
 				continue // 跳过这个区块，或者更健壮地处理错误
 			}
-			blockHash := sha256.Sum256(headerBytes)
 
 			// 从区块中的第一个交易中提取SaveTime
 			var saveTime time.Time
@@ -769,7 +717,7 @@ func (l *blockListener) persistBatch(batch []blockToSave) {
 
 			blockData := BlockData{
 				BlockNumber: blockNum,
-				BlockHash:   fmt.Sprintf("%x", blockHash[:]),
+				BlockHash:   fmt.Sprintf("%x", blockHash),
 				DataHash:    fmt.Sprintf("%x", item.block.GetHeader().GetDataHash()),
 				PrevHash:    fmt.Sprintf("%x", item.block.GetHeader().GetPreviousHash()),
 				TxCount:     len(item.block.GetData().GetData()),
@@ -777,46 +725,93 @@ func (l *blockListener) persistBatch(batch []blockToSave) {
 				Data:        item.block.GetData().GetData(),
 				ChannelName: item.channelName,
 			}
+			if metadata := item.block.GetMetadata().GetMetadata(); len(metadata) > int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+				blockData.TxValidationCodes = metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+			}
 
-			blockKey := fmt.Sprintf("%s_%s_%d", item.channelName, item.orgName, blockNum)
-			blockJson, err := json.Marshal(blockData)
+			channelBucket, err := blocksBucket.CreateBucketIfNotExists([]byte(item.channelName))
 			if err != nil {
-				utils.Log.Error(fmt.Sprintf("区块数据序列化失败 (区块 %s): %v", blockKey, err))
-				// 可能返回err以回滚事务，或记录并继续处理下一个区块
-				return fmt.Errorf("区块 %s 数据序列化失败: %v", blockKey, err)
-			}
-			if err := blocksBucket.Put([]byte(blockKey), blockJson); err != nil {
-				utils.Log.Error(fmt.Sprintf("保存区块数据失败 (区块 %s): %v", blockKey, err))
-				return fmt.Errorf("保存区块 %s 数据失败: %v", blockKey, err)
+				return fmt.Errorf("创建通道[%s]区块bucket失败: %v", item.channelName, err)
 			}
 
-			// 跟踪当前批处理中该orgName的最新区块
-			if lb, ok := latestBlockUpdates[item.orgName]; !ok || blockNum > lb.BlockNum {
-				latestBlockUpdates[item.orgName] = LatestBlock{
-					BlockNum: blockNum,
-					SaveTime: time.Now(), // 保存批处理的时间
+			// 同一通道的区块由各组织分别收到，已保存过的区块不再重复写入
+			if existing := channelBucket.Get(blockKey(blockNum)); existing != nil {
+				var existingBlock BlockData
+				if err := json.Unmarshal(existing, &existingBlock); err == nil && existingBlock.BlockHash != blockData.BlockHash {
+					utils.Log.Error(fmt.Sprintf("通道[%s]区块[%d]哈希不一致，组织[%s]收到%s，已保存%s",
+						item.channelName, blockNum, item.orgName, blockData.BlockHash, existingBlock.BlockHash))
+				}
+			} else {
+				blockJson, err := json.Marshal(blockData)
+				if err != nil {
+					utils.Log.Error(fmt.Sprintf("区块数据序列化失败 (通道 %s 区块 %d): %v", item.channelName, blockNum, err))
+					return fmt.Errorf("通道 %s 区块 %d 数据序列化失败: %v", item.channelName, blockNum, err)
 				}
+				if err := channelBucket.Put(blockKey(blockNum), blockJson); err != nil {
+					utils.Log.Error(fmt.Sprintf("保存区块数据失败 (通道 %s 区块 %d): %v", item.channelName, blockNum, err))
+					return fmt.Errorf("保存通道 %s 区块 %d 数据失败: %v", item.channelName, blockNum, err)
+				}
+				if err := l.indexBlock(tx, &blockData); err != nil {
+					return fmt.Errorf("通道 %s 区块 %d 建立索引失败: %v", item.channelName, blockNum, err)
+				}
+				newBlockList = append(newBlockList, &blockData)
+			}
+
+			// 跟踪当前批处理中该区块流的最后一个区块
+			key := streamKey(item.channelName, item.orgName)
+			if last, ok := streamList[key]; !ok || blockNum > last.block.GetHeader().GetNumber() {
+				streamList[key] = &batch[i]
 			}
 			utils.Log.Debug(fmt.Sprintf("通道[%s]组织[%s]区块[%d]已准备在批处理中保存", item.channelName, item.orgName, blockNum))
 		}
 
-		// 处理完批处理中的所有区块后，更新最新区块信息
-		for orgName, latestBlock := range latestBlockUpdates {
-			latestJson, err := json.Marshal(latestBlock)
+		// 处理完批处理中的所有区块后，各区块流的最新区块号只推进到连续保存的位置，有缺口时重新订阅补齐
+		for key, last := range streamList {
+			channelBucket := blocksBucket.Bucket([]byte(last.channelName))
+			var latestBlock LatestBlock
+			nextBlockNum := uint64(0)
+			if latestData := latestBucket.Get([]byte(key)); latestData != nil {
+				if err := json.Unmarshal(latestData, &latestBlock); err != nil {
+					return fmt.Errorf("区块流 %s 最新区块信息反序列化失败: %v", key, err)
+				}
+				nextBlockNum = latestBlock.BlockNum + 1
+			}
+			startBlockNum := nextBlockNum
+			for channelBucket.Get(blockKey(nextBlockNum)) != nil {
+				nextBlockNum++
+			}
+			if last.block.GetHeader().GetNumber() > nextBlockNum {
+				gapList = append(gapList, blockGap{channelName: last.channelName, orgName: last.orgName, blockNum: nextBlockNum})
+			}
+			if nextBlockNum == startBlockNum {
+				continue
+			}
+
+			latestJson, err := json.Marshal(LatestBlock{
+				BlockNum: nextBlockNum - 1,
+				SaveTime: time.Now(), // 保存批处理的时间
+			})
 			if err != nil {
-				return fmt.Errorf("组织 %s 最新区块信息序列化失败: %v", orgName, err)
+				return fmt.Errorf("区块流 %s 最新区块信息序列化失败: %v", key, err)
 			}
-			if err := latestBucket.Put([]byte(orgName), latestJson); err != nil {
-				return fmt.Errorf("组织 %s 保存最新区块信息失败: %v", orgName, err)
+			if err := latestBucket.Put([]byte(key), latestJson); err != nil {
+				return fmt.Errorf("区块流 %s 保存最新区块信息失败: %v", key, err)
 			}
 		}
 		return nil
 	})
 
 	if err != nil {
+		// 保存失败的区块会在之后的批处理中表现为缺口，由resyncStream重新订阅补齐
 		utils.Log.Error(fmt.Sprintf("批量保存区块失败 (共 %d 个区块): %v", len(batch), err))
-		// 如有必要，为失败的批处理实现重试逻辑或死信队列
 	} else {
+		for _, gap := range gapList {
+			l.resyncStream(gap.channelName, gap.orgName, gap.blockNum)
+		}
+		// 各组织收到的同一区块只推送一次，且推送时区块已可查询
+		for _, blockData := range newBlockList {
+			l.publishBlock(blockData)
+		}
 		var firstBlockNum, lastBlockNum uint64
 		if len(batch) > 0 {
 			firstBlockNum = batch[0].block.GetHeader().GetNumber()
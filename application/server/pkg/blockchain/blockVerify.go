@@ -0,0 +1,246 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+)
+
+// 从节点重新获取区块的超时时间
+const _PeerFetchTimeout = 2 * time.Minute
+
+// BlockVerifyResult 通道区块哈希链校验结果
+type BlockVerifyResult struct {
+	ChannelName       string    `json:"channelName"`       // 通道名
+	Intact            bool      `json:"intact"`            // 本地区块哈希链是否完整
+	VerifiedCount     int       `json:"verifiedCount"`     // 已校验通过的区块数
+	LastBlockNumber   uint64    `json:"lastBlockNumber"`   // 最后一个校验通过的区块号
+	BrokenBlockNumber *uint64   `json:"brokenBlockNumber"` // 第一个校验失败的区块号
+	Reason            string    `json:"reason"`            // 校验失败原因
+	PeerBlockList     []uint64  `json:"peerBlockList"`     // 与节点比对一致的区块号，每段连续区块比对最后一个
+	PeerError         string    `json:"peerError"`         // 无法从节点获取区块时的错误，此时只校验了本地哈希链
+	VerifyTime        time.Time `json:"verifyTime"`        // 校验时间
+}
+
+// blockSegment 本地连续保存的一段区块
+type blockSegment struct {
+	startBlock uint64
+	endBlock   uint64
+	endHash    string
+}
+
+// GetChannelNameList 获取本地保存了区块的通道
+func (l *blockListener) GetChannelNameList() ([]string, error) {
+	var channelNameList []string
+	err := l.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(_BlocksBucket)).ForEach(func(k, v []byte) error {
+			if v == nil {
+				channelNameList = append(channelNameList, string(k))
+			}
+			return nil
+		})
+	})
+	return channelNameList, err
+}
+
+// VerifyChannel 校验通道的本地区块哈希链，再用组织的网络从节点重新获取区块比对
+// 本地校验：按区块号顺序重新计算数据哈希和区块头哈希，并检查前一区块哈希与上一区块是否衔接
+// 节点比对：哈希链中每个区块都由下一个区块确认，只需比对每段连续区块的最后一个区块，不一致时再逐个比对找出第一个不一致的区块
+func (l *blockListener) VerifyChannel(channelName string, orgName string) (*BlockVerifyResult, error) {
+	result := &BlockVerifyResult{
+		ChannelName:   channelName,
+		Intact:        true,
+		PeerBlockList: []uint64{},
+		VerifyTime:    time.Now(),
+	}
+
+	segmentList, err := l.verifyLocalChain(result)
+	if err != nil {
+		return nil, err
+	}
+
+	network, err := l.getNetwork(channelName, orgName)
+	if err != nil {
+		result.PeerError = err.Error()
+		return result, nil
+	}
+	for _, segment := range segmentList {
+		brokenBlock, reason, err := l.verifySegmentWithPeer(network, segment)
+		if err != nil {
+			result.PeerError = err.Error()
+			return result, nil
+		}
+		if reason != "" {
+			result.Intact = false
+			result.BrokenBlockNumber = &brokenBlock
+			result.Reason = reason
+			return result, nil
+		}
+		result.PeerBlockList = append(result.PeerBlockList, segment.endBlock)
+	}
+	return result, nil
+}
+
+// verifyLocalChain 按区块号顺序校验本地区块，遇到第一个校验失败的区块时停止，返回校验通过的连续区块段
+func (l *blockListener) verifyLocalChain(result *BlockVerifyResult) ([]*blockSegment, error) {
+	var segmentList []*blockSegment
+	err := l.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(_BlocksBucket)).Bucket([]byte(result.ChannelName))
+		if b == nil {
+			return fmt.Errorf("通道[%s]没有保存区块", result.ChannelName)
+		}
+
+		var segment *blockSegment
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var blockData BlockData
+			if err := json.Unmarshal(v, &blockData); err != nil {
+				return fmt.Errorf("解析通道[%s]区块失败: %v", result.ChannelName, err)
+			}
+
+			reason := verifyBlockData(binary.BigEndian.Uint64(k), &blockData, segment)
+			if reason != "" {
+				brokenBlock := binary.BigEndian.Uint64(k)
+				result.Intact = false
+				result.BrokenBlockNumber = &brokenBlock
+				result.Reason = reason
+				break
+			}
+
+			// 区块号不连续时开始新的一段，缺口由区块监听器重新同步补齐
+			if segment == nil || blockData.BlockNumber != segment.endBlock+1 {
+				segment = &blockSegment{startBlock: blockData.BlockNumber}
+				segmentList = append(segmentList, segment)
+			}
+			segment.endBlock = blockData.BlockNumber
+			segment.endHash = blockData.BlockHash
+			result.VerifiedCount++
+			result.LastBlockNumber = blockData.BlockNumber
+		}
+		return nil
+	})
+	return segmentList, err
+}
+
+// verifyBlockData 校验单个区块，返回失败原因，校验通过时返回空字符串
+func verifyBlockData(blockNum uint64, blockData *BlockData, previous *blockSegment) string {
+	if blockData.BlockNumber != blockNum {
+		return fmt.Sprintf("区块[%d]保存位置的区块号为%d", blockNum, blockData.BlockNumber)
+	}
+
+	dataHash := sha256.Sum256(bytes.Join(blockData.Data, nil))
+	if hex.EncodeToString(dataHash[:]) != blockData.DataHash {
+		return fmt.Sprintf("区块[%d]数据哈希不一致: 计算值%x，保存值%s", blockNum, dataHash, blockData.DataHash)
+	}
+
+	prevHash, err := hex.DecodeString(blockData.PrevHash)
+	if err != nil {
+		return fmt.Sprintf("区块[%d]前一区块哈希格式错误: %v", blockNum, err)
+	}
+	blockHash, err := blockHeaderHash(blockNum, prevHash, dataHash[:])
+	if err != nil {
+		return fmt.Sprintf("区块[%d]区块头序列化失败: %v", blockNum, err)
+	}
+	if hex.EncodeToString(blockHash) != blockData.BlockHash {
+		return fmt.Sprintf("区块[%d]区块哈希不一致: 计算值%x，保存值%s", blockNum, blockHash, blockData.BlockHash)
+	}
+
+	if previous != nil && previous.endBlock+1 == blockNum && previous.endHash != blockData.PrevHash {
+		return fmt.Sprintf("区块[%d]的前一区块哈希%s与区块[%d]的哈希%s不衔接", blockNum, blockData.PrevHash, previous.endBlock, previous.endHash)
+	}
+	return ""
+}
+
+// getNetwork 获取组织当前网关节点上的通道网络
+func (l *blockListener) getNetwork(channelName string, orgName string) (*client.Network, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	gateway, ok := l.gateways[channelName][orgName]
+	if !ok {
+		return nil, fmt.Errorf("组织[%s]在通道[%s]的网络不存在", orgName, channelName)
+	}
+	network, _ := gateway.network(channelName)
+	return network, nil
+}
+
+// verifySegmentWithPeer 比对一段连续区块与节点上的区块，返回第一个不一致的区块号和原因，一致时原因为空
+func (l *blockListener) verifySegmentWithPeer(network *client.Network, segment *blockSegment) (uint64, string, error) {
+	var peerHash string
+	err := l.fetchPeerBlocks(network, segment.endBlock, segment.endBlock, func(block *common.Block) (bool, error) {
+		blockHash, err := peerBlockHash(block)
+		peerHash = blockHash
+		return false, err
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	if peerHash == segment.endHash {
+		return 0, "", nil
+	}
+
+	// 段尾区块不一致，从段首开始逐个比对
+	brokenBlock := segment.endBlock
+	reason := fmt.Sprintf("区块[%d]与节点不一致: 本地哈希%s，节点哈希%s", segment.endBlock, segment.endHash, peerHash)
+	err = l.fetchPeerBlocks(network, segment.startBlock, segment.endBlock, func(block *common.Block) (bool, error) {
+		blockNum := block.GetHeader().GetNumber()
+		localBlock, err := l.GetBlockByNumber(network.Name(), "", blockNum)
+		if err != nil {
+			return false, err
+		}
+		blockHash, err := peerBlockHash(block)
+		if err != nil {
+			return false, err
+		}
+		if blockHash != localBlock.BlockHash {
+			brokenBlock = blockNum
+			reason = fmt.Sprintf("区块[%d]与节点不一致: 本地哈希%s，节点哈希%s", blockNum, localBlock.BlockHash, blockHash)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	return brokenBlock, reason, nil
+}
+
+// fetchPeerBlocks 重新订阅区块事件，从节点获取[startBlock, endBlock]的区块，handle返回false时停止
+func (l *blockListener) fetchPeerBlocks(network *client.Network, startBlock uint64, endBlock uint64, handle func(block *common.Block) (bool, error)) error {
+	ctx, cancel := context.WithTimeout(l.ctx, _PeerFetchTimeout)
+	defer cancel()
+
+	events, err := network.BlockEvents(ctx, client.WithStartBlock(startBlock))
+	if err != nil {
+		return fmt.Errorf("从节点获取通道[%s]区块失败: %v", network.Name(), err)
+	}
+	for block := range events {
+		next, err := handle(block)
+		if err != nil {
+			return err
+		}
+		if !next || block.GetHeader().GetNumber() >= endBlock {
+			return nil
+		}
+	}
+	return fmt.Errorf("从节点获取通道[%s]区块[%d]中断: %v", network.Name(), startBlock, ctx.Err())
+}
+
+// peerBlockHash 计算从节点获取的区块的区块头哈希
+func peerBlockHash(block *common.Block) (string, error) {
+	header := block.GetHeader()
+	blockHash, err := blockHeaderHash(header.GetNumber(), header.GetPreviousHash(), header.GetDataHash())
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(blockHash), nil
+}
@@ -0,0 +1,389 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"grets_server/config"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// CA类型
+const (
+	CATypeFabricCA = "fabric-ca" // 通过Fabric CA登记
+	CATypeLocal    = "local"     // 用组织CA证书和私钥在本地签发，仅用于测试
+)
+
+const (
+	_RegistrarLabel    = "registrar"          // 钱包中登记员身份的标签，用户标签为64位哈希，不会重名
+	_CARequestTimeout  = 15 * time.Second     // 请求Fabric CA的超时时间
+	_LocalCertValidity = 365 * 24 * time.Hour // 本地签发的用户证书有效期
+	_ClientOU          = "client"             // 用户证书的OU，与组织MSP的NodeOUs配置对应
+)
+
+// certificateAuthority 为用户签发X.509身份
+type certificateAuthority interface {
+	// enroll 为标签对应的用户生成私钥并签发证书，返回PEM格式的证书和私钥
+	enroll(label string) ([]byte, []byte, error)
+	// enrollCSR 按用户提交的证书请求签发证书，私钥由用户持有，返回PEM格式的证书
+	enrollCSR(label string, csrPEM []byte) ([]byte, error)
+}
+
+// newCertificateAuthority 按配置创建CA客户端，未配置时返回nil
+func newCertificateAuthority(caConfig config.CAConfig, mspID string, userWallet *wallet) (certificateAuthority, error) {
+	switch caConfig.Type {
+	case "":
+		return nil, nil
+	case CATypeFabricCA:
+		return newFabricCA(caConfig, mspID, userWallet)
+	case CATypeLocal:
+		return newLocalCA(caConfig)
+	default:
+		return nil, fmt.Errorf("不支持的CA类型: %s", caConfig.Type)
+	}
+}
+
+// generateKey 生成P-256私钥，返回私钥和PKCS8格式的PEM
+func generateKey() (*ecdsa.PrivateKey, []byte, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("生成私钥失败: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("序列化私钥失败: %v", err)
+	}
+	return privateKey, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// parseCertificateRequest 解析PEM格式的证书请求并校验请求的签名
+func parseCertificateRequest(csrPEM []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("证书请求解码失败")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析证书请求失败: %v", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("证书请求签名无效: %v", err)
+	}
+	return csr, nil
+}
+
+// localCA 用组织CA的证书和私钥直接签发用户证书，签发的证书与Fabric CA签发的一样被组织MSP接受
+type localCA struct {
+	certificate *x509.Certificate
+	privateKey  crypto.Signer
+}
+
+func newLocalCA(caConfig config.CAConfig) (*localCA, error) {
+	certificate, err := loadCertificate(caConfig.CertPath)
+	if err != nil {
+		return nil, fmt.Errorf("加载CA证书失败: %v", err)
+	}
+	privateKey, err := loadPrivateKey(caConfig.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("加载CA私钥失败: %v", err)
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("CA私钥不支持签名")
+	}
+	return &localCA{certificate: certificate, privateKey: signer}, nil
+}
+
+func (ca *localCA) enroll(label string) ([]byte, []byte, error) {
+	privateKey, privateKeyPEM, err := generateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	certificatePEM, err := ca.issue(label, &privateKey.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return certificatePEM, privateKeyPEM, nil
+}
+
+func (ca *localCA) enrollCSR(label string, csrPEM []byte) ([]byte, error) {
+	csr, err := parseCertificateRequest(csrPEM)
+	if err != nil {
+		return nil, err
+	}
+	return ca.issue(label, csr.PublicKey)
+}
+
+// issue 为公钥签发用户证书
+func (ca *localCA) issue(label string, publicKey interface{}) ([]byte, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("生成证书序列号失败: %v", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:         label,
+			Organization:       ca.certificate.Subject.Organization,
+			OrganizationalUnit: []string{_ClientOU},
+		},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(_LocalCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, publicKey, ca.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("签发用户[%s]证书失败: %v", label, err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+// fabricCA Fabric CA的REST客户端：以登记员身份注册用户，再用用户名和密码登记证书
+type fabricCA struct {
+	config     config.CAConfig
+	mspID      string
+	wallet     *wallet
+	httpClient *http.Client
+}
+
+// fabricCAResponse Fabric CA接口的响应
+type fabricCAResponse struct {
+	Success bool            `json:"success"`
+	Result  json.RawMessage `json:"result"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func newFabricCA(caConfig config.CAConfig, mspID string, userWallet *wallet) (*fabricCA, error) {
+	tlsCertPEM, err := os.ReadFile(caConfig.TlsCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取CA的TLS证书失败: %v", err)
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(tlsCertPEM) {
+		return nil, fmt.Errorf("CA的TLS证书解析失败")
+	}
+
+	// cryptogen生成的CA证书没有SAN，只校验证书链，不校验主机名
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("CA未提供TLS证书")
+			}
+			certificate, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			intermediates := x509.NewCertPool()
+			for _, raw := range rawCerts[1:] {
+				if intermediate, err := x509.ParseCertificate(raw); err == nil {
+					intermediates.AddCert(intermediate)
+				}
+			}
+			_, err = certificate.Verify(x509.VerifyOptions{Roots: certPool, Intermediates: intermediates})
+			return err
+		},
+	}
+
+	return &fabricCA{
+		config: caConfig,
+		mspID:  mspID,
+		wallet: userWallet,
+		httpClient: &http.Client{
+			Timeout:   _CARequestTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+func (ca *fabricCA) enroll(label string) ([]byte, []byte, error) {
+	secret, err := ca.register(label)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ca.enrollWithSecret(label, secret)
+}
+
+func (ca *fabricCA) enrollCSR(label string, csrPEM []byte) ([]byte, error) {
+	if _, err := parseCertificateRequest(csrPEM); err != nil {
+		return nil, err
+	}
+	secret, err := ca.register(label)
+	if err != nil {
+		return nil, err
+	}
+	return ca.enrollWithCSR(label, secret, csrPEM)
+}
+
+// register 以登记员身份注册用户，返回登记密码
+func (ca *fabricCA) register(label string) (string, error) {
+	registrar, err := ca.registrar()
+	if err != nil {
+		return "", err
+	}
+
+	// 每次登记使用新的随机密码，用户已在CA注册过时（例如钱包丢失）重置密码后重新登记
+	secretBytes := make([]byte, 16)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", fmt.Errorf("生成登记密码失败: %v", err)
+	}
+	secret := hex.EncodeToString(secretBytes)
+
+	err = ca.request(http.MethodPost, "/api/v1/register", registrar, "", map[string]interface{}{
+		"id":              label,
+		"type":            _ClientOU,
+		"secret":          secret,
+		"max_enrollments": -1,
+		"caname":          ca.config.CAName,
+	}, nil)
+	if err != nil && strings.Contains(err.Error(), "already registered") {
+		err = ca.request(http.MethodPut, "/api/v1/identities/"+label, registrar, "", map[string]interface{}{
+			"secret": secret,
+			"caname": ca.config.CAName,
+		}, nil)
+	}
+	if err != nil {
+		return "", fmt.Errorf("在CA注册用户[%s]失败: %v", label, err)
+	}
+	return secret, nil
+}
+
+// registrar 获取登记员身份，钱包中没有时用配置的用户名和密码登记
+func (ca *fabricCA) registrar() (*userIdentity, error) {
+	registrar, err := ca.wallet.get(_RegistrarLabel)
+	if err != nil || registrar != nil {
+		return registrar, err
+	}
+	certificatePEM, privateKeyPEM, err := ca.enrollWithSecret(ca.config.Registrar, ca.config.RegistrarSecret)
+	if err != nil {
+		return nil, fmt.Errorf("登记员登记失败: %v", err)
+	}
+	return ca.wallet.put(_RegistrarLabel, ca.mspID, certificatePEM, privateKeyPEM)
+}
+
+// enrollWithSecret 用用户名和密码登记，返回PEM格式的证书和私钥
+func (ca *fabricCA) enrollWithSecret(enrollmentID string, secret string) ([]byte, []byte, error) {
+	privateKey, privateKeyPEM, err := generateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: enrollmentID},
+	}, privateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("生成证书请求失败: %v", err)
+	}
+	certificatePEM, err := ca.enrollWithCSR(enrollmentID, secret, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}))
+	if err != nil {
+		return nil, nil, err
+	}
+	return certificatePEM, privateKeyPEM, nil
+}
+
+// enrollWithCSR 用用户名、密码和证书请求登记，返回PEM格式的证书
+func (ca *fabricCA) enrollWithCSR(enrollmentID string, secret string, csrPEM []byte) ([]byte, error) {
+	var result struct {
+		Cert string `json:"Cert"`
+	}
+	err := ca.request(http.MethodPost, "/api/v1/enroll", nil, enrollmentID+":"+secret, map[string]interface{}{
+		"certificate_request": string(csrPEM),
+		"caname":              ca.config.CAName,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	certificatePEM, err := base64.StdEncoding.DecodeString(result.Cert)
+	if err != nil {
+		return nil, fmt.Errorf("解析CA签发的证书失败: %v", err)
+	}
+	return certificatePEM, nil
+}
+
+// request 调用Fabric CA接口，signer不为空时使用令牌认证，否则使用basicAuth（用户名:密码）认证
+func (ca *fabricCA) request(method string, uri string, signer *userIdentity, basicAuth string, body interface{}, result interface{}) error {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %v", err)
+	}
+	request, err := http.NewRequest(method, strings.TrimSuffix(ca.config.URL, "/")+uri, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	if signer != nil {
+		token, err := authToken(signer, method, uri, bodyBytes)
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Authorization", token)
+	} else {
+		username, password, _ := strings.Cut(basicAuth, ":")
+		request.SetBasicAuth(username, password)
+	}
+
+	response, err := ca.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("请求CA失败: %v", err)
+	}
+	defer response.Body.Close()
+	responseBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("读取CA响应失败: %v", err)
+	}
+
+	var caResponse fabricCAResponse
+	if err := json.Unmarshal(responseBytes, &caResponse); err != nil {
+		return fmt.Errorf("解析CA响应失败(HTTP %d): %s", response.StatusCode, string(responseBytes))
+	}
+	if !caResponse.Success {
+		messageList := make([]string, 0, len(caResponse.Errors))
+		for _, item := range caResponse.Errors {
+			messageList = append(messageList, fmt.Sprintf("%d: %s", item.Code, item.Message))
+		}
+		return fmt.Errorf("CA返回错误(HTTP %d): %s", response.StatusCode, strings.Join(messageList, "; "))
+	}
+	if result != nil {
+		if err := json.Unmarshal(caResponse.Result, result); err != nil {
+			return fmt.Errorf("解析CA响应结果失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// authToken 生成Fabric CA的令牌：base64(证书).base64(签名)
+// 签名内容为 方法.base64(URI).base64(请求体).base64(证书) 的SHA256，签名函数已按Fabric要求使用低S值
+func authToken(signer *userIdentity, method string, uri string, body []byte) (string, error) {
+	b64Cert := base64.StdEncoding.EncodeToString(signer.id.Credentials())
+	payload := method + "." + base64.StdEncoding.EncodeToString([]byte(uri)) + "." +
+		base64.StdEncoding.EncodeToString(body) + "." + b64Cert
+	digest := sha256.Sum256([]byte(payload))
+
+	signature, err := signer.sign(digest[:])
+	if err != nil {
+		return "", fmt.Errorf("生成CA令牌失败: %v", err)
+	}
+	return b64Cert + "." + base64.StdEncoding.EncodeToString(signature), nil
+}
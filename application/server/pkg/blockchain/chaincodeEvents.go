@@ -0,0 +1,269 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"grets_server/pkg/utils"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// ChaincodeEventAll 订阅全部类型的链码事件
+const ChaincodeEventAll = "*"
+
+// ChaincodeEvent 链码事件，由链码通过SetEvent发送的JSON解析而来
+type ChaincodeEvent struct {
+	ChannelName   string          `json:"channelName"`   // 通道名
+	ChaincodeName string          `json:"chaincodeName"` // 链码名
+	BlockNumber   uint64          `json:"blockNumber"`   // 区块号
+	EventType     string          `json:"eventType"`     // 事件类型
+	Version       int             `json:"version"`       // 事件结构版本
+	TxID          string          `json:"txID"`          // 交易ID
+	Timestamp     time.Time       `json:"timestamp"`     // 交易时间
+	Payload       json.RawMessage `json:"payload"`       // 事件内容，按eventType和version解析
+}
+
+// ChaincodeEventHandler 链码事件处理函数，返回错误只记录日志，不影响其他处理函数和检查点
+type ChaincodeEventHandler func(event *ChaincodeEvent) error
+
+// 链码事件订阅
+type chaincodeEventSource struct {
+	channelName   string
+	chaincodeName string
+	orgName       string
+}
+
+// 链码事件订阅器，每个通道只订阅一次，处理完成后记录检查点，重启后从检查点继续
+type chaincodeEvents struct {
+	sync.RWMutex
+	sourceList []*chaincodeEventSource
+	handlers   map[string][]ChaincodeEventHandler
+	dataDir    string
+	ctx        context.Context
+	cancel     context.CancelFunc
+	started    bool
+}
+
+var eventSubscriber = &chaincodeEvents{
+	handlers: make(map[string][]ChaincodeEventHandler),
+	dataDir:  filepath.Join("data", "events"),
+}
+
+// addChaincodeEventSource 添加通道的链码事件订阅，同一通道只使用第一个组织的网关
+func addChaincodeEventSource(channelName string, chaincodeName string, orgName string) {
+	eventSubscriber.Lock()
+	defer eventSubscriber.Unlock()
+
+	for _, source := range eventSubscriber.sourceList {
+		if source.channelName == channelName {
+			return
+		}
+	}
+	eventSubscriber.sourceList = append(eventSubscriber.sourceList, &chaincodeEventSource{
+		channelName:   channelName,
+		chaincodeName: chaincodeName,
+		orgName:       orgName,
+	})
+}
+
+// RegisterChaincodeEventHandler 注册链码事件处理函数，eventType为ChaincodeEventAll时处理全部事件
+// 须在StartChaincodeEvents之前注册，否则启动前已确认的事件不会再分发给该处理函数
+func RegisterChaincodeEventHandler(eventType string, handler ChaincodeEventHandler) {
+	eventSubscriber.Lock()
+	defer eventSubscriber.Unlock()
+
+	eventSubscriber.handlers[eventType] = append(eventSubscriber.handlers[eventType], handler)
+}
+
+// StartChaincodeEvents 开始订阅各通道的链码事件
+func StartChaincodeEvents() error {
+	eventSubscriber.Lock()
+	defer eventSubscriber.Unlock()
+
+	if eventSubscriber.started {
+		return nil
+	}
+
+	if err := os.MkdirAll(eventSubscriber.dataDir, 0755); err != nil {
+		return fmt.Errorf("创建链码事件检查点目录失败: %v", err)
+	}
+
+	eventSubscriber.ctx, eventSubscriber.cancel = context.WithCancel(context.Background())
+	for _, source := range eventSubscriber.sourceList {
+		checkpointer, err := client.NewFileCheckpointer(filepath.Join(eventSubscriber.dataDir, source.channelName+".json"))
+		if err != nil {
+			eventSubscriber.cancel()
+			return fmt.Errorf("创建通道[%s]链码事件检查点失败: %v", source.channelName, err)
+		}
+		go eventSubscriber.listen(source, checkpointer)
+	}
+	eventSubscriber.started = true
+
+	utils.Log.Info(fmt.Sprintf("开始订阅%d个通道的链码事件", len(eventSubscriber.sourceList)))
+	return nil
+}
+
+// StopChaincodeEvents 停止订阅链码事件
+func StopChaincodeEvents() {
+	eventSubscriber.Lock()
+	defer eventSubscriber.Unlock()
+
+	if !eventSubscriber.started {
+		return
+	}
+	eventSubscriber.cancel()
+	eventSubscriber.started = false
+}
+
+// ChaincodeEventChannelList 获取已订阅链码事件的通道列表
+func ChaincodeEventChannelList() []string {
+	eventSubscriber.RLock()
+	defer eventSubscriber.RUnlock()
+
+	channelList := make([]string, 0, len(eventSubscriber.sourceList))
+	for _, source := range eventSubscriber.sourceList {
+		channelList = append(channelList, source.channelName)
+	}
+	return channelList
+}
+
+// ChaincodeEventsFrom 单独读取通道的链码事件，不经过注册的处理函数，由调用方自行保存检查点
+// transactionID为空时从blockNumber区块开始读取，否则跳过该区块中transactionID及之前的交易
+// 事件解析失败时EventType为空、Payload为原始内容，调用方可以跳过后继续保存检查点；ctx取消后通道关闭
+func ChaincodeEventsFrom(ctx context.Context, channelName string, blockNumber uint64, transactionID string) (<-chan *ChaincodeEvent, error) {
+	var source *chaincodeEventSource
+	eventSubscriber.RLock()
+	for _, item := range eventSubscriber.sourceList {
+		if item.channelName == channelName {
+			source = item
+			break
+		}
+	}
+	eventSubscriber.RUnlock()
+	if source == nil {
+		return nil, fmt.Errorf("通道[%s]未初始化", channelName)
+	}
+
+	checkpointer := new(client.InMemoryCheckpointer)
+	if transactionID != "" {
+		checkpointer.CheckpointTransaction(blockNumber, transactionID)
+	}
+	network, _ := orgGateways[source.orgName].network(channelName)
+	rawEvents, err := network.ChaincodeEvents(
+		ctx,
+		source.chaincodeName,
+		client.WithStartBlock(blockNumber),
+		client.WithCheckpoint(checkpointer),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("订阅通道[%s]链码事件失败: %v", channelName, err)
+	}
+
+	events := make(chan *ChaincodeEvent)
+	go func() {
+		defer close(events)
+		for rawEvent := range rawEvents {
+			event, err := parseChaincodeEvent(channelName, rawEvent)
+			if err != nil {
+				utils.Log.Error(err.Error())
+				event = &ChaincodeEvent{
+					ChannelName:   channelName,
+					ChaincodeName: rawEvent.ChaincodeName,
+					BlockNumber:   rawEvent.BlockNumber,
+					TxID:          rawEvent.TransactionID,
+					Payload:       rawEvent.Payload,
+				}
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// listen 订阅单个通道的链码事件，连接中断后报告节点不可用，从检查点在组织当前的网关节点上重新订阅
+func (e *chaincodeEvents) listen(source *chaincodeEventSource, checkpointer *client.FileCheckpointer) {
+	defer checkpointer.Close()
+
+	gateway := orgGateways[source.orgName]
+	retryCount := 0
+	for {
+		network, peer := gateway.network(source.channelName)
+		events, err := network.ChaincodeEvents(
+			e.ctx,
+			source.chaincodeName,
+			client.WithStartBlock(0),
+			client.WithCheckpoint(checkpointer),
+		)
+		if err != nil {
+			utils.Log.Error(fmt.Sprintf("订阅通道[%s]链码事件失败（已重试%d次）: %v", source.channelName, retryCount, err))
+		} else {
+			for event := range events {
+				e.dispatch(source, event)
+				if err := checkpointer.CheckpointChaincodeEvent(event); err != nil {
+					utils.Log.Error(fmt.Sprintf("保存通道[%s]链码事件检查点失败: %v", source.channelName, err))
+				}
+			}
+			if e.ctx.Err() != nil {
+				return
+			}
+			utils.Log.Warn(fmt.Sprintf("通道[%s]链码事件订阅中断（已重试%d次），准备重试...", source.channelName, retryCount))
+			err = fmt.Errorf("通道[%s]的链码事件订阅中断", source.channelName)
+		}
+		gateway.reportFailure(peer, err)
+
+		retryCount++
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-time.After(_RetryInterval):
+		}
+	}
+}
+
+// dispatch 解析链码事件并分发给对应的处理函数
+func (e *chaincodeEvents) dispatch(source *chaincodeEventSource, rawEvent *client.ChaincodeEvent) {
+	event, err := parseChaincodeEvent(source.channelName, rawEvent)
+	if err != nil {
+		utils.Log.Error(err.Error())
+		return
+	}
+
+	e.RLock()
+	handlers := append([]ChaincodeEventHandler{}, e.handlers[event.EventType]...)
+	handlers = append(handlers, e.handlers[ChaincodeEventAll]...)
+	e.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(event); err != nil {
+			utils.Log.Error(fmt.Sprintf("处理通道[%s]链码事件[%s]失败, txID: %s, error: %v",
+				source.channelName, event.EventType, event.TxID, err))
+		}
+	}
+}
+
+// parseChaincodeEvent 解析链码发送的事件JSON，补充通道、区块等信息
+func parseChaincodeEvent(channelName string, rawEvent *client.ChaincodeEvent) (*ChaincodeEvent, error) {
+	event := &ChaincodeEvent{}
+	if err := json.Unmarshal(rawEvent.Payload, event); err != nil {
+		return nil, fmt.Errorf("解析通道[%s]链码事件[%s]失败: %v", channelName, rawEvent.EventName, err)
+	}
+	event.ChannelName = channelName
+	event.ChaincodeName = rawEvent.ChaincodeName
+	event.BlockNumber = rawEvent.BlockNumber
+	if event.EventType == "" {
+		event.EventType = rawEvent.EventName
+	}
+	if event.TxID == "" {
+		event.TxID = rawEvent.TransactionID
+	}
+	return event, nil
+}
@@ -0,0 +1,270 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"grets_server/pkg/utils"
+	"math/rand"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	_ContractMaxAttempts  = 4                      // 可重试错误的最大调用次数（含第一次）
+	_ContractRetryBackoff = 200 * time.Millisecond // 第一次重试的退避上限，之后每次翻倍
+	_ContractRetryMaxWait = 3 * time.Second        // 单次退避上限
+)
+
+// 链上调用错误类型
+const (
+	ChainErrorEndorsement = "ENDORSEMENT" // 背书失败：背书策略无法满足、各节点背书结果不一致或上链后背书校验失败
+	ChainErrorChaincode   = "CHAINCODE"   // 链码业务错误：链码执行返回错误，重试不会改变结果
+	ChainErrorMVCC        = "MVCC"        // 读写冲突：读集在上链前已被其他交易修改，交易未生效，可重新提交
+	ChainErrorTimeout     = "TIMEOUT"     // 调用超时
+	ChainErrorUnavailable = "UNAVAILABLE" // 节点或排序节点不可用
+	ChainErrorInvalid     = "INVALID"     // 上链后因其他原因校验失败，交易未生效
+	ChainErrorUnknown     = "UNKNOWN"     // 其他错误
+)
+
+// 链码错误信息中的函数名，链码返回的错误格式为"[函数名] 错误信息"
+var chaincodeFunctionPattern = regexp.MustCompile(`\[(\w+)\]\s*(.*)`)
+
+// ChainError 分类后的链上调用错误
+type ChainError struct {
+	Kind            string // 错误类型
+	TransactionName string // 调用的链码函数
+	Function        string // 链码错误信息中的函数名，未给出时为调用的链码函数
+	Message         string // 链码错误信息，已去掉函数名前缀
+	TxID            string // 交易ID，背书前失败时为空
+	Attempts        int    // 实际调用次数
+	Err             error  // 网关返回的原始错误
+}
+
+func (e *ChainError) Error() string {
+	if e.Kind == ChainErrorChaincode {
+		return fmt.Sprintf("[%s] %s", e.Function, e.Message)
+	}
+	return fmt.Sprintf("调用链码[%s]失败(%s): %s", e.TransactionName, e.Kind, e.Message)
+}
+
+func (e *ChainError) Unwrap() error {
+	return e.Err
+}
+
+// ChainErrorKind 返回错误链中链上调用错误的类型，不是链上调用错误时返回空字符串
+func ChainErrorKind(err error) string {
+	var chainErr *ChainError
+	if errors.As(err, &chainErr) {
+		return chainErr.Kind
+	}
+	return ""
+}
+
+// Contract 合约客户端，对网关返回的错误分类，读写冲突、超时和节点不可用时按抖动退避重试
+// 只有确定交易未生效的错误才会重试：背书或查询阶段失败、上链后因读写冲突被判为无效
+// 每次调用使用组织当前的网关节点，节点不可用时切换到下一个节点后重试
+type Contract struct {
+	gateway       *orgGateway
+	channelName   string
+	chaincodeName string
+	user          *userIdentity // 签名的用户身份，为nil时使用组织身份
+}
+
+func newContract(gateway *orgGateway, channelName string, chaincodeName string) *Contract {
+	return &Contract{gateway: gateway, channelName: channelName, chaincodeName: chaincodeName}
+}
+
+// ChaincodeName 链码名称
+func (c *Contract) ChaincodeName() string {
+	return c.chaincodeName
+}
+
+// ChannelName 通道名
+func (c *Contract) ChannelName() string {
+	return c.channelName
+}
+
+// AsUser 返回以用户身份签名的合约客户端，用户在钱包中还没有身份时先通过组织的CA登记
+// 用户不属于该合约客户端的组织，或组织未配置CA时，仍使用组织身份签名
+func (c *Contract) AsUser(organization string, citizenIDHash string) (*Contract, error) {
+	if organization != c.gateway.orgName {
+		return c, nil
+	}
+	user, err := c.gateway.userIdentity(citizenIDHash)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return c, nil
+	}
+	return &Contract{gateway: c.gateway, channelName: c.channelName, chaincodeName: c.chaincodeName, user: user}, nil
+}
+
+// EvaluateTransaction 查询链码
+func (c *Contract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	return c.call(name, func(contract *client.Contract) ([]byte, error) {
+		return contract.EvaluateTransaction(name, args...)
+	})
+}
+
+// SubmitTransaction 提交交易并等待上链
+func (c *Contract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	return c.call(name, func(contract *client.Contract) ([]byte, error) {
+		return contract.SubmitTransaction(name, args...)
+	})
+}
+
+// Submit 按指定选项提交交易并等待上链，例如指定背书组织
+func (c *Contract) Submit(name string, options ...client.ProposalOption) ([]byte, error) {
+	return c.call(name, func(contract *client.Contract) ([]byte, error) {
+		return contract.Submit(name, options...)
+	})
+}
+
+// submitAsync 提交交易，发送给排序节点后返回，上链状态由调用方等待
+func (c *Contract) submitAsync(name string, args ...string) ([]byte, *client.Commit, error) {
+	var commit *client.Commit
+	result, err := c.call(name, func(contract *client.Contract) ([]byte, error) {
+		result, submitted, err := contract.SubmitAsync(name, client.WithArguments(args...))
+		commit = submitted
+		return result, err
+	})
+	return result, commit, err
+}
+
+// call 调用链码，可重试的错误按抖动退避重试，失败时返回分类后的错误
+func (c *Contract) call(name string, invoke func(contract *client.Contract) ([]byte, error)) ([]byte, error) {
+	backoff := _ContractRetryBackoff
+	for attempt := 1; ; attempt++ {
+		network, peer := c.gateway.network(c.channelName)
+		if c.user != nil {
+			userNetwork, err := peer.userNetwork(c.user, c.channelName)
+			if err != nil {
+				return nil, err
+			}
+			network = userNetwork
+		}
+		result, err := invoke(network.GetContract(c.chaincodeName))
+		if err == nil {
+			return result, nil
+		}
+
+		chainErr, retryable := classifyChainError(name, err)
+		chainErr.Attempts = attempt
+		if retryable && chainErr.Kind == ChainErrorUnavailable {
+			c.gateway.reportFailure(peer, err)
+		}
+		if !retryable || attempt >= _ContractMaxAttempts {
+			return nil, chainErr
+		}
+
+		wait := retryWait(backoff)
+		utils.Log.Warn(fmt.Sprintf("调用通道[%s]链码[%s]的[%s]失败(%s)，%v后第%d次重试: %s",
+			c.channelName, c.chaincodeName, name, chainErr.Kind, wait, attempt, chainErr.Message))
+		time.Sleep(wait)
+		backoff = min(backoff*2, _ContractRetryMaxWait)
+	}
+}
+
+// retryWait 在[backoff/2, backoff)之间随机等待，避免并发冲突的交易同时重试再次冲突
+func retryWait(backoff time.Duration) time.Duration {
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)))
+}
+
+// isMVCCConflict 判断上链校验结果是否为读写集冲突，冲突的交易重新背书后可以重试
+func isMVCCConflict(code peer.TxValidationCode) bool {
+	return code == peer.TxValidationCode_MVCC_READ_CONFLICT || code == peer.TxValidationCode_PHANTOM_READ_CONFLICT
+}
+
+// classifyChainError 对网关返回的错误分类，并返回交易是否确定未生效且可以重试
+func classifyChainError(name string, err error) (*ChainError, bool) {
+	chainErr := &ChainError{
+		Kind:            ChainErrorUnknown,
+		TransactionName: name,
+		Function:        name,
+		Message:         err.Error(),
+		Err:             err,
+	}
+
+	// 上链后校验失败，交易已排序但写集未生效
+	var commitErr *client.CommitError
+	if errors.As(err, &commitErr) {
+		chainErr.TxID = commitErr.TransactionID
+		chainErr.Message = commitErr.Code.String()
+		if isMVCCConflict(commitErr.Code) {
+			chainErr.Kind = ChainErrorMVCC
+			return chainErr, true
+		}
+		switch commitErr.Code {
+		case peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE:
+			chainErr.Kind = ChainErrorEndorsement
+		default:
+			chainErr.Kind = ChainErrorInvalid
+		}
+		return chainErr, false
+	}
+
+	// 链码返回的错误在网关错误的详情中（查询时在错误信息中），格式为"chaincode response 500, [函数名] 错误信息"
+	grpcStatus := status.Convert(err)
+	messageList := []string{grpcStatus.Message()}
+	for _, detail := range grpcStatus.Details() {
+		if errorDetail, ok := detail.(*gateway.ErrorDetail); ok {
+			messageList = append(messageList, errorDetail.GetMessage())
+		}
+	}
+	for _, message := range messageList {
+		_, response, found := strings.Cut(message, "chaincode response ")
+		if !found {
+			continue
+		}
+		chainErr.Kind = ChainErrorChaincode
+		if _, text, ok := strings.Cut(response, ", "); ok {
+			response = text
+		}
+		chainErr.Message = response
+		if match := chaincodeFunctionPattern.FindStringSubmatch(response); match != nil {
+			chainErr.Function = match[1]
+			chainErr.Message = match[2]
+		}
+		break
+	}
+
+	var endorseErr *client.EndorseError
+	var submitErr *client.SubmitError
+	var commitStatusErr *client.CommitStatusError
+	switch {
+	case errors.As(err, &endorseErr):
+		chainErr.TxID = endorseErr.TransactionID
+	case errors.As(err, &submitErr):
+		chainErr.TxID = submitErr.TransactionID
+	case errors.As(err, &commitStatusErr):
+		chainErr.TxID = commitStatusErr.TransactionID
+	}
+	if chainErr.Kind == ChainErrorChaincode {
+		return chainErr, false
+	}
+	chainErr.Message = grpcStatus.Message()
+
+	switch grpcStatus.Code() {
+	case codes.Unavailable:
+		chainErr.Kind = ChainErrorUnavailable
+	case codes.DeadlineExceeded:
+		chainErr.Kind = ChainErrorTimeout
+	default:
+		if endorseErr != nil {
+			chainErr.Kind = ChainErrorEndorsement
+		}
+	}
+
+	// 已发送给排序节点的交易可能已经生效，只有查询或背书阶段的超时、不可用可以重试
+	sentToOrderer := submitErr != nil || commitStatusErr != nil
+	retryable := !sentToOrderer && (chainErr.Kind == ChainErrorUnavailable || chainErr.Kind == ChainErrorTimeout)
+	return chainErr, retryable
+}
@@ -0,0 +1,153 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyChainError(t *testing.T) {
+	chaincodeDetail, err := status.New(codes.Aborted, "failed to endorse transaction, see attached details for more info").
+		WithDetails(&gateway.ErrorDetail{
+			Address: "peer0.investor.grets.com:7051",
+			MspId:   "InvestorMSP",
+			Message: "chaincode response 500, [CreateTransaction] 房产已有进行中的交易: tx-1",
+		})
+	if err != nil {
+		t.Fatalf("构造错误详情失败: %v", err)
+	}
+
+	testCaseList := []struct {
+		name      string
+		err       error
+		kind      string
+		function  string
+		message   string
+		txID      string
+		retryable bool
+	}{
+		{
+			name:      "MVCC冲突可以重试",
+			err:       &client.CommitError{TransactionID: "tx-mvcc", Code: peer.TxValidationCode_MVCC_READ_CONFLICT},
+			kind:      ChainErrorMVCC,
+			function:  "PayForTransaction",
+			message:   "MVCC_READ_CONFLICT",
+			txID:      "tx-mvcc",
+			retryable: true,
+		},
+		{
+			name:      "幻读冲突可以重试",
+			err:       fmt.Errorf("提交失败: %w", &client.CommitError{TransactionID: "tx-phantom", Code: peer.TxValidationCode_PHANTOM_READ_CONFLICT}),
+			kind:      ChainErrorMVCC,
+			function:  "PayForTransaction",
+			message:   "PHANTOM_READ_CONFLICT",
+			txID:      "tx-phantom",
+			retryable: true,
+		},
+		{
+			name:     "上链后背书校验失败",
+			err:      &client.CommitError{TransactionID: "tx-policy", Code: peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE},
+			kind:     ChainErrorEndorsement,
+			function: "PayForTransaction",
+			message:  "ENDORSEMENT_POLICY_FAILURE",
+			txID:     "tx-policy",
+		},
+		{
+			name:     "上链后其他校验失败",
+			err:      &client.CommitError{TransactionID: "tx-invalid", Code: peer.TxValidationCode_BAD_RWSET},
+			kind:     ChainErrorInvalid,
+			function: "PayForTransaction",
+			message:  "BAD_RWSET",
+			txID:     "tx-invalid",
+		},
+		{
+			name:     "错误详情中的链码错误",
+			err:      chaincodeDetail.Err(),
+			kind:     ChainErrorChaincode,
+			function: "CreateTransaction",
+			message:  "房产已有进行中的交易: tx-1",
+		},
+		{
+			name:     "错误信息中的链码错误",
+			err:      status.Error(codes.Unknown, "evaluate call to endorser returned error: chaincode response 500, [QueryRealty] 房产不存在"),
+			kind:     ChainErrorChaincode,
+			function: "QueryRealty",
+			message:  "房产不存在",
+		},
+		{
+			name:     "链码错误没有函数名",
+			err:      status.Error(codes.Unknown, "chaincode response 500, 参数数量错误"),
+			kind:     ChainErrorChaincode,
+			function: "PayForTransaction",
+			message:  "参数数量错误",
+		},
+		{
+			name:      "节点不可用可以重试",
+			err:       status.Error(codes.Unavailable, "connection refused"),
+			kind:      ChainErrorUnavailable,
+			function:  "PayForTransaction",
+			message:   "connection refused",
+			retryable: true,
+		},
+		{
+			name:      "调用超时可以重试",
+			err:       status.Error(codes.DeadlineExceeded, "context deadline exceeded"),
+			kind:      ChainErrorTimeout,
+			function:  "PayForTransaction",
+			message:   "context deadline exceeded",
+			retryable: true,
+		},
+		{
+			name:     "其他错误",
+			err:      errors.New("unexpected"),
+			kind:     ChainErrorUnknown,
+			function: "PayForTransaction",
+			message:  "unexpected",
+		},
+	}
+
+	for _, testCase := range testCaseList {
+		t.Run(testCase.name, func(t *testing.T) {
+			chainErr, retryable := classifyChainError("PayForTransaction", testCase.err)
+			if chainErr.Kind != testCase.kind {
+				t.Errorf("错误类型 = %s, 期望 %s", chainErr.Kind, testCase.kind)
+			}
+			if chainErr.Function != testCase.function {
+				t.Errorf("函数名 = %s, 期望 %s", chainErr.Function, testCase.function)
+			}
+			if chainErr.Message != testCase.message {
+				t.Errorf("错误信息 = %q, 期望 %q", chainErr.Message, testCase.message)
+			}
+			if chainErr.TxID != testCase.txID {
+				t.Errorf("交易ID = %s, 期望 %s", chainErr.TxID, testCase.txID)
+			}
+			if retryable != testCase.retryable {
+				t.Errorf("可重试 = %t, 期望 %t", retryable, testCase.retryable)
+			}
+			if chainErr.TransactionName != "PayForTransaction" {
+				t.Errorf("调用函数 = %s, 期望 PayForTransaction", chainErr.TransactionName)
+			}
+			if !errors.Is(chainErr, testCase.err) {
+				t.Errorf("分类后的错误应保留原始错误")
+			}
+		})
+	}
+}
+
+func TestChainErrorMessage(t *testing.T) {
+	chaincodeErr := &ChainError{Kind: ChainErrorChaincode, TransactionName: "CreateTransaction", Function: "CreateTransaction", Message: "房产不存在"}
+	if got := chaincodeErr.Error(); got != "[CreateTransaction] 房产不存在" {
+		t.Errorf("链码错误信息 = %q", got)
+	}
+
+	timeoutErr := &ChainError{Kind: ChainErrorTimeout, TransactionName: "CreateTransaction", Message: "context deadline exceeded"}
+	if got := timeoutErr.Error(); got != "调用链码[CreateTransaction]失败(TIMEOUT): context deadline exceeded" {
+		t.Errorf("超时错误信息 = %q", got)
+	}
+}
@@ -9,10 +9,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"time"
 
-	"github.com/hyperledger/fabric-gateway/pkg/client"
-	"github.com/hyperledger/fabric-gateway/pkg/hash"
 	"github.com/hyperledger/fabric-gateway/pkg/identity"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -20,9 +17,11 @@ import (
 
 var (
 	// 组织对应的合约客户端
-	mainContracts = make(map[string]*client.Contract)
+	mainContracts = make(map[string]*Contract)
 	// 地区-组织-合约客户端
-	subContracts = make(map[string]map[string]*client.Contract)
+	subContracts = make(map[string]map[string]*Contract)
+	// 组织对应的审计日志合约客户端
+	auditContracts = make(map[string]*Contract)
 )
 
 // InitFabricClient 初始化Fabric客户端
@@ -34,65 +33,47 @@ func InitFabricClient() error {
 
 	// 为每个组织创建客户端
 	for orgName, orgConfig := range config.GlobalConfig.Fabric.Organizations {
-		// 创建grpc链接
-		clientConnection, err := newGrpcConnection(orgConfig)
+		gw, err := newOrgGateway(orgName, orgConfig)
 		if err != nil {
-			return fmt.Errorf("创建grpc连接失败: %v", err)
+			return err
 		}
+		orgGateways[orgName] = gw
 
-		// 创建身份
-		id, err := newIdentity(orgConfig)
-		if err != nil {
-			return fmt.Errorf("创建身份失败: %v", err)
-		}
-
-		// 创建签名函数
-		sign, err := newSign(orgConfig)
-		if err != nil {
-			return fmt.Errorf("创建组织[%s]签名函数失败：%v", orgName, err)
-		}
-
-		gw, err := client.Connect(
-			id,
-			client.WithSign(sign),
-			client.WithHash(hash.SHA256),
-			client.WithClientConnection(clientConnection),
-			client.WithEvaluateTimeout(5*time.Second),
-			client.WithEndorseTimeout(15*time.Second),
-			client.WithSubmitTimeout(5*time.Second),
-			client.WithCommitStatusTimeout(1*time.Minute),
-		)
-		if err != nil {
-			return fmt.Errorf("连接组织[%s]的Fabric网关失败：%v", orgName, err)
+		mainContracts[orgName] = newContract(gw, config.GlobalConfig.Fabric.MainChannelName, config.GlobalConfig.Fabric.MainChainCodeName)
+		if config.GlobalConfig.Fabric.AuditChainCodeName != "" {
+			auditContracts[orgName] = newContract(gw, config.GlobalConfig.Fabric.MainChannelName, config.GlobalConfig.Fabric.AuditChainCodeName)
 		}
 
-		mainNetwork := gw.GetNetwork(config.GlobalConfig.Fabric.MainChannelName)
-		mainContracts[orgName] = mainNetwork.GetContract(config.GlobalConfig.Fabric.MainChainCodeName)
-
 		// 添加网络到区块链监听器
-		if err := addMainNetwork(orgName, mainNetwork); err != nil {
+		if err := addMainNetwork(orgName, gw); err != nil {
 			return fmt.Errorf("添加主通道网络到区块链监听器失败: %v", err)
 		}
+		addChaincodeEventSource(config.GlobalConfig.Fabric.MainChannelName, config.GlobalConfig.Fabric.MainChainCodeName, orgName)
 
 		for i := 0; i < len(config.GlobalConfig.Fabric.SubChannelName); i++ {
-			subNetwork := gw.GetNetwork(config.GlobalConfig.Fabric.SubChannelName[i])
-			if subContracts[config.GlobalConfig.Fabric.SubChannelName[i]] == nil {
-				subContracts[config.GlobalConfig.Fabric.SubChannelName[i]] = make(map[string]*client.Contract)
+			subChannelName := config.GlobalConfig.Fabric.SubChannelName[i]
+			if subContracts[subChannelName] == nil {
+				subContracts[subChannelName] = make(map[string]*Contract)
 			}
-			subContracts[config.GlobalConfig.Fabric.SubChannelName[i]][orgName] = subNetwork.GetContract(config.GlobalConfig.Fabric.SubChainCodeName[i])
-			if err := addSubNetwork(config.GlobalConfig.Fabric.SubChannelName[i], orgName, subNetwork); err != nil {
+			subContracts[subChannelName][orgName] = newContract(gw, subChannelName, config.GlobalConfig.Fabric.SubChainCodeName[i])
+			if err := addSubNetwork(subChannelName, orgName, gw); err != nil {
 				return fmt.Errorf("添加子通道网络到区块链监听器失败: %v", err)
 			}
+			addChaincodeEventSource(subChannelName, config.GlobalConfig.Fabric.SubChainCodeName[i], orgName)
 		}
 
-		utils.Log.Info(fmt.Sprintf("创建组织[%s]合约客户端成功", orgName))
+		utils.Log.Info(fmt.Sprintf("创建组织[%s]合约客户端成功，网关节点%d个", orgName, len(gw.peerList)))
 	}
 
+	// 启动前检查一次网关节点，选出各组织可用的节点
+	probePeers()
+	startPeerHealthCheck()
+
 	return nil
 }
 
 // GetMainContract 获取主通道的指定组织的合约客户端
-func GetMainContract(orgName string) (*client.Contract, error) {
+func GetMainContract(orgName string) (*Contract, error) {
 	contract, ok := mainContracts[orgName]
 	if !ok {
 		return nil, fmt.Errorf("组织[%s]合约客户端不存在", orgName)
@@ -101,7 +82,7 @@ func GetMainContract(orgName string) (*client.Contract, error) {
 }
 
 // GetSubContract 获取子通道的指定组织的合约客户端
-func GetSubContract(subChannelName string, orgName string) (*client.Contract, error) {
+func GetSubContract(subChannelName string, orgName string) (*Contract, error) {
 	contract, ok := subContracts[subChannelName][orgName]
 	if !ok {
 		return nil, fmt.Errorf("组织[%s]合约客户端不存在", orgName)
@@ -109,6 +90,15 @@ func GetSubContract(subChannelName string, orgName string) (*client.Contract, er
 	return contract, nil
 }
 
+// GetAuditContract 获取主通道审计日志链码的指定组织的合约客户端
+func GetAuditContract(orgName string) (*Contract, error) {
+	contract, ok := auditContracts[orgName]
+	if !ok {
+		return nil, fmt.Errorf("组织[%s]审计日志合约客户端不存在", orgName)
+	}
+	return contract, nil
+}
+
 // 加载证书
 func loadCertificate(certPath string) (*x509.Certificate, error) {
 	certPEM, err := ioutil.ReadFile(certPath)
@@ -157,8 +147,8 @@ func loadTLSCertificate(tlsCertPath string) (*x509.CertPool, error) {
 }
 
 // newGrpcConnection 创建 gRPC 连接
-func newGrpcConnection(orgConfig config.OrganizationConfig) (*grpc.ClientConn, error) {
-	certificatePEM, err := os.ReadFile(orgConfig.TlsCertPath)
+func newGrpcConnection(peerConfig config.PeerConfig) (*grpc.ClientConn, error) {
+	certificatePEM, err := os.ReadFile(peerConfig.TlsCertPath)
 	if err != nil {
 		return nil, fmt.Errorf("读取证书失败: %v", err)
 	}
@@ -170,9 +160,9 @@ func newGrpcConnection(orgConfig config.OrganizationConfig) (*grpc.ClientConn, e
 
 	certPool := x509.NewCertPool()
 	certPool.AddCert(certificate)
-	transportCredentials := credentials.NewClientTLSFromCert(certPool, orgConfig.GatewayPeer)
+	transportCredentials := credentials.NewClientTLSFromCert(certPool, peerConfig.GatewayPeer)
 
-	connection, err := grpc.Dial(orgConfig.PeerEndpoint, grpc.WithTransportCredentials(transportCredentials))
+	connection, err := grpc.Dial(peerConfig.PeerEndpoint, grpc.WithTransportCredentials(transportCredentials))
 	if err != nil {
 		return nil, fmt.Errorf("创建grpc连接失败: %v", err)
 	}
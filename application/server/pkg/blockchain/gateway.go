@@ -0,0 +1,353 @@
+package blockchain
+
+import (
+	"fmt"
+	"grets_server/config"
+	"grets_server/pkg/utils"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/hash"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/protobuf/proto"
+)
+
+// 默认的网关节点健康检查间隔
+const _DefaultPeerHealthInterval = 10 * time.Second
+
+// 组织对应的网关
+var orgGateways = make(map[string]*orgGateway)
+
+// PeerState 网关节点状态
+type PeerState struct {
+	OrgName         string     `json:"orgName"`         // 组织
+	PeerEndpoint    string     `json:"peerEndpoint"`    // 节点地址
+	GatewayPeer     string     `json:"gatewayPeer"`     // 节点名称
+	Active          bool       `json:"active"`          // 是否为组织当前使用的节点
+	Healthy         bool       `json:"healthy"`         // 最近一次检查是否可用
+	ConnectionState string     `json:"connectionState"` // gRPC连接状态
+	BlockHeight     uint64     `json:"blockHeight"`     // 主通道区块高度
+	LastError       string     `json:"lastError"`       // 最近一次不可用的原因
+	LastCheckTime   *time.Time `json:"lastCheckTime"`   // 最近一次检查时间
+}
+
+// peerGateway 组织通过单个节点连接的网关
+type peerGateway struct {
+	config        config.PeerConfig
+	connection    *grpc.ClientConn
+	gateway       *client.Gateway
+	healthy       bool
+	blockHeight   uint64
+	lastError     string
+	lastCheckTime *time.Time
+	userGateways  sync.Map // 用户标签 -> 以用户身份连接的网关，与组织网关共用gRPC连接
+}
+
+// orgGateway 组织的网关，连接配置的全部节点，使用优先级最高的可用节点
+// 节点不可用时切换到下一个可用节点，优先级更高的节点恢复后切换回去
+type orgGateway struct {
+	sync.RWMutex
+	orgName    string
+	mspID      string
+	peerList   []*peerGateway
+	active     int
+	wallet     *wallet              // 用户身份钱包
+	ca         certificateAuthority // 为用户签发身份的CA，为nil时用户交易使用组织身份签名
+	enrollLock sync.Mutex           // 同一时间只登记一个用户，避免并发请求重复登记
+}
+
+// newOrgGateway 连接组织配置的全部网关节点，gRPC连接在首次使用时建立，节点暂时不可用不影响启动
+func newOrgGateway(orgName string, orgConfig config.OrganizationConfig) (*orgGateway, error) {
+	// 创建身份
+	id, err := newIdentity(orgConfig)
+	if err != nil {
+		return nil, fmt.Errorf("创建身份失败: %v", err)
+	}
+
+	// 创建签名函数
+	sign, err := newSign(orgConfig)
+	if err != nil {
+		return nil, fmt.Errorf("创建组织[%s]签名函数失败：%v", orgName, err)
+	}
+
+	userWallet, err := newWallet(filepath.Join("data", "wallet", orgName))
+	if err != nil {
+		return nil, err
+	}
+	ca, err := newCertificateAuthority(orgConfig.CA, orgConfig.MspID, userWallet)
+	if err != nil {
+		return nil, fmt.Errorf("创建组织[%s]的CA客户端失败：%v", orgName, err)
+	}
+
+	g := &orgGateway{orgName: orgName, mspID: orgConfig.MspID, wallet: userWallet, ca: ca}
+	for _, peerConfig := range orgConfig.PeerList() {
+		peer, err := connectPeer(peerConfig, id, sign)
+		if err != nil {
+			return nil, fmt.Errorf("连接组织[%s]的节点[%s]失败：%v", orgName, peerConfig.PeerEndpoint, err)
+		}
+		g.peerList = append(g.peerList, peer)
+	}
+	return g, nil
+}
+
+// connectPeer 创建到单个节点的gRPC连接和网关
+func connectPeer(peerConfig config.PeerConfig, id identity.Identity, sign identity.Sign) (*peerGateway, error) {
+	// 创建grpc链接
+	clientConnection, err := newGrpcConnection(peerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("创建grpc连接失败: %v", err)
+	}
+
+	gw, err := connectGateway(clientConnection, id, sign)
+	if err != nil {
+		clientConnection.Close()
+		return nil, fmt.Errorf("连接Fabric网关失败：%v", err)
+	}
+	return &peerGateway{config: peerConfig, connection: clientConnection, gateway: gw, healthy: true}, nil
+}
+
+// connectGateway 在gRPC连接上以指定身份创建网关，sign为nil时网关只能提交用户已签名的提案和交易
+func connectGateway(connection *grpc.ClientConn, id identity.Identity, sign identity.Sign) (*client.Gateway, error) {
+	options := []client.ConnectOption{
+		client.WithHash(hash.SHA256),
+		client.WithClientConnection(connection),
+		client.WithEvaluateTimeout(5 * time.Second),
+		client.WithEndorseTimeout(15 * time.Second),
+		client.WithSubmitTimeout(5 * time.Second),
+		client.WithCommitStatusTimeout(1 * time.Minute),
+	}
+	if sign != nil {
+		options = append(options, client.WithSign(sign))
+	}
+	return client.Connect(id, options...)
+}
+
+// userNetwork 以用户身份获取节点上的通道网络
+func (p *peerGateway) userNetwork(user *userIdentity, channelName string) (*client.Network, error) {
+	gw, err := p.userGateway(user)
+	if err != nil {
+		return nil, err
+	}
+	return gw.GetNetwork(channelName), nil
+}
+
+// userGateway 获取以用户身份连接的网关
+func (p *peerGateway) userGateway(user *userIdentity) (*client.Gateway, error) {
+	if gw, ok := p.userGateways.Load(user.label); ok {
+		return gw.(*client.Gateway), nil
+	}
+	gw, err := connectGateway(p.connection, user.id, user.sign)
+	if err != nil {
+		return nil, fmt.Errorf("以用户[%s]身份连接Fabric网关失败：%v", user.label, err)
+	}
+	actual, _ := p.userGateways.LoadOrStore(user.label, gw)
+	return actual.(*client.Gateway), nil
+}
+
+// forgetUser 用户身份更新后丢弃各节点上以旧身份连接的网关
+func (g *orgGateway) forgetUser(label string) {
+	g.RLock()
+	defer g.RUnlock()
+	for _, peer := range g.peerList {
+		peer.userGateways.Delete(label)
+	}
+}
+
+// userIdentity 获取用户身份，钱包中没有时通过CA登记，组织未配置CA时返回nil
+func (g *orgGateway) userIdentity(label string) (*userIdentity, error) {
+	if g.ca == nil {
+		return nil, nil
+	}
+	user, err := g.wallet.get(label)
+	if err != nil || user != nil {
+		return user, err
+	}
+
+	g.enrollLock.Lock()
+	defer g.enrollLock.Unlock()
+	if user, err := g.wallet.get(label); err != nil || user != nil {
+		return user, err
+	}
+	certificatePEM, privateKeyPEM, err := g.ca.enroll(label)
+	if err != nil {
+		return nil, fmt.Errorf("为组织[%s]的用户登记身份失败: %v", g.orgName, err)
+	}
+	user, err = g.wallet.put(label, g.mspID, certificatePEM, privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	utils.Log.Info(fmt.Sprintf("已为组织[%s]的用户[%s]登记身份", g.orgName, label))
+	return user, nil
+}
+
+// network 获取当前节点上的通道网络，返回的节点用于在调用失败时报告
+func (g *orgGateway) network(channelName string) (*client.Network, *peerGateway) {
+	g.RLock()
+	defer g.RUnlock()
+
+	peer := g.peerList[g.active]
+	return peer.gateway.GetNetwork(channelName), peer
+}
+
+// reportFailure 报告节点不可用，当前正在使用该节点时切换到下一个可用节点
+func (g *orgGateway) reportFailure(peer *peerGateway, err error) {
+	g.Lock()
+	defer g.Unlock()
+
+	peer.healthy = false
+	peer.lastError = err.Error()
+	if g.peerList[g.active] != peer {
+		return
+	}
+	for i := 1; i < len(g.peerList); i++ {
+		next := (g.active + i) % len(g.peerList)
+		if g.peerList[next].healthy {
+			g.switchTo(next)
+			return
+		}
+	}
+}
+
+// switchTo 切换当前使用的节点，调用方须持有写锁
+func (g *orgGateway) switchTo(index int) {
+	if index == g.active {
+		return
+	}
+	utils.Log.Warn(fmt.Sprintf("组织[%s]的网关节点从[%s]切换到[%s]",
+		g.orgName, g.peerList[g.active].config.PeerEndpoint, g.peerList[index].config.PeerEndpoint))
+	g.active = index
+}
+
+// probe 检查全部节点，选择优先级最高的可用节点，全部不可用时保持当前节点
+// 通过查询系统链码qscc的主通道区块高度检查节点，不可用节点的连接立即重连，不等待gRPC的重连退避
+func (g *orgGateway) probe(channelName string) {
+	g.RLock()
+	peerList := slices.Clone(g.peerList)
+	g.RUnlock()
+
+	resultList := make([]error, len(peerList))
+	heightList := make([]uint64, len(peerList))
+	for i, peer := range peerList {
+		heightList[i], resultList[i] = peer.chainHeight(channelName)
+		if resultList[i] != nil && peer.connection.GetState() == connectivity.TransientFailure {
+			peer.connection.ResetConnectBackoff()
+		}
+	}
+
+	now := time.Now()
+	g.Lock()
+	defer g.Unlock()
+	for i, peer := range peerList {
+		peer.lastCheckTime = &now
+		if resultList[i] != nil {
+			if peer.healthy {
+				utils.Log.Warn(fmt.Sprintf("组织[%s]的网关节点[%s]不可用: %v", g.orgName, peer.config.PeerEndpoint, resultList[i]))
+			}
+			peer.healthy = false
+			peer.lastError = resultList[i].Error()
+			continue
+		}
+		if !peer.healthy {
+			utils.Log.Info(fmt.Sprintf("组织[%s]的网关节点[%s]已恢复", g.orgName, peer.config.PeerEndpoint))
+		}
+		peer.healthy = true
+		peer.blockHeight = heightList[i]
+	}
+	for i, peer := range peerList {
+		if peer.healthy {
+			g.switchTo(i)
+			break
+		}
+	}
+}
+
+// chainHeight 查询节点上通道的区块高度
+func (p *peerGateway) chainHeight(channelName string) (uint64, error) {
+	result, err := p.gateway.GetNetwork(channelName).GetContract("qscc").EvaluateTransaction("GetChainInfo", channelName)
+	if err != nil {
+		return 0, err
+	}
+	chainInfo := &common.BlockchainInfo{}
+	if err := proto.Unmarshal(result, chainInfo); err != nil {
+		return 0, fmt.Errorf("解析通道[%s]区块链信息失败: %v", channelName, err)
+	}
+	return chainInfo.GetHeight(), nil
+}
+
+// probePeers 检查全部组织的网关节点
+func probePeers() {
+	var wg sync.WaitGroup
+	for _, g := range orgGateways {
+		wg.Add(1)
+		go func(g *orgGateway) {
+			defer wg.Done()
+			g.probe(config.GlobalConfig.Fabric.MainChannelName)
+		}(g)
+	}
+	wg.Wait()
+}
+
+// startPeerHealthCheck 按配置的间隔检查网关节点
+func startPeerHealthCheck() {
+	interval := time.Duration(config.GlobalConfig.Fabric.PeerHealthInterval) * time.Second
+	if interval <= 0 {
+		interval = _DefaultPeerHealthInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			probePeers()
+		}
+	}()
+}
+
+// GetPeerStateList 获取全部网关节点的状态，按组织和优先级排列
+func GetPeerStateList() []*PeerState {
+	orgNameList := make([]string, 0, len(orgGateways))
+	for orgName := range orgGateways {
+		orgNameList = append(orgNameList, orgName)
+	}
+	sort.Strings(orgNameList)
+
+	var stateList []*PeerState
+	for _, orgName := range orgNameList {
+		g := orgGateways[orgName]
+		g.RLock()
+		for i, peer := range g.peerList {
+			stateList = append(stateList, &PeerState{
+				OrgName:         orgName,
+				PeerEndpoint:    peer.config.PeerEndpoint,
+				GatewayPeer:     peer.config.GatewayPeer,
+				Active:          i == g.active,
+				Healthy:         peer.healthy,
+				ConnectionState: peer.connection.GetState().String(),
+				BlockHeight:     peer.blockHeight,
+				LastError:       peer.lastError,
+				LastCheckTime:   peer.lastCheckTime,
+			})
+		}
+		g.RUnlock()
+	}
+	return stateList
+}
+
+// IsReady 每个组织当前使用的网关节点都可用时返回true
+func IsReady() bool {
+	for _, g := range orgGateways {
+		g.RLock()
+		healthy := g.peerList[g.active].healthy
+		g.RUnlock()
+		if !healthy {
+			return false
+		}
+	}
+	return len(orgGateways) > 0
+}
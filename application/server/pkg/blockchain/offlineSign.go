@@ -0,0 +1,505 @@
+package blockchain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"grets_server/pkg/utils"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	_OfflineSessionTTL    = 5 * time.Minute // 离线签名会话每个阶段的有效期
+	_OfflineCommitTimeout = 1 * time.Minute // 等待离线签名交易出现在区块中的超时时间
+	_OfflineLabelSuffix   = "-offline"      // 钱包中离线签名证书的标签后缀，与服务端托管私钥的身份区分
+)
+
+// 离线签名会话阶段
+const (
+	OfflineStageEnroll      = "ENROLL"      // 等待签名证书请求
+	OfflineStageProposal    = "PROPOSAL"    // 等待签名交易提案
+	OfflineStageTransaction = "TRANSACTION" // 等待签名背书后的交易
+	OfflineStageDone        = "DONE"        // 已完成：证书已登记或交易已提交排序
+)
+
+// ecdsa-with-SHA256
+var oidSignatureECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+
+// OfflineSession 离线签名会话，客户端用DID私钥对Message签名（即对其SHA256摘要Digest签名），提交签名后进入下一阶段
+// 交易需要签名两次：先签名提案，服务端背书后再签名交易，服务端提交排序
+type OfflineSession struct {
+	SessionID    string     `json:"sessionID"`              // 会话ID
+	Stage        string     `json:"stage"`                  // 当前阶段
+	FunctionName string     `json:"functionName,omitempty"` // 链码函数名称，登记证书时为空
+	TxID         string     `json:"txID,omitempty"`         // 交易ID，登记证书时为空
+	Message      string     `json:"message,omitempty"`      // 待签名内容，base64编码
+	Digest       string     `json:"digest,omitempty"`       // 待签名内容的SHA256摘要，十六进制
+	ExpireTime   *time.Time `json:"expireTime,omitempty"`   // 当前阶段的过期时间
+}
+
+// offlineSession 服务端保存的会话状态，待签名内容只在服务端生成，客户端不能替换
+type offlineSession struct {
+	OfflineSession
+	owner         string           // 会话所属用户：组织/用户标签
+	gateway       *orgGateway      // 用户所在组织的网关
+	label         string           // 用户标签
+	publicKey     *ecdsa.PublicKey // 用户的DID公钥，用于提前校验签名
+	user          *userIdentity    // 离线签名身份，登记证书时为nil
+	channelName   string
+	chaincodeName string
+	bytes         []byte // 证书请求主体、序列化的提案或交易
+	onCommit      func(status *ChainTxStatus)
+}
+
+// offlineSessionStore 内存中的离线签名会话，服务重启后未完成的会话失效，客户端重新发起即可
+type offlineSessionStore struct {
+	sync.Mutex
+	sessionList map[string]*offlineSession
+}
+
+var offlineSessions = &offlineSessionStore{sessionList: make(map[string]*offlineSession)}
+
+// put 保存会话并刷新过期时间，同时清理已过期的会话
+func (store *offlineSessionStore) put(session *offlineSession) {
+	now := time.Now()
+	expireTime := now.Add(_OfflineSessionTTL)
+	session.ExpireTime = &expireTime
+
+	store.Lock()
+	defer store.Unlock()
+	for sessionID, item := range store.sessionList {
+		if item.ExpireTime.Before(now) {
+			delete(store.sessionList, sessionID)
+		}
+	}
+	store.sessionList[session.SessionID] = session
+}
+
+// take 取出会话，处理期间其他请求无法使用同一会话，处理失败可重试时由调用方放回
+func (store *offlineSessionStore) take(sessionID string, owner string) (*offlineSession, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	session, ok := store.sessionList[sessionID]
+	if !ok || session.owner != owner {
+		return nil, fmt.Errorf("离线签名会话[%s]不存在", sessionID)
+	}
+	delete(store.sessionList, sessionID)
+	if session.ExpireTime.Before(time.Now()) {
+		return nil, fmt.Errorf("离线签名会话[%s]已过期", sessionID)
+	}
+	return session, nil
+}
+
+func offlineLabel(label string) string {
+	return label + _OfflineLabelSuffix
+}
+
+func sessionOwner(orgName string, label string) string {
+	return orgName + "/" + label
+}
+
+func newSessionID() (string, error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", fmt.Errorf("生成会话ID失败: %v", err)
+	}
+	return hex.EncodeToString(idBytes), nil
+}
+
+// setMessage 设置当前阶段的待签名内容
+func (session *offlineSession) setMessage(stage string, message []byte) {
+	digest := sha256.Sum256(message)
+	session.Stage = stage
+	session.Message = base64.StdEncoding.EncodeToString(message)
+	session.Digest = hex.EncodeToString(digest[:])
+}
+
+// PrepareOfflineEnroll 为用户的DID公钥生成证书请求，用户签名后由组织的CA签发离线签名证书
+// 证书的CN与服务端托管身份相同，链上仍是同一个用户
+func PrepareOfflineEnroll(orgName string, label string, publicKey *ecdsa.PublicKey) (*OfflineSession, error) {
+	g, ok := orgGateways[orgName]
+	if !ok {
+		return nil, fmt.Errorf("组织[%s]网关不存在", orgName)
+	}
+	if g.ca == nil {
+		return nil, fmt.Errorf("组织[%s]未配置CA，不支持离线签名", orgName)
+	}
+	requestInfo, err := certificateRequestInfo(label, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	sessionID, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &offlineSession{
+		owner:     sessionOwner(orgName, label),
+		gateway:   g,
+		label:     label,
+		publicKey: publicKey,
+		bytes:     requestInfo,
+	}
+	session.SessionID = sessionID
+	session.setMessage(OfflineStageEnroll, requestInfo)
+	offlineSessions.put(session)
+	result := session.OfflineSession
+	return &result, nil
+}
+
+// PrepareOffline 以用户的离线签名证书创建交易提案，返回待签名的提案
+// 交易在用户签名提案后背书、签名交易后提交，onCommit在确认上链状态后调用，可为nil
+func (c *Contract) PrepareOffline(organization string, label string, publicKey *ecdsa.PublicKey, name string, onCommit func(status *ChainTxStatus), args ...string) (*OfflineSession, error) {
+	if organization != c.gateway.orgName {
+		return nil, fmt.Errorf("用户不属于组织[%s]，无法以离线签名身份提交", c.gateway.orgName)
+	}
+	user, err := c.gateway.offlineIdentity(label, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	_, peer := c.gateway.network(c.channelName)
+	gw, err := peer.userGateway(user)
+	if err != nil {
+		return nil, err
+	}
+	proposal, err := gw.GetNetwork(c.channelName).GetContract(c.chaincodeName).NewProposal(name, client.WithArguments(args...))
+	if err != nil {
+		return nil, fmt.Errorf("创建交易提案失败: %v", err)
+	}
+	proposalBytes, err := proposal.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("序列化交易提案失败: %v", err)
+	}
+	proposedTransaction := &gateway.ProposedTransaction{}
+	if err := proto.Unmarshal(proposalBytes, proposedTransaction); err != nil {
+		return nil, fmt.Errorf("解析交易提案失败: %v", err)
+	}
+	sessionID, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &offlineSession{
+		owner:         sessionOwner(organization, label),
+		gateway:       c.gateway,
+		label:         label,
+		publicKey:     publicKey,
+		user:          user,
+		channelName:   c.channelName,
+		chaincodeName: c.chaincodeName,
+		bytes:         proposalBytes,
+		onCommit:      onCommit,
+	}
+	session.SessionID = sessionID
+	session.FunctionName = name
+	session.TxID = proposal.TransactionID()
+	session.setMessage(OfflineStageProposal, proposedTransaction.GetProposal().GetProposalBytes())
+	offlineSessions.put(session)
+	result := session.OfflineSession
+	return &result, nil
+}
+
+// SignOffline 提交用户对当前阶段的签名，返回下一阶段的会话，交易提交排序或证书登记后阶段为DONE
+// 签名为十六进制的r||s（与DID签名格式相同）或DER编码，背书或提交失败时会话保留，可用同一签名重试
+func SignOffline(sessionID string, organization string, label string, signatureHex string) (*OfflineSession, error) {
+	session, err := offlineSessions.take(sessionID, sessionOwner(organization, label))
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := parseOfflineSignature(session.publicKey, session.Digest, signatureHex)
+	if err != nil {
+		offlineSessions.put(session)
+		return nil, err
+	}
+
+	switch session.Stage {
+	case OfflineStageEnroll:
+		err = session.enroll(signature)
+	case OfflineStageProposal:
+		err = session.endorse(signature)
+	case OfflineStageTransaction:
+		err = session.submit(signature)
+	}
+	if err != nil {
+		offlineSessions.put(session)
+		return nil, err
+	}
+
+	if session.Stage != OfflineStageDone {
+		offlineSessions.put(session)
+	}
+	result := session.OfflineSession
+	return &result, nil
+}
+
+// enroll 用签名组装证书请求，由CA签发证书后保存到钱包，钱包中不保存私钥
+func (session *offlineSession) enroll(signature []byte) error {
+	g := session.gateway
+	csr, err := asn1.Marshal(struct {
+		RequestInfo        asn1.RawValue
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          asn1.BitString
+	}{
+		RequestInfo:        asn1.RawValue{FullBytes: session.bytes},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSignatureECDSAWithSHA256},
+		Signature:          asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+	})
+	if err != nil {
+		return fmt.Errorf("生成证书请求失败: %v", err)
+	}
+
+	certificatePEM, err := g.ca.enrollCSR(session.label, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}))
+	if err != nil {
+		return fmt.Errorf("为组织[%s]的用户登记离线签名证书失败: %v", g.orgName, err)
+	}
+	if _, err := g.wallet.put(offlineLabel(session.label), g.mspID, certificatePEM, nil); err != nil {
+		return err
+	}
+	g.forgetUser(offlineLabel(session.label))
+	utils.Log.Info(fmt.Sprintf("已为组织[%s]的用户[%s]登记离线签名证书", g.orgName, session.label))
+
+	session.Stage = OfflineStageDone
+	session.Message = ""
+	session.Digest = ""
+	return nil
+}
+
+// endorse 用签名的提案背书，背书通过后待签名内容为交易信封
+func (session *offlineSession) endorse(signature []byte) error {
+	_, peer := session.gateway.network(session.channelName)
+	gw, err := peer.userGateway(session.user)
+	if err != nil {
+		return err
+	}
+	proposal, err := gw.NewSignedProposal(session.bytes, signature)
+	if err != nil {
+		return fmt.Errorf("解析交易提案失败: %v", err)
+	}
+	transaction, err := proposal.Endorse()
+	if err != nil {
+		return session.chainError(peer, err)
+	}
+
+	transactionBytes, err := transaction.Bytes()
+	if err != nil {
+		return fmt.Errorf("序列化交易失败: %v", err)
+	}
+	session.bytes = transactionBytes
+	// 交易信封的签名内容为信封的载荷
+	preparedTransaction := &gateway.PreparedTransaction{}
+	if err := proto.Unmarshal(transactionBytes, preparedTransaction); err != nil {
+		return fmt.Errorf("解析交易失败: %v", err)
+	}
+	session.setMessage(OfflineStageTransaction, preparedTransaction.GetEnvelope().GetPayload())
+	return nil
+}
+
+// submit 提交签名的交易，上链状态从同步的区块中确定
+// 查询上链状态的请求也需要用户签名，因此不向网关查询，改为等待区块推送
+func (session *offlineSession) submit(signature []byte) error {
+	_, peer := session.gateway.network(session.channelName)
+	gw, err := peer.userGateway(session.user)
+	if err != nil {
+		return err
+	}
+	transaction, err := gw.NewSignedTransaction(session.bytes, signature)
+	if err != nil {
+		return fmt.Errorf("解析交易失败: %v", err)
+	}
+
+	// 提交前订阅，避免交易在订阅前已出块
+	subscription := listener.Subscribe(FeedFilter{ChannelList: []string{session.channelName}})
+	if _, err := transaction.Submit(); err != nil {
+		listener.Unsubscribe(subscription)
+		return session.chainError(peer, err)
+	}
+
+	now := time.Now()
+	status := &ChainTxStatus{
+		TxID:          session.TxID,
+		ChannelName:   session.channelName,
+		ChaincodeName: session.chaincodeName,
+		FunctionName:  session.FunctionName,
+		Status:        TxStatusPending,
+		SubmitTime:    &now,
+	}
+	if err := listener.saveTxStatus(status); err != nil {
+		utils.Log.Error(fmt.Sprintf("保存交易[%s]提交状态失败: %v", status.TxID, err))
+	}
+	go listener.waitForBlockCommit(subscription, status, session.onCommit)
+
+	session.Stage = OfflineStageDone
+	session.Message = ""
+	session.Digest = ""
+	return nil
+}
+
+// chainError 对背书或提交错误分类，节点不可用时切换节点，客户端可用同一签名重试
+func (session *offlineSession) chainError(peer *peerGateway, err error) error {
+	chainErr, _ := classifyChainError(session.FunctionName, err)
+	chainErr.Attempts = 1
+	if chainErr.Kind == ChainErrorUnavailable {
+		session.gateway.reportFailure(peer, err)
+	}
+	return chainErr
+}
+
+// waitForBlockCommit 等待交易出现在同步的区块中并记录校验结果，超时后从区块索引中再确认一次
+func (l *blockListener) waitForBlockCommit(subscription *FeedSubscription, status *ChainTxStatus, onCommit func(status *ChainTxStatus)) {
+	defer l.Unsubscribe(subscription)
+	timer := time.NewTimer(_OfflineCommitTimeout)
+	defer timer.Stop()
+
+	found := false
+	for !found {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			if event.Transaction == nil || event.Transaction.TransactionID != status.TxID {
+				continue
+			}
+			found = true
+			status.BlockNumber = event.Transaction.BlockNumber
+			status.ValidationCode = event.Transaction.ValidationCode
+		case <-timer.C:
+			// 推送缓冲已满时事件会被丢弃，从区块索引中确认
+			if indexed, err := l.GetTxStatus(status.TxID); err == nil && indexed.Status != TxStatusPending && indexed.Status != TxStatusUnknown {
+				found = true
+				status.BlockNumber = indexed.BlockNumber
+				status.ValidationCode = indexed.ValidationCode
+				break
+			}
+			now := time.Now()
+			status.CommitTime = &now
+			status.Status = TxStatusUnknown
+			status.ErrorMessage = "等待交易上链超时"
+			utils.Log.Warn(fmt.Sprintf("等待交易[%s]上链超时", status.TxID))
+			l.finishCommit(status, onCommit)
+			return
+		}
+	}
+
+	now := time.Now()
+	status.CommitTime = &now
+	status.Status = TxStatusCommitted
+	if status.ValidationCode != peer.TxValidationCode_VALID.String() {
+		status.Status = TxStatusInvalid
+		utils.Log.Error(fmt.Sprintf("交易[%s]调用[%s]上链后校验失败: %s", status.TxID, status.FunctionName, status.ValidationCode))
+	}
+	l.finishCommit(status, onCommit)
+}
+
+// finishCommit 保存交易的上链状态并通知调用方
+func (l *blockListener) finishCommit(status *ChainTxStatus, onCommit func(status *ChainTxStatus)) {
+	if err := l.saveTxStatus(status); err != nil {
+		utils.Log.Error(fmt.Sprintf("保存交易[%s]提交状态失败: %v", status.TxID, err))
+	}
+	if onCommit != nil {
+		onCommit(status)
+	}
+}
+
+// offlineIdentity 获取用户的离线签名身份，证书必须与用户当前的DID公钥一致
+func (g *orgGateway) offlineIdentity(label string, publicKey *ecdsa.PublicKey) (*userIdentity, error) {
+	user, err := g.wallet.get(offlineLabel(label))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("用户尚未登记离线签名证书")
+	}
+	certificate, err := identity.CertificateFromPEM(user.id.Credentials())
+	if err != nil {
+		return nil, fmt.Errorf("解析离线签名证书失败: %v", err)
+	}
+	certificateKey, ok := certificate.PublicKey.(*ecdsa.PublicKey)
+	if !ok || !certificateKey.Equal(publicKey) {
+		return nil, fmt.Errorf("离线签名证书与用户当前的DID公钥不一致，请重新登记")
+	}
+	return user, nil
+}
+
+// certificateRequestInfo 生成PKCS#10证书请求中待签名的部分，CN为用户标签
+func certificateRequestInfo(label string, publicKey *ecdsa.PublicKey) ([]byte, error) {
+	subject, err := asn1.Marshal(pkix.Name{CommonName: label}.ToRDNSequence())
+	if err != nil {
+		return nil, fmt.Errorf("生成证书请求主体失败: %v", err)
+	}
+	publicKeyInfo, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("序列化公钥失败: %v", err)
+	}
+	requestInfo, err := asn1.Marshal(struct {
+		Version    int
+		Subject    asn1.RawValue
+		PublicKey  asn1.RawValue
+		Attributes asn1.RawValue
+	}{
+		Subject:   asn1.RawValue{FullBytes: subject},
+		PublicKey: asn1.RawValue{FullBytes: publicKeyInfo},
+		// 空的属性集合 [0] IMPLICIT SET
+		Attributes: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("生成证书请求失败: %v", err)
+	}
+	return requestInfo, nil
+}
+
+// parseOfflineSignature 解析签名并用DID公钥校验，返回Fabric要求的低S值DER编码签名
+func parseOfflineSignature(publicKey *ecdsa.PublicKey, digestHex string, signatureHex string) ([]byte, error) {
+	signatureBytes, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return nil, fmt.Errorf("解码签名失败: %v", err)
+	}
+
+	var signature struct {
+		R, S *big.Int
+	}
+	if len(signatureBytes) == 64 {
+		signature.R = new(big.Int).SetBytes(signatureBytes[:32])
+		signature.S = new(big.Int).SetBytes(signatureBytes[32:])
+	} else if rest, err := asn1.Unmarshal(signatureBytes, &signature); err != nil || len(rest) > 0 {
+		return nil, fmt.Errorf("无效的签名格式")
+	}
+
+	digest, err := hex.DecodeString(digestHex)
+	if err != nil {
+		return nil, fmt.Errorf("解码摘要失败: %v", err)
+	}
+	if !ecdsa.Verify(publicKey, digest, signature.R, signature.S) {
+		return nil, fmt.Errorf("签名验证失败，请使用DID私钥对待签名内容签名")
+	}
+
+	// s和N-s都是有效签名，Fabric只接受较小的一个
+	curveOrder := elliptic.P256().Params().N
+	if signature.S.Cmp(new(big.Int).Rsh(curveOrder, 1)) > 0 {
+		signature.S = new(big.Int).Sub(curveOrder, signature.S)
+	}
+	der, err := asn1.Marshal(signature)
+	if err != nil {
+		return nil, fmt.Errorf("编码签名失败: %v", err)
+	}
+	return der, nil
+}
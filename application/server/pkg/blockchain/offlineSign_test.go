@@ -0,0 +1,176 @@
+package blockchain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"testing"
+)
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	return privateKey
+}
+
+// signDigest 签名摘要，highS为true时返回高S值的等价签名
+func signDigest(t *testing.T, privateKey *ecdsa.PrivateKey, digest []byte, highS bool) (*big.Int, *big.Int) {
+	t.Helper()
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	halfOrder := new(big.Int).Rsh(elliptic.P256().Params().N, 1)
+	if (s.Cmp(halfOrder) > 0) != highS {
+		s = new(big.Int).Sub(elliptic.P256().Params().N, s)
+	}
+	return r, s
+}
+
+// rawSignatureHex 编码为DID签名使用的十六进制r||s
+func rawSignatureHex(r *big.Int, s *big.Int) string {
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return hex.EncodeToString(signature)
+}
+
+func derSignatureHex(t *testing.T, r *big.Int, s *big.Int) string {
+	t.Helper()
+	der, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		t.Fatalf("编码签名失败: %v", err)
+	}
+	return hex.EncodeToString(der)
+}
+
+func TestParseOfflineSignature(t *testing.T) {
+	privateKey := newTestKey(t)
+	otherKey := newTestKey(t)
+	digest := sha256.Sum256([]byte("proposal bytes"))
+	digestHex := hex.EncodeToString(digest[:])
+	otherDigest := sha256.Sum256([]byte("other proposal bytes"))
+
+	lowR, lowS := signDigest(t, privateKey, digest[:], false)
+	highR, highS := signDigest(t, privateKey, digest[:], true)
+
+	testCaseList := []struct {
+		name         string
+		publicKey    *ecdsa.PublicKey
+		digestHex    string
+		signatureHex string
+		valid        bool
+	}{
+		{"r||s签名", &privateKey.PublicKey, digestHex, rawSignatureHex(lowR, lowS), true},
+		{"高S值的r||s签名", &privateKey.PublicKey, digestHex, rawSignatureHex(highR, highS), true},
+		{"DER签名", &privateKey.PublicKey, digestHex, derSignatureHex(t, lowR, lowS), true},
+		{"高S值的DER签名", &privateKey.PublicKey, digestHex, derSignatureHex(t, highR, highS), true},
+		{"其他密钥的签名", &otherKey.PublicKey, digestHex, rawSignatureHex(lowR, lowS), false},
+		{"其他内容的签名", &privateKey.PublicKey, hex.EncodeToString(otherDigest[:]), rawSignatureHex(lowR, lowS), false},
+		{"签名不是十六进制", &privateKey.PublicKey, digestHex, "not-hex", false},
+		{"签名格式错误", &privateKey.PublicKey, digestHex, hex.EncodeToString([]byte("short")), false},
+		{"DER签名后有多余数据", &privateKey.PublicKey, digestHex, derSignatureHex(t, lowR, lowS) + "00", false},
+		{"摘要不是十六进制", &privateKey.PublicKey, "not-hex", rawSignatureHex(lowR, lowS), false},
+	}
+
+	halfOrder := new(big.Int).Rsh(elliptic.P256().Params().N, 1)
+	for _, testCase := range testCaseList {
+		t.Run(testCase.name, func(t *testing.T) {
+			der, err := parseOfflineSignature(testCase.publicKey, testCase.digestHex, testCase.signatureHex)
+			if !testCase.valid {
+				if err == nil {
+					t.Fatalf("期望签名校验失败")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("签名校验失败: %v", err)
+			}
+
+			var signature struct{ R, S *big.Int }
+			if rest, err := asn1.Unmarshal(der, &signature); err != nil || len(rest) > 0 {
+				t.Fatalf("返回的签名不是DER编码: %v", err)
+			}
+			if signature.S.Cmp(halfOrder) > 0 {
+				t.Errorf("返回的签名不是低S值")
+			}
+			if !ecdsa.VerifyASN1(testCase.publicKey, digest[:], der) {
+				t.Errorf("返回的签名无法通过校验")
+			}
+		})
+	}
+}
+
+func TestCertificateRequestInfo(t *testing.T) {
+	privateKey := newTestKey(t)
+	label := "ffa2a4575771ec5c865a19c45930c02e"
+
+	requestInfo, err := certificateRequestInfo(label, &privateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("生成证书请求失败: %v", err)
+	}
+
+	// 与登记离线证书时相同：用户对请求摘要签名，服务端组装为PKCS#10证书请求
+	digest := sha256.Sum256(requestInfo)
+	r, s := signDigest(t, privateKey, digest[:], false)
+	signature, err := parseOfflineSignature(&privateKey.PublicKey, hex.EncodeToString(digest[:]), rawSignatureHex(r, s))
+	if err != nil {
+		t.Fatalf("签名校验失败: %v", err)
+	}
+	csr, err := asn1.Marshal(struct {
+		RequestInfo        asn1.RawValue
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          asn1.BitString
+	}{
+		RequestInfo:        asn1.RawValue{FullBytes: requestInfo},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSignatureECDSAWithSHA256},
+		Signature:          asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+	})
+	if err != nil {
+		t.Fatalf("组装证书请求失败: %v", err)
+	}
+
+	request, err := parseCertificateRequest(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}))
+	if err != nil {
+		t.Fatalf("解析证书请求失败: %v", err)
+	}
+	if request.Subject.CommonName != label {
+		t.Errorf("证书请求CN = %s, 期望 %s", request.Subject.CommonName, label)
+	}
+	publicKey, ok := request.PublicKey.(*ecdsa.PublicKey)
+	if !ok || !publicKey.Equal(&privateKey.PublicKey) {
+		t.Errorf("证书请求公钥与用户公钥不一致")
+	}
+
+	// 其他密钥的签名不能通过证书请求的签名校验
+	otherKey := newTestKey(t)
+	otherR, otherS := signDigest(t, otherKey, digest[:], false)
+	otherSignature, err := asn1.Marshal(struct{ R, S *big.Int }{otherR, otherS})
+	if err != nil {
+		t.Fatalf("编码签名失败: %v", err)
+	}
+	forgedCSR, err := asn1.Marshal(struct {
+		RequestInfo        asn1.RawValue
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          asn1.BitString
+	}{
+		RequestInfo:        asn1.RawValue{FullBytes: requestInfo},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSignatureECDSAWithSHA256},
+		Signature:          asn1.BitString{Bytes: otherSignature, BitLength: len(otherSignature) * 8},
+	})
+	if err != nil {
+		t.Fatalf("组装证书请求失败: %v", err)
+	}
+	if _, err := parseCertificateRequest(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: forgedCSR})); err == nil {
+		t.Errorf("期望其他密钥签名的证书请求校验失败")
+	}
+}
@@ -0,0 +1,205 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"grets_server/pkg/utils"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+)
+
+// 异步提交交易的状态记录bucket，与区块数据无关，区块存储升级时不清空
+const _TxStatusBucket = "txStatus"
+
+// 链上交易提交状态
+const (
+	TxStatusPending   = "PENDING"   // 已提交排序，等待上链
+	TxStatusCommitted = "COMMITTED" // 已上链且校验通过
+	TxStatusInvalid   = "INVALID"   // 已上链但校验未通过，写集未生效
+	TxStatusUnknown   = "UNKNOWN"   // 等待上链状态超时或失败，区块同步到该交易后可确定状态
+)
+
+// SubmittedTransaction 已提交排序的交易
+type SubmittedTransaction struct {
+	TxID   string // 交易ID
+	Result []byte // 链码返回结果
+}
+
+// ChainTxStatus 链上交易的提交状态
+type ChainTxStatus struct {
+	TxID           string     `json:"txID"`           // 交易ID
+	ChannelName    string     `json:"channelName"`    // 通道名
+	ChaincodeName  string     `json:"chaincodeName"`  // 链码名称
+	FunctionName   string     `json:"functionName"`   // 链码函数名称
+	Status         string     `json:"status"`         // 提交状态
+	ValidationCode string     `json:"validationCode"` // 交易校验结果，VALID表示已生效
+	BlockNumber    uint64     `json:"blockNumber"`    // 所在区块号
+	ErrorMessage   string     `json:"errorMessage"`   // 等待上链状态失败的原因
+	ResubmitTxID   string     `json:"resubmitTxID"`   // 读写集冲突后重新提交的交易ID，查询状态时以重新提交的交易为准
+	SubmitTime     *time.Time `json:"submitTime"`     // 提交时间，非本服务提交的交易为空
+	CommitTime     *time.Time `json:"commitTime"`     // 确认上链状态的时间
+}
+
+// SubmitAsync 提交交易，背书并发送给排序节点后立即返回交易ID，上链状态在后台等待并记录
+// 上链校验出现读写集冲突时与同步提交一样重新背书提交，重新提交的交易ID记录在原交易的状态中
+// onCommit在确认最终的上链状态后调用（状态为COMMITTED、INVALID或UNKNOWN），可为nil
+func SubmitAsync(contract *Contract, functionName string, onCommit func(status *ChainTxStatus), args ...string) (*SubmittedTransaction, error) {
+	result, commit, err := contract.submitAsync(functionName, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	status := listener.pendingTxStatus(contract, functionName, commit)
+	resubmit := func() (*client.Commit, error) {
+		_, commit, err := contract.submitAsync(functionName, args...)
+		return commit, err
+	}
+	go listener.waitForCommit(commit, status, resubmit, onCommit)
+	return &SubmittedTransaction{TxID: status.TxID, Result: result}, nil
+}
+
+// pendingTxStatus 记录已提交排序、等待上链的交易
+func (l *blockListener) pendingTxStatus(contract *Contract, functionName string, commit *client.Commit) *ChainTxStatus {
+	now := time.Now()
+	status := &ChainTxStatus{
+		TxID:          commit.TransactionID(),
+		ChannelName:   contract.ChannelName(),
+		ChaincodeName: contract.ChaincodeName(),
+		FunctionName:  functionName,
+		Status:        TxStatusPending,
+		SubmitTime:    &now,
+	}
+	if err := l.saveTxStatus(status); err != nil {
+		utils.Log.Error(fmt.Sprintf("保存交易[%s]提交状态失败: %v", status.TxID, err))
+	}
+	return status
+}
+
+// waitForCommit 等待交易上链并记录校验结果，读写集冲突时通过resubmit重新提交，最多提交_ContractMaxAttempts次
+func (l *blockListener) waitForCommit(commit *client.Commit, status *ChainTxStatus, resubmit func() (*client.Commit, error), onCommit func(status *ChainTxStatus)) {
+	backoff := _ContractRetryBackoff
+	for attempt := 1; ; attempt++ {
+		commitStatus, err := commit.Status()
+		now := time.Now()
+		status.CommitTime = &now
+		if err != nil {
+			status.Status = TxStatusUnknown
+			status.ErrorMessage = err.Error()
+			utils.Log.Warn(fmt.Sprintf("等待交易[%s]上链状态失败: %v", status.TxID, err))
+			break
+		}
+		status.ValidationCode = commitStatus.Code.String()
+		status.BlockNumber = commitStatus.BlockNumber
+		status.Status = TxStatusCommitted
+		if commitStatus.Successful {
+			break
+		}
+		status.Status = TxStatusInvalid
+		if !isMVCCConflict(commitStatus.Code) || attempt >= _ContractMaxAttempts {
+			utils.Log.Error(fmt.Sprintf("交易[%s]调用[%s]上链后校验失败: %s", status.TxID, status.FunctionName, status.ValidationCode))
+			break
+		}
+
+		wait := retryWait(backoff)
+		utils.Log.Warn(fmt.Sprintf("交易[%s]调用[%s]上链后读写集冲突(%s)，%v后第%d次重新提交",
+			status.TxID, status.FunctionName, status.ValidationCode, wait, attempt))
+		time.Sleep(wait)
+		backoff = min(backoff*2, _ContractRetryMaxWait)
+
+		// 重新背书时链码可能因状态已变化拒绝交易，此时以原交易的校验结果为准
+		nextCommit, err := resubmit()
+		if err != nil {
+			status.ErrorMessage = err.Error()
+			utils.Log.Error(fmt.Sprintf("交易[%s]调用[%s]读写集冲突后重新提交失败: %v", status.TxID, status.FunctionName, err))
+			break
+		}
+		status.ResubmitTxID = nextCommit.TransactionID()
+		if err := l.saveTxStatus(status); err != nil {
+			utils.Log.Error(fmt.Sprintf("保存交易[%s]提交状态失败: %v", status.TxID, err))
+		}
+		nextStatus := *status
+		nextStatus.TxID = nextCommit.TransactionID()
+		nextStatus.Status = TxStatusPending
+		nextStatus.ValidationCode = ""
+		nextStatus.BlockNumber = 0
+		nextStatus.ResubmitTxID = ""
+		nextStatus.CommitTime = nil
+		if err := l.saveTxStatus(&nextStatus); err != nil {
+			utils.Log.Error(fmt.Sprintf("保存交易[%s]提交状态失败: %v", nextStatus.TxID, err))
+		}
+		commit, status = nextCommit, &nextStatus
+	}
+
+	if err := l.saveTxStatus(status); err != nil {
+		utils.Log.Error(fmt.Sprintf("保存交易[%s]提交状态失败: %v", status.TxID, err))
+	}
+	if onCommit != nil {
+		onCommit(status)
+	}
+}
+
+// saveTxStatus 保存交易提交状态
+func (l *blockListener) saveTxStatus(status *ChainTxStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return l.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(_TxStatusBucket)).Put([]byte(status.TxID), data)
+	})
+}
+
+// GetTxStatus 查询交易提交状态，尚未确认或非本服务提交的交易从已同步的区块中确定状态
+// 读写集冲突后重新提交的交易返回重新提交后的交易状态
+func (l *blockListener) GetTxStatus(txID string) (*ChainTxStatus, error) {
+	status, err := l.getTxStatus(txID)
+	for attempt := 1; err == nil && status.ResubmitTxID != "" && attempt < _ContractMaxAttempts; attempt++ {
+		status, err = l.getTxStatus(status.ResubmitTxID)
+	}
+	return status, err
+}
+
+// getTxStatus 查询单个交易ID的提交状态
+func (l *blockListener) getTxStatus(txID string) (*ChainTxStatus, error) {
+	var status *ChainTxStatus
+	err := l.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket([]byte(_TxStatusBucket)).Get([]byte(txID))
+		if data == nil {
+			return nil
+		}
+		status = &ChainTxStatus{}
+		return json.Unmarshal(data, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if status != nil && status.Status != TxStatusPending && status.Status != TxStatusUnknown {
+		return status, nil
+	}
+
+	blockData, txIndex, err := l.GetTransactionBlock(txID)
+	if err != nil {
+		if status != nil {
+			return status, nil
+		}
+		return nil, err
+	}
+	if status == nil {
+		status = &ChainTxStatus{TxID: txID}
+	}
+	status.ChannelName = blockData.ChannelName
+	status.BlockNumber = blockData.BlockNumber
+	status.ValidationCode = blockData.TxValidationCode(txIndex)
+	status.Status = TxStatusCommitted
+	if status.ValidationCode != "" && status.ValidationCode != peer.TxValidationCode_VALID.String() {
+		status.Status = TxStatusInvalid
+	}
+	if detail, err := l.GetTransactionByID(txID); err == nil {
+		status.ChaincodeName = detail.ChaincodeName
+		status.FunctionName = detail.ChainCodeFunctionName
+	}
+	return status, nil
+}
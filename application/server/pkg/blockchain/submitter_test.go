@@ -0,0 +1,45 @@
+package blockchain
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+)
+
+func TestGetTxStatusFollowsResubmit(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "status.db"), 0o600, nil)
+	if err != nil {
+		t.Fatalf("打开状态数据库失败: %v", err)
+	}
+	defer db.Close()
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(_TxStatusBucket))
+		return err
+	}); err != nil {
+		t.Fatalf("创建状态bucket失败: %v", err)
+	}
+	l := &blockListener{db: db}
+
+	// 两次读写集冲突后第三次提交成功
+	statusList := []*ChainTxStatus{
+		{TxID: "tx-1", FunctionName: "PayForTransaction", Status: TxStatusInvalid, ValidationCode: "MVCC_READ_CONFLICT", ResubmitTxID: "tx-2"},
+		{TxID: "tx-2", FunctionName: "PayForTransaction", Status: TxStatusInvalid, ValidationCode: "PHANTOM_READ_CONFLICT", ResubmitTxID: "tx-3"},
+		{TxID: "tx-3", FunctionName: "PayForTransaction", Status: TxStatusCommitted, ValidationCode: "VALID", BlockNumber: 9},
+	}
+	for _, status := range statusList {
+		if err := l.saveTxStatus(status); err != nil {
+			t.Fatalf("保存交易状态失败: %v", err)
+		}
+	}
+
+	for _, txID := range []string{"tx-1", "tx-2", "tx-3"} {
+		status, err := l.GetTxStatus(txID)
+		if err != nil {
+			t.Fatalf("查询交易[%s]状态失败: %v", txID, err)
+		}
+		if status.TxID != "tx-3" || status.Status != TxStatusCommitted || status.BlockNumber != 9 {
+			t.Errorf("交易[%s]状态 = %+v, 期望重新提交后的tx-3已上链", txID, status)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// userIdentity 用户的X.509身份和签名函数
+type userIdentity struct {
+	label string
+	id    *identity.X509Identity
+	sign  identity.Sign // 私钥由用户持有（离线签名身份）时为nil
+}
+
+// walletEntry 钱包中保存的身份，格式与Fabric SDK的文件钱包一致
+type walletEntry struct {
+	Credentials struct {
+		Certificate string `json:"certificate"`
+		PrivateKey  string `json:"privateKey"`
+	} `json:"credentials"`
+	MspID   string `json:"mspId"`
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+}
+
+// wallet 组织的服务端钱包，每个身份保存为目录下的一个文件，文件只有服务进程可读
+type wallet struct {
+	sync.Mutex
+	dir          string
+	identityList map[string]*userIdentity // 已加载的身份，键为标签
+}
+
+func newWallet(dir string) (*wallet, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建钱包目录失败: %v", err)
+	}
+	return &wallet{dir: dir, identityList: make(map[string]*userIdentity)}, nil
+}
+
+// get 获取身份，钱包中没有该身份时返回nil
+func (w *wallet) get(label string) (*userIdentity, error) {
+	w.Lock()
+	defer w.Unlock()
+
+	if user, ok := w.identityList[label]; ok {
+		return user, nil
+	}
+	data, err := os.ReadFile(w.path(label))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取钱包身份[%s]失败: %v", label, err)
+	}
+	var entry walletEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("解析钱包身份[%s]失败: %v", label, err)
+	}
+	user, err := newUserIdentity(label, entry.MspID, []byte(entry.Credentials.Certificate), []byte(entry.Credentials.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	w.identityList[label] = user
+	return user, nil
+}
+
+// put 保存身份，已有同名身份时覆盖，离线签名身份的私钥为空
+func (w *wallet) put(label string, mspID string, certificatePEM []byte, privateKeyPEM []byte) (*userIdentity, error) {
+	user, err := newUserIdentity(label, mspID, certificatePEM, privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := walletEntry{MspID: mspID, Type: "X.509", Version: 1}
+	entry.Credentials.Certificate = string(certificatePEM)
+	entry.Credentials.PrivateKey = string(privateKeyPEM)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("序列化钱包身份[%s]失败: %v", label, err)
+	}
+
+	w.Lock()
+	defer w.Unlock()
+	// 先写临时文件再改名，避免写入中断留下不完整的身份
+	tmpPath := w.path(label) + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return nil, fmt.Errorf("保存钱包身份[%s]失败: %v", label, err)
+	}
+	if err := os.Rename(tmpPath, w.path(label)); err != nil {
+		return nil, fmt.Errorf("保存钱包身份[%s]失败: %v", label, err)
+	}
+	w.identityList[label] = user
+	return user, nil
+}
+
+func (w *wallet) path(label string) string {
+	return filepath.Join(w.dir, label+".id")
+}
+
+// newUserIdentity 由PEM格式的证书和私钥创建身份和签名函数，私钥为空时只创建身份
+func newUserIdentity(label string, mspID string, certificatePEM []byte, privateKeyPEM []byte) (*userIdentity, error) {
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		return nil, fmt.Errorf("解析身份[%s]证书失败: %v", label, err)
+	}
+	id, err := identity.NewX509Identity(mspID, certificate)
+	if err != nil {
+		return nil, fmt.Errorf("创建身份[%s]失败: %v", label, err)
+	}
+	if len(privateKeyPEM) == 0 {
+		return &userIdentity{label: label, id: id}, nil
+	}
+	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("解析身份[%s]私钥失败: %v", label, err)
+	}
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("创建身份[%s]签名函数失败: %v", label, err)
+	}
+	return &userIdentity{label: label, id: id, sign: sign}, nil
+}
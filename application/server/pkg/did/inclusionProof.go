@@ -0,0 +1,171 @@
+package did
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"google.golang.org/protobuf/proto"
+)
+
+// 交易包含证明格式版本
+const InclusionProofVersion = "1"
+
+// Fabric区块哈希的计算方式，随证明一起给出，便于不使用本程序的第三方自行复算
+const (
+	DataHashDerivation  = "DataHash = SHA256(envelopeList[0] || envelopeList[1] || ... || envelopeList[n-1])"
+	BlockHashDerivation = "BlockHash = SHA256(ASN.1 DER SEQUENCE { INTEGER blockNumber, OCTET STRING previousHash, OCTET STRING dataHash })"
+)
+
+// TransactionInclusionProof 交易包含证明，证明交易信封包含在指定区块中
+// Fabric的数据哈希不是默克尔树，而是区块内全部交易信封顺序拼接后的SHA256，因此证明中包含区块的全部交易信封
+// 区块哈希需要与独立来源（如通过peer channel fetch获取的区块、后一区块的PreviousHash）比对后才能作为可信锚点
+type TransactionInclusionProof struct {
+	Version             string   `json:"version"`             // 证明格式版本
+	TxID                string   `json:"txID"`                // 交易ID
+	ChannelName         string   `json:"channelName"`         // 通道名
+	BlockNumber         uint64   `json:"blockNumber"`         // 区块号
+	PreviousHash        string   `json:"previousHash"`        // 区块头中的前一区块哈希（十六进制）
+	DataHash            string   `json:"dataHash"`            // 区块头中的数据哈希（十六进制）
+	BlockHash           string   `json:"blockHash"`           // 区块哈希（十六进制）
+	TxIndex             int      `json:"txIndex"`             // 交易信封在区块中的序号
+	ValidationCode      string   `json:"validationCode"`      // 交易校验结果，来自区块元数据，不在区块哈希覆盖范围内，仅供参考
+	EnvelopeList        []string `json:"envelopeList"`        // 区块中全部交易信封的原始字节（Base64）
+	DataHashDerivation  string   `json:"dataHashDerivation"`  // 数据哈希计算方式
+	BlockHashDerivation string   `json:"blockHashDerivation"` // 区块哈希计算方式
+}
+
+// InclusionProofResult 交易包含证明的校验结果
+type InclusionProofResult struct {
+	Valid          bool   `json:"valid"`          // 证明是否成立
+	TxID           string `json:"txID"`           // 交易ID
+	ChannelName    string `json:"channelName"`    // 通道名
+	BlockNumber    uint64 `json:"blockNumber"`    // 区块号
+	BlockHash      string `json:"blockHash"`      // 复算得到的区块哈希，需与可信来源比对
+	ValidationCode string `json:"validationCode"` // 证明中声明的交易校验结果
+	Reason         string `json:"reason"`         // 证明不成立的原因
+}
+
+// asn1BlockHeader 区块头的ASN.1编码结构
+type asn1BlockHeader struct {
+	Number       *big.Int
+	PreviousHash []byte
+	DataHash     []byte
+}
+
+// VerifyInclusionProof 离线校验交易包含证明：复算数据哈希和区块哈希，并检查指定位置的信封是否为该通道的该笔交易
+func VerifyInclusionProof(proof *TransactionInclusionProof) (*InclusionProofResult, error) {
+	result := &InclusionProofResult{
+		TxID:           proof.TxID,
+		ChannelName:    proof.ChannelName,
+		BlockNumber:    proof.BlockNumber,
+		ValidationCode: proof.ValidationCode,
+	}
+	if proof.Version != InclusionProofVersion {
+		return nil, fmt.Errorf("不支持的证明版本: %s", proof.Version)
+	}
+
+	envelopeList := make([][]byte, 0, len(proof.EnvelopeList))
+	for i, encoded := range proof.EnvelopeList {
+		envelope, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("解析第%d个交易信封失败: %v", i, err)
+		}
+		envelopeList = append(envelopeList, envelope)
+	}
+	previousHash, err := hex.DecodeString(proof.PreviousHash)
+	if err != nil {
+		return nil, fmt.Errorf("解析前一区块哈希失败: %v", err)
+	}
+
+	// 复算数据哈希
+	dataHash := sha256.Sum256(bytes.Join(envelopeList, nil))
+	if hex.EncodeToString(dataHash[:]) != proof.DataHash {
+		result.Reason = fmt.Sprintf("数据哈希不一致: 复算值%x，证明中为%s", dataHash, proof.DataHash)
+		return result, nil
+	}
+
+	// 复算区块哈希
+	headerBytes, err := asn1.Marshal(asn1BlockHeader{
+		Number:       new(big.Int).SetUint64(proof.BlockNumber),
+		PreviousHash: previousHash,
+		DataHash:     dataHash[:],
+	})
+	if err != nil {
+		return nil, fmt.Errorf("区块头序列化失败: %v", err)
+	}
+	blockHash := sha256.Sum256(headerBytes)
+	result.BlockHash = hex.EncodeToString(blockHash[:])
+	if result.BlockHash != proof.BlockHash {
+		result.Reason = fmt.Sprintf("区块哈希不一致: 复算值%s，证明中为%s", result.BlockHash, proof.BlockHash)
+		return result, nil
+	}
+
+	// 检查交易信封
+	if proof.TxIndex < 0 || proof.TxIndex >= len(envelopeList) {
+		result.Reason = fmt.Sprintf("交易序号%d超出区块交易数%d", proof.TxIndex, len(envelopeList))
+		return result, nil
+	}
+	channelHeader, err := envelopeChannelHeader(envelopeList[proof.TxIndex])
+	if err != nil {
+		result.Reason = err.Error()
+		return result, nil
+	}
+	if channelHeader.TxId != proof.TxID {
+		result.Reason = fmt.Sprintf("第%d个交易信封的交易ID为%s", proof.TxIndex, channelHeader.TxId)
+		return result, nil
+	}
+	if channelHeader.ChannelId != proof.ChannelName {
+		result.Reason = fmt.Sprintf("交易信封的通道为%s", channelHeader.ChannelId)
+		return result, nil
+	}
+
+	result.Valid = true
+	return result, nil
+}
+
+// VerifyInclusionProofFile 离线校验保存为JSON文件的交易包含证明
+func VerifyInclusionProofFile(path string) (*InclusionProofResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取证明文件失败: %v", err)
+	}
+
+	// 兼容直接保存的接口响应
+	var response struct {
+		Data *TransactionInclusionProof `json:"data"`
+	}
+	if err := json.Unmarshal(data, &response); err == nil && response.Data != nil && response.Data.Version != "" {
+		return VerifyInclusionProof(response.Data)
+	}
+
+	var proof TransactionInclusionProof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		return nil, fmt.Errorf("解析证明文件失败: %v", err)
+	}
+	return VerifyInclusionProof(&proof)
+}
+
+// envelopeChannelHeader 解析交易信封的通道头
+func envelopeChannelHeader(envelopeBytes []byte) (*common.ChannelHeader, error) {
+	envelope := &common.Envelope{}
+	if err := proto.Unmarshal(envelopeBytes, envelope); err != nil {
+		return nil, fmt.Errorf("解析交易信封失败: %v", err)
+	}
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return nil, fmt.Errorf("解析交易payload失败: %v", err)
+	}
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader); err != nil {
+		return nil, fmt.Errorf("解析交易通道头失败: %v", err)
+	}
+	return channelHeader, nil
+}
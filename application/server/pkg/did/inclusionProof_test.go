@@ -0,0 +1,181 @@
+package did
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"google.golang.org/protobuf/proto"
+)
+
+// newTestEnvelope 构造只包含通道头的交易信封
+func newTestEnvelope(t *testing.T, channelName string, txID string) []byte {
+	t.Helper()
+	channelHeader, err := proto.Marshal(&common.ChannelHeader{
+		Type:      int32(common.HeaderType_ENDORSER_TRANSACTION),
+		ChannelId: channelName,
+		TxId:      txID,
+	})
+	if err != nil {
+		t.Fatalf("序列化通道头失败: %v", err)
+	}
+	payload, err := proto.Marshal(&common.Payload{Header: &common.Header{ChannelHeader: channelHeader}})
+	if err != nil {
+		t.Fatalf("序列化交易内容失败: %v", err)
+	}
+	envelope, err := proto.Marshal(&common.Envelope{Payload: payload, Signature: []byte("signature")})
+	if err != nil {
+		t.Fatalf("序列化交易信封失败: %v", err)
+	}
+	return envelope
+}
+
+// newTestProof 构造区块中第2笔交易的包含证明
+func newTestProof(t *testing.T) *TransactionInclusionProof {
+	t.Helper()
+	envelopeList := [][]byte{
+		newTestEnvelope(t, "shanghaichannel", "tx-0"),
+		newTestEnvelope(t, "shanghaichannel", "tx-1"),
+		newTestEnvelope(t, "shanghaichannel", "tx-2"),
+	}
+	previousHash := sha256.Sum256([]byte("previous block"))
+	dataHash := sha256.Sum256(bytes.Join(envelopeList, nil))
+	headerBytes, err := asn1.Marshal(asn1BlockHeader{
+		Number:       big.NewInt(12),
+		PreviousHash: previousHash[:],
+		DataHash:     dataHash[:],
+	})
+	if err != nil {
+		t.Fatalf("序列化区块头失败: %v", err)
+	}
+	blockHash := sha256.Sum256(headerBytes)
+
+	proof := &TransactionInclusionProof{
+		Version:             InclusionProofVersion,
+		TxID:                "tx-1",
+		ChannelName:         "shanghaichannel",
+		BlockNumber:         12,
+		PreviousHash:        hex.EncodeToString(previousHash[:]),
+		DataHash:            hex.EncodeToString(dataHash[:]),
+		BlockHash:           hex.EncodeToString(blockHash[:]),
+		TxIndex:             1,
+		ValidationCode:      "VALID",
+		DataHashDerivation:  DataHashDerivation,
+		BlockHashDerivation: BlockHashDerivation,
+	}
+	for _, envelope := range envelopeList {
+		proof.EnvelopeList = append(proof.EnvelopeList, base64.StdEncoding.EncodeToString(envelope))
+	}
+	return proof
+}
+
+func TestVerifyInclusionProof(t *testing.T) {
+	testCaseList := []struct {
+		name   string
+		modify func(proof *TransactionInclusionProof)
+		reason string
+	}{
+		{"证明成立", func(proof *TransactionInclusionProof) {}, ""},
+		{"交易信封被篡改", func(proof *TransactionInclusionProof) {
+			proof.EnvelopeList[2] = base64.StdEncoding.EncodeToString(newTestEnvelope(t, "shanghaichannel", "tx-forged"))
+		}, "数据哈希不一致"},
+		{"缺少交易信封", func(proof *TransactionInclusionProof) {
+			proof.EnvelopeList = proof.EnvelopeList[:2]
+		}, "数据哈希不一致"},
+		{"区块号不一致", func(proof *TransactionInclusionProof) {
+			proof.BlockNumber = 13
+		}, "区块哈希不一致"},
+		{"前一区块哈希不一致", func(proof *TransactionInclusionProof) {
+			otherHash := sha256.Sum256([]byte("other block"))
+			proof.PreviousHash = hex.EncodeToString(otherHash[:])
+		}, "区块哈希不一致"},
+		{"交易序号超出范围", func(proof *TransactionInclusionProof) {
+			proof.TxIndex = 3
+		}, "超出区块交易数"},
+		{"交易序号指向其他交易", func(proof *TransactionInclusionProof) {
+			proof.TxIndex = 0
+		}, "交易ID为tx-0"},
+		{"通道不一致", func(proof *TransactionInclusionProof) {
+			proof.ChannelName = "beijingchannel"
+		}, "通道为shanghaichannel"},
+	}
+
+	for _, testCase := range testCaseList {
+		t.Run(testCase.name, func(t *testing.T) {
+			proof := newTestProof(t)
+			testCase.modify(proof)
+
+			result, err := VerifyInclusionProof(proof)
+			if err != nil {
+				t.Fatalf("校验证明失败: %v", err)
+			}
+			if result.Valid != (testCase.reason == "") {
+				t.Fatalf("证明成立 = %t, 原因: %s", result.Valid, result.Reason)
+			}
+			if !strings.Contains(result.Reason, testCase.reason) {
+				t.Errorf("原因 = %q, 期望包含 %q", result.Reason, testCase.reason)
+			}
+			if result.TxID != proof.TxID || result.BlockNumber != proof.BlockNumber {
+				t.Errorf("校验结果中的交易信息与证明不一致")
+			}
+		})
+	}
+}
+
+func TestVerifyInclusionProofInvalidInput(t *testing.T) {
+	proof := newTestProof(t)
+	proof.Version = "0"
+	if _, err := VerifyInclusionProof(proof); err == nil {
+		t.Errorf("期望不支持的证明版本返回错误")
+	}
+
+	proof = newTestProof(t)
+	proof.EnvelopeList[0] = "not base64!"
+	if _, err := VerifyInclusionProof(proof); err == nil {
+		t.Errorf("期望无法解码的交易信封返回错误")
+	}
+
+	proof = newTestProof(t)
+	proof.PreviousHash = "not hex"
+	if _, err := VerifyInclusionProof(proof); err == nil {
+		t.Errorf("期望无法解码的前一区块哈希返回错误")
+	}
+}
+
+func TestVerifyInclusionProofFile(t *testing.T) {
+	proof := newTestProof(t)
+	dir := t.TempDir()
+
+	// 直接保存的证明和保存的接口响应都可以校验
+	proofJSON, err := json.Marshal(proof)
+	if err != nil {
+		t.Fatalf("序列化证明失败: %v", err)
+	}
+	responseJSON, err := json.Marshal(map[string]interface{}{"code": 200, "message": "ok", "data": proof})
+	if err != nil {
+		t.Fatalf("序列化接口响应失败: %v", err)
+	}
+
+	for name, content := range map[string][]byte{"proof.json": proofJSON, "response.json": responseJSON} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			t.Fatalf("写入证明文件失败: %v", err)
+		}
+		result, err := VerifyInclusionProofFile(path)
+		if err != nil {
+			t.Fatalf("校验证明文件[%s]失败: %v", name, err)
+		}
+		if !result.Valid {
+			t.Errorf("证明文件[%s]校验不成立: %s", name, result.Reason)
+		}
+	}
+}
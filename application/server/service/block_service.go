@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"grets_server/config"
@@ -8,7 +9,16 @@ import (
 	"grets_server/dao"
 	blockDto "grets_server/dto/block_dto"
 	"grets_server/pkg/blockchain"
-	"sort"
+	"grets_server/pkg/did"
+	"grets_server/pkg/utils"
+	"slices"
+	"time"
+)
+
+// 查询交易提交状态时的最长等待时间和轮询间隔
+const (
+	maxTxStatusWaitSeconds = 60
+	txStatusPollInterval   = 500 * time.Millisecond
 )
 
 var GlobalBlockService BlockService
@@ -29,134 +39,214 @@ type BlockService interface {
 	QueryBlockList(queryBlockDTO blockDto.QueryBlockDTO) (*blockchain.BlockQueryResult, error)
 	// QueryBlockTransactionList 查询区块交易列表
 	QueryBlockTransactionList(queryBlockTransactionDTO blockDto.QueryBlockTransactionDTO) ([]*blockchain.BlockTransactionDetail, error)
+	// QueryChainTransactionList 按创建者、链码函数、时间等条件查询链上交易
+	QueryChainTransactionList(queryChainTransactionDTO blockDto.QueryChainTransactionDTO) (*blockchain.TransactionQueryResult, error)
+	// GetTransactionByID 根据交易ID查询链上交易
+	GetTransactionByID(txID string) (*blockchain.BlockTransactionDetail, error)
+	// QueryBlockInvocationList 解析区块中各交易的链码调用、背书组织和读写集
+	QueryBlockInvocationList(queryBlockTransactionDTO blockDto.QueryBlockTransactionDTO) ([]*blockchain.TransactionInvocationDetail, error)
+	// GetTransactionInvocation 根据交易ID解析链码调用、背书组织和读写集
+	GetTransactionInvocation(txID string) ([]*blockchain.TransactionInvocationDetail, error)
+	// GetChainTxStatus 查询链上交易提交状态，waitSeconds大于0时等待交易确认
+	GetChainTxStatus(txID string, waitSeconds int) (*blockchain.ChainTxStatus, error)
+	// SubscribeBlockFeed 订阅新区块和新交易推送
+	SubscribeBlockFeed(streamBlockDTO blockDto.StreamBlockDTO) (*blockchain.FeedSubscription, error)
+	// UnsubscribeBlockFeed 取消订阅新区块和新交易推送
+	UnsubscribeBlockFeed(subscription *blockchain.FeedSubscription)
+	// GetTransactionInclusionProof 根据交易ID生成可离线校验的交易包含证明
+	GetTransactionInclusionProof(txID string) (*did.TransactionInclusionProof, error)
 }
 
 func (b *blockService) QueryBlockList(queryBlockDTO blockDto.QueryBlockDTO) (*blockchain.BlockQueryResult, error) {
-	// 获取所有子通道的数据，并且根据时间戳进行分页排序
-	blocks := make([]*blockchain.BlockData, 0)
-	for _, channelName := range config.GlobalConfig.Fabric.SubChannelName {
-		block, err := blockchain.GetBlockListener().GetAllBlocksByChannelAndOrg(
-			channelName,
-			queryBlockDTO.Organization,
-		)
+	// 省份与子通道一一对应，按省份查询时只查询该省份的子通道
+	channelList := config.GlobalConfig.Fabric.SubChannelName
+	if queryBlockDTO.ProvinceName != "" {
+		channelName, err := getChannelNameByProvince(queryBlockDTO.ProvinceName)
 		if err != nil {
 			return nil, err
 		}
-		blocks = append(blocks, block...)
+		channelList = []string{channelName}
 	}
 
-	// 根据时间戳进行排序
-	sort.Slice(blocks, func(i, j int) bool {
-		return blocks[i].SaveTime.After(blocks[j].SaveTime)
-	})
-
 	if queryBlockDTO.BlockHash != "" {
-		for _, block := range blocks {
-			if block.BlockHash == queryBlockDTO.BlockHash {
-				blocks = []*blockchain.BlockData{block}
-				break
-			}
-		}
-		if len(blocks) != 1 {
+		block, err := blockchain.GetBlockListener().GetBlockByHash(queryBlockDTO.BlockHash)
+		if err != nil || !slices.Contains(channelList, block.ChannelName) {
 			return nil, nil
 		}
+		return &blockchain.BlockQueryResult{
+			Blocks:   []*blockchain.BlockData{block},
+			Total:    1,
+			PageSize: queryBlockDTO.PageSize,
+			PageNum:  queryBlockDTO.PageNumber,
+		}, nil
 	}
 
-	if queryBlockDTO.ProvinceName != "" {
-		filteredBlocks, err := filterBlocksByProvince(blocks, queryBlockDTO.ProvinceName)
-		if err != nil {
-			return nil, err
-		}
-		blocks = filteredBlocks
-	}
-
-	if queryBlockDTO.Creator != "" {
-		filteredBlocks, err := filterBlocksByCreator(blocks, queryBlockDTO.Creator)
-		if err != nil {
-			return nil, err
-		}
-		blocks = filteredBlocks
-	}
-
-	total := len(blocks)
-
-	// 分页
-	startIndex := (queryBlockDTO.PageNumber - 1) * queryBlockDTO.PageSize
-	endIndex := startIndex + queryBlockDTO.PageSize
-	if endIndex > len(blocks) {
-		endIndex = len(blocks)
+	filter, err := newBlockIndexFilter(channelList, queryBlockDTO.Organization, queryBlockDTO.StartDate, queryBlockDTO.EndDate)
+	if err != nil {
+		return nil, err
 	}
-	blocks = blocks[startIndex:endIndex]
+	filter.Creator = queryBlockDTO.Creator
 
-	// 返回分页结果
-	return &blockchain.BlockQueryResult{
-		Blocks:   blocks,
-		Total:    total,
-		PageSize: queryBlockDTO.PageSize,
-		PageNum:  queryBlockDTO.PageNumber,
-		HasMore:  endIndex < total,
-	}, nil
+	// 按区块时间倒序分页读取索引，只加载当前页的区块
+	return blockchain.GetBlockListener().QueryBlockPage(filter, queryBlockDTO.PageNumber, queryBlockDTO.PageSize)
 }
 
-func filterBlocksByProvince(blocks []*blockchain.BlockData, provinceName string) ([]*blockchain.BlockData, error) {
+// getChannelNameByProvince 根据省份名称查询对应的子通道
+func getChannelNameByProvince(provinceName string) (string, error) {
 	// 将省市名转化为省市代码
 	region, err := dao.NewRegionDAO().GetRegionByProvince(provinceName)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	// 获取该省市对应的子通道
 	mainContract, err := blockchain.GetMainContract(constants.GovernmentOrganization)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	channelInfoBytes, err := mainContract.EvaluateTransaction(
 		"GetChannelInfoByRegionCode",
 		region.ProvinceCode,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("%s未开通GRETS服务", provinceName)
+		return "", fmt.Errorf("%s未开通GRETS服务", provinceName)
 	}
 	var channelInfo blockDto.ChannelInfo
 	err = json.Unmarshal(channelInfoBytes, &channelInfo)
 	if err != nil {
-		return nil, err
-	}
-	filteredBlocks := make([]*blockchain.BlockData, 0)
-	for _, block := range blocks {
-		if block.ChannelName == channelInfo.ChannelName {
-			filteredBlocks = append(filteredBlocks, block)
-		}
+		return "", err
 	}
-	return filteredBlocks, nil
+	return channelInfo.ChannelName, nil
 }
 
-func filterBlocksByCreator(blocks []*blockchain.BlockData, creator string) ([]*blockchain.BlockData, error) {
-	filteredBlocks := make([]*blockchain.BlockData, 0)
-	for _, block := range blocks {
-		// 如果是最后一块区块，则跳过
-		if block.BlockNumber == blocks[len(blocks)-1].BlockNumber {
-			continue
+// newBlockIndexFilter 构建索引查询条件，日期格式为2006-01-02，结束日期包含当天
+func newBlockIndexFilter(channelList []string, orgName string, startDate string, endDate string) (*blockchain.BlockIndexFilter, error) {
+	filter := &blockchain.BlockIndexFilter{
+		ChannelList: channelList,
+		OrgName:     orgName,
+	}
+	if startDate != "" {
+		startTime, err := time.ParseInLocation("2006-01-02", startDate, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("解析开始日期失败: %v", err)
+		}
+		filter.StartTime = startTime
+	}
+	if endDate != "" {
+		endTime, err := time.ParseInLocation("2006-01-02", endDate, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("解析结束日期失败: %v", err)
 		}
+		filter.EndTime = endTime.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return filter, nil
+}
 
-		// 从block中获取所有的交易
-		envelopeList, err := blockchain.GetBlockListener().GetEnvelopeListFromBoltBlockData(block)
+func (b *blockService) QueryChainTransactionList(queryChainTransactionDTO blockDto.QueryChainTransactionDTO) (*blockchain.TransactionQueryResult, error) {
+	channelList := config.GlobalConfig.Fabric.SubChannelName
+	if queryChainTransactionDTO.ChannelName != "" {
+		channelList = []string{queryChainTransactionDTO.ChannelName}
+	} else if queryChainTransactionDTO.ProvinceName != "" {
+		channelName, err := getChannelNameByProvince(queryChainTransactionDTO.ProvinceName)
 		if err != nil {
 			return nil, err
 		}
+		channelList = []string{channelName}
+	}
 
-		transactionDetailList, err := blockchain.GetBlockListener().GetTransactionDetailListFromEnvelopeList(envelopeList)
+	filter, err := newBlockIndexFilter(channelList, queryChainTransactionDTO.Organization, queryChainTransactionDTO.StartDate, queryChainTransactionDTO.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	filter.Creator = queryChainTransactionDTO.Creator
+	filter.ChaincodeName = queryChainTransactionDTO.ChaincodeName
+	filter.FunctionName = queryChainTransactionDTO.FunctionName
+
+	return blockchain.GetBlockListener().QueryTransactionPage(filter, queryChainTransactionDTO.PageNumber, queryChainTransactionDTO.PageSize)
+}
+
+func (b *blockService) GetTransactionByID(txID string) (*blockchain.BlockTransactionDetail, error) {
+	return blockchain.GetBlockListener().GetTransactionByID(txID)
+}
+
+func (b *blockService) QueryBlockInvocationList(queryBlockTransactionDTO blockDto.QueryBlockTransactionDTO) ([]*blockchain.TransactionInvocationDetail, error) {
+	return blockchain.GetBlockListener().GetTransactionInvocationList(queryBlockTransactionDTO.ChannelName, queryBlockTransactionDTO.BlockNumber)
+}
+
+func (b *blockService) GetTransactionInvocation(txID string) ([]*blockchain.TransactionInvocationDetail, error) {
+	return blockchain.GetBlockListener().GetTransactionInvocation(txID)
+}
+
+func (b *blockService) GetChainTxStatus(txID string, waitSeconds int) (*blockchain.ChainTxStatus, error) {
+	if waitSeconds > maxTxStatusWaitSeconds {
+		waitSeconds = maxTxStatusWaitSeconds
+	}
+	deadline := time.Now().Add(time.Duration(waitSeconds) * time.Second)
+	for {
+		status, err := blockchain.GetBlockListener().GetTxStatus(txID)
+		if err == nil && status.Status != blockchain.TxStatusPending {
+			return status, nil
+		}
+		if time.Now().After(deadline) {
+			return status, err
+		}
+		time.Sleep(txStatusPollInterval)
+	}
+}
+
+func (b *blockService) SubscribeBlockFeed(streamBlockDTO blockDto.StreamBlockDTO) (*blockchain.FeedSubscription, error) {
+	filter := blockchain.FeedFilter{FunctionName: streamBlockDTO.FunctionName}
+	if streamBlockDTO.ChannelName != "" {
+		filter.ChannelList = []string{streamBlockDTO.ChannelName}
+	} else if streamBlockDTO.ProvinceName != "" {
+		channelName, err := getChannelNameByProvince(streamBlockDTO.ProvinceName)
 		if err != nil {
 			return nil, err
 		}
+		filter.ChannelList = []string{channelName}
+	}
+	return blockchain.GetBlockListener().Subscribe(filter), nil
+}
 
-		for _, transactionDetail := range transactionDetailList {
-			if transactionDetail.Creator == creator {
-				filteredBlocks = append(filteredBlocks, block)
-			}
-		}
+func (b *blockService) UnsubscribeBlockFeed(subscription *blockchain.FeedSubscription) {
+	blockchain.GetBlockListener().Unsubscribe(subscription)
+}
+
+func (b *blockService) GetTransactionInclusionProof(txID string) (*did.TransactionInclusionProof, error) {
+	blockData, txIndex, err := blockchain.GetBlockListener().GetTransactionBlock(txID)
+	if err != nil {
+		return nil, err
+	}
+
+	envelopeList := make([]string, 0, len(blockData.Data))
+	for _, envelope := range blockData.Data {
+		envelopeList = append(envelopeList, base64.StdEncoding.EncodeToString(envelope))
+	}
+	proof := &did.TransactionInclusionProof{
+		Version:             did.InclusionProofVersion,
+		TxID:                txID,
+		ChannelName:         blockData.ChannelName,
+		BlockNumber:         blockData.BlockNumber,
+		PreviousHash:        blockData.PrevHash,
+		DataHash:            blockData.DataHash,
+		BlockHash:           blockData.BlockHash,
+		TxIndex:             txIndex,
+		ValidationCode:      blockData.TxValidationCode(txIndex),
+		EnvelopeList:        envelopeList,
+		DataHashDerivation:  did.DataHashDerivation,
+		BlockHashDerivation: did.BlockHashDerivation,
+	}
+
+	// 返回前自行校验一次，本地区块存储损坏时不返回无效的证明
+	result, err := did.VerifyInclusionProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Valid {
+		utils.Log.Error(fmt.Sprintf("交易[%s]包含证明校验失败: %s", txID, result.Reason))
+		return nil, fmt.Errorf("交易[%s]包含证明校验失败: %s", txID, result.Reason)
 	}
-	return filteredBlocks, nil
+	return proof, nil
 }
 
 func (b *blockService) QueryBlockTransactionList(queryBlockTransactionDTO blockDto.QueryBlockTransactionDTO) ([]*blockchain.BlockTransactionDetail, error) {
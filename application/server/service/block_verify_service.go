@@ -0,0 +1,163 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"grets_server/config"
+	"grets_server/constants"
+	"grets_server/pkg/blockchain"
+	"grets_server/pkg/utils"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 全局区块哈希链校验服务实例
+var GlobalBlockVerifyService BlockVerifyService
+
+// InitBlockVerifyService 初始化区块哈希链校验服务
+func InitBlockVerifyService() {
+	GlobalBlockVerifyService = NewBlockVerifyService()
+	utils.Log.Info("区块哈希链校验服务初始化完成")
+}
+
+// BlockVerifyService 区块哈希链校验服务接口
+// 重新计算本地保存的区块哈希并检查哈希链衔接，再与节点上的区块比对，发现问题时记录到审计日志链码
+type BlockVerifyService interface {
+	Run(triggerType string, channelName string) ([]*blockchain.BlockVerifyResult, error)
+	GetLatestResultList() []*blockchain.BlockVerifyResult
+}
+
+// blockVerifyService 区块哈希链校验服务实现
+type blockVerifyService struct {
+	running        sync.Mutex
+	resultLock     sync.RWMutex
+	latestList     map[string]*blockchain.BlockVerifyResult // 通道 -> 最近一次校验结果
+	recordedReason map[string]string                        // 通道 -> 已上链记录的问题，问题未变化时不重复记录
+}
+
+// NewBlockVerifyService 创建区块哈希链校验服务实例
+func NewBlockVerifyService() BlockVerifyService {
+	return &blockVerifyService{
+		latestList:     make(map[string]*blockchain.BlockVerifyResult),
+		recordedReason: make(map[string]string),
+	}
+}
+
+// StartBlockVerifyJob 按配置的间隔定时校验区块哈希链，间隔为0时不启动
+func StartBlockVerifyJob() {
+	interval := config.GlobalConfig.BlockVerify.Interval
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := GlobalBlockVerifyService.Run(constants.BlockVerifyTriggerScheduled, ""); err != nil {
+				utils.Log.Error(fmt.Sprintf("定时校验区块哈希链失败: %v", err))
+			}
+		}
+	}()
+}
+
+// Run 校验指定通道的区块哈希链，通道为空时校验所有本地保存了区块的通道，同一时间只允许一次校验
+func (s *blockVerifyService) Run(triggerType string, channelName string) ([]*blockchain.BlockVerifyResult, error) {
+	if !s.running.TryLock() {
+		return nil, fmt.Errorf("区块哈希链校验正在进行中，请稍后再试")
+	}
+	defer s.running.Unlock()
+
+	channelNameList := []string{channelName}
+	if channelName == "" {
+		var err error
+		channelNameList, err = blockchain.GetBlockListener().GetChannelNameList()
+		if err != nil {
+			utils.Log.Error(fmt.Sprintf("查询区块通道列表失败: %v", err))
+			return nil, fmt.Errorf("查询区块通道列表失败: %v", err)
+		}
+	}
+
+	resultList := make([]*blockchain.BlockVerifyResult, 0, len(channelNameList))
+	for _, name := range channelNameList {
+		// 使用审计机构的网络从节点获取区块
+		result, err := blockchain.GetBlockListener().VerifyChannel(name, constants.AuditOrganization)
+		if err != nil {
+			utils.Log.Error(fmt.Sprintf("校验通道[%s]区块哈希链失败: %v", name, err))
+			return nil, fmt.Errorf("校验通道[%s]区块哈希链失败: %v", name, err)
+		}
+		if result.PeerError != "" {
+			utils.Log.Warn(fmt.Sprintf("通道[%s]未能与节点比对区块: %s", name, result.PeerError))
+		}
+		if result.Intact {
+			utils.Log.Info(fmt.Sprintf("通道[%s]区块哈希链校验通过，共%d个区块", name, result.VerifiedCount))
+		} else {
+			utils.Log.Error(fmt.Sprintf("通道[%s]区块哈希链在区块[%d]处断裂: %s", name, *result.BrokenBlockNumber, result.Reason))
+		}
+		s.recordFinding(triggerType, result)
+
+		s.resultLock.Lock()
+		s.latestList[name] = result
+		s.resultLock.Unlock()
+		resultList = append(resultList, result)
+	}
+	return resultList, nil
+}
+
+// GetLatestResultList 获取各通道最近一次的校验结果
+func (s *blockVerifyService) GetLatestResultList() []*blockchain.BlockVerifyResult {
+	s.resultLock.RLock()
+	defer s.resultLock.RUnlock()
+
+	resultList := make([]*blockchain.BlockVerifyResult, 0, len(s.latestList))
+	for _, channelName := range sortedKeys(s.latestList) {
+		resultList = append(resultList, s.latestList[channelName])
+	}
+	return resultList
+}
+
+// recordFinding 哈希链断裂时以审计机构身份写入审计日志链码，同一问题只记录一次，恢复完整后再次断裂时重新记录
+func (s *blockVerifyService) recordFinding(triggerType string, result *blockchain.BlockVerifyResult) {
+	if result.Intact {
+		delete(s.recordedReason, result.ChannelName)
+		return
+	}
+	if s.recordedReason[result.ChannelName] == result.Reason {
+		return
+	}
+
+	contract, err := blockchain.GetAuditContract(constants.AuditOrganization)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("记录通道[%s]区块哈希链校验结果失败: %v", result.ChannelName, err))
+		return
+	}
+
+	violationsJSON, _ := json.Marshal([]string{result.Reason})
+	recommendationsJSON, _ := json.Marshal([]string{
+		fmt.Sprintf("核查服务器本地区块存储，删除通道[%s]区块[%d]之后的本地区块后重新从节点同步", result.ChannelName, *result.BrokenBlockNumber),
+	})
+	relatedDocumentsJSON, _ := json.Marshal([]string{
+		fmt.Sprintf("%s/%d", result.ChannelName, *result.BrokenBlockNumber),
+	})
+	comments := fmt.Sprintf("触发方式: %s，第一个断裂的区块: %d，已校验通过%d个区块",
+		triggerType, *result.BrokenBlockNumber, result.VerifiedCount)
+
+	_, err = contract.SubmitTransaction("CreateAuditRecord",
+		uuid.New().String(),
+		constants.BlockVerifyAuditTargetType,
+		result.ChannelName,
+		constants.BlockVerifyAuditResultTampered,
+		comments,
+		string(violationsJSON),
+		string(recommendationsJSON),
+		constants.BlockVerifyStatusIntact,
+		constants.BlockVerifyStatusBroken,
+		string(relatedDocumentsJSON),
+	)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("记录通道[%s]区块哈希链校验结果失败: %v", result.ChannelName, err))
+		return
+	}
+	s.recordedReason[result.ChannelName] = result.Reason
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"grets_server/constants"
+	"grets_server/pkg/blockchain"
+	"grets_server/pkg/cache"
+	"grets_server/pkg/utils"
+)
+
+// InitChaincodeEventHandlers 注册进程内的链码事件处理函数
+// 链上状态变化（包括其他节点或客户端提交的交易）到达后清除对应缓存，避免读取到过期数据
+func InitChaincodeEventHandlers() {
+	cacheService := cache.GetCacheService()
+
+	blockchain.RegisterChaincodeEventHandler(blockchain.ChaincodeEventAll, func(event *blockchain.ChaincodeEvent) error {
+		utils.Log.Debug(fmt.Sprintf("收到链码事件: channel=%s, block=%d, type=%s, txID=%s",
+			event.ChannelName, event.BlockNumber, event.EventType, event.TxID))
+		return nil
+	})
+
+	removeRealtyCache := func(event *blockchain.ChaincodeEvent) error {
+		var payload struct {
+			RealtyCertHash string `json:"realtyCertHash"`
+		}
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("解析房产事件失败: %v", err)
+		}
+		cacheService.Remove(cache.RealtyPrefix + "hash:" + payload.RealtyCertHash)
+		return nil
+	}
+	blockchain.RegisterChaincodeEventHandler(constants.EventRealtyCreated, removeRealtyCache)
+	blockchain.RegisterChaincodeEventHandler(constants.EventRealtyUpdated, removeRealtyCache)
+	blockchain.RegisterChaincodeEventHandler(constants.EventMortgageUpdated, removeRealtyCache)
+
+	removeTransactionCache := func(event *blockchain.ChaincodeEvent) error {
+		var payload struct {
+			TransactionUUID string `json:"transactionUUID"`
+			RealtyCertHash  string `json:"realtyCertHash"`
+		}
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("解析交易事件失败: %v", err)
+		}
+		cacheService.Remove(cache.TransactionPrefix + "uuid:" + payload.TransactionUUID)
+		cacheService.Remove(cache.RealtyPrefix + "hash:" + payload.RealtyCertHash)
+		return nil
+	}
+	blockchain.RegisterChaincodeEventHandler(constants.EventTransactionCreated, removeTransactionCache)
+	blockchain.RegisterChaincodeEventHandler(constants.EventTransactionUpdated, removeTransactionCache)
+	blockchain.RegisterChaincodeEventHandler(constants.EventTransactionCompleted, removeTransactionCache)
+
+	blockchain.RegisterChaincodeEventHandler(constants.EventContractUpdated, func(event *blockchain.ChaincodeEvent) error {
+		var payload struct {
+			ContractUUID string `json:"contractUUID"`
+		}
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("解析合同事件失败: %v", err)
+		}
+		cacheService.Remove(cache.ContractPrefix + "uuid:" + payload.ContractUUID)
+		return nil
+	})
+}
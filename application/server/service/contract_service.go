@@ -30,12 +30,14 @@ type ContractService interface {
 	CreateContract(req *contractDto.CreateContractDTO) error
 	GetContractByID(id string) (*contractDto.ContractDTO, error)
 	QueryContractList(query *contractDto.QueryContractDTO) ([]*contractDto.ContractDTO, int, error)
-	SignContract(id string, req *contractDto.SignContractDTO) error
-	AuditContract(id string, req *contractDto.AuditContractDTO) error
+	SignContract(id string, citizenID string, organization string, req *contractDto.SignContractDTO) error
+	// SignContractOffline 由签署人自己签名链上交易签署合同，返回待签名的提案
+	SignContractOffline(id string, citizenID string, organization string, req *contractDto.SignContractDTO) (*blockchain.OfflineSession, error)
+	AuditContract(id string, organization string, req *contractDto.AuditContractDTO) (string, error)
 	UpdateContract(req *contractDto.UpdateContractDTO) error
 	GetContractByUUID(contractUUID string) (*contractDto.ContractDTO, error)
 	UpdateContractStatus(req *contractDto.UpdateContractStatusDTO) error
-	BindTransaction(req *contractDto.BindTransactionDTO) error
+	BindTransaction(req *contractDto.BindTransactionDTO) (string, error)
 }
 
 // contractService 合同服务实现
@@ -142,6 +144,10 @@ func (s *contractService) UpdateContractStatus(req *contractDto.UpdateContractSt
 
 // CreateContract 创建合同
 func (s *contractService) CreateContract(dto *contractDto.CreateContractDTO) error {
+	if dto.BuyerCitizenID == dto.SellerCitizenID {
+		return fmt.Errorf("买方和卖方不能为同一人")
+	}
+
 	contractUUID := uuid.New().String()
 	docHash := utils.GenerateRandomHash()
 
@@ -180,6 +186,8 @@ func (s *contractService) CreateContract(dto *contractDto.CreateContractDTO) err
 		docHash,
 		dto.ContractType,
 		utils.GenerateHash(dto.CreatorCitizenID),
+		utils.GenerateHash(dto.BuyerCitizenID),
+		utils.GenerateHash(dto.SellerCitizenID),
 	)
 
 	if err != nil {
@@ -319,51 +327,102 @@ func (s *contractService) QueryContractList(dto *contractDto.QueryContractDTO) (
 	return result, int(total), nil
 }
 
-// SignContract 签署合同
-func (s *contractService) SignContract(id string, req *contractDto.SignContractDTO) error {
-	// 调用链码签署合同
-	contract, err := blockchain.GetMainContract(constants.InvestorOrganization)
+// SignContract 签署合同，签名由签署人使用DID私钥对合同文档哈希生成，链码验证签名
+func (s *contractService) SignContract(id string, citizenID string, organization string, req *contractDto.SignContractDTO) error {
+	contractModel, err := s.contractDAO.GetContractByUUID(id)
 	if err != nil {
-		utils.Log.Error(fmt.Sprintf("获取合约失败: %v", err))
-		return fmt.Errorf("获取合约失败: %v", err)
+		utils.Log.Error(fmt.Sprintf("查询合同失败: %v", err))
+		return fmt.Errorf("查询合同失败: %v", err)
+	}
+
+	subContract, err := s.getSubContractByContract(contractModel, organization)
+	if err != nil {
+		return err
+	}
+	subContract, err = subContract.AsUser(organization, utils.GenerateHash(citizenID))
+	if err != nil {
+		return fmt.Errorf("获取签署人身份失败: %v", err)
 	}
-	_, err = contract.SubmitTransaction("SignContract",
+
+	_, err = subContract.SubmitTransaction("SignContract",
 		id,
 		req.SignerType,
+		utils.GenerateHash(citizenID),
+		organization,
+		req.Signature,
 	)
-
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("签署合同失败: %v", err))
 		return fmt.Errorf("签署合同失败: %v", err)
 	}
 
+	s.cache.Remove(cache.ContractPrefix + "uuid:" + id)
 	return nil
 }
 
-// AuditContract 审核合同
-func (s *contractService) AuditContract(id string, req *contractDto.AuditContractDTO) error {
-	// 调用链码审核合同
-	contract, err := blockchain.GetMainContract(constants.InvestorOrganization)
+// SignContractOffline 签署合同，链上交易由签署人用DID私钥签名，服务端不持有签署人的私钥
+func (s *contractService) SignContractOffline(id string, citizenID string, organization string, req *contractDto.SignContractDTO) (*blockchain.OfflineSession, error) {
+	contractModel, err := s.contractDAO.GetContractByUUID(id)
 	if err != nil {
-		utils.Log.Error(fmt.Sprintf("获取合约失败: %v", err))
-		return fmt.Errorf("获取合约失败: %v", err)
+		utils.Log.Error(fmt.Sprintf("查询合同失败: %v", err))
+		return nil, fmt.Errorf("查询合同失败: %v", err)
+	}
+	publicKey, err := userDIDPublicKey(citizenID, organization)
+	if err != nil {
+		return nil, fmt.Errorf("获取签署人DID公钥失败: %v", err)
+	}
+
+	subContract, err := s.getSubContractByContract(contractModel, organization)
+	if err != nil {
+		return nil, err
+	}
+
+	onCommit := func(status *blockchain.ChainTxStatus) {
+		s.cache.Remove(cache.ContractPrefix + "uuid:" + id)
+	}
+	session, err := subContract.PrepareOffline(organization, utils.GenerateHash(citizenID), publicKey, "SignContract", onCommit,
+		id,
+		req.SignerType,
+		utils.GenerateHash(citizenID),
+		organization,
+		req.Signature,
+	)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("创建签署合同提案失败: %v", err))
+		return nil, fmt.Errorf("创建签署合同提案失败: %v", err)
+	}
+	return session, nil
+}
+
+// AuditContract 审核合同，异步提交并返回链上交易ID
+func (s *contractService) AuditContract(id string, organization string, req *contractDto.AuditContractDTO) (string, error) {
+	contractModel, err := s.contractDAO.GetContractByUUID(id)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询合同失败: %v", err))
+		return "", fmt.Errorf("查询合同失败: %v", err)
 	}
 
-	// 构建审核参数
-	_, err = contract.SubmitTransaction("AuditContract",
+	subContract, err := s.getSubContractByContract(contractModel, organization)
+	if err != nil {
+		return "", err
+	}
+
+	onCommit := func(status *blockchain.ChainTxStatus) {
+		s.cache.Remove(cache.ContractPrefix + "uuid:" + id)
+	}
+	submitted, err := blockchain.SubmitAsync(subContract, "AuditContract", onCommit,
 		id,
 		req.Result,
 		req.Comments,
 		req.RevisionRequirements,
 		req.RejectionReason,
 	)
-
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("审核合同失败: %v", err))
-		return fmt.Errorf("审核合同失败: %v", err)
+		return "", fmt.Errorf("审核合同失败: %v", err)
 	}
 
-	return nil
+	return submitted.TxID, nil
 }
 
 // UpdateContract 更新合同
@@ -490,10 +549,23 @@ func (s *contractService) GetContractByUUID(contractUUID string) (*contractDto.C
 
 	contract := contractList[0]
 
-	// 创建缓存
-	s.cache.Set(cache.ContractPrefix+"uuid:"+contract.ContractUUID, contract, 0, 5*time.Minute)
+	// 签署和审核记录只保存在链上
+	subContract, err := s.getSubContractByContract(contract, constants.InvestorOrganization)
+	if err != nil {
+		return nil, err
+	}
+	chaincodeContractBytes, err := subContract.EvaluateTransaction("QueryContract", contractUUID)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询链上合同失败: %v", err))
+		return nil, fmt.Errorf("查询链上合同失败: %v", err)
+	}
+	var chaincodeContract contractDto.ContractDTO
+	if err := json.Unmarshal(chaincodeContractBytes, &chaincodeContract); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析链上合同失败: %v", err))
+		return nil, fmt.Errorf("解析链上合同失败: %v", err)
+	}
 
-	return &contractDto.ContractDTO{
+	contractDTO := &contractDto.ContractDTO{
 		ID:                   contract.ID,
 		ContractUUID:         contract.ContractUUID,
 		Title:                contract.Title,
@@ -503,32 +575,89 @@ func (s *contractService) GetContractByUUID(contractUUID string) (*contractDto.C
 		Status:               contract.Status,
 		ContractType:         contract.ContractType,
 		CreatorCitizenIDHash: contract.CreatorCitizenIDHash,
+		RequiredPartyList:    chaincodeContract.RequiredPartyList,
+		SignatureList:        chaincodeContract.SignatureList,
+		AuditList:            chaincodeContract.AuditList,
 		CreateTime:           contract.CreateTime,
 		UpdateTime:           contract.UpdateTime,
-	}, nil
+	}
+
+	// 创建缓存
+	s.cache.Set(cache.ContractPrefix+"uuid:"+contract.ContractUUID, contractDTO, 0, 5*time.Minute)
+
+	return contractDTO, nil
 }
 
 // BindTransaction 绑定交易
-func (s *contractService) BindTransaction(req *contractDto.BindTransactionDTO) error {
-	// 直接修改本地数据库
+func (s *contractService) BindTransaction(req *contractDto.BindTransactionDTO) (string, error) {
 	contractModel, err := s.contractDAO.GetContractByUUID(req.ContractUUID)
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("获取合同失败: %v", err))
-		return fmt.Errorf("获取合同失败: %v", err)
+		return "", fmt.Errorf("获取合同失败: %v", err)
 	}
 
 	if contractModel == nil {
 		utils.Log.Error(fmt.Sprintf("合同不存在: %v", req.ContractUUID))
-		return fmt.Errorf("合同不存在: %v", req.ContractUUID)
+		return "", fmt.Errorf("合同不存在: %v", req.ContractUUID)
 	}
 
-	contractModel.TransactionUUID = req.TransactionUUID
-	contractModel.Status = constants.ContractStatusInProgress
-	err = s.contractDAO.UpdateContract(contractModel)
+	// 链码检查合同是否已全部签署并审核通过，异步提交，上链后再修改本地数据库
+	subContract, err := s.getSubContractByContract(contractModel, constants.InvestorOrganization)
 	if err != nil {
-		utils.Log.Error(fmt.Sprintf("更新合同失败: %v", err))
-		return fmt.Errorf("更新合同失败: %v", err)
+		return "", err
+	}
+	onCommit := func(status *blockchain.ChainTxStatus) {
+		if status.Status != blockchain.TxStatusCommitted {
+			return
+		}
+		s.cache.Remove(cache.ContractPrefix + "uuid:" + req.ContractUUID)
+		contractModel.TransactionUUID = req.TransactionUUID
+		contractModel.Status = constants.ContractStatusInProgress
+		if err := s.contractDAO.UpdateContract(contractModel); err != nil {
+			utils.Log.Error(fmt.Sprintf("更新合同失败: %v", err))
+		}
+	}
+	submitted, err := blockchain.SubmitAsync(subContract, "BindContractTransaction", onCommit, req.ContractUUID, req.TransactionUUID)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("绑定交易失败: %v", err))
+		return "", fmt.Errorf("绑定交易失败: %v", err)
 	}
 
-	return nil
+	return submitted.TxID, nil
+}
+
+// getSubContractByContract 根据合同创建人所在地区获取指定组织的子通道合约
+func (s *contractService) getSubContractByContract(contractModel *models.Contract, organization string) (*blockchain.Contract, error) {
+	if contractModel == nil {
+		return nil, fmt.Errorf("合同不存在")
+	}
+
+	mainContract, err := blockchain.GetMainContract(organization)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取主通道合约失败: %v", err))
+		return nil, fmt.Errorf("获取主通道合约失败: %v", err)
+	}
+
+	channelInfoBytes, err := mainContract.EvaluateTransaction(
+		"GetChannelInfoByRegionCode",
+		contractModel.CreatorCitizenID[:2],
+	)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询通道信息失败: %v", err))
+		return nil, fmt.Errorf("查询通道信息失败: %v", err)
+	}
+
+	var channelInfo blockDto.ChannelInfo
+	if err := json.Unmarshal(channelInfoBytes, &channelInfo); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析通道信息失败: %v", err))
+		return nil, fmt.Errorf("解析通道信息失败: %v", err)
+	}
+
+	subContract, err := blockchain.GetSubContract(channelInfo.ChannelName, organization)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取子通道合约失败: %v", err))
+		return nil, fmt.Errorf("获取子通道合约失败: %v", err)
+	}
+
+	return subContract, nil
 }
@@ -56,6 +56,10 @@ type DIDService interface {
 	GetDIDByUser(citizenID, organization string) (string, error)
 	// VerifyDIDToken 验证DID Token
 	VerifyDIDToken(token string) (*DIDUserInfo, error)
+	// PrepareOfflineEnroll 为用户的DID公钥生成离线签名证书请求
+	PrepareOfflineEnroll(citizenID, organization string) (*blockchain.OfflineSession, error)
+	// SubmitOfflineSignature 提交离线签名会话当前阶段的签名
+	SubmitOfflineSignature(sessionID, citizenID, organization, signature string) (*blockchain.OfflineSession, error)
 }
 
 // didService DID服务实现
@@ -621,6 +625,55 @@ func (s *didService) GetDIDByUser(citizenID, organization string) (string, error
 	return s.didDAO.GetDIDByUser(citizenID, organization)
 }
 
+// PrepareOfflineEnroll 为用户的DID公钥生成离线签名证书请求，用户用DID私钥签名后由组织的CA签发证书
+// 证书只绑定公钥，服务端不保存用户私钥，之后的链上交易由用户逐笔签名
+func (s *didService) PrepareOfflineEnroll(citizenID, organization string) (*blockchain.OfflineSession, error) {
+	publicKey, err := s.userPublicKey(citizenID, organization)
+	if err != nil {
+		return nil, err
+	}
+	session, err := blockchain.PrepareOfflineEnroll(organization, utils.GenerateHash(citizenID), publicKey)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("生成离线签名证书请求失败: %v", err))
+		return nil, fmt.Errorf("生成离线签名证书请求失败: %v", err)
+	}
+	return session, nil
+}
+
+// SubmitOfflineSignature 提交离线签名会话当前阶段的签名
+func (s *didService) SubmitOfflineSignature(sessionID, citizenID, organization, signature string) (*blockchain.OfflineSession, error) {
+	session, err := blockchain.SignOffline(sessionID, organization, utils.GenerateHash(citizenID), signature)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("提交离线签名失败: %v", err))
+		return nil, err
+	}
+	return session, nil
+}
+
+// userPublicKey 获取用户DID当前的公钥
+func (s *didService) userPublicKey(citizenID, organization string) (*ecdsa.PublicKey, error) {
+	didStr, err := s.didDAO.GetDIDByUser(citizenID, organization)
+	if err != nil {
+		return nil, err
+	}
+	if didStr == "" {
+		return nil, fmt.Errorf("用户尚未创建DID")
+	}
+	publicKeyHex, err := s.didDAO.GetPublicKeyByDID(didStr)
+	if err != nil {
+		return nil, err
+	}
+	if publicKeyHex == "" {
+		return nil, fmt.Errorf("用户DID没有有效的公钥")
+	}
+	return did.HexToPublicKey(publicKeyHex)
+}
+
+// userDIDPublicKey 获取用户DID当前的公钥，供离线签名时校验用户签名
+func userDIDPublicKey(citizenID, organization string) (*ecdsa.PublicKey, error) {
+	return GlobalDIDService.(*didService).userPublicKey(citizenID, organization)
+}
+
 // VerifyDIDToken 验证DID Token
 func (s *didService) VerifyDIDToken(token string) (*DIDUserInfo, error) {
 	// 这里简化处理，实际应该解析JWT或VP格式的token
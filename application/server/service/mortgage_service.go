@@ -0,0 +1,188 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"grets_server/constants"
+	blockDto "grets_server/dto/block_dto"
+	mortgageDto "grets_server/dto/mortgage_dto"
+	"grets_server/pkg/blockchain"
+	"grets_server/pkg/cache"
+	"grets_server/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// 全局抵押服务实例
+var GlobalMortgageService MortgageService
+
+// InitMortgageService 初始化抵押服务
+func InitMortgageService() {
+	GlobalMortgageService = NewMortgageService()
+	utils.Log.Info("抵押服务初始化完成")
+}
+
+// MortgageService 抵押服务接口
+// 抵押操作只写入子通道，提交排序后立即返回链上交易ID，调用方通过交易ID查询上链状态
+type MortgageService interface {
+	CreateMortgage(req *mortgageDto.CreateMortgageDTO) (mortgageUUID string, txID string, err error)
+	ApproveMortgage(req *mortgageDto.MortgageActionDTO) (string, error)
+	AssumeMortgage(req *mortgageDto.AssumeMortgageDTO) (string, error)
+	ReleaseMortgage(req *mortgageDto.MortgageActionDTO) (string, error)
+	QueryMortgagesByRealty(realtyCertHash string) ([]*mortgageDto.MortgageDTO, error)
+}
+
+// mortgageService 抵押服务实现
+type mortgageService struct {
+	cacheService cache.CacheService
+}
+
+// NewMortgageService 创建抵押服务实例
+func NewMortgageService() MortgageService {
+	return &mortgageService{
+		cacheService: cache.GetCacheService(),
+	}
+}
+
+// CreateMortgage 创建抵押申请
+func (s *mortgageService) CreateMortgage(req *mortgageDto.CreateMortgageDTO) (string, string, error) {
+	realtyCertHash := utils.GenerateHash(req.RealtyCert)
+
+	subContract, err := s.getSubContractByRealtyCertHash(realtyCertHash)
+	if err != nil {
+		return "", "", err
+	}
+
+	mortgageUUID := uuid.New().String()
+	submitted, err := blockchain.SubmitAsync(subContract,
+		"CreateMortgage",
+		nil,
+		mortgageUUID,
+		realtyCertHash,
+		utils.GenerateHash(req.MortgagorCitizenID),
+		req.MortgagorOrganization,
+		fmt.Sprintf("%.2f", req.LoanAmount),
+		fmt.Sprintf("%.4f", req.InterestRate),
+		fmt.Sprintf("%d", req.Term),
+		fmt.Sprintf("%.2f", req.CollateralValue),
+		req.PaymentPlan,
+	)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("创建抵押失败: %v", err))
+		return "", "", fmt.Errorf("创建抵押失败: %v", err)
+	}
+
+	return mortgageUUID, submitted.TxID, nil
+}
+
+// ApproveMortgage 批准抵押
+func (s *mortgageService) ApproveMortgage(req *mortgageDto.MortgageActionDTO) (string, error) {
+	subContract, err := s.getSubContractByRealtyCertHash(req.RealtyCertHash)
+	if err != nil {
+		return "", err
+	}
+
+	submitted, err := blockchain.SubmitAsync(subContract, "ApproveMortgage", s.removeRealtyCache(req.RealtyCertHash), req.MortgageUUID)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("批准抵押失败: %v", err))
+		return "", fmt.Errorf("批准抵押失败: %v", err)
+	}
+	return submitted.TxID, nil
+}
+
+// AssumeMortgage 同意买方承接抵押
+func (s *mortgageService) AssumeMortgage(req *mortgageDto.AssumeMortgageDTO) (string, error) {
+	subContract, err := s.getSubContractByRealtyCertHash(req.RealtyCertHash)
+	if err != nil {
+		return "", err
+	}
+
+	submitted, err := blockchain.SubmitAsync(subContract,
+		"AssumeMortgage",
+		nil,
+		req.MortgageUUID,
+		utils.GenerateHash(req.AssumeCitizenID),
+		req.AssumeOrganization,
+	)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("承接抵押失败: %v", err))
+		return "", fmt.Errorf("承接抵押失败: %v", err)
+	}
+	return submitted.TxID, nil
+}
+
+// ReleaseMortgage 解除抵押
+func (s *mortgageService) ReleaseMortgage(req *mortgageDto.MortgageActionDTO) (string, error) {
+	subContract, err := s.getSubContractByRealtyCertHash(req.RealtyCertHash)
+	if err != nil {
+		return "", err
+	}
+
+	submitted, err := blockchain.SubmitAsync(subContract, "ReleaseMortgage", s.removeRealtyCache(req.RealtyCertHash), req.MortgageUUID)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("解除抵押失败: %v", err))
+		return "", fmt.Errorf("解除抵押失败: %v", err)
+	}
+	return submitted.TxID, nil
+}
+
+// removeRealtyCache 房产状态在交易上链后才变更，上链后清除房产缓存
+func (s *mortgageService) removeRealtyCache(realtyCertHash string) func(status *blockchain.ChainTxStatus) {
+	return func(status *blockchain.ChainTxStatus) {
+		s.cacheService.Remove(cache.RealtyPrefix + "hash:" + realtyCertHash)
+	}
+}
+
+// QueryMortgagesByRealty 查询房产上的抵押
+func (s *mortgageService) QueryMortgagesByRealty(realtyCertHash string) ([]*mortgageDto.MortgageDTO, error) {
+	subContract, err := s.getSubContractByRealtyCertHash(realtyCertHash)
+	if err != nil {
+		return nil, err
+	}
+
+	mortgageBytes, err := subContract.EvaluateTransaction("QueryMortgagesByRealty", realtyCertHash)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询抵押信息失败: %v", err))
+		return nil, fmt.Errorf("查询抵押信息失败: %v", err)
+	}
+
+	mortgageList := []*mortgageDto.MortgageDTO{}
+	if len(mortgageBytes) == 0 {
+		return mortgageList, nil
+	}
+	if err := json.Unmarshal(mortgageBytes, &mortgageList); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析抵押信息失败: %v", err))
+		return nil, fmt.Errorf("解析抵押信息失败: %v", err)
+	}
+
+	return mortgageList, nil
+}
+
+// getSubContractByRealtyCertHash 根据房产索引获取银行在房产所在子通道的合约
+func (s *mortgageService) getSubContractByRealtyCertHash(realtyCertHash string) (*blockchain.Contract, error) {
+	mainContract, err := blockchain.GetMainContract(constants.BankOrganization)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取主通道合约失败: %v", err))
+		return nil, fmt.Errorf("获取主通道合约失败: %v", err)
+	}
+
+	realtyIndexBytes, err := mainContract.EvaluateTransaction("GetRealtyIndex", realtyCertHash)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取房产索引失败: %v", err))
+		return nil, fmt.Errorf("获取房产索引失败: %v", err)
+	}
+
+	var realtyIndex blockDto.RealtyIndex
+	if err := json.Unmarshal(realtyIndexBytes, &realtyIndex); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析房产索引失败: %v", err))
+		return nil, fmt.Errorf("解析房产索引失败: %v", err)
+	}
+
+	subContract, err := blockchain.GetSubContract(realtyIndex.ChannelName, constants.BankOrganization)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取子通道合约失败: %v", err))
+		return nil, fmt.Errorf("获取子通道合约失败: %v", err)
+	}
+
+	return subContract, nil
+}
@@ -0,0 +1,399 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"grets_server/constants"
+	"grets_server/dao"
+	"grets_server/db/models"
+	operationDto "grets_server/dto/operation_dto"
+	"grets_server/pkg/blockchain"
+	"grets_server/pkg/utils"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 全局业务操作服务实例
+var GlobalOperationService OperationService
+
+// InitOperationService 初始化业务操作服务
+func InitOperationService(operationDAO *dao.OperationDAO) {
+	GlobalOperationService = NewOperationService(operationDAO)
+	utils.Log.Info("业务操作服务初始化完成")
+}
+
+// OperationService 业务操作服务接口
+// 链上+数据库的多步流程先写入操作表，再按步骤执行，失败后按退避间隔重试，重试耗尽或被拒绝时逆序补偿已完成的步骤
+type OperationService interface {
+	Submit(operationType string, businessKey string, payload interface{}) (*operationDto.OperationDTO, error)
+	GetOperationByUUID(operationUUID string) (*operationDto.OperationDTO, error)
+	RunDueOperations() error
+}
+
+// operationStep 业务操作步骤定义
+// action必须幂等：重试时可能重复执行已经生效的步骤，需要先检查链上或数据库中是否已完成
+// compensate为nil表示该步骤无需或无法补偿
+type operationStep struct {
+	name       string
+	action     func(payload string) error
+	compensate func(payload string) error
+}
+
+// operationAbortError 不可重试的错误，例如链码拒绝，直接进入补偿
+type operationAbortError struct {
+	err error
+}
+
+func (e *operationAbortError) Error() string {
+	return e.err.Error()
+}
+
+func (e *operationAbortError) Unwrap() error {
+	return e.err
+}
+
+// operationWaitError 步骤依赖的链上交易尚未上链，按等待间隔重新检查，不计入执行次数
+type operationWaitError struct {
+	err error
+}
+
+func (e *operationWaitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *operationWaitError) Unwrap() error {
+	return e.err
+}
+
+// checkTxCommitted 根据链上交易的提交状态判断步骤能否继续：已上链返回nil，校验未通过或超过deadline仍未上链不可重试，其余情况继续等待
+// 离线签名的交易在用户签名提交前查询不到，同样继续等待
+func checkTxCommitted(txID string, status *blockchain.ChainTxStatus, err error, deadline time.Time, now time.Time) error {
+	if err == nil && status.Status == blockchain.TxStatusCommitted {
+		return nil
+	}
+	if err == nil && status.Status == blockchain.TxStatusInvalid {
+		return &operationAbortError{err: fmt.Errorf("交易[%s]上链后校验失败: %s", status.TxID, status.ValidationCode)}
+	}
+	if now.After(deadline) {
+		return &operationAbortError{err: fmt.Errorf("交易[%s]超过%s未上链", txID, constants.OperationWaitTimeout)}
+	}
+	if err != nil {
+		return &operationWaitError{err: fmt.Errorf("交易[%s]尚未上链: %v", txID, err)}
+	}
+	return &operationWaitError{err: fmt.Errorf("交易[%s]尚未上链: %s", txID, status.Status)}
+}
+
+// waitTxCommitStep 等待链上交易上链的步骤，txID和deadline从业务操作参数中读取
+func waitTxCommitStep(parse func(payload string) (txID string, deadline time.Time, err error)) operationStep {
+	return operationStep{
+		name: "WaitCommit",
+		action: func(payload string) error {
+			txID, deadline, err := parse(payload)
+			if err != nil {
+				return err
+			}
+			status, err := blockchain.GetBlockListener().GetTxStatus(txID)
+			return checkTxCommitted(txID, status, err, deadline, time.Now())
+		},
+	}
+}
+
+// abortOperation 将链码拒绝和背书失败的错误标记为不可重试，读写冲突、网络、超时等错误保持可重试
+func abortOperation(err error) error {
+	switch blockchain.ChainErrorKind(err) {
+	case blockchain.ChainErrorChaincode, blockchain.ChainErrorEndorsement:
+		return &operationAbortError{err: err}
+	}
+	return err
+}
+
+// operationService 业务操作服务实现
+type operationService struct {
+	operationDAO *dao.OperationDAO
+	definitions  map[string][]operationStep
+	runningList  sync.Map
+}
+
+// NewOperationService 创建业务操作服务实例
+func NewOperationService(operationDAO *dao.OperationDAO) OperationService {
+	return &operationService{
+		operationDAO: operationDAO,
+		definitions: map[string][]operationStep{
+			constants.OperationCreateTransaction: createTransactionSteps(),
+			constants.OperationPayForTransaction: payForTransactionSteps(),
+			constants.OperationCreatePayment:     createPaymentSteps(),
+
+			constants.OperationConfirmTransactionStep: confirmTransactionStepSteps(),
+			constants.OperationCloseTransaction:       closeTransactionSteps(),
+		},
+	}
+}
+
+// StartOperationWorker 定期执行到达重试时间的业务操作
+func StartOperationWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := GlobalOperationService.RunDueOperations(); err != nil {
+				utils.Log.Error(fmt.Sprintf("执行业务操作失败: %v", err))
+			}
+		}
+	}()
+}
+
+// Submit 保存业务操作后立即执行一次，未完成的步骤由工作器继续重试
+// 操作已补偿或失败时返回最近一次错误，仍在等待重试时不返回错误，调用方可通过操作UUID查询进度
+func (s *operationService) Submit(operationType string, businessKey string, payload interface{}) (*operationDto.OperationDTO, error) {
+	stepDefinitionList, ok := s.definitions[operationType]
+	if !ok {
+		return nil, fmt.Errorf("不支持的业务操作类型: %s", operationType)
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("序列化业务操作参数失败: %v", err))
+		return nil, fmt.Errorf("序列化业务操作参数失败: %v", err)
+	}
+
+	operation := &models.Operation{
+		OperationUUID: uuid.New().String(),
+		OperationType: operationType,
+		BusinessKey:   businessKey,
+		Payload:       string(payloadJSON),
+		Status:        constants.OperationStatusPending,
+		NextRetryTime: time.Now(),
+	}
+	stepList := make([]*models.OperationStep, 0, len(stepDefinitionList))
+	for i, stepDefinition := range stepDefinitionList {
+		stepList = append(stepList, &models.OperationStep{
+			OperationUUID: operation.OperationUUID,
+			StepIndex:     i,
+			StepName:      stepDefinition.name,
+			Status:        constants.OperationStepPending,
+		})
+	}
+	if err := s.operationDAO.CreateOperation(operation, stepList); err != nil {
+		utils.Log.Error(fmt.Sprintf("保存业务操作失败: %v", err))
+		return nil, fmt.Errorf("保存业务操作失败: %v", err)
+	}
+
+	s.execute(operation.OperationUUID)
+
+	result, err := s.GetOperationByUUID(operation.OperationUUID)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status == constants.OperationStatusCompensated || result.Status == constants.OperationStatusFailed {
+		return result, errors.New(result.LastError)
+	}
+	return result, nil
+}
+
+// GetOperationByUUID 查询业务操作及各步骤的执行状态
+func (s *operationService) GetOperationByUUID(operationUUID string) (*operationDto.OperationDTO, error) {
+	operation, err := s.operationDAO.GetOperationByUUID(operationUUID)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询业务操作失败: %v", err))
+		return nil, fmt.Errorf("查询业务操作失败: %v", err)
+	}
+
+	stepList, err := s.operationDAO.GetOperationStepList(operationUUID)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询业务操作步骤失败: %v", err))
+		return nil, fmt.Errorf("查询业务操作步骤失败: %v", err)
+	}
+
+	result := &operationDto.OperationDTO{
+		OperationUUID: operation.OperationUUID,
+		OperationType: operation.OperationType,
+		BusinessKey:   operation.BusinessKey,
+		Status:        operation.Status,
+		CurrentStep:   operation.CurrentStep,
+		LastError:     operation.LastError,
+		NextRetryTime: operation.NextRetryTime,
+		CreateTime:    operation.CreateTime,
+		UpdateTime:    operation.UpdateTime,
+		StepList:      make([]*operationDto.OperationStepDTO, 0, len(stepList)),
+	}
+	for _, step := range stepList {
+		result.StepList = append(result.StepList, &operationDto.OperationStepDTO{
+			StepIndex:  step.StepIndex,
+			StepName:   step.StepName,
+			Status:     step.Status,
+			Attempts:   step.Attempts,
+			LastError:  step.LastError,
+			FinishTime: step.FinishTime,
+		})
+	}
+	return result, nil
+}
+
+// RunDueOperations 执行到达重试时间的业务操作
+func (s *operationService) RunDueOperations() error {
+	operationList, err := s.operationDAO.QueryDueOperationList(time.Now(), 100)
+	if err != nil {
+		return err
+	}
+	for _, operation := range operationList {
+		s.execute(operation.OperationUUID)
+	}
+	return nil
+}
+
+// execute 从当前步骤继续执行业务操作，同一操作同时只有一个执行者，取得执行权后重新读取进度
+func (s *operationService) execute(operationUUID string) {
+	if _, running := s.runningList.LoadOrStore(operationUUID, true); running {
+		return
+	}
+	defer s.runningList.Delete(operationUUID)
+
+	operation, err := s.operationDAO.GetOperationByUUID(operationUUID)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("加载业务操作[%s]失败: %v", operationUUID, err))
+		return
+	}
+
+	stepDefinitionList, ok := s.definitions[operation.OperationType]
+	if !ok {
+		operation.Status = constants.OperationStatusFailed
+		operation.LastError = fmt.Sprintf("不支持的业务操作类型: %s", operation.OperationType)
+		s.saveOperation(operation, nil)
+		return
+	}
+
+	stepList, err := s.operationDAO.GetOperationStepList(operation.OperationUUID)
+	if err != nil || len(stepList) != len(stepDefinitionList) {
+		utils.Log.Error(fmt.Sprintf("加载业务操作[%s]步骤失败: %v", operation.OperationUUID, err))
+		return
+	}
+
+	for operation.Status == constants.OperationStatusPending && operation.CurrentStep < len(stepDefinitionList) {
+		stepDefinition := stepDefinitionList[operation.CurrentStep]
+		step := stepList[operation.CurrentStep]
+
+		operation.Attempts++
+		step.Attempts++
+		err := stepDefinition.action(operation.Payload)
+		if err == nil {
+			now := time.Now()
+			step.Status = constants.OperationStepSucceeded
+			step.FinishTime = &now
+			operation.CurrentStep++
+			operation.Attempts = 0
+			operation.LastError = ""
+			if operation.CurrentStep == len(stepDefinitionList) {
+				operation.Status = constants.OperationStatusSucceeded
+			}
+			if !s.saveOperation(operation, step) {
+				return
+			}
+			continue
+		}
+
+		// 等待链上交易上链，不计入执行次数
+		var waitErr *operationWaitError
+		if errors.As(err, &waitErr) {
+			operation.Attempts--
+			step.Attempts--
+			operation.LastError = err.Error()
+			operation.NextRetryTime = time.Now().Add(constants.OperationWaitInterval)
+			s.saveOperation(operation, step)
+			return
+		}
+
+		utils.Log.Warn(fmt.Sprintf("业务操作[%s]步骤[%s]第%d次执行失败: %v",
+			operation.OperationUUID, stepDefinition.name, operation.Attempts, err))
+		step.LastError = err.Error()
+		operation.LastError = err.Error()
+
+		var abortErr *operationAbortError
+		if errors.As(err, &abortErr) || operation.Attempts >= constants.OperationMaxAttempts {
+			step.Status = constants.OperationStepFailed
+			operation.Status = constants.OperationStatusCompensating
+			operation.Attempts = 0
+			operation.NextRetryTime = time.Now()
+			if !s.saveOperation(operation, step) {
+				return
+			}
+			break
+		}
+
+		operation.NextRetryTime = time.Now().Add(operationBackoff(operation.Attempts))
+		s.saveOperation(operation, step)
+		return
+	}
+
+	if operation.Status == constants.OperationStatusCompensating {
+		s.compensate(operation, stepDefinitionList, stepList)
+	}
+}
+
+// compensate 逆序补偿已完成的步骤，补偿失败时按退避间隔重试，重试耗尽后标记为失败等待人工处理
+func (s *operationService) compensate(operation *models.Operation, stepDefinitionList []operationStep, stepList []*models.OperationStep) {
+	for i := operation.CurrentStep - 1; i >= 0; i-- {
+		step := stepList[i]
+		if step.Status != constants.OperationStepSucceeded {
+			continue
+		}
+
+		now := time.Now()
+		if stepDefinitionList[i].compensate == nil {
+			step.Status = constants.OperationStepSkipped
+			step.FinishTime = &now
+			if !s.saveOperation(operation, step) {
+				return
+			}
+			continue
+		}
+
+		operation.Attempts++
+		if err := stepDefinitionList[i].compensate(operation.Payload); err != nil {
+			utils.Log.Error(fmt.Sprintf("业务操作[%s]补偿步骤[%s]第%d次失败: %v",
+				operation.OperationUUID, step.StepName, operation.Attempts, err))
+			step.LastError = fmt.Sprintf("补偿失败: %v", err)
+			if operation.Attempts >= constants.OperationMaxAttempts {
+				operation.Status = constants.OperationStatusFailed
+				operation.LastError = fmt.Sprintf("%s; 补偿步骤[%s]失败: %v", operation.LastError, step.StepName, err)
+			} else {
+				operation.NextRetryTime = now.Add(operationBackoff(operation.Attempts))
+			}
+			s.saveOperation(operation, step)
+			return
+		}
+
+		step.Status = constants.OperationStepCompensated
+		step.FinishTime = &now
+		operation.Attempts = 0
+		if !s.saveOperation(operation, step) {
+			return
+		}
+	}
+
+	operation.Status = constants.OperationStatusCompensated
+	s.saveOperation(operation, nil)
+}
+
+// saveOperation 保存执行进度，失败时只记录日志，工作器会从上次保存的进度重新执行
+func (s *operationService) saveOperation(operation *models.Operation, step *models.OperationStep) bool {
+	if err := s.operationDAO.SaveOperation(operation, step); err != nil {
+		utils.Log.Error(fmt.Sprintf("保存业务操作[%s]进度失败: %v", operation.OperationUUID, err))
+		return false
+	}
+	return true
+}
+
+// operationBackoff 计算第attempts次失败后的重试间隔
+func operationBackoff(attempts int) time.Duration {
+	backoff := constants.OperationRetryBackoff
+	for i := 1; i < attempts && backoff < constants.OperationMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > constants.OperationMaxBackoff {
+		backoff = constants.OperationMaxBackoff
+	}
+	return backoff
+}
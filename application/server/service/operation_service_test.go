@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"grets_server/constants"
+	"grets_server/dao"
+	"grets_server/db"
+	"grets_server/db/models"
+	"grets_server/pkg/blockchain"
+	"grets_server/pkg/utils"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dryRunConnPool 只生成SQL不执行的数据库连接，补偿流程保存进度时不需要真实的MySQL
+type dryRunConnPool struct{}
+
+func (dryRunConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("dry run")
+}
+
+func (dryRunConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errors.New("dry run")
+}
+
+func (dryRunConnPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("dry run")
+}
+
+func (dryRunConnPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func (dryRunConnPool) Commit() error {
+	return nil
+}
+
+func (dryRunConnPool) Rollback() error {
+	return nil
+}
+
+func newTestOperationService(t *testing.T) *operationService {
+	t.Helper()
+	utils.Log = zap.NewNop()
+
+	mysqlDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      dryRunConnPool{},
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("创建数据库连接失败: %v", err)
+	}
+	originalDB := db.GlobalMysql
+	db.GlobalMysql = mysqlDB
+	t.Cleanup(func() { db.GlobalMysql = originalDB })
+
+	return &operationService{operationDAO: dao.NewOperationDAO()}
+}
+
+// newTestOperation 构造已完成前completedSteps个步骤、正在补偿的业务操作
+func newTestOperation(stepDefinitionList []operationStep, completedSteps int) (*models.Operation, []*models.OperationStep) {
+	operation := &models.Operation{
+		ID:            1,
+		OperationUUID: "operation-1",
+		Payload:       "{}",
+		Status:        constants.OperationStatusCompensating,
+		CurrentStep:   completedSteps,
+		LastError:     "步骤失败",
+	}
+	stepList := make([]*models.OperationStep, len(stepDefinitionList))
+	for i, stepDefinition := range stepDefinitionList {
+		status := constants.OperationStepPending
+		if i < completedSteps {
+			status = constants.OperationStepSucceeded
+		} else if i == completedSteps {
+			status = constants.OperationStepFailed
+		}
+		stepList[i] = &models.OperationStep{
+			ID:            int64(i + 1),
+			OperationUUID: operation.OperationUUID,
+			StepIndex:     i,
+			StepName:      stepDefinition.name,
+			Status:        status,
+		}
+	}
+	return operation, stepList
+}
+
+func TestOperationBackoff(t *testing.T) {
+	testCaseList := []struct {
+		attempts int
+		backoff  time.Duration
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{5, 32 * time.Second},
+		{8, 256 * time.Second},
+		{9, constants.OperationMaxBackoff},
+		{100, constants.OperationMaxBackoff},
+	}
+	for _, testCase := range testCaseList {
+		if backoff := operationBackoff(testCase.attempts); backoff != testCase.backoff {
+			t.Errorf("第%d次失败后的重试间隔 = %s, 期望 %s", testCase.attempts, backoff, testCase.backoff)
+		}
+	}
+}
+
+func TestOperationCompensate(t *testing.T) {
+	service := newTestOperationService(t)
+
+	var compensatedList []string
+	compensateStep := func(name string) func(payload string) error {
+		return func(payload string) error {
+			compensatedList = append(compensatedList, name)
+			return nil
+		}
+	}
+	stepDefinitionList := []operationStep{
+		{name: "LockRealty", compensate: compensateStep("LockRealty")},
+		{name: "SaveTransaction"},
+		{name: "CreateTransaction", compensate: compensateStep("CreateTransaction")},
+		{name: "NotifySeller", compensate: compensateStep("NotifySeller")},
+	}
+	// 前三个步骤已完成，第四个步骤失败
+	operation, stepList := newTestOperation(stepDefinitionList, 3)
+
+	service.compensate(operation, stepDefinitionList, stepList)
+
+	// 逆序补偿已完成的步骤，失败的步骤不补偿
+	expectedList := []string{"CreateTransaction", "LockRealty"}
+	if len(compensatedList) != len(expectedList) {
+		t.Fatalf("补偿顺序 = %v, 期望 %v", compensatedList, expectedList)
+	}
+	for i := range expectedList {
+		if compensatedList[i] != expectedList[i] {
+			t.Fatalf("补偿顺序 = %v, 期望 %v", compensatedList, expectedList)
+		}
+	}
+
+	expectedStatusList := []string{
+		constants.OperationStepCompensated,
+		constants.OperationStepSkipped,
+		constants.OperationStepCompensated,
+		constants.OperationStepFailed,
+	}
+	for i, step := range stepList {
+		if step.Status != expectedStatusList[i] {
+			t.Errorf("步骤[%s]状态 = %s, 期望 %s", step.StepName, step.Status, expectedStatusList[i])
+		}
+	}
+	if operation.Status != constants.OperationStatusCompensated {
+		t.Errorf("操作状态 = %s, 期望 %s", operation.Status, constants.OperationStatusCompensated)
+	}
+}
+
+func TestOperationCompensateRetry(t *testing.T) {
+	service := newTestOperationService(t)
+
+	var compensatedList []string
+	failing := true
+	stepDefinitionList := []operationStep{
+		{name: "LockRealty", compensate: func(payload string) error {
+			compensatedList = append(compensatedList, "LockRealty")
+			return nil
+		}},
+		{name: "CreateTransaction", compensate: func(payload string) error {
+			if failing {
+				return errors.New("节点不可用")
+			}
+			compensatedList = append(compensatedList, "CreateTransaction")
+			return nil
+		}},
+		{name: "NotifySeller"},
+	}
+	operation, stepList := newTestOperation(stepDefinitionList, 2)
+
+	// 补偿失败时停在失败的步骤，等待重试，不继续补偿更早的步骤
+	service.compensate(operation, stepDefinitionList, stepList)
+	if len(compensatedList) != 0 {
+		t.Fatalf("补偿失败后不应继续补偿更早的步骤: %v", compensatedList)
+	}
+	if operation.Status != constants.OperationStatusCompensating || operation.Attempts != 1 {
+		t.Fatalf("操作状态 = %s, 已执行 %d 次, 期望等待第2次补偿", operation.Status, operation.Attempts)
+	}
+	if stepList[1].Status != constants.OperationStepSucceeded || stepList[1].LastError == "" {
+		t.Errorf("补偿失败的步骤应保持已完成状态并记录错误")
+	}
+	if operation.NextRetryTime.Before(time.Now().Add(constants.OperationRetryBackoff - time.Second)) {
+		t.Errorf("补偿失败后应按退避间隔重试")
+	}
+
+	// 重试成功后继续逆序补偿
+	failing = false
+	service.compensate(operation, stepDefinitionList, stepList)
+	if len(compensatedList) != 2 || compensatedList[0] != "CreateTransaction" || compensatedList[1] != "LockRealty" {
+		t.Fatalf("补偿顺序 = %v, 期望 [CreateTransaction LockRealty]", compensatedList)
+	}
+	if operation.Status != constants.OperationStatusCompensated {
+		t.Errorf("操作状态 = %s, 期望 %s", operation.Status, constants.OperationStatusCompensated)
+	}
+
+	// 重试耗尽后标记为失败，等待人工处理
+	operation, stepList = newTestOperation(stepDefinitionList, 2)
+	failing = true
+	for i := 0; i < constants.OperationMaxAttempts; i++ {
+		service.compensate(operation, stepDefinitionList, stepList)
+	}
+	if operation.Status != constants.OperationStatusFailed {
+		t.Errorf("操作状态 = %s, 期望 %s", operation.Status, constants.OperationStatusFailed)
+	}
+}
+
+func TestCheckTxCommitted(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	deadline := now.Add(time.Minute)
+
+	testCaseList := []struct {
+		name     string
+		status   *blockchain.ChainTxStatus
+		err      error
+		deadline time.Time
+		result   string // ok、wait、abort
+	}{
+		{"已上链", &blockchain.ChainTxStatus{TxID: "tx-1", Status: blockchain.TxStatusCommitted}, nil, deadline, "ok"},
+		{"超过截止时间前已上链", &blockchain.ChainTxStatus{TxID: "tx-1", Status: blockchain.TxStatusCommitted}, nil, now.Add(-time.Minute), "ok"},
+		{"等待上链", &blockchain.ChainTxStatus{TxID: "tx-1", Status: blockchain.TxStatusPending}, nil, deadline, "wait"},
+		{"上链状态未知", &blockchain.ChainTxStatus{TxID: "tx-1", Status: blockchain.TxStatusUnknown}, nil, deadline, "wait"},
+		{"离线签名尚未提交", nil, errors.New("交易不存在"), deadline, "wait"},
+		{"校验未通过", &blockchain.ChainTxStatus{TxID: "tx-1", Status: blockchain.TxStatusInvalid, ValidationCode: "MVCC_READ_CONFLICT"}, nil, deadline, "abort"},
+		{"超时未上链", &blockchain.ChainTxStatus{TxID: "tx-1", Status: blockchain.TxStatusPending}, nil, now.Add(-time.Minute), "abort"},
+		{"超时仍未提交", nil, errors.New("交易不存在"), now.Add(-time.Minute), "abort"},
+	}
+
+	for _, testCase := range testCaseList {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := checkTxCommitted("tx-1", testCase.status, testCase.err, testCase.deadline, now)
+
+			var waitErr *operationWaitError
+			var abortErr *operationAbortError
+			result := "ok"
+			switch {
+			case errors.As(err, &waitErr):
+				result = "wait"
+			case errors.As(err, &abortErr):
+				result = "abort"
+			case err != nil:
+				result = "retry"
+			}
+			if result != testCase.result {
+				t.Errorf("结果 = %s(%v), 期望 %s", result, err, testCase.result)
+			}
+		})
+	}
+}
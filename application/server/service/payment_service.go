@@ -2,6 +2,7 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"grets_server/constants"
 	"grets_server/dao"
@@ -26,15 +27,22 @@ func InitPaymentService(paymentDAO *dao.PaymentDAO) {
 
 // PaymentService 支付服务接口
 type PaymentService interface {
-	CreatePayment(req *paymentDto.CreatePaymentDTO) error
+	CreatePayment(req *paymentDto.CreatePaymentDTO) (string, string, error)
 	GetPaymentByUUID(paymentUUID string) (*paymentDto.PaymentDTO, error)
 	QueryPaymentList(query *paymentDto.QueryPaymentDTO) ([]*paymentDto.PaymentDTO, int, error)
-	VerifyPayment(id string) error
-	CompletePayment(id string) error
-	PayForTransaction(dto *paymentDto.PayForTransactionDTO) error
+	VerifyPayment(id string) (string, error)
+	CompletePayment(id string) (string, error)
+	ReversePayment(id string, req *paymentDto.ReversePaymentDTO) (string, error)
+	PayForTransaction(dto *paymentDto.PayForTransactionDTO) (string, string, error)
+	// PayForTransactionOffline 由付款人自己签名支付交易，返回待签名的提案
+	PayForTransactionOffline(dto *paymentDto.PayForTransactionDTO) (*blockchain.OfflineSession, error)
+	GetEscrowByTransactionUUID(transactionUUID string) (*paymentDto.EscrowDTO, error)
 	GetTotalPaymentAmount() (int64, error)
 }
 
+// ErrEscrowPaymentSettle 交易支付进入托管账户，由过户时统一结算，不能单独结算
+var ErrEscrowPaymentSettle = errors.New("交易支付由托管账户在过户时结算，不能单独结算")
+
 // paymentService 支付服务实现
 type paymentService struct {
 	paymentDAO *dao.PaymentDAO
@@ -56,25 +64,76 @@ func (s *paymentService) GetTotalPaymentAmount() (int64, error) {
 	return totalAmount, nil
 }
 
-// PayForTransaction 支付交易
-func (s *paymentService) PayForTransaction(dto *paymentDto.PayForTransactionDTO) error {
+// PayForTransaction 支付交易，返回支付UUID和业务操作UUID
+func (s *paymentService) PayForTransaction(dto *paymentDto.PayForTransactionDTO) (string, string, error) {
+	op, err := newPayForTransactionOperation(dto)
+	if err != nil {
+		return "", "", err
+	}
+
+	// 链上支付和数据库记录由业务操作按步骤写入，失败后重试或冲正，重试时沿用同一个支付UUID
+	operation, err := GlobalOperationService.Submit(constants.OperationPayForTransaction, op.PaymentUUID, op)
+	if err != nil {
+		return "", "", fmt.Errorf("支付交易失败: %v", err)
+	}
+
+	return op.PaymentUUID, operation.OperationUUID, nil
+}
+
+// PayForTransactionOffline 由付款人用DID私钥签名支付交易，交易上链后保存支付信息
+// 付款人自己签名，交易未上链时资金没有进入托管，不需要冲正
+func (s *paymentService) PayForTransactionOffline(dto *paymentDto.PayForTransactionDTO) (*blockchain.OfflineSession, error) {
+	op, err := newPayForTransactionOperation(dto)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := userDIDPublicKey(dto.PayerCitizenID, dto.PayerOrganization)
+	if err != nil {
+		return nil, fmt.Errorf("获取付款人DID公钥失败: %v", err)
+	}
+
+	subContract, err := blockchain.GetSubContract(op.ChannelName, op.PayerOrganization)
+	if err != nil {
+		return nil, fmt.Errorf("获取子通道合约失败: %v", err)
+	}
+
+	onCommit := func(status *blockchain.ChainTxStatus) {
+		if status.Status != blockchain.TxStatusCommitted {
+			return
+		}
+		if err := s.savePayment(op); err != nil {
+			utils.Log.Error(fmt.Sprintf("支付[%s]上链后保存失败: %v", op.PaymentUUID, err))
+		}
+	}
+	session, err := subContract.PrepareOffline(op.PayerOrganization, op.PayerCitizenIDHash, publicKey,
+		"PayForTransaction", onCommit, op.chaincodeArgs()...)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("创建支付交易提案失败: %v", err))
+		return nil, fmt.Errorf("创建支付交易提案失败: %v", err)
+	}
+	return session, nil
+}
+
+// newPayForTransactionOperation 校验交易状态并生成支付参数
+func newPayForTransactionOperation(dto *paymentDto.PayForTransactionDTO) (*payForTransactionOperation, error) {
 	// 查看交易是否存在
 	transaction, err := GlobalTransactionService.GetTransactionByTransactionUUID(dto.TransactionUUID)
 	if err != nil {
-		return fmt.Errorf("查询交易失败: %v", err)
+		return nil, fmt.Errorf("查询交易失败: %v", err)
 	}
 
 	// 查看交易是否已支结束
-	if transaction.Status == constants.TxStatusCompleted {
-		return fmt.Errorf("交易已结束")
+	if transaction.Status == constants.TxStatusCompleted ||
+		transaction.Status == constants.TxStatusRejected ||
+		transaction.Status == constants.TxStatusCancelled ||
+		transaction.Status == constants.TxStatusExpired {
+		return nil, fmt.Errorf("交易已结束")
 	}
 
-	paymentUUID := uuid.New().String()
-
 	// 调用链码支付交易
 	mainContract, err := blockchain.GetMainContract(constants.InvestorOrganization)
 	if err != nil {
-		return fmt.Errorf("获取合约失败: %v", err)
+		return nil, fmt.Errorf("获取合约失败: %v", err)
 	}
 
 	// 查询交易索引
@@ -83,19 +142,13 @@ func (s *paymentService) PayForTransaction(dto *paymentDto.PayForTransactionDTO)
 		dto.TransactionUUID,
 	)
 	if err != nil {
-		return fmt.Errorf("查询交易索引失败: %v", err)
+		return nil, fmt.Errorf("查询交易索引失败: %v", err)
 	}
 
 	var transactionIndexDTO block_dto.TransactionIndex
 	err = json.Unmarshal(transactionIndex, &transactionIndexDTO)
 	if err != nil {
-		return fmt.Errorf("解析交易索引失败: %v", err)
-	}
-
-	// 查询子通道合约
-	subContract, err := blockchain.GetSubContract(transactionIndexDTO.ChannelName, constants.InvestorOrganization)
-	if err != nil {
-		return fmt.Errorf("获取子通道合约失败: %v", err)
+		return nil, fmt.Errorf("解析交易索引失败: %v", err)
 	}
 
 	// 如果是新房或者税费，则收款人为GovernmentDefault
@@ -109,44 +162,329 @@ func (s *paymentService) PayForTransaction(dto *paymentDto.PayForTransactionDTO)
 		receiverCitizenIDHash = dto.ReceiverCitizenIDHash
 	}
 
-	_, err = subContract.SubmitTransaction(
-		"PayForTransaction",
-		dto.TransactionUUID,
-		paymentUUID,
-		dto.PaymentType,
-		fmt.Sprintf("%.2f", dto.Amount),
-		utils.GenerateHash(dto.PayerCitizenID),
-		dto.PayerOrganization,
-		receiverCitizenIDHash,
-		dto.ReceiverOrganization,
-	)
-	if err != nil {
-		return fmt.Errorf("支付交易失败: %v", err)
-	}
-
-	// 保存支付信息
-	err = s.paymentDAO.CreatePayment(&models.Payment{
-		PaymentUUID:           paymentUUID,
+	return &payForTransactionOperation{
+		PaymentUUID:           uuid.New().String(),
 		TransactionUUID:       dto.TransactionUUID,
+		ChannelName:           transactionIndexDTO.ChannelName,
 		PaymentType:           dto.PaymentType,
 		Amount:                dto.Amount,
 		PayerCitizenIDHash:    utils.GenerateHash(dto.PayerCitizenID),
 		PayerOrganization:     dto.PayerOrganization,
 		ReceiverCitizenIDHash: receiverCitizenIDHash,
 		ReceiverOrganization:  dto.ReceiverOrganization,
-		CreateTime:            time.Now(),
 		Remarks:               dto.Remarks,
-	})
-	if err != nil {
+	}, nil
+}
+
+// payForTransactionOperation 支付交易、创建独立支付业务操作的参数，独立支付的TransactionUUID为空
+type payForTransactionOperation struct {
+	PaymentUUID           string  `json:"paymentUUID"`
+	TransactionUUID       string  `json:"transactionUUID"`
+	ChannelName           string  `json:"channelName"`
+	RegionCode            string  `json:"regionCode,omitempty"` // 独立支付付款人的地区代码，用于注册支付索引
+	PaymentType           string  `json:"paymentType"`
+	Amount                float64 `json:"amount"`
+	PayerCitizenIDHash    string  `json:"payerCitizenIDHash"`
+	PayerOrganization     string  `json:"payerOrganization"`
+	ReceiverCitizenIDHash string  `json:"receiverCitizenIDHash"`
+	ReceiverOrganization  string  `json:"receiverOrganization"`
+	Remarks               string  `json:"remarks"`
+}
+
+// chaincodeArgs 链码PayForTransaction的参数
+func (op *payForTransactionOperation) chaincodeArgs() []string {
+	return []string{
+		op.TransactionUUID,
+		op.PaymentUUID,
+		op.PaymentType,
+		fmt.Sprintf("%.2f", op.Amount),
+		op.PayerCitizenIDHash,
+		op.PayerOrganization,
+		op.ReceiverCitizenIDHash,
+		op.ReceiverOrganization,
+	}
+}
+
+// savePayment 保存支付信息，支付已存在时覆盖
+func (s *paymentService) savePayment(op *payForTransactionOperation) error {
+	if err := s.paymentDAO.CreatePayment(&models.Payment{
+		PaymentUUID:           op.PaymentUUID,
+		TransactionUUID:       op.TransactionUUID,
+		PaymentType:           op.PaymentType,
+		Amount:                op.Amount,
+		PayerCitizenIDHash:    op.PayerCitizenIDHash,
+		PayerOrganization:     op.PayerOrganization,
+		ReceiverCitizenIDHash: op.ReceiverCitizenIDHash,
+		ReceiverOrganization:  op.ReceiverOrganization,
+		Status:                constants.PaymentStatusInitiated,
+		CreateTime:            time.Now(),
+		Remarks:               op.Remarks,
+	}); err != nil {
 		return fmt.Errorf("保存支付信息失败: %v", err)
 	}
+	return nil
+}
 
+// payForTransactionSteps 支付交易的业务操作步骤：链上支付进入托管、写入数据库
+func payForTransactionSteps() []operationStep {
+	parse := func(payload string) (*payForTransactionOperation, error) {
+		var op payForTransactionOperation
+		if err := json.Unmarshal([]byte(payload), &op); err != nil {
+			return nil, &operationAbortError{err: fmt.Errorf("解析支付参数失败: %v", err)}
+		}
+		return &op, nil
+	}
+
+	return []operationStep{
+		{
+			name: "PayForTransaction",
+			action: func(payload string) error {
+				op, err := parse(payload)
+				if err != nil {
+					return err
+				}
+				subContract, err := blockchain.GetSubContract(op.ChannelName, constants.InvestorOrganization)
+				if err != nil {
+					return fmt.Errorf("获取子通道合约失败: %v", err)
+				}
+				if _, err := subContract.EvaluateTransaction("QueryPayment", op.PaymentUUID); err == nil {
+					return nil
+				}
+				subContract, err = subContract.AsUser(op.PayerOrganization, op.PayerCitizenIDHash)
+				if err != nil {
+					return fmt.Errorf("获取付款人身份失败: %v", err)
+				}
+				_, err = subContract.SubmitTransaction("PayForTransaction", op.chaincodeArgs()...)
+				if err != nil {
+					return abortOperation(fmt.Errorf("支付交易失败: %w", err))
+				}
+				return nil
+			},
+			// 由银行冲正，款项退回付款人
+			compensate: func(payload string) error {
+				op, err := parse(payload)
+				if err != nil {
+					return err
+				}
+				return reverseUnfinishedPayment(op)
+			},
+		},
+		{
+			name: "SavePayment",
+			action: func(payload string) error {
+				op, err := parse(payload)
+				if err != nil {
+					return err
+				}
+				return GlobalPaymentService.(*paymentService).savePayment(op)
+			},
+		},
+	}
+}
+
+// reverseUnfinishedPayment 以银行身份冲正流程未完成的支付，已冲正时跳过
+func reverseUnfinishedPayment(op *payForTransactionOperation) error {
+	subContract, err := blockchain.GetSubContract(op.ChannelName, constants.BankOrganization)
+	if err != nil {
+		return fmt.Errorf("获取子通道合约失败: %v", err)
+	}
+	paymentBytes, err := subContract.EvaluateTransaction("QueryPayment", op.PaymentUUID)
+	if err != nil {
+		return fmt.Errorf("查询支付失败: %v", err)
+	}
+	var chaincodePayment struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(paymentBytes, &chaincodePayment); err != nil {
+		return fmt.Errorf("解析支付失败: %v", err)
+	}
+	if chaincodePayment.Status == constants.PaymentStatusReversed {
+		return nil
+	}
+	if _, err := subContract.SubmitTransaction("ReversePayment", op.PaymentUUID, "支付流程未完成，自动冲正"); err != nil {
+		return fmt.Errorf("冲正支付失败: %v", err)
+	}
 	return nil
 }
 
-// CreatePayment 创建支付
-func (s *paymentService) CreatePayment(req *paymentDto.CreatePaymentDTO) error {
-	panic("not implemented")
+// GetEscrowByTransactionUUID 查询交易资金托管账户
+func (s *paymentService) GetEscrowByTransactionUUID(transactionUUID string) (*paymentDto.EscrowDTO, error) {
+	mainContract, err := blockchain.GetMainContract(constants.InvestorOrganization)
+	if err != nil {
+		return nil, fmt.Errorf("获取合约失败: %v", err)
+	}
+
+	// 查询交易索引
+	transactionIndex, err := mainContract.EvaluateTransaction(
+		"GetTransactionIndex",
+		transactionUUID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询交易索引失败: %v", err)
+	}
+
+	var transactionIndexDTO block_dto.TransactionIndex
+	err = json.Unmarshal(transactionIndex, &transactionIndexDTO)
+	if err != nil {
+		return nil, fmt.Errorf("解析交易索引失败: %v", err)
+	}
+
+	subContract, err := blockchain.GetSubContract(transactionIndexDTO.ChannelName, constants.InvestorOrganization)
+	if err != nil {
+		return nil, fmt.Errorf("获取子通道合约失败: %v", err)
+	}
+
+	escrowBytes, err := subContract.EvaluateTransaction("QueryEscrow", transactionUUID)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询托管账户失败: %v", err))
+		return nil, fmt.Errorf("查询托管账户失败: %v", err)
+	}
+
+	var escrow paymentDto.EscrowDTO
+	if err := json.Unmarshal(escrowBytes, &escrow); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析托管账户失败: %v", err))
+		return nil, fmt.Errorf("解析托管账户失败: %v", err)
+	}
+
+	return &escrow, nil
+}
+
+// CreatePayment 创建不属于房产交易的独立支付，返回支付UUID和业务操作UUID
+// 支付位于付款人所在的子通道，主通道登记支付索引供银行核验、结算、冲正时定位子通道
+func (s *paymentService) CreatePayment(req *paymentDto.CreatePaymentDTO) (string, string, error) {
+	if req.Amount <= 0 {
+		return "", "", fmt.Errorf("支付金额必须大于0")
+	}
+	if len(req.PayerCitizenID) < 2 || req.ReceiverCitizenID == "" || req.ReceiverOrganization == "" {
+		return "", "", fmt.Errorf("付款人和收款人不能为空")
+	}
+
+	mainContract, err := blockchain.GetMainContract(constants.InvestorOrganization)
+	if err != nil {
+		return "", "", fmt.Errorf("获取合约失败: %v", err)
+	}
+	regionCode := req.PayerCitizenID[:2]
+	channelInfoBytes, err := mainContract.EvaluateTransaction("GetChannelInfoByRegionCode", regionCode)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取子通道信息失败: %v", err))
+		return "", "", fmt.Errorf("获取子通道信息失败: %v", err)
+	}
+	var channelInfo block_dto.ChannelInfo
+	if err := json.Unmarshal(channelInfoBytes, &channelInfo); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析子通道信息失败: %v", err))
+		return "", "", fmt.Errorf("解析子通道信息失败: %v", err)
+	}
+
+	op := &payForTransactionOperation{
+		PaymentUUID:           uuid.New().String(),
+		ChannelName:           channelInfo.ChannelName,
+		RegionCode:            regionCode,
+		PaymentType:           req.PaymentType,
+		Amount:                req.Amount,
+		PayerCitizenIDHash:    utils.GenerateHash(req.PayerCitizenID),
+		PayerOrganization:     req.PayerOrganization,
+		ReceiverCitizenIDHash: utils.GenerateHash(req.ReceiverCitizenID),
+		ReceiverOrganization:  req.ReceiverOrganization,
+		Remarks:               req.Remarks,
+	}
+
+	// 支付索引、链上支付和数据库记录由业务操作按步骤写入，失败后重试或冲正，重试时沿用同一个支付UUID
+	operation, err := GlobalOperationService.Submit(constants.OperationCreatePayment, op.PaymentUUID, op)
+	if err != nil {
+		return "", "", fmt.Errorf("创建支付失败: %v", err)
+	}
+
+	return op.PaymentUUID, operation.OperationUUID, nil
+}
+
+// createPaymentSteps 创建独立支付的业务操作步骤：注册支付索引、链上创建支付、写入数据库
+func createPaymentSteps() []operationStep {
+	parse := func(payload string) (*payForTransactionOperation, error) {
+		var op payForTransactionOperation
+		if err := json.Unmarshal([]byte(payload), &op); err != nil {
+			return nil, &operationAbortError{err: fmt.Errorf("解析支付参数失败: %v", err)}
+		}
+		return &op, nil
+	}
+
+	return []operationStep{
+		{
+			// 主通道没有删除索引的接口，补偿时保留索引，指向的支付会被冲正
+			name: "RegisterPaymentIndex",
+			action: func(payload string) error {
+				op, err := parse(payload)
+				if err != nil {
+					return err
+				}
+				mainContract, err := blockchain.GetMainContract(constants.InvestorOrganization)
+				if err != nil {
+					return fmt.Errorf("获取合约失败: %v", err)
+				}
+				if _, err := mainContract.EvaluateTransaction("GetPaymentIndex", op.PaymentUUID); err == nil {
+					return nil
+				}
+				mainContract, err = mainContract.AsUser(op.PayerOrganization, op.PayerCitizenIDHash)
+				if err != nil {
+					return fmt.Errorf("获取付款人身份失败: %v", err)
+				}
+				if _, err := mainContract.SubmitTransaction("RegisterPaymentIndex", op.PaymentUUID, op.RegionCode); err != nil {
+					return abortOperation(fmt.Errorf("创建支付索引失败: %w", err))
+				}
+				return nil
+			},
+		},
+		{
+			name: "CreatePayment",
+			action: func(payload string) error {
+				op, err := parse(payload)
+				if err != nil {
+					return err
+				}
+				subContract, err := blockchain.GetSubContract(op.ChannelName, constants.InvestorOrganization)
+				if err != nil {
+					return fmt.Errorf("获取子通道合约失败: %v", err)
+				}
+				if _, err := subContract.EvaluateTransaction("QueryPayment", op.PaymentUUID); err == nil {
+					return nil
+				}
+				subContract, err = subContract.AsUser(op.PayerOrganization, op.PayerCitizenIDHash)
+				if err != nil {
+					return fmt.Errorf("获取付款人身份失败: %v", err)
+				}
+				_, err = subContract.SubmitTransaction("CreatePayment",
+					op.PaymentUUID,
+					fmt.Sprintf("%.2f", op.Amount),
+					op.PayerCitizenIDHash,
+					op.PayerOrganization,
+					op.ReceiverCitizenIDHash,
+					op.ReceiverOrganization,
+					op.PaymentType,
+				)
+				if err != nil {
+					return abortOperation(fmt.Errorf("创建支付失败: %w", err))
+				}
+				return nil
+			},
+			// 由银行冲正，款项退回付款人
+			compensate: func(payload string) error {
+				op, err := parse(payload)
+				if err != nil {
+					return err
+				}
+				return reverseUnfinishedPayment(op)
+			},
+		},
+		{
+			name: "SavePayment",
+			action: func(payload string) error {
+				op, err := parse(payload)
+				if err != nil {
+					return err
+				}
+				return GlobalPaymentService.(*paymentService).savePayment(op)
+			},
+		},
+	}
 }
 
 // GetPaymentByUUID 根据UUID获取支付信息
@@ -166,6 +504,7 @@ func (s *paymentService) GetPaymentByUUID(paymentUUID string) (*paymentDto.Payme
 		PayerOrganization:     payment.PayerOrganization,
 		ReceiverCitizenIDHash: payment.ReceiverCitizenIDHash,
 		ReceiverOrganization:  payment.ReceiverOrganization,
+		Status:                payment.Status,
 		CreateTime:            payment.CreateTime,
 		Remarks:               payment.Remarks,
 	}, nil
@@ -222,6 +561,7 @@ func (s *paymentService) QueryPaymentList(dto *paymentDto.QueryPaymentDTO) ([]*p
 			PayerOrganization:     payment.PayerOrganization,
 			ReceiverCitizenIDHash: payment.ReceiverCitizenIDHash,
 			ReceiverOrganization:  payment.ReceiverOrganization,
+			Status:                payment.Status,
 			CreateTime:            payment.CreateTime,
 			Remarks:               payment.Remarks,
 		}
@@ -236,36 +576,87 @@ func (s *paymentService) QueryPaymentList(dto *paymentDto.QueryPaymentDTO) ([]*p
 	return result, total, nil
 }
 
-// VerifyPayment 验证支付
-func (s *paymentService) VerifyPayment(id string) error {
-	// 调用链码验证支付
-	contract, err := blockchain.GetMainContract(constants.BankOrganization)
+// VerifyPayment 银行核验支付
+func (s *paymentService) VerifyPayment(id string) (string, error) {
+	return s.submitPaymentAction(id, constants.PaymentStatusBankVerified, "VerifyPayment", id)
+}
+
+// CompletePayment 银行结算支付
+func (s *paymentService) CompletePayment(id string) (string, error) {
+	return s.submitPaymentAction(id, constants.PaymentStatusSettled, "SettlePayment", id)
+}
+
+// ReversePayment 银行冲正支付
+func (s *paymentService) ReversePayment(id string, req *paymentDto.ReversePaymentDTO) (string, error) {
+	return s.submitPaymentAction(id, constants.PaymentStatusReversed, "ReversePayment", id, req.Reason)
+}
+
+// submitPaymentAction 在支付所在子通道以银行身份异步提交支付状态变更并返回链上交易ID，上链后同步数据库中的支付状态
+func (s *paymentService) submitPaymentAction(id string, status string, name string, args ...string) (string, error) {
+	payment, err := s.paymentDAO.GetPaymentByUUID(id)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询支付信息失败: %v", err))
+		return "", fmt.Errorf("查询支付信息失败: %v", err)
+	}
+
+	indexFunction, indexKey, err := paymentChannelIndex(payment, name)
+	if err != nil {
+		return "", err
+	}
+
+	mainContract, err := blockchain.GetMainContract(constants.BankOrganization)
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("获取合约失败: %v", err))
-		return fmt.Errorf("获取合约失败: %v", err)
+		return "", fmt.Errorf("获取合约失败: %v", err)
 	}
-	_, err = contract.SubmitTransaction("VerifyPayment", id)
+
+	indexBytes, err := mainContract.EvaluateTransaction(indexFunction, indexKey)
 	if err != nil {
-		utils.Log.Error(fmt.Sprintf("验证支付失败: %v", err))
-		return fmt.Errorf("验证支付失败: %v", err)
+		utils.Log.Error(fmt.Sprintf("查询支付所在子通道失败: %v", err))
+		return "", fmt.Errorf("查询支付所在子通道失败: %v", err)
 	}
 
-	return nil
-}
+	var index struct {
+		ChannelName string `json:"channelName"`
+	}
+	if err := json.Unmarshal(indexBytes, &index); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析支付所在子通道失败: %v", err))
+		return "", fmt.Errorf("解析支付所在子通道失败: %v", err)
+	}
 
-// CompletePayment 完成支付
-func (s *paymentService) CompletePayment(id string) error {
-	// 调用链码完成支付
-	contract, err := blockchain.GetMainContract(constants.BankOrganization)
+	subContract, err := blockchain.GetSubContract(index.ChannelName, constants.BankOrganization)
 	if err != nil {
-		utils.Log.Error(fmt.Sprintf("获取合约失败: %v", err))
-		return fmt.Errorf("获取合约失败: %v", err)
+		utils.Log.Error(fmt.Sprintf("获取子通道合约失败: %v", err))
+		return "", fmt.Errorf("获取子通道合约失败: %v", err)
 	}
-	_, err = contract.SubmitTransaction("CompletePayment", id)
+
+	onCommit := func(txStatus *blockchain.ChainTxStatus) {
+		if txStatus.Status != blockchain.TxStatusCommitted {
+			return
+		}
+		payment.Status = status
+		if err := s.paymentDAO.UpdatePayment(payment); err != nil {
+			utils.Log.Error(fmt.Sprintf("保存支付状态失败: %v", err))
+		}
+	}
+	submitted, err := blockchain.SubmitAsync(subContract, name, onCommit, args...)
 	if err != nil {
-		utils.Log.Error(fmt.Sprintf("完成支付失败: %v", err))
-		return fmt.Errorf("完成支付失败: %v", err)
+		utils.Log.Error(fmt.Sprintf("更新支付状态失败: %v", err))
+		return "", fmt.Errorf("更新支付状态失败: %v", err)
 	}
 
-	return nil
+	return submitted.TxID, nil
+}
+
+// paymentChannelIndex 返回定位支付所在子通道的主通道索引查询函数和键
+// 交易支付与交易位于同一子通道，按交易索引查询；独立支付按支付索引查询
+// 交易支付由托管账户在过户时结算，结算请求在提交前拒绝
+func paymentChannelIndex(payment *models.Payment, name string) (string, string, error) {
+	if payment.TransactionUUID == "" {
+		return "GetPaymentIndex", payment.PaymentUUID, nil
+	}
+	if name == "SettlePayment" {
+		return "", "", fmt.Errorf("%w: %s", ErrEscrowPaymentSettle, payment.TransactionUUID)
+	}
+	return "GetTransactionIndex", payment.TransactionUUID, nil
 }
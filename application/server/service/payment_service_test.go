@@ -0,0 +1,40 @@
+package service
+
+import (
+	"errors"
+	"grets_server/db/models"
+	"testing"
+)
+
+func TestPaymentChannelIndex(t *testing.T) {
+	escrowPayment := &models.Payment{PaymentUUID: "payment-1", TransactionUUID: "transaction-1"}
+	standalonePayment := &models.Payment{PaymentUUID: "payment-2"}
+
+	testCaseList := []struct {
+		name     string
+		payment  *models.Payment
+		action   string
+		function string
+		key      string
+		err      error
+	}{
+		{"独立支付结算按支付索引定位", standalonePayment, "SettlePayment", "GetPaymentIndex", "payment-2", nil},
+		{"独立支付核验按支付索引定位", standalonePayment, "VerifyPayment", "GetPaymentIndex", "payment-2", nil},
+		{"独立支付冲正按支付索引定位", standalonePayment, "ReversePayment", "GetPaymentIndex", "payment-2", nil},
+		{"交易支付不能单独结算", escrowPayment, "SettlePayment", "", "", ErrEscrowPaymentSettle},
+		{"交易支付核验按交易索引定位", escrowPayment, "VerifyPayment", "GetTransactionIndex", "transaction-1", nil},
+		{"交易支付冲正按交易索引定位", escrowPayment, "ReversePayment", "GetTransactionIndex", "transaction-1", nil},
+	}
+
+	for _, testCase := range testCaseList {
+		t.Run(testCase.name, func(t *testing.T) {
+			function, key, err := paymentChannelIndex(testCase.payment, testCase.action)
+			if !errors.Is(err, testCase.err) {
+				t.Fatalf("错误 = %v, 期望 %v", err, testCase.err)
+			}
+			if function != testCase.function || key != testCase.key {
+				t.Errorf("索引 = %s(%s), 期望 %s(%s)", function, key, testCase.function, testCase.key)
+			}
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"grets_server/constants"
+	"grets_server/dao"
+	"grets_server/db/models"
+	projectionDto "grets_server/dto/projection_dto"
+	"grets_server/pkg/blockchain"
+	"grets_server/pkg/cache"
+	"grets_server/pkg/utils"
+	"sync"
+	"time"
+)
+
+// 投影失败后的重试间隔
+const projectionRetryInterval = 10 * time.Second
+
+// 全局投影服务实例
+var GlobalProjectionService ProjectionService
+
+// InitProjectionService 初始化投影服务
+func InitProjectionService(projectionDAO *dao.ProjectionDAO, paymentDAO *dao.PaymentDAO) {
+	GlobalProjectionService = NewProjectionService(projectionDAO, paymentDAO)
+	utils.Log.Info("投影服务初始化完成")
+}
+
+// ProjectionService 链上数据投影服务接口
+// 按通道读取链码事件，把房产、交易、支付的链上状态写入MySQL读模型，每个通道在MySQL中保存检查点
+type ProjectionService interface {
+	Start()
+	Rebuild() error
+	GetProjectionStatus() (*projectionDto.ProjectionStatusDTO, error)
+}
+
+// projectionService 投影服务实现
+type projectionService struct {
+	sync.Mutex
+	projectionDAO *dao.ProjectionDAO
+	paymentDAO    *dao.PaymentDAO
+	cacheService  cache.CacheService
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+	running       bool
+}
+
+// NewProjectionService 创建投影服务实例
+func NewProjectionService(projectionDAO *dao.ProjectionDAO, paymentDAO *dao.PaymentDAO) ProjectionService {
+	return &projectionService{
+		projectionDAO: projectionDAO,
+		paymentDAO:    paymentDAO,
+		cacheService:  cache.GetCacheService(),
+	}
+}
+
+// Start 从各通道的检查点开始投影链码事件
+func (s *projectionService) Start() {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.running {
+		return
+	}
+
+	var ctx context.Context
+	ctx, s.cancel = context.WithCancel(context.Background())
+	channelList := blockchain.ChaincodeEventChannelList()
+	for _, channelName := range channelList {
+		s.wg.Add(1)
+		go s.run(ctx, channelName)
+	}
+	s.running = true
+
+	utils.Log.Info(fmt.Sprintf("开始投影%d个通道的链码事件", len(channelList)))
+}
+
+// Rebuild 删除全部检查点并从0号区块重新投影，只更新链上字段，不删除已有记录
+func (s *projectionService) Rebuild() error {
+	s.Lock()
+	if s.running {
+		s.cancel()
+		s.running = false
+	}
+	s.Unlock()
+	s.wg.Wait()
+
+	if err := s.projectionDAO.DeleteCheckpointList(); err != nil {
+		utils.Log.Error(fmt.Sprintf("重建投影失败: %v", err))
+		return fmt.Errorf("重建投影失败: %v", err)
+	}
+
+	utils.Log.Info("已删除投影检查点，从0号区块重建投影")
+	s.Start()
+	return nil
+}
+
+// GetProjectionStatus 获取投影运行状态和各通道检查点
+func (s *projectionService) GetProjectionStatus() (*projectionDto.ProjectionStatusDTO, error) {
+	checkpointList, err := s.projectionDAO.QueryCheckpointList()
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询投影检查点失败: %v", err))
+		return nil, fmt.Errorf("查询投影检查点失败: %v", err)
+	}
+
+	s.Lock()
+	status := &projectionDto.ProjectionStatusDTO{
+		Running:        s.running,
+		CheckpointList: make([]*projectionDto.ProjectionCheckpointDTO, 0, len(checkpointList)),
+	}
+	s.Unlock()
+
+	for _, checkpoint := range checkpointList {
+		status.CheckpointList = append(status.CheckpointList, &projectionDto.ProjectionCheckpointDTO{
+			ChannelName:   checkpoint.ChannelName,
+			BlockNumber:   checkpoint.BlockNumber,
+			TransactionID: checkpoint.TransactionID,
+			UpdateTime:    checkpoint.UpdateTime,
+		})
+	}
+	return status, nil
+}
+
+// run 投影单个通道的链码事件，读取中断或写入失败后从检查点重试
+func (s *projectionService) run(ctx context.Context, channelName string) {
+	defer s.wg.Done()
+
+	for {
+		if err := s.projectChannel(ctx, channelName); err != nil {
+			utils.Log.Error(fmt.Sprintf("投影通道[%s]链码事件失败，准备重试: %v", channelName, err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(projectionRetryInterval):
+		}
+	}
+}
+
+// projectChannel 从检查点读取通道的链码事件并逐个写入MySQL，直到出错或ctx取消
+func (s *projectionService) projectChannel(ctx context.Context, channelName string) error {
+	checkpoint, err := s.projectionDAO.GetCheckpoint(channelName)
+	if err != nil {
+		return err
+	}
+	var blockNumber uint64
+	var transactionID string
+	if checkpoint != nil {
+		blockNumber = checkpoint.BlockNumber
+		transactionID = checkpoint.TransactionID
+	}
+
+	eventCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, err := blockchain.ChaincodeEventsFrom(eventCtx, channelName, blockNumber, transactionID)
+	if err != nil {
+		return err
+	}
+
+	for event := range events {
+		if err := s.project(event); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return fmt.Errorf("链码事件读取中断")
+}
+
+// project 把单个链码事件写入MySQL并保存检查点
+// 只使用事件中的数据，不查询链上最新状态，从0号区块重建时每个事件都还原为当时的状态
+// 事件内容无法解析时记录日志后跳过，写入数据库失败时返回错误，由调用方重试
+func (s *projectionService) project(event *blockchain.ChaincodeEvent) error {
+	projection := parseProjection(event)
+
+	// 交易终止或完成时托管款项随之退款/放款，链码不单独发送支付事件，按交易事件更新该交易尚未结算的支付
+	var paymentList []*models.Payment
+	if projection.escrowPaymentStatus != "" {
+		transactionPaymentList, err := s.paymentDAO.GetPaymentListByTransactionUUIDList([]string{projection.escrowTransactionUUID})
+		if err != nil {
+			return err
+		}
+		paymentList = settleEscrowPaymentList(transactionPaymentList, projection.escrowPaymentStatus)
+	}
+	paymentList = append(paymentList, projection.paymentList...)
+
+	checkpoint := &models.ProjectionCheckpoint{
+		ChannelName:   event.ChannelName,
+		BlockNumber:   event.BlockNumber,
+		TransactionID: event.TxID,
+	}
+	if err := s.projectionDAO.SaveProjection(checkpoint, projection.realtyList, projection.transactionList, paymentList); err != nil {
+		return err
+	}
+
+	for _, realty := range projection.realtyList {
+		if realty.RealtyCert != "" {
+			s.cacheService.Remove(cache.RealtyPrefix + "cert:" + realty.RealtyCert)
+		}
+		s.cacheService.Remove(cache.RealtyPrefix + "hash:" + realty.RealtyCertHash)
+	}
+	for _, transaction := range projection.transactionList {
+		s.cacheService.Remove(cache.TransactionPrefix + "uuid:" + transaction.TransactionUUID)
+	}
+	return nil
+}
+
+// eventProjection 单个链码事件对应的读模型变更
+type eventProjection struct {
+	realtyList      []*models.Realty
+	transactionList []*models.Transaction
+	paymentList     []*models.Payment
+	// 交易终止或完成时托管支付应变更的状态及所属交易
+	escrowPaymentStatus   string
+	escrowTransactionUUID string
+}
+
+// parseProjection 解析链码事件中的房产、交易、支付数据，房产只包含事件中出现的字段，未出现的字段为空、不更新
+func parseProjection(event *blockchain.ChaincodeEvent) *eventProjection {
+	projection := &eventProjection{}
+
+	switch event.EventType {
+	case constants.EventRealtyCreated, constants.EventRealtyUpdated:
+		var payload struct {
+			RealtyCertHash            string `json:"realtyCertHash"`
+			RealtyCert                string `json:"realtyCert"`
+			RealtyType                string `json:"realtyType"`
+			Status                    string `json:"status"`
+			CurrentOwnerCitizenIDHash string `json:"currentOwnerCitizenIDHash"`
+			CurrentOwnerOrganization  string `json:"currentOwnerOrganization"`
+		}
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			utils.Log.Error(fmt.Sprintf("解析房产事件失败，跳过, txID: %s, error: %v", event.TxID, err))
+			break
+		}
+		projection.realtyList = append(projection.realtyList, &models.Realty{
+			RealtyCert:                payload.RealtyCert,
+			RealtyCertHash:            payload.RealtyCertHash,
+			RealtyType:                payload.RealtyType,
+			Status:                    payload.Status,
+			CurrentOwnerCitizenIDHash: payload.CurrentOwnerCitizenIDHash,
+			CurrentOwnerOrganization:  payload.CurrentOwnerOrganization,
+			CreateTime:                event.Timestamp,
+		})
+
+	case constants.EventTransactionCreated, constants.EventTransactionUpdated, constants.EventTransactionCompleted:
+		var payload struct {
+			TransactionUUID          string `json:"transactionUUID"`
+			RealtyCertHash           string `json:"realtyCertHash"`
+			SellerCitizenIDHash      string `json:"sellerCitizenIDHash"`
+			SellerOrganization       string `json:"sellerOrganization"`
+			BuyerCitizenIDHash       string `json:"buyerCitizenIDHash"`
+			BuyerOrganization        string `json:"buyerOrganization"`
+			Status                   string `json:"status"`
+			RealtyStatus             string `json:"realtyStatus"`
+			RealtyOwnerCitizenIDHash string `json:"realtyOwnerCitizenIDHash"`
+			RealtyOwnerOrganization  string `json:"realtyOwnerOrganization"`
+		}
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			utils.Log.Error(fmt.Sprintf("解析交易事件失败，跳过, txID: %s, error: %v", event.TxID, err))
+			break
+		}
+		projection.transactionList = append(projection.transactionList, &models.Transaction{
+			TransactionUUID:     payload.TransactionUUID,
+			RealtyCertHash:      payload.RealtyCertHash,
+			SellerCitizenIDHash: payload.SellerCitizenIDHash,
+			SellerOrganization:  payload.SellerOrganization,
+			BuyerCitizenIDHash:  payload.BuyerCitizenIDHash,
+			BuyerOrganization:   payload.BuyerOrganization,
+			Status:              payload.Status,
+			CreateTime:          event.Timestamp,
+		})
+
+		// 同一笔链上交易中的房产加锁、过户、解锁记录在交易事件中，房产未变更时为空
+		if payload.RealtyStatus != "" {
+			projection.realtyList = append(projection.realtyList, &models.Realty{
+				RealtyCertHash:            payload.RealtyCertHash,
+				Status:                    payload.RealtyStatus,
+				CurrentOwnerCitizenIDHash: payload.RealtyOwnerCitizenIDHash,
+				CurrentOwnerOrganization:  payload.RealtyOwnerOrganization,
+			})
+		}
+
+		switch payload.Status {
+		case constants.TxStatusCompleted:
+			projection.escrowPaymentStatus = constants.PaymentStatusSettled
+		case constants.TxStatusRejected, constants.TxStatusCancelled, constants.TxStatusExpired:
+			projection.escrowPaymentStatus = constants.PaymentStatusReversed
+		}
+		projection.escrowTransactionUUID = payload.TransactionUUID
+
+	case constants.EventMortgageUpdated:
+		var payload struct {
+			RealtyCertHash string `json:"realtyCertHash"`
+			RealtyStatus   string `json:"realtyStatus"`
+		}
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			utils.Log.Error(fmt.Sprintf("解析抵押事件失败，跳过, txID: %s, error: %v", event.TxID, err))
+			break
+		}
+
+		// 抵押批准、解除会变更房产状态，以事件中记录的房产状态为准
+		if payload.RealtyStatus != "" {
+			projection.realtyList = append(projection.realtyList, &models.Realty{
+				RealtyCertHash: payload.RealtyCertHash,
+				Status:         payload.RealtyStatus,
+			})
+		}
+
+	case constants.EventPaymentMade, constants.EventPaymentUpdated:
+		var payload struct {
+			PaymentUUID           string  `json:"paymentUUID"`
+			TransactionUUID       string  `json:"transactionUUID"`
+			PaymentType           string  `json:"paymentType"`
+			Amount                float64 `json:"amount"`
+			PayerCitizenIDHash    string  `json:"payerCitizenIDHash"`
+			PayerOrganization     string  `json:"payerOrganization"`
+			ReceiverCitizenIDHash string  `json:"receiverCitizenIDHash"`
+			ReceiverOrganization  string  `json:"receiverOrganization"`
+			Status                string  `json:"status"`
+		}
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			utils.Log.Error(fmt.Sprintf("解析支付事件失败，跳过, txID: %s, error: %v", event.TxID, err))
+			break
+		}
+		projection.paymentList = append(projection.paymentList, &models.Payment{
+			PaymentUUID:           payload.PaymentUUID,
+			TransactionUUID:       payload.TransactionUUID,
+			PaymentType:           payload.PaymentType,
+			Amount:                payload.Amount,
+			PayerCitizenIDHash:    payload.PayerCitizenIDHash,
+			PayerOrganization:     payload.PayerOrganization,
+			ReceiverCitizenIDHash: payload.ReceiverCitizenIDHash,
+			ReceiverOrganization:  payload.ReceiverOrganization,
+			Status:                payload.Status,
+			CreateTime:            event.Timestamp,
+		})
+	}
+
+	if projection.escrowPaymentStatus == "" {
+		projection.escrowTransactionUUID = ""
+	}
+	return projection
+}
+
+// settleEscrowPaymentList 交易终止或完成时，交易的支付均为托管存入，尚未结算的按链码规则变为已结算或已冲正
+// paymentList为读模型中按事件顺序投影到当前事件时的支付，重放时结果与当时一致
+func settleEscrowPaymentList(paymentList []*models.Payment, status string) []*models.Payment {
+	var settledList []*models.Payment
+	for _, payment := range paymentList {
+		if payment.Status == constants.PaymentStatusSettled || payment.Status == constants.PaymentStatusReversed {
+			continue
+		}
+		payment.Status = status
+		settledList = append(settledList, payment)
+	}
+	return settledList
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"encoding/json"
+	"grets_server/constants"
+	"grets_server/db/models"
+	"grets_server/pkg/blockchain"
+	"grets_server/pkg/utils"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// newTestChaincodeEvent 构造子通道上的链码事件
+func newTestChaincodeEvent(t *testing.T, eventType string, payload interface{}) *blockchain.ChaincodeEvent {
+	t.Helper()
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("序列化事件内容失败: %v", err)
+	}
+	return &blockchain.ChaincodeEvent{
+		ChannelName: "shanghaichannel",
+		EventType:   eventType,
+		TxID:        "tx-" + eventType,
+		Timestamp:   time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		Payload:     payloadBytes,
+	}
+}
+
+func TestParseProjectionReplay(t *testing.T) {
+	utils.Log = zap.NewNop()
+
+	transaction := map[string]interface{}{
+		"transactionUUID":     "transaction-1",
+		"realtyCertHash":      "realty-1",
+		"sellerCitizenIDHash": "seller",
+		"sellerOrganization":  "investor",
+		"buyerCitizenIDHash":  "buyer",
+		"buyerOrganization":   "investor",
+	}
+	withFields := func(fields map[string]interface{}) map[string]interface{} {
+		payload := map[string]interface{}{}
+		for key, value := range transaction {
+			payload[key] = value
+		}
+		for key, value := range fields {
+			payload[key] = value
+		}
+		return payload
+	}
+
+	// 按区块顺序重放同一笔交易的事件，每个事件只还原当时的房产状态
+	testCaseList := []struct {
+		name          string
+		event         *blockchain.ChaincodeEvent
+		realty        *models.Realty
+		paymentStatus string
+	}{
+		{
+			name: "创建交易时房产加锁",
+			event: newTestChaincodeEvent(t, constants.EventTransactionCreated, withFields(map[string]interface{}{
+				"status":                   constants.TxStatusPending,
+				"realtyStatus":             constants.RealtyStatusInSale,
+				"realtyOwnerCitizenIDHash": "seller",
+				"realtyOwnerOrganization":  "investor",
+			})),
+			realty: &models.Realty{RealtyCertHash: "realty-1", Status: constants.RealtyStatusInSale, CurrentOwnerCitizenIDHash: "seller", CurrentOwnerOrganization: "investor"},
+		},
+		{
+			name: "确认步骤不变更房产",
+			event: newTestChaincodeEvent(t, constants.EventTransactionUpdated, withFields(map[string]interface{}{
+				"status": constants.TxStatusPending,
+				"step":   "BUYER_SIGNED",
+			})),
+		},
+		{
+			name: "过户后房产归买方",
+			event: newTestChaincodeEvent(t, constants.EventTransactionCompleted, withFields(map[string]interface{}{
+				"status":                   constants.TxStatusCompleted,
+				"step":                     "TITLE_TRANSFERRED",
+				"realtyStatus":             constants.RealtyStatusNormal,
+				"realtyOwnerCitizenIDHash": "buyer",
+				"realtyOwnerOrganization":  "investor",
+			})),
+			realty:        &models.Realty{RealtyCertHash: "realty-1", Status: constants.RealtyStatusNormal, CurrentOwnerCitizenIDHash: "buyer", CurrentOwnerOrganization: "investor"},
+			paymentStatus: constants.PaymentStatusSettled,
+		},
+		{
+			name: "取消交易退还托管资金",
+			event: newTestChaincodeEvent(t, constants.EventTransactionUpdated, withFields(map[string]interface{}{
+				"status":                   constants.TxStatusCancelled,
+				"realtyStatus":             constants.RealtyStatusPendingSale,
+				"realtyOwnerCitizenIDHash": "seller",
+				"realtyOwnerOrganization":  "investor",
+			})),
+			realty:        &models.Realty{RealtyCertHash: "realty-1", Status: constants.RealtyStatusPendingSale, CurrentOwnerCitizenIDHash: "seller", CurrentOwnerOrganization: "investor"},
+			paymentStatus: constants.PaymentStatusReversed,
+		},
+		{
+			name: "抵押事件只更新房产状态",
+			event: newTestChaincodeEvent(t, constants.EventMortgageUpdated, map[string]interface{}{
+				"mortgageUUID":   "mortgage-1",
+				"realtyCertHash": "realty-1",
+				"status":         "ACTIVE",
+				"realtyStatus":   constants.RealtyStatusInMortgage,
+			}),
+			realty: &models.Realty{RealtyCertHash: "realty-1", Status: constants.RealtyStatusInMortgage},
+		},
+	}
+
+	for _, testCase := range testCaseList {
+		t.Run(testCase.name, func(t *testing.T) {
+			projection := parseProjection(testCase.event)
+
+			if testCase.realty == nil {
+				if len(projection.realtyList) != 0 {
+					t.Errorf("不应更新房产: %+v", projection.realtyList[0])
+				}
+			} else {
+				if len(projection.realtyList) != 1 {
+					t.Fatalf("更新的房产数 = %d, 期望 1", len(projection.realtyList))
+				}
+				realty := projection.realtyList[0]
+				if realty.RealtyCertHash != testCase.realty.RealtyCertHash || realty.Status != testCase.realty.Status ||
+					realty.CurrentOwnerCitizenIDHash != testCase.realty.CurrentOwnerCitizenIDHash ||
+					realty.CurrentOwnerOrganization != testCase.realty.CurrentOwnerOrganization {
+					t.Errorf("房产 = %+v, 期望 %+v", realty, testCase.realty)
+				}
+			}
+
+			if projection.escrowPaymentStatus != testCase.paymentStatus {
+				t.Errorf("托管支付状态 = %q, 期望 %q", projection.escrowPaymentStatus, testCase.paymentStatus)
+			}
+			if testCase.paymentStatus != "" && projection.escrowTransactionUUID != "transaction-1" {
+				t.Errorf("托管支付所属交易 = %q, 期望 transaction-1", projection.escrowTransactionUUID)
+			}
+		})
+	}
+}
+
+func TestSettleEscrowPaymentList(t *testing.T) {
+	paymentList := []*models.Payment{
+		{PaymentUUID: "payment-1", Status: constants.PaymentStatusBankVerified},
+		{PaymentUUID: "payment-2", Status: constants.PaymentStatusReversed},
+		{PaymentUUID: "payment-3", Status: constants.PaymentStatusInitiated},
+	}
+
+	settledList := settleEscrowPaymentList(paymentList, constants.PaymentStatusSettled)
+
+	// 已冲正的支付保持不变，其余变为已结算
+	if len(settledList) != 2 || settledList[0].PaymentUUID != "payment-1" || settledList[1].PaymentUUID != "payment-3" {
+		t.Fatalf("结算的支付 = %v, 期望 [payment-1 payment-3]", settledList)
+	}
+	for _, payment := range settledList {
+		if payment.Status != constants.PaymentStatusSettled {
+			t.Errorf("支付[%s]状态 = %s, 期望 %s", payment.PaymentUUID, payment.Status, constants.PaymentStatusSettled)
+		}
+	}
+	if paymentList[1].Status != constants.PaymentStatusReversed {
+		t.Errorf("已冲正的支付不应变更")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"grets_server/config"
 	"grets_server/constants"
 	"grets_server/dao"
 	"grets_server/db/models"
@@ -37,6 +38,11 @@ type RealtyService interface {
 	GetRealtyByRealtyCert(realtyCert string) (*realtyDto.RealtyDTO, error)
 	GetRealtyByRealtyCertHash(realtyCertHash string) (*realtyDto.RealtyDTO, error)
 	QueryRealtyByOrganizationAndCitizenID(organization string, citizenID string) ([]*realtyDto.RealtyDTO, error)
+	FreezeRealty(realtyCertHash string, organization string, req *realtyDto.FreezeRealtyDTO) (string, error)
+	UnfreezeRealty(realtyCertHash string, organization string, req *realtyDto.UnfreezeRealtyDTO) (string, error)
+	SetRealtyOwnerList(realtyCertHash string, req *realtyDto.SetRealtyOwnerListDTO) error
+	QueryRealtyHistory(realtyCertHash string, pageSize int, pageNumber int) ([]*realtyDto.RealtyHistoryDTO, int, error)
+	ReleaseExpiredFreezes() error
 }
 
 // realtyService 房产服务实现
@@ -53,55 +59,59 @@ func NewRealtyService(realtyDAO *dao.RealEstateDAO) RealtyService {
 	}
 }
 
+// QueryRealtyByOrganizationAndCitizenID 查询用户作为所有者或共有人的房产
 func (r *realtyService) QueryRealtyByOrganizationAndCitizenID(organization string, citizenID string) ([]*realtyDto.RealtyDTO, error) {
-	mainContract, err := blockchain.GetMainContract(constants.GovernmentOrganization)
-	if err != nil {
-		utils.Log.Error(fmt.Sprintf("获取合约失败: %v", err))
-		return nil, fmt.Errorf("获取合约失败: %v", err)
-	}
-
 	if organization == constants.GovernmentOrganization {
 		citizenID = "GovernmentDefault"
 	}
+	citizenIDHash := utils.GenerateHash(citizenID)
 
-	// 创建房产查询条件
-	var conditions = map[string]interface{}{
-		"currentOwnerOrganization":  organization,
-		"currentOwnerCitizenIDHash": utils.GenerateHash(citizenID),
-	}
-	conditionsJSON, err := json.Marshal(conditions)
-	if err != nil {
-		utils.Log.Error(fmt.Sprintf("序列化查询条件失败: %v", err))
-		return nil, fmt.Errorf("序列化查询条件失败: %v", err)
-	}
+	// 共有人只记录在子通道，逐个子通道查询
+	realtyCertHashSet := make(map[string]bool)
+	var realtyCertHashList []string
+	for _, channelName := range config.GlobalConfig.Fabric.SubChannelName {
+		subContract, err := blockchain.GetSubContract(channelName, constants.GovernmentOrganization)
+		if err != nil {
+			utils.Log.Error(fmt.Sprintf("获取子通道合约失败: %v", err))
+			return nil, fmt.Errorf("获取子通道合约失败: %v", err)
+		}
 
-	realtyIndicesBytes, err := mainContract.EvaluateTransaction(
-		"QueryRealtyIndexByConditions",
-		string(conditionsJSON),
-	)
-	if err != nil {
-		utils.Log.Error(fmt.Sprintf("查询房产索引失败: %v", err))
-		return nil, fmt.Errorf("查询房产索引失败: %v", err)
-	}
-	if realtyIndicesBytes == nil {
-		return []*realtyDto.RealtyDTO{}, nil
-	}
+		resultBytes, err := subContract.EvaluateTransaction(
+			"QueryRealtyByOrganizationAndCitizenIDHash",
+			organization,
+			citizenIDHash,
+		)
+		if err != nil {
+			utils.Log.Error(fmt.Sprintf("查询房产失败: %v", err))
+			return nil, fmt.Errorf("查询房产失败: %v", err)
+		}
+		if len(resultBytes) == 0 {
+			continue
+		}
 
-	var realtyIndices []*blockDto.RealtyIndex
-	if err := json.Unmarshal(realtyIndicesBytes, &realtyIndices); err != nil {
-		utils.Log.Error(fmt.Sprintf("解析房产索引失败: %v", err))
-		return nil, fmt.Errorf("解析房产索引失败: %v", err)
+		var chaincodeRealtyList []*realtyDto.RealtyDTO
+		if err := json.Unmarshal(resultBytes, &chaincodeRealtyList); err != nil {
+			utils.Log.Error(fmt.Sprintf("解析房产信息失败: %v", err))
+			return nil, fmt.Errorf("解析房产信息失败: %v", err)
+		}
+		for _, chaincodeRealty := range chaincodeRealtyList {
+			if realtyCertHashSet[chaincodeRealty.RealtyCertHash] {
+				continue
+			}
+			realtyCertHashSet[chaincodeRealty.RealtyCertHash] = true
+			realtyCertHashList = append(realtyCertHashList, chaincodeRealty.RealtyCertHash)
+		}
 	}
 
 	var realtyList []*realtyDto.RealtyDTO
-	for _, realtyIndex := range realtyIndices {
-		realty, err := r.GetRealtyByRealtyCertHash(realtyIndex.RealtyCertHash)
+	for _, realtyCertHash := range realtyCertHashList {
+		realty, err := r.GetRealtyByRealtyCertHash(realtyCertHash)
 		if err != nil {
 			return nil, fmt.Errorf("查询房产失败: %v", err)
 		}
 		realtyList = append(realtyList, realty)
 		// 创建缓存
-		r.cacheService.Set(cache.RealtyPrefix+"hash:"+realtyIndex.RealtyCertHash, realty, 0, 5*time.Minute)
+		r.cacheService.Set(cache.RealtyPrefix+"hash:"+realtyCertHash, realty, 0, 5*time.Minute)
 	}
 
 	return realtyList, nil
@@ -229,6 +239,7 @@ func (s *realtyService) GetRealtyByRealtyCertHash(realtyCertHash string) (*realt
 		CurrentOwnerCitizenIDHash:       blockchainResult.CurrentOwnerCitizenIDHash,
 		CurrentOwnerOrganization:        blockchainResult.CurrentOwnerOrganization,
 		PreviousOwnersCitizenIDHashList: blockchainResult.PreviousOwnersCitizenIDHashList,
+		OwnerList:                       blockchainResult.OwnerList,
 		Status:                          realty.Status,
 		Description:                     realty.Description,
 		Images:                          realty.Images,
@@ -245,6 +256,8 @@ func (s *realtyService) GetRealtyByRealtyCertHash(realtyCertHash string) (*realt
 		CreateTime:                      realty.CreateTime,
 		LastUpdateTime:                  realty.UpdateTime,
 		RelContractUUID:                 realty.RelContractUUID,
+		ActiveTransactionUUID:           blockchainResult.ActiveTransactionUUID,
+		FreezeHistory:                   blockchainResult.FreezeHistory,
 	}
 
 	// 将结果存入缓存，设置5分钟过期时间
@@ -691,7 +704,10 @@ func (s *realtyService) UpdateRealty(req *realtyDto.UpdateRealtyDTO) error {
 		realty.HouseType = req.HouseType
 	}
 	if req.Status != "" {
-		realty.Status = req.Status
+		// 抵押中的房产挂牌、撤牌时链上保持抵押中，挂牌状态记录在抵押上
+		if realty.Status != constants.RealtyStatusInMortgage {
+			realty.Status = req.Status
+		}
 		if req.Status != constants.RealtyStatusPendingSale {
 			realty.RelContractUUID = ""
 		}
@@ -718,3 +734,257 @@ func (s *realtyService) UpdateRealty(req *realtyDto.UpdateRealtyDTO) error {
 
 	return nil
 }
+
+// FreezeRealty 依据法律文书司法冻结房产，异步提交并返回链上交易ID
+func (s *realtyService) FreezeRealty(realtyCertHash string, organization string, req *realtyDto.FreezeRealtyDTO) (string, error) {
+	subContract, err := s.getSubContractByRealtyCertHash(realtyCertHash, organization)
+	if err != nil {
+		return "", err
+	}
+
+	submitted, err := blockchain.SubmitAsync(subContract,
+		"FreezeRealty",
+		s.syncRealtyStatusOnCommit(subContract, realtyCertHash),
+		realtyCertHash,
+		req.CourtOrderHash,
+		req.Authority,
+		req.ExpireTime.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("冻结房产失败: %v", err))
+		return "", fmt.Errorf("冻结房产失败: %v", err)
+	}
+
+	return submitted.TxID, nil
+}
+
+// UnfreezeRealty 解除房产司法冻结，异步提交并返回链上交易ID
+func (s *realtyService) UnfreezeRealty(realtyCertHash string, organization string, req *realtyDto.UnfreezeRealtyDTO) (string, error) {
+	subContract, err := s.getSubContractByRealtyCertHash(realtyCertHash, organization)
+	if err != nil {
+		return "", err
+	}
+
+	submitted, err := blockchain.SubmitAsync(subContract, "UnfreezeRealty", s.syncRealtyStatusOnCommit(subContract, realtyCertHash),
+		realtyCertHash,
+		req.Reason,
+	)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("解冻房产失败: %v", err))
+		return "", fmt.Errorf("解冻房产失败: %v", err)
+	}
+
+	return submitted.TxID, nil
+}
+
+// syncRealtyStatusOnCommit 房产状态在交易上链后才变更，上链后将链上房产状态同步到数据库
+func (s *realtyService) syncRealtyStatusOnCommit(subContract *blockchain.Contract, realtyCertHash string) func(status *blockchain.ChainTxStatus) {
+	return func(status *blockchain.ChainTxStatus) {
+		if status.Status != blockchain.TxStatusCommitted {
+			return
+		}
+		if err := s.syncRealtyStatus(subContract, realtyCertHash); err != nil {
+			utils.Log.Error(fmt.Sprintf("同步房产[%s]状态失败: %v", realtyCertHash, err))
+		}
+	}
+}
+
+// SetRealtyOwnerList 以政府身份登记房产共有人及份额
+func (s *realtyService) SetRealtyOwnerList(realtyCertHash string, req *realtyDto.SetRealtyOwnerListDTO) error {
+	ownerList := make([]*realtyDto.RealtyOwnerDTO, 0, len(req.OwnerList))
+	for _, owner := range req.OwnerList {
+		ownerList = append(ownerList, &realtyDto.RealtyOwnerDTO{
+			CitizenIDHash: utils.GenerateHash(owner.CitizenID),
+			Organization:  owner.Organization,
+			Share:         owner.Share,
+		})
+	}
+	ownerListJSON, err := json.Marshal(ownerList)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("序列化共有人列表失败: %v", err))
+		return fmt.Errorf("序列化共有人列表失败: %v", err)
+	}
+
+	subContract, err := s.getSubContractByRealtyCertHash(realtyCertHash, constants.GovernmentOrganization)
+	if err != nil {
+		return err
+	}
+
+	_, err = subContract.SubmitTransaction("SetRealtyOwnerList", realtyCertHash, string(ownerListJSON))
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("登记房产共有人失败: %v", err))
+		return fmt.Errorf("登记房产共有人失败: %v", err)
+	}
+
+	// 主通道索引记录主登记所有者
+	mainContract, err := blockchain.GetMainContract(constants.GovernmentOrganization)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取主通道合约失败: %v", err))
+		return fmt.Errorf("获取主通道合约失败: %v", err)
+	}
+	_, err = mainContract.SubmitTransaction(
+		"UpdateRealtyIndex",
+		realtyCertHash,
+		ownerList[0].CitizenIDHash,
+		ownerList[0].Organization,
+	)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("更新房产索引失败: %v", err))
+		return fmt.Errorf("更新房产索引失败: %v", err)
+	}
+
+	return s.syncRealtyStatus(subContract, realtyCertHash)
+}
+
+// QueryRealtyHistory 分页查询房产链上历史版本，按写入时间从早到晚排列
+func (s *realtyService) QueryRealtyHistory(realtyCertHash string, pageSize int, pageNumber int) ([]*realtyDto.RealtyHistoryDTO, int, error) {
+	subContract, err := s.getSubContractByRealtyCertHash(realtyCertHash, constants.GovernmentOrganization)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	historyBytes, err := subContract.EvaluateTransaction("QueryRealtyHistory", realtyCertHash)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询房产历史失败: %v", err))
+		return nil, 0, fmt.Errorf("查询房产历史失败: %v", err)
+	}
+
+	var historyList []*realtyDto.RealtyHistoryDTO
+	if err := json.Unmarshal(historyBytes, &historyList); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析房产历史失败: %v", err))
+		return nil, 0, fmt.Errorf("解析房产历史失败: %v", err)
+	}
+
+	// 链码一次返回全部版本，在此分页
+	total := len(historyList)
+	start := (pageNumber - 1) * pageSize
+	if start >= total {
+		return []*realtyDto.RealtyHistoryDTO{}, total, nil
+	}
+	end := min(start+pageSize, total)
+
+	return historyList[start:end], total, nil
+}
+
+// ReleaseExpiredFreezes 以政府身份解冻所有冻结期限届满的房产
+func (s *realtyService) ReleaseExpiredFreezes() error {
+	realtyList, err := s.realtyDAO.QueryRealEstates("", constants.RealtyStatusFrozen, "")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, realty := range realtyList {
+		subContract, err := s.getSubContractByRealtyCertHash(realty.RealtyCertHash, constants.GovernmentOrganization)
+		if err != nil {
+			continue
+		}
+		chaincodeRealty, err := s.queryChaincodeRealty(subContract, realty.RealtyCertHash)
+		if err != nil {
+			continue
+		}
+
+		expired := false
+		for _, freeze := range chaincodeRealty.FreezeHistory {
+			if freeze.Status == constants.FreezeStatusActive && !now.Before(freeze.ExpireTime) {
+				expired = true
+			}
+		}
+		if !expired {
+			continue
+		}
+
+		_, err = s.UnfreezeRealty(realty.RealtyCertHash, constants.GovernmentOrganization, &realtyDto.UnfreezeRealtyDTO{
+			Reason: "冻结期限届满自动解冻",
+		})
+		if err != nil {
+			utils.Log.Error(fmt.Sprintf("自动解冻房产[%s]失败: %v", realty.RealtyCertHash, err))
+			continue
+		}
+		utils.Log.Info(fmt.Sprintf("房产[%s]冻结期限届满，已自动解冻", realty.RealtyCertHash))
+	}
+
+	return nil
+}
+
+// StartFreezeExpiryWatcher 定期检查并解冻冻结期限届满的房产
+func StartFreezeExpiryWatcher(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := GlobalRealtyService.ReleaseExpiredFreezes(); err != nil {
+				utils.Log.Error(fmt.Sprintf("检查冻结到期房产失败: %v", err))
+			}
+		}
+	}()
+}
+
+// syncRealtyStatus 将链上房产状态同步到数据库
+func (s *realtyService) syncRealtyStatus(subContract *blockchain.Contract, realtyCertHash string) error {
+	chaincodeRealty, err := s.queryChaincodeRealty(subContract, realtyCertHash)
+	if err != nil {
+		return err
+	}
+
+	realty, err := s.realtyDAO.GetRealtyByRealtyCertHash(realtyCertHash)
+	if err != nil {
+		return fmt.Errorf("查询房产失败: %v", err)
+	}
+	realty.Status = chaincodeRealty.Status
+	if err := s.realtyDAO.UpdateRealEstate(realty); err != nil {
+		return fmt.Errorf("更新房产失败: %v", err)
+	}
+
+	s.cacheService.Remove(cache.RealtyPrefix + "cert:" + realty.RealtyCert)
+	s.cacheService.Remove(cache.RealtyPrefix + "hash:" + realtyCertHash)
+	if realty.ID > 0 {
+		s.cacheService.Remove(cache.RealtyPrefix + "id:" + fmt.Sprintf("%d", realty.ID))
+	}
+	return nil
+}
+
+// queryChaincodeRealty 查询链上房产信息
+func (s *realtyService) queryChaincodeRealty(subContract *blockchain.Contract, realtyCertHash string) (*realtyDto.RealtyDTO, error) {
+	resultBytes, err := subContract.EvaluateTransaction("QueryRealty", realtyCertHash)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询房产信息失败: %v", err))
+		return nil, fmt.Errorf("查询房产信息失败: %v", err)
+	}
+
+	var result realtyDto.RealtyDTO
+	if err := json.Unmarshal(resultBytes, &result); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析房产信息失败: %v", err))
+		return nil, fmt.Errorf("解析房产信息失败: %v", err)
+	}
+	return &result, nil
+}
+
+// getSubContractByRealtyCertHash 根据房产索引获取指定组织在房产所在子通道的合约
+func (s *realtyService) getSubContractByRealtyCertHash(realtyCertHash string, organization string) (*blockchain.Contract, error) {
+	mainContract, err := blockchain.GetMainContract(organization)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取主通道合约失败: %v", err))
+		return nil, fmt.Errorf("获取主通道合约失败: %v", err)
+	}
+
+	realtyIndexBytes, err := mainContract.EvaluateTransaction("GetRealtyIndex", realtyCertHash)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取房产索引失败: %v", err))
+		return nil, fmt.Errorf("获取房产索引失败: %v", err)
+	}
+
+	var realtyIndex blockDto.RealtyIndex
+	if err := json.Unmarshal(realtyIndexBytes, &realtyIndex); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析房产索引失败: %v", err))
+		return nil, fmt.Errorf("解析房产索引失败: %v", err)
+	}
+
+	subContract, err := blockchain.GetSubContract(realtyIndex.ChannelName, organization)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取子通道合约失败: %v", err))
+		return nil, fmt.Errorf("获取子通道合约失败: %v", err)
+	}
+
+	return subContract, nil
+}
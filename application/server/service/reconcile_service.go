@@ -0,0 +1,679 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"grets_server/config"
+	"grets_server/constants"
+	"grets_server/dao"
+	"grets_server/db/models"
+	reconcileDto "grets_server/dto/reconcile_dto"
+	"grets_server/pkg/blockchain"
+	"grets_server/pkg/cache"
+	"grets_server/pkg/utils"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 对账时每页读取的记录数
+const reconcilePageSize = 100
+
+// 全局对账服务实例
+var GlobalReconcileService ReconcileService
+
+// InitReconcileService 初始化对账服务
+func InitReconcileService(reconcileDAO *dao.ReconcileDAO) {
+	GlobalReconcileService = NewReconcileService(reconcileDAO)
+	utils.Log.Info("对账服务初始化完成")
+}
+
+// ReconcileService 链上与数据库对账服务接口
+// 分页遍历主通道索引、各子通道账本和MySQL，比较关键字段后生成差异报告，可选用链上数据修复MySQL
+type ReconcileService interface {
+	Run(triggerType string, autoRepair bool) (*reconcileDto.ReconcileReportDTO, error)
+	GetReportByUUID(reportUUID string) (*reconcileDto.ReconcileReportDTO, error)
+	QueryReportList(dto *reconcileDto.QueryReconcileReportDTO) ([]*reconcileDto.ReconcileReportDTO, int64, error)
+}
+
+// reconcileService 对账服务实现
+type reconcileService struct {
+	reconcileDAO *dao.ReconcileDAO
+	cacheService cache.CacheService
+	running      sync.Mutex
+}
+
+// NewReconcileService 创建对账服务实例
+func NewReconcileService(reconcileDAO *dao.ReconcileDAO) ReconcileService {
+	return &reconcileService{
+		reconcileDAO: reconcileDAO,
+		cacheService: cache.GetCacheService(),
+	}
+}
+
+// StartReconcileJob 按配置的间隔定时对账，间隔为0时不启动
+func StartReconcileJob() {
+	interval := config.GlobalConfig.Reconcile.Interval
+	if interval <= 0 {
+		return
+	}
+	autoRepair := config.GlobalConfig.Reconcile.AutoRepair
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := GlobalReconcileService.Run(constants.ReconcileTriggerScheduled, autoRepair); err != nil {
+				utils.Log.Error(fmt.Sprintf("定时对账失败: %v", err))
+			}
+		}
+	}()
+}
+
+// 链上房产索引
+type chainRealtyIndex struct {
+	RealtyCertHash            string `json:"realtyCertHash"`
+	ChannelName               string `json:"channelName"`
+	CurrentOwnerCitizenIDHash string `json:"currentOwnerCitizenIDHash"`
+	CurrentOwnerOrganization  string `json:"currentOwnerOrganization"`
+}
+
+// 链上交易索引
+type chainTransactionIndex struct {
+	TransactionUUID string `json:"transactionUUID"`
+	ChannelName     string `json:"channelName"`
+	RealtyCertHash  string `json:"realtyCertHash"`
+}
+
+// 子通道上的房产
+type chainRealty struct {
+	RealtyCertHash            string `json:"realtyCertHash"`
+	RealtyCert                string `json:"realtyCert"`
+	RealtyType                string `json:"realtyType"`
+	CurrentOwnerCitizenIDHash string `json:"currentOwnerCitizenIDHash"`
+	CurrentOwnerOrganization  string `json:"currentOwnerOrganization"`
+	Status                    string `json:"status"`
+	channelName               string
+}
+
+// 子通道上的交易
+type chainTransaction struct {
+	TransactionUUID     string `json:"transactionUUID"`
+	RealtyCertHash      string `json:"realtyCertHash"`
+	SellerCitizenIDHash string `json:"sellerCitizenIDHash"`
+	SellerOrganization  string `json:"sellerOrganization"`
+	BuyerCitizenIDHash  string `json:"buyerCitizenIDHash"`
+	BuyerOrganization   string `json:"buyerOrganization"`
+	Status              string `json:"status"`
+	channelName         string
+}
+
+// 子通道上的支付
+type chainPayment struct {
+	PaymentUUID           string  `json:"paymentUUID"`
+	TransactionUUID       string  `json:"transactionUUID"`
+	PaymentType           string  `json:"paymentType"`
+	Amount                float64 `json:"amount"`
+	PayerCitizenIDHash    string  `json:"payerCitizenIDHash"`
+	PayerOrganization     string  `json:"payerOrganization"`
+	ReceiverCitizenIDHash string  `json:"receiverCitizenIDHash"`
+	ReceiverOrganization  string  `json:"receiverOrganization"`
+	Status                string  `json:"status"`
+	channelName           string
+}
+
+// reconcileRun 单次对账的中间状态，链上数据按主键保存，与MySQL比较后删除，剩余的即MySQL缺少的记录
+type reconcileRun struct {
+	report               *models.ReconcileReport
+	discrepancyList      []*reconcileDto.DiscrepancyDTO
+	realtyIndexList      map[string]*chainRealtyIndex
+	transactionIndexList map[string]*chainTransactionIndex
+	realtyList           map[string]*chainRealty
+	transactionList      map[string]*chainTransaction
+	paymentList          map[string]*chainPayment
+}
+
+// reconcileField 待比较的字段，expect为子通道上的值
+type reconcileField struct {
+	name   string
+	expect string
+	actual string
+}
+
+// Run 创建对账报告后在后台对账，同一时间只允许一次对账，调用方可通过报告UUID查询结果
+func (s *reconcileService) Run(triggerType string, autoRepair bool) (*reconcileDto.ReconcileReportDTO, error) {
+	if !s.running.TryLock() {
+		return nil, fmt.Errorf("对账正在进行中，请稍后再试")
+	}
+
+	report := &models.ReconcileReport{
+		ReportUUID:  uuid.New().String(),
+		TriggerType: triggerType,
+		AutoRepair:  autoRepair,
+		Status:      constants.ReconcileStatusRunning,
+		StartTime:   time.Now(),
+	}
+	if err := s.reconcileDAO.CreateReport(report); err != nil {
+		s.running.Unlock()
+		utils.Log.Error(fmt.Sprintf("创建对账报告失败: %v", err))
+		return nil, fmt.Errorf("创建对账报告失败: %v", err)
+	}
+
+	go func() {
+		defer s.running.Unlock()
+		s.reconcile(report)
+	}()
+
+	return buildReconcileReportDTO(report, nil), nil
+}
+
+// GetReportByUUID 查询对账报告及差异明细
+func (s *reconcileService) GetReportByUUID(reportUUID string) (*reconcileDto.ReconcileReportDTO, error) {
+	report, err := s.reconcileDAO.GetReportByUUID(reportUUID)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询对账报告失败: %v", err))
+		return nil, fmt.Errorf("查询对账报告失败: %v", err)
+	}
+
+	discrepancyList := make([]*reconcileDto.DiscrepancyDTO, 0)
+	if report.DiscrepancyList != "" {
+		if err := json.Unmarshal([]byte(report.DiscrepancyList), &discrepancyList); err != nil {
+			utils.Log.Error(fmt.Sprintf("解析对账差异失败: %v", err))
+			return nil, fmt.Errorf("解析对账差异失败: %v", err)
+		}
+	}
+	return buildReconcileReportDTO(report, discrepancyList), nil
+}
+
+// QueryReportList 分页查询对账报告，不含差异明细
+func (s *reconcileService) QueryReportList(dto *reconcileDto.QueryReconcileReportDTO) ([]*reconcileDto.ReconcileReportDTO, int64, error) {
+	// 设置默认分页参数
+	pageSize := 10
+	pageNumber := 1
+	if dto.PageSize > 0 {
+		pageSize = dto.PageSize
+	}
+	if dto.PageNumber > 0 {
+		pageNumber = dto.PageNumber
+	}
+
+	reportList, total, err := s.reconcileDAO.QueryReportList(pageSize, pageNumber)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询对账报告列表失败: %v", err))
+		return nil, 0, fmt.Errorf("查询对账报告列表失败: %v", err)
+	}
+
+	result := make([]*reconcileDto.ReconcileReportDTO, 0, len(reportList))
+	for _, report := range reportList {
+		result = append(result, buildReconcileReportDTO(report, nil))
+	}
+	return result, total, nil
+}
+
+// reconcile 依次核对主通道索引与子通道、子通道与MySQL，结束后保存报告
+func (s *reconcileService) reconcile(report *models.ReconcileReport) {
+	utils.Log.Info(fmt.Sprintf("开始对账[%s]，修复MySQL: %v", report.ReportUUID, report.AutoRepair))
+
+	run := &reconcileRun{
+		report:               report,
+		discrepancyList:      make([]*reconcileDto.DiscrepancyDTO, 0),
+		realtyIndexList:      make(map[string]*chainRealtyIndex),
+		transactionIndexList: make(map[string]*chainTransactionIndex),
+		realtyList:           make(map[string]*chainRealty),
+		transactionList:      make(map[string]*chainTransaction),
+		paymentList:          make(map[string]*chainPayment),
+	}
+
+	err := s.loadMainIndex(run)
+	if err == nil {
+		for _, channelName := range config.GlobalConfig.Fabric.SubChannelName {
+			if err = s.reconcileSubChannel(run, channelName); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		s.reconcileMissingSubChannel(run)
+		err = s.reconcileDatabase(run)
+	}
+
+	report.Status = constants.ReconcileStatusCompleted
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("对账[%s]中断: %v", report.ReportUUID, err))
+		report.Status = constants.ReconcileStatusFailed
+		report.ErrorMessage = err.Error()
+	}
+	report.DiscrepancyCount = len(run.discrepancyList)
+	report.RepairedCount = 0
+	for _, discrepancy := range run.discrepancyList {
+		if discrepancy.Repaired {
+			report.RepairedCount++
+		}
+	}
+	discrepancyJSON, _ := json.Marshal(run.discrepancyList)
+	report.DiscrepancyList = string(discrepancyJSON)
+	endTime := time.Now()
+	report.EndTime = &endTime
+
+	if err := s.reconcileDAO.UpdateReport(report); err != nil {
+		utils.Log.Error(fmt.Sprintf("保存对账报告[%s]失败: %v", report.ReportUUID, err))
+		return
+	}
+	utils.Log.Info(fmt.Sprintf("对账[%s]结束，状态: %s，差异%d条，已修复%d条",
+		report.ReportUUID, report.Status, report.DiscrepancyCount, report.RepairedCount))
+}
+
+// loadMainIndex 分页读取主通道的房产索引和交易索引
+func (s *reconcileService) loadMainIndex(run *reconcileRun) error {
+	mainContract, err := blockchain.GetMainContract(constants.GovernmentOrganization)
+	if err != nil {
+		return fmt.Errorf("获取主通道合约失败: %v", err)
+	}
+
+	err = evaluatePageList(mainContract, "QueryRealtyIndexPage", func(pageBytes []byte) (string, error) {
+		var page struct {
+			RealtyIndexList []*chainRealtyIndex `json:"realtyIndexList"`
+			Bookmark        string              `json:"bookmark"`
+		}
+		if err := json.Unmarshal(pageBytes, &page); err != nil {
+			return "", err
+		}
+		for _, index := range page.RealtyIndexList {
+			run.realtyIndexList[index.RealtyCertHash] = index
+		}
+		return page.Bookmark, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return evaluatePageList(mainContract, "QueryTransactionIndexPage", func(pageBytes []byte) (string, error) {
+		var page struct {
+			TransactionIndexList []*chainTransactionIndex `json:"transactionIndexList"`
+			Bookmark             string                   `json:"bookmark"`
+		}
+		if err := json.Unmarshal(pageBytes, &page); err != nil {
+			return "", err
+		}
+		for _, index := range page.TransactionIndexList {
+			run.transactionIndexList[index.TransactionUUID] = index
+		}
+		return page.Bookmark, nil
+	})
+}
+
+// reconcileSubChannel 分页读取子通道的房产、交易、支付，房产和交易与主通道索引比较，已核对的索引从列表中删除
+func (s *reconcileService) reconcileSubChannel(run *reconcileRun, channelName string) error {
+	subContract, err := blockchain.GetSubContract(channelName, constants.GovernmentOrganization)
+	if err != nil {
+		return fmt.Errorf("获取子通道[%s]合约失败: %v", channelName, err)
+	}
+
+	err = evaluatePageList(subContract, "QueryRealtyPage", func(pageBytes []byte) (string, error) {
+		var page struct {
+			RealtyList []*chainRealty `json:"realtyList"`
+			Bookmark   string         `json:"bookmark"`
+		}
+		if err := json.Unmarshal(pageBytes, &page); err != nil {
+			return "", err
+		}
+		for _, realty := range page.RealtyList {
+			realty.channelName = channelName
+			run.realtyList[realty.RealtyCertHash] = realty
+
+			index, ok := run.realtyIndexList[realty.RealtyCertHash]
+			if !ok {
+				run.addDiscrepancy(constants.ReconcileEntityRealty, realty.RealtyCertHash, channelName,
+					constants.DiscrepancyMissingIndex, reconcileField{})
+				continue
+			}
+			delete(run.realtyIndexList, realty.RealtyCertHash)
+			for _, field := range diffFields(
+				reconcileField{"channelName", channelName, index.ChannelName},
+				reconcileField{"currentOwnerCitizenIDHash", realty.CurrentOwnerCitizenIDHash, index.CurrentOwnerCitizenIDHash},
+				reconcileField{"currentOwnerOrganization", realty.CurrentOwnerOrganization, index.CurrentOwnerOrganization},
+			) {
+				run.addDiscrepancy(constants.ReconcileEntityRealty, realty.RealtyCertHash, channelName,
+					constants.DiscrepancyIndexMismatch, field)
+			}
+		}
+		return page.Bookmark, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = evaluatePageList(subContract, "QueryTransactionPage", func(pageBytes []byte) (string, error) {
+		var page struct {
+			TransactionList []*chainTransaction `json:"transactionList"`
+			Bookmark        string              `json:"bookmark"`
+		}
+		if err := json.Unmarshal(pageBytes, &page); err != nil {
+			return "", err
+		}
+		for _, transaction := range page.TransactionList {
+			transaction.channelName = channelName
+			run.transactionList[transaction.TransactionUUID] = transaction
+
+			index, ok := run.transactionIndexList[transaction.TransactionUUID]
+			if !ok {
+				run.addDiscrepancy(constants.ReconcileEntityTransaction, transaction.TransactionUUID, channelName,
+					constants.DiscrepancyMissingIndex, reconcileField{})
+				continue
+			}
+			delete(run.transactionIndexList, transaction.TransactionUUID)
+			for _, field := range diffFields(
+				reconcileField{"channelName", channelName, index.ChannelName},
+				reconcileField{"realtyCertHash", transaction.RealtyCertHash, index.RealtyCertHash},
+			) {
+				run.addDiscrepancy(constants.ReconcileEntityTransaction, transaction.TransactionUUID, channelName,
+					constants.DiscrepancyIndexMismatch, field)
+			}
+		}
+		return page.Bookmark, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return evaluatePageList(subContract, "QueryPaymentPage", func(pageBytes []byte) (string, error) {
+		var page struct {
+			PaymentList []*chainPayment `json:"paymentList"`
+			Bookmark    string          `json:"bookmark"`
+		}
+		if err := json.Unmarshal(pageBytes, &page); err != nil {
+			return "", err
+		}
+		for _, payment := range page.PaymentList {
+			payment.channelName = channelName
+			run.paymentList[payment.PaymentUUID] = payment
+		}
+		return page.Bookmark, nil
+	})
+}
+
+// reconcileMissingSubChannel 主通道索引在各子通道都找不到对应记录
+func (s *reconcileService) reconcileMissingSubChannel(run *reconcileRun) {
+	for _, realtyCertHash := range sortedKeys(run.realtyIndexList) {
+		run.addDiscrepancy(constants.ReconcileEntityRealty, realtyCertHash, run.realtyIndexList[realtyCertHash].ChannelName,
+			constants.DiscrepancyMissingSubChannel, reconcileField{})
+	}
+	for _, transactionUUID := range sortedKeys(run.transactionIndexList) {
+		run.addDiscrepancy(constants.ReconcileEntityTransaction, transactionUUID, run.transactionIndexList[transactionUUID].ChannelName,
+			constants.DiscrepancyMissingSubChannel, reconcileField{})
+	}
+}
+
+// reconcileDatabase 分页读取MySQL中的房产、交易、支付并与子通道数据比较，最后处理MySQL缺少的链上记录
+func (s *reconcileService) reconcileDatabase(run *reconcileRun) error {
+	var lastID int64
+	for {
+		realtyList, err := s.reconcileDAO.QueryRealtyPage(lastID, reconcilePageSize)
+		if err != nil {
+			return err
+		}
+		for _, realty := range realtyList {
+			lastID = realty.ID
+			run.report.RealtyChecked++
+			chain, ok := run.realtyList[realty.RealtyCertHash]
+			if !ok {
+				run.addDiscrepancy(constants.ReconcileEntityRealty, realty.RealtyCertHash, "",
+					constants.DiscrepancyMissingChain, reconcileField{})
+				continue
+			}
+			delete(run.realtyList, realty.RealtyCertHash)
+			s.reconcileRealty(run, chain, diffFields(
+				reconcileField{"realtyType", chain.RealtyType, realty.RealtyType},
+				reconcileField{"status", chain.Status, realty.Status},
+				reconcileField{"currentOwnerCitizenIDHash", chain.CurrentOwnerCitizenIDHash, realty.CurrentOwnerCitizenIDHash},
+				reconcileField{"currentOwnerOrganization", chain.CurrentOwnerOrganization, realty.CurrentOwnerOrganization},
+			))
+		}
+		if len(realtyList) < reconcilePageSize {
+			break
+		}
+	}
+	for _, realtyCertHash := range sortedKeys(run.realtyList) {
+		s.reconcileRealty(run, run.realtyList[realtyCertHash], nil)
+	}
+
+	lastID = 0
+	for {
+		transactionList, err := s.reconcileDAO.QueryTransactionPage(lastID, reconcilePageSize)
+		if err != nil {
+			return err
+		}
+		for _, transaction := range transactionList {
+			lastID = transaction.ID
+			run.report.TransactionChecked++
+			chain, ok := run.transactionList[transaction.TransactionUUID]
+			if !ok {
+				run.addDiscrepancy(constants.ReconcileEntityTransaction, transaction.TransactionUUID, "",
+					constants.DiscrepancyMissingChain, reconcileField{})
+				continue
+			}
+			delete(run.transactionList, transaction.TransactionUUID)
+			s.reconcileTransaction(run, chain, diffFields(
+				reconcileField{"realtyCertHash", chain.RealtyCertHash, transaction.RealtyCertHash},
+				reconcileField{"sellerCitizenIDHash", chain.SellerCitizenIDHash, transaction.SellerCitizenIDHash},
+				reconcileField{"sellerOrganization", chain.SellerOrganization, transaction.SellerOrganization},
+				reconcileField{"buyerCitizenIDHash", chain.BuyerCitizenIDHash, transaction.BuyerCitizenIDHash},
+				reconcileField{"buyerOrganization", chain.BuyerOrganization, transaction.BuyerOrganization},
+				reconcileField{"status", chain.Status, transaction.Status},
+			))
+		}
+		if len(transactionList) < reconcilePageSize {
+			break
+		}
+	}
+	for _, transactionUUID := range sortedKeys(run.transactionList) {
+		s.reconcileTransaction(run, run.transactionList[transactionUUID], nil)
+	}
+
+	lastID = 0
+	for {
+		paymentList, err := s.reconcileDAO.QueryPaymentPage(lastID, reconcilePageSize)
+		if err != nil {
+			return err
+		}
+		for _, payment := range paymentList {
+			lastID = payment.ID
+			run.report.PaymentChecked++
+			chain, ok := run.paymentList[payment.PaymentUUID]
+			if !ok {
+				run.addDiscrepancy(constants.ReconcileEntityPayment, payment.PaymentUUID, "",
+					constants.DiscrepancyMissingChain, reconcileField{})
+				continue
+			}
+			delete(run.paymentList, payment.PaymentUUID)
+			s.reconcilePayment(run, chain, diffFields(
+				reconcileField{"transactionUUID", chain.TransactionUUID, payment.TransactionUUID},
+				reconcileField{"paymentType", chain.PaymentType, payment.PaymentType},
+				reconcileField{"amount", formatAmount(chain.Amount), formatAmount(payment.Amount)},
+				reconcileField{"payerCitizenIDHash", chain.PayerCitizenIDHash, payment.PayerCitizenIDHash},
+				reconcileField{"receiverCitizenIDHash", chain.ReceiverCitizenIDHash, payment.ReceiverCitizenIDHash},
+				reconcileField{"status", chain.Status, payment.Status},
+			))
+		}
+		if len(paymentList) < reconcilePageSize {
+			break
+		}
+	}
+	for _, paymentUUID := range sortedKeys(run.paymentList) {
+		s.reconcilePayment(run, run.paymentList[paymentUUID], nil)
+	}
+	return nil
+}
+
+// reconcileRealty 记录房产与MySQL的差异并按需修复，fieldList为nil表示MySQL缺少该房产
+func (s *reconcileService) reconcileRealty(run *reconcileRun, chain *chainRealty, fieldList []reconcileField) {
+	discrepancyList := run.addDatabaseDiscrepancy(constants.ReconcileEntityRealty, chain.RealtyCertHash, chain.channelName, fieldList)
+	if len(discrepancyList) == 0 || !run.report.AutoRepair {
+		return
+	}
+
+	err := s.reconcileDAO.RepairRealty(&models.Realty{
+		RealtyCert:                chain.RealtyCert,
+		RealtyCertHash:            chain.RealtyCertHash,
+		RealtyType:                chain.RealtyType,
+		Status:                    chain.Status,
+		CurrentOwnerCitizenIDHash: chain.CurrentOwnerCitizenIDHash,
+		CurrentOwnerOrganization:  chain.CurrentOwnerOrganization,
+	})
+	markRepaired(discrepancyList, err)
+	if err == nil {
+		s.cacheService.Remove(cache.RealtyPrefix + "cert:" + chain.RealtyCert)
+		s.cacheService.Remove(cache.RealtyPrefix + "hash:" + chain.RealtyCertHash)
+	}
+}
+
+// reconcileTransaction 记录交易与MySQL的差异并按需修复，fieldList为nil表示MySQL缺少该交易
+func (s *reconcileService) reconcileTransaction(run *reconcileRun, chain *chainTransaction, fieldList []reconcileField) {
+	discrepancyList := run.addDatabaseDiscrepancy(constants.ReconcileEntityTransaction, chain.TransactionUUID, chain.channelName, fieldList)
+	if len(discrepancyList) == 0 || !run.report.AutoRepair {
+		return
+	}
+
+	err := s.reconcileDAO.RepairTransaction(&models.Transaction{
+		TransactionUUID:     chain.TransactionUUID,
+		RealtyCertHash:      chain.RealtyCertHash,
+		SellerCitizenIDHash: chain.SellerCitizenIDHash,
+		SellerOrganization:  chain.SellerOrganization,
+		BuyerCitizenIDHash:  chain.BuyerCitizenIDHash,
+		BuyerOrganization:   chain.BuyerOrganization,
+		Status:              chain.Status,
+	})
+	markRepaired(discrepancyList, err)
+	if err == nil {
+		s.cacheService.Remove(cache.TransactionPrefix + "uuid:" + chain.TransactionUUID)
+	}
+}
+
+// reconcilePayment 记录支付与MySQL的差异并按需修复，fieldList为nil表示MySQL缺少该支付
+func (s *reconcileService) reconcilePayment(run *reconcileRun, chain *chainPayment, fieldList []reconcileField) {
+	discrepancyList := run.addDatabaseDiscrepancy(constants.ReconcileEntityPayment, chain.PaymentUUID, chain.channelName, fieldList)
+	if len(discrepancyList) == 0 || !run.report.AutoRepair {
+		return
+	}
+
+	err := s.reconcileDAO.RepairPayment(&models.Payment{
+		PaymentUUID:           chain.PaymentUUID,
+		TransactionUUID:       chain.TransactionUUID,
+		PaymentType:           chain.PaymentType,
+		Amount:                chain.Amount,
+		PayerCitizenIDHash:    chain.PayerCitizenIDHash,
+		PayerOrganization:     chain.PayerOrganization,
+		ReceiverCitizenIDHash: chain.ReceiverCitizenIDHash,
+		ReceiverOrganization:  chain.ReceiverOrganization,
+		Status:                chain.Status,
+	})
+	markRepaired(discrepancyList, err)
+}
+
+// addDiscrepancy 记录一条差异
+func (run *reconcileRun) addDiscrepancy(entityType string, entityKey string, channelName string, kind string, field reconcileField) *reconcileDto.DiscrepancyDTO {
+	discrepancy := &reconcileDto.DiscrepancyDTO{
+		EntityType:  entityType,
+		EntityKey:   entityKey,
+		ChannelName: channelName,
+		Kind:        kind,
+		Field:       field.name,
+		ExpectValue: field.expect,
+		ActualValue: field.actual,
+	}
+	run.discrepancyList = append(run.discrepancyList, discrepancy)
+	return discrepancy
+}
+
+// addDatabaseDiscrepancy 记录链上记录与MySQL的差异，fieldList为nil时记录为MySQL缺少该记录
+func (run *reconcileRun) addDatabaseDiscrepancy(entityType string, entityKey string, channelName string, fieldList []reconcileField) []*reconcileDto.DiscrepancyDTO {
+	if fieldList == nil {
+		return []*reconcileDto.DiscrepancyDTO{
+			run.addDiscrepancy(entityType, entityKey, channelName, constants.DiscrepancyMissingDatabase, reconcileField{}),
+		}
+	}
+
+	discrepancyList := make([]*reconcileDto.DiscrepancyDTO, 0, len(fieldList))
+	for _, field := range fieldList {
+		discrepancyList = append(discrepancyList,
+			run.addDiscrepancy(entityType, entityKey, channelName, constants.DiscrepancyDatabaseMismatch, field))
+	}
+	return discrepancyList
+}
+
+// markRepaired 记录修复结果
+func markRepaired(discrepancyList []*reconcileDto.DiscrepancyDTO, err error) {
+	for _, discrepancy := range discrepancyList {
+		if err != nil {
+			discrepancy.RepairError = err.Error()
+		} else {
+			discrepancy.Repaired = true
+		}
+	}
+}
+
+// diffFields 返回值不一致的字段，全部一致时返回空列表（非nil）
+func diffFields(fieldList ...reconcileField) []reconcileField {
+	result := make([]reconcileField, 0)
+	for _, field := range fieldList {
+		if field.expect != field.actual {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+// evaluatePageList 按书签逐页调用链码分页查询，visit返回下一页书签，书签为空或不再变化时结束
+func evaluatePageList(contract *blockchain.Contract, function string, visit func(pageBytes []byte) (string, error)) error {
+	bookmark := ""
+	for {
+		pageBytes, err := contract.EvaluateTransaction(function, strconv.Itoa(reconcilePageSize), bookmark)
+		if err != nil {
+			return fmt.Errorf("调用链码[%s]失败: %v", function, err)
+		}
+		nextBookmark, err := visit(pageBytes)
+		if err != nil {
+			return fmt.Errorf("解析链码[%s]结果失败: %v", function, err)
+		}
+		if nextBookmark == "" || nextBookmark == bookmark {
+			return nil
+		}
+		bookmark = nextBookmark
+	}
+}
+
+// sortedKeys 按主键排序，保证报告中差异的顺序稳定
+func sortedKeys[T any](itemList map[string]T) []string {
+	keyList := make([]string, 0, len(itemList))
+	for key := range itemList {
+		keyList = append(keyList, key)
+	}
+	sort.Strings(keyList)
+	return keyList
+}
+
+// formatAmount 金额转为字符串用于比较和展示
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// buildReconcileReportDTO 构建对账报告DTO
+func buildReconcileReportDTO(report *models.ReconcileReport, discrepancyList []*reconcileDto.DiscrepancyDTO) *reconcileDto.ReconcileReportDTO {
+	return &reconcileDto.ReconcileReportDTO{
+		ReportUUID:         report.ReportUUID,
+		TriggerType:        report.TriggerType,
+		AutoRepair:         report.AutoRepair,
+		Status:             report.Status,
+		RealtyChecked:      report.RealtyChecked,
+		TransactionChecked: report.TransactionChecked,
+		PaymentChecked:     report.PaymentChecked,
+		DiscrepancyCount:   report.DiscrepancyCount,
+		RepairedCount:      report.RepairedCount,
+		DiscrepancyList:    discrepancyList,
+		ErrorMessage:       report.ErrorMessage,
+		StartTime:          report.StartTime,
+		EndTime:            report.EndTime,
+	}
+}
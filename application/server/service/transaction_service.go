@@ -2,6 +2,7 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"grets_server/constants"
 	"grets_server/dao"
@@ -13,7 +14,9 @@ import (
 	"grets_server/pkg/blockchain"
 	"grets_server/pkg/cache"
 	"grets_server/pkg/utils"
+	"math"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,15 +24,27 @@ import (
 
 // TransactionService 交易服务接口
 type TransactionService interface {
-	CreateTransaction(req *transactionDto.CreateTransactionDTO) error
+	CreateTransaction(req *transactionDto.CreateTransactionDTO) (string, string, error)
 	GetTransactionByTransactionUUID(transactionUUID string) (*transactionDto.TransactionDTO, error)
 	QueryTransactionList(query *transactionDto.QueryTransactionListDTO) ([]*transactionDto.TransactionDTO, int, error)
-	CompleteTransaction(completeTransactionDTO *transactionDto.CompleteTransactionDTO) error
-	UpdateTransaction(dto *transactionDto.UpdateTransactionDTO) error
+	ConfirmTransactionStep(transactionUUID string, step string, citizenID string, organization string) (string, error)
+	GetTransactionTimeline(transactionUUID string) ([]*transactionDto.TransactionRecordDTO, error)
+	ConsentTransaction(transactionUUID string, citizenID string, organization string) (string, error)
+	// ConfirmTransactionStepOffline 以用户自己签名的方式确认交易步骤，返回待签名的提案
+	ConfirmTransactionStepOffline(transactionUUID string, step string, citizenID string, organization string) (*blockchain.OfflineSession, error)
+	// ConsentTransactionOffline 以共有人自己签名的方式同意出售，返回待签名的提案
+	ConsentTransactionOffline(transactionUUID string, citizenID string, organization string) (*blockchain.OfflineSession, error)
+	RejectTransaction(req *transactionDto.RejectTransactionDTO, citizenID string, organization string) (string, error)
+	CancelTransaction(req *transactionDto.CancelTransactionDTO, citizenID string, organization string) (string, error)
+	ExpireTransaction(req *transactionDto.ExpireTransactionDTO) (string, error)
+	PreviewTransactionTax(req *transactionDto.PreviewTransactionTaxDTO) ([]*transactionDto.TaxDTO, float64, error)
 	// QueryTransactionStatistics 返回总交易量、总交易额、平均单价、税收总额
 	QueryTransactionStatistics(query *transactionDto.QueryTransactionStatisticsDTO) (int, float64, float64, float64, []*transactionDto.TransactionDTO, error)
 }
 
+// ErrRealtyInSale 房产已有进行中的交易
+var ErrRealtyInSale = errors.New("房产已有进行中的交易")
+
 // transactionService 交易服务实现
 type transactionService struct {
 	txDAO        *dao.TransactionDAO
@@ -53,8 +68,8 @@ func NewTransactionService(txDAO *dao.TransactionDAO) TransactionService {
 	}
 }
 
-// CreateTransaction 创建交易
-func (s *transactionService) CreateTransaction(req *transactionDto.CreateTransactionDTO) error {
+// CreateTransaction 创建交易，返回交易UUID和业务操作UUID
+func (s *transactionService) CreateTransaction(req *transactionDto.CreateTransactionDTO) (string, string, error) {
 	// 创建新交易前删除相关缓存
 	// 清除房产和买卖方的相关缓存
 	realtyCertHash := utils.GenerateHash(req.RealtyCert)
@@ -68,18 +83,7 @@ func (s *transactionService) CreateTransaction(req *transactionDto.CreateTransac
 	mainContract, err := blockchain.GetMainContract(constants.InvestorOrganization)
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("获取合约失败: %v", err))
-		return fmt.Errorf("获取合约失败: %v", err)
-	}
-
-	// 创建交易索引
-	_, err = mainContract.SubmitTransaction(
-		"RegisterTransactionIndex",
-		transactionUUID,
-		realtyCertHash,
-	)
-	if err != nil {
-		utils.Log.Error(fmt.Sprintf("创建交易索引失败: %v", err))
-		return fmt.Errorf("创建交易索引失败: %v", err)
+		return "", "", fmt.Errorf("获取合约失败: %v", err)
 	}
 
 	// 查询房产索引
@@ -89,25 +93,25 @@ func (s *transactionService) CreateTransaction(req *transactionDto.CreateTransac
 	)
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("获取房产索引失败: %v", err))
-		return fmt.Errorf("获取房产索引失败: %v", err)
+		return "", "", fmt.Errorf("获取房产索引失败: %v", err)
 	}
 
 	var realtyIndex blockDto.RealtyIndex
 	if err := json.Unmarshal(realtyIndexBytes, &realtyIndex); err != nil {
 		utils.Log.Error(fmt.Sprintf("解析房产索引失败: %v", err))
-		return fmt.Errorf("解析房产索引失败: %v", err)
+		return "", "", fmt.Errorf("解析房产索引失败: %v", err)
 	}
 
 	subContract, err := blockchain.GetSubContract(realtyIndex.ChannelName, constants.InvestorOrganization)
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("获取子通道合约失败: %v", err))
-		return fmt.Errorf("获取子通道合约失败: %v", err)
+		return "", "", fmt.Errorf("获取子通道合约失败: %v", err)
 	}
 
 	paymentUUIDListJSON, err := json.Marshal(req.PaymentUUIDList)
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("序列化支付ID列表失败: %v", err))
-		return fmt.Errorf("序列化支付ID列表失败: %v", err)
+		return "", "", fmt.Errorf("序列化支付ID列表失败: %v", err)
 	}
 
 	buyerCitizenIDHash := utils.GenerateHash(req.BuyerCitizenID)
@@ -116,7 +120,7 @@ func (s *transactionService) CreateTransaction(req *transactionDto.CreateTransac
 	realty, err := GlobalRealtyService.GetRealtyByRealtyCert(req.RealtyCert)
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("获取房产信息失败: %v", err))
-		return fmt.Errorf("获取房产信息失败: %v", err)
+		return "", "", fmt.Errorf("获取房产信息失败: %v", err)
 	}
 
 	// 查询卖家信息
@@ -126,17 +130,28 @@ func (s *transactionService) CreateTransaction(req *transactionDto.CreateTransac
 	)
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("查询卖家信息失败: %v", err))
-		return fmt.Errorf("查询卖家信息失败: %v", err)
+		return "", "", fmt.Errorf("查询卖家信息失败: %v", err)
 	}
 
 	var chaincodeRealtyResult realtyDto.RealtyDTO
 	if err := json.Unmarshal(realtyBytes, &chaincodeRealtyResult); err != nil {
 		utils.Log.Error(fmt.Sprintf("解析房产信息失败: %v", err))
-		return fmt.Errorf("解析房产信息失败: %v", err)
+		return "", "", fmt.Errorf("解析房产信息失败: %v", err)
 	}
 
 	if buyerCitizenIDHash == chaincodeRealtyResult.CurrentOwnerCitizenIDHash && chaincodeRealtyResult.CurrentOwnerOrganization != constants.GovernmentOrganization {
-		return fmt.Errorf("买家和卖家不能为同一人")
+		return "", "", fmt.Errorf("买家和卖家不能为同一人")
+	}
+
+	if chaincodeRealtyResult.Status == constants.RealtyStatusFrozen {
+		return "", "", fmt.Errorf("房产已被司法冻结，不能交易")
+	}
+
+	// 房产已被其他交易锁定时，只有超时的交易可以被终止释放
+	if chaincodeRealtyResult.Status == constants.RealtyStatusInSale {
+		if err := s.releaseExpiredRealtyLock(subContract, chaincodeRealtyResult.ActiveTransactionUUID); err != nil {
+			return "", "", err
+		}
 	}
 
 	// 对买方进行验资
@@ -147,19 +162,19 @@ func (s *transactionService) CreateTransaction(req *transactionDto.CreateTransac
 	)
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("获取子通道信息失败: %v", err))
-		return fmt.Errorf("获取子通道信息失败: %v", err)
+		return "", "", fmt.Errorf("获取子通道信息失败: %v", err)
 	}
 
 	var channelInfo blockDto.ChannelInfo
 	if err := json.Unmarshal(channelInfoBytes, &channelInfo); err != nil {
 		utils.Log.Error(fmt.Sprintf("解析子通道信息失败: %v", err))
-		return fmt.Errorf("解析子通道信息失败: %v", err)
+		return "", "", fmt.Errorf("解析子通道信息失败: %v", err)
 	}
 
 	buyerSubContract, err := blockchain.GetSubContract(channelInfo.ChannelName, constants.InvestorOrganization)
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("获取子通道合约失败: %v", err))
-		return fmt.Errorf("获取子通道合约失败: %v", err)
+		return "", "", fmt.Errorf("获取子通道合约失败: %v", err)
 	}
 
 	buyerBalanceBytes, err := buyerSubContract.EvaluateTransaction(
@@ -169,64 +184,329 @@ func (s *transactionService) CreateTransaction(req *transactionDto.CreateTransac
 	)
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("获取买方余额失败: %v", err))
-		return fmt.Errorf("获取买方余额失败: %v", err)
+		return "", "", fmt.Errorf("获取买方余额失败: %v", err)
 	}
 
 	var buyerBalance float64
 	if err := json.Unmarshal(buyerBalanceBytes, &buyerBalance); err != nil {
 		utils.Log.Error(fmt.Sprintf("解析买方余额失败: %v", err))
-		return fmt.Errorf("解析买方余额失败: %v", err)
+		return "", "", fmt.Errorf("解析买方余额失败: %v", err)
+	}
+
+	// 按链上税率表核定税费，买方需同时承担房款和契税
+	taxList, err := s.assessTransactionTax(realty.RealtyCertHash, buyerCitizenIDHash, req.BuyerOrganization, req.Price)
+	if err != nil {
+		return "", "", err
+	}
+	buyerTax := 0.0
+	for _, tax := range taxList {
+		if tax.PayerRole == constants.TaxPayerBuyer {
+			buyerTax += tax.TaxAmount
+		}
+	}
+
+	if buyerBalance < req.Price+buyerTax {
+		return "", "", fmt.Errorf("买方余额不足")
+	}
+
+	// 交易索引、子通道交易、数据库记录由业务操作按步骤写入，失败后重试或补偿，重试时沿用同一个交易UUID
+	operation, err := GlobalOperationService.Submit(constants.OperationCreateTransaction, transactionUUID, &createTransactionOperation{
+		TransactionUUID:     transactionUUID,
+		RealtyCertHash:      realtyCertHash,
+		ChannelName:         realtyIndex.ChannelName,
+		SellerCitizenIDHash: chaincodeRealtyResult.CurrentOwnerCitizenIDHash,
+		SellerOrganization:  chaincodeRealtyResult.CurrentOwnerOrganization,
+		BuyerCitizenIDHash:  buyerCitizenIDHash,
+		BuyerOrganization:   req.BuyerOrganization,
+		RelContractUUID:     realty.RelContractUUID,
+		PaymentUUIDList:     string(paymentUUIDListJSON),
+		Price:               req.Price,
+	})
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("创建交易失败: %v", err))
+		// 并发创建时链码会拒绝后提交的交易
+		if strings.Contains(err.Error(), ErrRealtyInSale.Error()) {
+			return "", "", fmt.Errorf("%w", ErrRealtyInSale)
+		}
+		return "", "", fmt.Errorf("创建交易失败: %v", err)
+	}
+
+	return transactionUUID, operation.OperationUUID, nil
+}
+
+// createTransactionOperation 创建交易业务操作的参数
+type createTransactionOperation struct {
+	TransactionUUID     string  `json:"transactionUUID"`
+	RealtyCertHash      string  `json:"realtyCertHash"`
+	ChannelName         string  `json:"channelName"`
+	SellerCitizenIDHash string  `json:"sellerCitizenIDHash"`
+	SellerOrganization  string  `json:"sellerOrganization"`
+	BuyerCitizenIDHash  string  `json:"buyerCitizenIDHash"`
+	BuyerOrganization   string  `json:"buyerOrganization"`
+	RelContractUUID     string  `json:"relContractUUID"`
+	PaymentUUIDList     string  `json:"paymentUUIDList"`
+	Price               float64 `json:"price"`
+}
+
+// createTransactionSteps 创建交易的业务操作步骤：注册交易索引、创建子通道交易、写入数据库
+func createTransactionSteps() []operationStep {
+	parse := func(payload string) (*createTransactionOperation, error) {
+		var op createTransactionOperation
+		if err := json.Unmarshal([]byte(payload), &op); err != nil {
+			return nil, &operationAbortError{err: fmt.Errorf("解析创建交易参数失败: %v", err)}
+		}
+		return &op, nil
+	}
+
+	return []operationStep{
+		{
+			// 主通道没有删除索引的接口，补偿时保留索引，指向的子通道交易会被取消
+			name: "RegisterTransactionIndex",
+			action: func(payload string) error {
+				op, err := parse(payload)
+				if err != nil {
+					return err
+				}
+				mainContract, err := blockchain.GetMainContract(constants.InvestorOrganization)
+				if err != nil {
+					return fmt.Errorf("获取合约失败: %v", err)
+				}
+				if _, err := mainContract.EvaluateTransaction("GetTransactionIndex", op.TransactionUUID); err == nil {
+					return nil
+				}
+				mainContract, err = mainContract.AsUser(op.BuyerOrganization, op.BuyerCitizenIDHash)
+				if err != nil {
+					return fmt.Errorf("获取买方身份失败: %v", err)
+				}
+				if _, err := mainContract.SubmitTransaction("RegisterTransactionIndex", op.TransactionUUID, op.RealtyCertHash); err != nil {
+					return abortOperation(fmt.Errorf("创建交易索引失败: %w", err))
+				}
+				return nil
+			},
+		},
+		{
+			name: "CreateTransaction",
+			action: func(payload string) error {
+				op, err := parse(payload)
+				if err != nil {
+					return err
+				}
+				subContract, err := blockchain.GetSubContract(op.ChannelName, constants.InvestorOrganization)
+				if err != nil {
+					return fmt.Errorf("获取子通道合约失败: %v", err)
+				}
+				if _, err := subContract.EvaluateTransaction("QueryTransaction", op.TransactionUUID); err == nil {
+					return nil
+				}
+				subContract, err = subContract.AsUser(op.BuyerOrganization, op.BuyerCitizenIDHash)
+				if err != nil {
+					return fmt.Errorf("获取买方身份失败: %v", err)
+				}
+				_, err = subContract.SubmitTransaction(
+					"CreateTransaction",
+					op.RealtyCertHash,
+					op.TransactionUUID,
+					op.SellerCitizenIDHash,
+					op.SellerOrganization,
+					op.BuyerCitizenIDHash,
+					op.BuyerOrganization,
+					op.RelContractUUID,
+					op.PaymentUUIDList,
+					fmt.Sprintf("%.2f", op.Price),
+				)
+				if err != nil {
+					return abortOperation(fmt.Errorf("创建交易失败: %w", err))
+				}
+				return nil
+			},
+			// 取消链上交易，退还托管资金并释放房产
+			compensate: func(payload string) error {
+				op, err := parse(payload)
+				if err != nil {
+					return err
+				}
+				subContract, err := blockchain.GetSubContract(op.ChannelName, constants.InvestorOrganization)
+				if err != nil {
+					return fmt.Errorf("获取子通道合约失败: %v", err)
+				}
+				transactionBytes, err := subContract.EvaluateTransaction("QueryTransaction", op.TransactionUUID)
+				if err != nil {
+					return fmt.Errorf("查询交易失败: %v", err)
+				}
+				var chaincodeTransaction struct {
+					Status string `json:"status"`
+				}
+				if err := json.Unmarshal(transactionBytes, &chaincodeTransaction); err != nil {
+					return fmt.Errorf("解析交易失败: %v", err)
+				}
+				if chaincodeTransaction.Status != constants.TxStatusPending && chaincodeTransaction.Status != constants.TxStatusInProcess {
+					return nil
+				}
+				subContract, err = subContract.AsUser(op.BuyerOrganization, op.BuyerCitizenIDHash)
+				if err != nil {
+					return fmt.Errorf("获取买方身份失败: %v", err)
+				}
+				if _, err := subContract.SubmitTransaction("CancelTransaction", op.TransactionUUID); err != nil {
+					return fmt.Errorf("取消交易失败: %v", err)
+				}
+				return nil
+			},
+		},
+		{
+			name: "SaveTransaction",
+			action: func(payload string) error {
+				op, err := parse(payload)
+				if err != nil {
+					return err
+				}
+				s := GlobalTransactionService.(*transactionService)
+
+				// 链上房产已锁定，同步数据库房产状态
+				subContract, err := blockchain.GetSubContract(op.ChannelName, constants.InvestorOrganization)
+				if err != nil {
+					return fmt.Errorf("获取子通道合约失败: %v", err)
+				}
+				if err := s.syncRealtyStatus(subContract, op.RealtyCertHash); err != nil {
+					return err
+				}
+				s.cacheService.Remove(cache.RealtyPrefix + "hash:" + op.RealtyCertHash)
+
+				// 修改数据库将合同绑定到交易
+				if _, err := GlobalContractService.GetContractByUUID(op.RelContractUUID); err != nil {
+					return fmt.Errorf("获取合同失败: %v", err)
+				}
+				GlobalContractService.UpdateContract(&contractDto.UpdateContractDTO{
+					ContractUUID:    op.RelContractUUID,
+					TransactionUUID: op.TransactionUUID,
+				})
+
+				// 调用DAO层创建交易，交易已存在时覆盖
+				return s.txDAO.CreateTransaction(&models.Transaction{
+					TransactionUUID:     op.TransactionUUID,
+					RealtyCertHash:      op.RealtyCertHash,
+					SellerCitizenIDHash: op.SellerCitizenIDHash,
+					SellerOrganization:  op.SellerOrganization,
+					BuyerCitizenIDHash:  op.BuyerCitizenIDHash,
+					BuyerOrganization:   op.BuyerOrganization,
+					Status:              constants.TxStatusPending,
+					ContractUUID:        op.RelContractUUID,
+					CreateTime:          time.Now(),
+					UpdateTime:          time.Now(),
+				})
+			},
+		},
+	}
+}
+
+// releaseExpiredRealtyLock 终止锁定房产的超时交易，交易未超时时返回ErrRealtyInSale
+func (s *transactionService) releaseExpiredRealtyLock(subContract *blockchain.Contract, activeTransactionUUID string) error {
+	transactionBytes, err := subContract.EvaluateTransaction("QueryTransaction", activeTransactionUUID)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询进行中的交易失败: %v", err))
+		return fmt.Errorf("%w: %s", ErrRealtyInSale, activeTransactionUUID)
 	}
 
-	if buyerBalance < req.Price+req.Tax {
-		return fmt.Errorf("买方余额不足")
+	var activeTransaction transactionDto.TransactionDTO
+	if err := json.Unmarshal(transactionBytes, &activeTransaction); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析交易失败: %v", err))
+		return fmt.Errorf("解析交易失败: %v", err)
 	}
 
-	_, err = subContract.SubmitTransaction(
-		"CreateTransaction",
+	if time.Since(activeTransaction.UpdateTime) < constants.TransactionTimeout {
+		return fmt.Errorf("%w: %s", ErrRealtyInSale, activeTransactionUUID)
+	}
+
+	utils.Log.Info(fmt.Sprintf("交易[%s]已超时，终止交易并释放房产", activeTransactionUUID))
+	// 终止交易上链后房产才解锁，等待上链后再创建新交易
+	committed := make(chan *blockchain.ChainTxStatus, 1)
+	_, err = s.closeTransaction(activeTransactionUUID, "ExpireTransaction", constants.TxStatusExpired, "", constants.InvestorOrganization,
+		func(status *blockchain.ChainTxStatus) { committed <- status })
+	if err != nil {
+		return err
+	}
+	select {
+	case status := <-committed:
+		if status.Status != blockchain.TxStatusCommitted {
+			return fmt.Errorf("终止超时交易失败: 交易[%s]%s", status.TxID, status.ValidationCode)
+		}
+	case <-time.After(constants.TransactionExpireWait):
+		return fmt.Errorf("%w: %s", ErrRealtyInSale, activeTransactionUUID)
+	}
+	return nil
+}
+
+// PreviewTransactionTax 预估交易税费，返回税费明细和合计
+func (s *transactionService) PreviewTransactionTax(req *transactionDto.PreviewTransactionTaxDTO) ([]*transactionDto.TaxDTO, float64, error) {
+	if req.Price <= 0 {
+		return nil, 0, fmt.Errorf("成交价格必须大于0")
+	}
+
+	taxList, err := s.assessTransactionTax(
 		utils.GenerateHash(req.RealtyCert),
-		transactionUUID,
-		chaincodeRealtyResult.CurrentOwnerCitizenIDHash,
-		chaincodeRealtyResult.CurrentOwnerOrganization,
-		buyerCitizenIDHash,
+		utils.GenerateHash(req.BuyerCitizenID),
 		req.BuyerOrganization,
-		realty.RelContractUUID,
-		string(paymentUUIDListJSON),
-		fmt.Sprintf("%.2f", req.Tax),
-		fmt.Sprintf("%.2f", req.Price),
+		req.Price,
 	)
 	if err != nil {
-		utils.Log.Error(fmt.Sprintf("创建交易失败: %v", err))
-		return fmt.Errorf("创建交易失败: %v", err)
+		return nil, 0, err
+	}
+
+	totalTax := 0.0
+	for _, tax := range taxList {
+		totalTax += tax.TaxAmount
 	}
 
-	// 修改数据库将合同绑定到交易
-	realtyContract, err := GlobalContractService.GetContractByUUID(realty.RelContractUUID)
+	return taxList, math.Round(totalTax*100) / 100, nil
+}
+
+// assessTransactionTax 调用房产所在子通道的链码核定税费
+func (s *transactionService) assessTransactionTax(realtyCertHash string, buyerCitizenIDHash string, buyerOrganization string, price float64) ([]*transactionDto.TaxDTO, error) {
+	mainContract, err := blockchain.GetMainContract(constants.InvestorOrganization)
 	if err != nil {
-		utils.Log.Error(fmt.Sprintf("获取合同失败: %v", err))
-		return fmt.Errorf("获取合同失败: %v", err)
+		utils.Log.Error(fmt.Sprintf("获取合约失败: %v", err))
+		return nil, fmt.Errorf("获取合约失败: %v", err)
 	}
-	realtyContract.TransactionUUID = transactionUUID
-	GlobalContractService.UpdateContract(&contractDto.UpdateContractDTO{
-		ContractUUID:    realtyContract.ContractUUID,
-		TransactionUUID: transactionUUID,
-	})
 
-	tx := &models.Transaction{
-		TransactionUUID:     transactionUUID,
-		RealtyCertHash:      utils.GenerateHash(req.RealtyCert),
-		SellerCitizenIDHash: chaincodeRealtyResult.CurrentOwnerCitizenIDHash,
-		SellerOrganization:  chaincodeRealtyResult.CurrentOwnerOrganization,
-		BuyerCitizenIDHash:  buyerCitizenIDHash,
-		BuyerOrganization:   req.BuyerOrganization,
-		Status:              constants.TxStatusPending,
-		ContractUUID:        realty.RelContractUUID,
-		CreateTime:          time.Now(),
-		UpdateTime:          time.Now(),
+	realtyIndexBytes, err := mainContract.EvaluateTransaction("GetRealtyIndex", realtyCertHash)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取房产索引失败: %v", err))
+		return nil, fmt.Errorf("获取房产索引失败: %v", err)
 	}
 
-	// 调用DAO层创建交易
-	return s.txDAO.CreateTransaction(tx)
+	var realtyIndex blockDto.RealtyIndex
+	if err := json.Unmarshal(realtyIndexBytes, &realtyIndex); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析房产索引失败: %v", err))
+		return nil, fmt.Errorf("解析房产索引失败: %v", err)
+	}
+
+	subContract, err := blockchain.GetSubContract(realtyIndex.ChannelName, constants.InvestorOrganization)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取子通道合约失败: %v", err))
+		return nil, fmt.Errorf("获取子通道合约失败: %v", err)
+	}
+
+	taxBytes, err := subContract.EvaluateTransaction(
+		"AssessTransactionTax",
+		realtyCertHash,
+		buyerCitizenIDHash,
+		buyerOrganization,
+		fmt.Sprintf("%.2f", price),
+	)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("核定税费失败: %v", err))
+		return nil, fmt.Errorf("核定税费失败: %v", err)
+	}
+
+	taxList := []*transactionDto.TaxDTO{}
+	if len(taxBytes) == 0 {
+		return taxList, nil
+	}
+	if err := json.Unmarshal(taxBytes, &taxList); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析税费信息失败: %v", err))
+		return nil, fmt.Errorf("解析税费信息失败: %v", err)
+	}
+
+	return taxList, nil
 }
 
 // GetTransactionByTransactionUUID GetTransactionByUUID 根据ID获取交易信息
@@ -303,6 +583,8 @@ func (s *transactionService) GetTransactionByTransactionUUID(transactionUUID str
 		UpdateTime:          tx.UpdateTime,
 		Price:               chaincodeTransactionResult.Price,
 		Tax:                 chaincodeTransactionResult.Tax,
+		CompletedStepList:   chaincodeTransactionResult.CompletedStepList,
+		SellerConsentList:   chaincodeTransactionResult.SellerConsentList,
 	}
 
 	// 将交易信息存入缓存，设置5分钟过期时间
@@ -381,112 +663,256 @@ func (s *transactionService) QueryTransactionList(dto *transactionDto.QueryTrans
 	return result, total, nil
 }
 
-// UpdateTransaction 更新交易信息
-func (s *transactionService) UpdateTransaction(req *transactionDto.UpdateTransactionDTO) error {
+// AuditTransaction 审计交易
+// func (s *transactionService) AuditTransaction(userID, id string, auditResult string, comments string) error {
+// 	// 调用DAO层审计交易
+// 	return s.txDAO.AuditTransaction(id, auditResult, comments, constants.AgencyOrganization)
+// }
+
+// ConfirmTransactionStep 以调用者本人的证书确认交易步骤，异步提交并返回链上交易ID，交易上链后再同步数据库
+func (s *transactionService) ConfirmTransactionStep(transactionUUID string, step string, citizenID string, organization string) (string, error) {
 	// 查询交易
-	transaction, err := s.txDAO.GetTransactionByTransactionUUID(req.TransactionUUID)
+	transaction, err := s.txDAO.GetTransactionByTransactionUUID(transactionUUID)
 	if err != nil {
-		return fmt.Errorf("查询交易失败: %v", err)
+		return "", fmt.Errorf("查询交易失败: %v", err)
+	}
+	if err := checkStepSigner(transaction, step, citizenID, organization); err != nil {
+		return "", err
 	}
 
-	// 清除交易缓存
-	s.cacheService.Remove(cache.TransactionPrefix + "uuid:" + req.TransactionUUID)
-
-	// 调用链码更新交易
-	mainContract, err := blockchain.GetMainContract(constants.InvestorOrganization)
+	subContract, err := s.getSubContractByTransactionUUID(transactionUUID, organization)
 	if err != nil {
-		utils.Log.Error(fmt.Sprintf("获取合约失败: %v", err))
-		return fmt.Errorf("获取合约失败: %v", err)
+		return "", err
 	}
-	transactionIndexBytes, err := mainContract.EvaluateTransaction(
-		"GetTransactionIndex",
-		req.TransactionUUID,
-	)
+	subContract, err = subContract.AsUser(organization, utils.GenerateHash(citizenID))
 	if err != nil {
-		utils.Log.Error(fmt.Sprintf("查询交易索引失败: %v", err))
-		return fmt.Errorf("查询交易索引失败: %v", err)
+		return "", fmt.Errorf("获取用户身份失败: %v", err)
 	}
 
-	var transactionIndex blockDto.TransactionIndex
-	if err := json.Unmarshal(transactionIndexBytes, &transactionIndex); err != nil {
-		utils.Log.Error(fmt.Sprintf("解析交易索引失败: %v", err))
-		return fmt.Errorf("解析交易索引失败: %v", err)
+	submitted, err := blockchain.SubmitAsync(subContract, "ConfirmTransactionStep",
+		s.removeTransactionCache(transactionUUID), transactionUUID, step)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("确认交易步骤失败: %v", err))
+		return "", fmt.Errorf("确认交易步骤失败: %v", err)
 	}
 
-	subContract, err := blockchain.GetSubContract(transactionIndex.ChannelName, constants.InvestorOrganization)
+	s.submitConfirmTransactionStepOperation(submitted.TxID, transactionUUID, step)
+	return submitted.TxID, nil
+}
+
+// ConfirmTransactionStepOffline 以用户自己签名的方式确认交易步骤，交易上链后再同步数据库
+func (s *transactionService) ConfirmTransactionStepOffline(transactionUUID string, step string, citizenID string, organization string) (*blockchain.OfflineSession, error) {
+	transaction, err := s.txDAO.GetTransactionByTransactionUUID(transactionUUID)
 	if err != nil {
-		utils.Log.Error(fmt.Sprintf("获取子通道合约失败: %v", err))
-		return fmt.Errorf("获取子通道合约失败: %v", err)
+		return nil, fmt.Errorf("查询交易失败: %v", err)
+	}
+	if err := checkStepSigner(transaction, step, citizenID, organization); err != nil {
+		return nil, err
+	}
+	publicKey, err := userDIDPublicKey(citizenID, organization)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户DID公钥失败: %v", err)
 	}
 
-	_, err = subContract.SubmitTransaction(
-		"UpdateTransaction",
-		req.TransactionUUID,
-		req.Status,
-	)
+	subContract, err := s.getSubContractByTransactionUUID(transactionUUID, organization)
 	if err != nil {
-		utils.Log.Error(fmt.Sprintf("更新交易失败: %v", err))
-		return fmt.Errorf("更新交易失败: %v", err)
+		return nil, err
 	}
 
-	// 调用DAO层更新交易
-	transaction.Status = req.Status
-	return s.txDAO.UpdateTransaction(transaction)
+	session, err := subContract.PrepareOffline(organization, utils.GenerateHash(citizenID), publicKey,
+		"ConfirmTransactionStep", s.removeTransactionCache(transactionUUID), transactionUUID, step)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("创建确认交易步骤提案失败: %v", err))
+		return nil, fmt.Errorf("创建确认交易步骤提案失败: %v", err)
+	}
+
+	// 交易ID在创建提案时已确定，用户签名提交前登记同步操作，服务重启后仍能在上链后同步
+	s.submitConfirmTransactionStepOperation(session.TxID, transactionUUID, step)
+	return session, nil
 }
 
-// AuditTransaction 审计交易
-// func (s *transactionService) AuditTransaction(userID, id string, auditResult string, comments string) error {
-// 	// 调用DAO层审计交易
-// 	return s.txDAO.AuditTransaction(id, auditResult, comments, constants.AgencyOrganization)
-// }
+// checkStepSigner 链码只能校验到组织，买卖双方签署需要校验到个人
+func checkStepSigner(transaction *models.Transaction, step string, citizenID string, organization string) error {
+	switch step {
+	case constants.TxStepBuyerSigned:
+		if organization != transaction.BuyerOrganization || utils.GenerateHash(citizenID) != transaction.BuyerCitizenIDHash {
+			return fmt.Errorf("只有买方可以签署")
+		}
+	case constants.TxStepSellerSigned:
+		if organization != transaction.SellerOrganization {
+			return fmt.Errorf("只有卖方可以签署")
+		}
+		// 政府作为卖方（新房）时由政府用户代为签署
+		if organization != constants.GovernmentOrganization && utils.GenerateHash(citizenID) != transaction.SellerCitizenIDHash {
+			return fmt.Errorf("只有卖方可以签署")
+		}
+	}
+	return nil
+}
 
-// CompleteTransaction 完成交易
-func (s *transactionService) CompleteTransaction(completeTransactionDTO *transactionDto.CompleteTransactionDTO) error {
+// transactionSyncOperation 交易步骤确认、交易终止上链后同步数据的业务操作参数
+type transactionSyncOperation struct {
+	TxID            string    `json:"txID"`             // 链上交易ID
+	TransactionUUID string    `json:"transactionUUID"`  // 交易UUID
+	Step            string    `json:"step,omitempty"`   // 确认的交易步骤
+	Status          string    `json:"status,omitempty"` // 终止后的交易状态
+	Deadline        time.Time `json:"deadline"`         // 等待上链的截止时间
+}
 
-	// 查询交易
-	transaction, err := s.txDAO.GetTransactionByTransactionUUID(completeTransactionDTO.TransactionUUID)
+func parseTransactionSyncOperation(payload string) (*transactionSyncOperation, error) {
+	var op transactionSyncOperation
+	if err := json.Unmarshal([]byte(payload), &op); err != nil {
+		return nil, &operationAbortError{err: fmt.Errorf("解析交易同步参数失败: %v", err)}
+	}
+	return &op, nil
+}
+
+// waitTransactionSyncCommit 等待交易同步操作对应的链上交易上链
+func waitTransactionSyncCommit() operationStep {
+	return waitTxCommitStep(func(payload string) (string, time.Time, error) {
+		op, err := parseTransactionSyncOperation(payload)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		return op.TxID, op.Deadline, nil
+	})
+}
+
+// submitConfirmTransactionStepOperation 登记交易步骤上链后的同步操作，只有审批和过户需要同步
+// 链上交易已提交，登记失败时只记录日志，数据库由链码事件投影和对账修复
+func (s *transactionService) submitConfirmTransactionStepOperation(txID string, transactionUUID string, step string) {
+	if step != constants.TxStepGovernmentApproved && step != constants.TxStepTitleTransferred {
+		return
+	}
+	_, err := GlobalOperationService.Submit(constants.OperationConfirmTransactionStep, transactionUUID, &transactionSyncOperation{
+		TxID:            txID,
+		TransactionUUID: transactionUUID,
+		Step:            step,
+		Deadline:        time.Now().Add(constants.OperationWaitTimeout),
+	})
 	if err != nil {
-		return fmt.Errorf("查询交易失败: %v", err)
+		utils.Log.Error(fmt.Sprintf("登记交易[%s]步骤[%s]同步操作失败: %v", transactionUUID, step, err))
+	}
+}
+
+// confirmTransactionStepSteps 交易步骤上链后的业务操作步骤：等待上链、更新主通道房产索引（仅过户）、同步数据库
+func confirmTransactionStepSteps() []operationStep {
+	return []operationStep{
+		waitTransactionSyncCommit(),
+		{
+			name: "UpdateRealtyIndex",
+			action: func(payload string) error {
+				op, err := parseTransactionSyncOperation(payload)
+				if err != nil || op.Step != constants.TxStepTitleTransferred {
+					return err
+				}
+				s := GlobalTransactionService.(*transactionService)
+				transaction, err := s.txDAO.GetTransactionByTransactionUUID(op.TransactionUUID)
+				if err != nil {
+					return fmt.Errorf("查询交易失败: %v", err)
+				}
+				return s.updateRealtyIndexOwner(transaction)
+			},
+		},
+		{
+			name: "SyncDatabase",
+			action: func(payload string) error {
+				op, err := parseTransactionSyncOperation(payload)
+				if err != nil {
+					return err
+				}
+				s := GlobalTransactionService.(*transactionService)
+				transaction, err := s.txDAO.GetTransactionByTransactionUUID(op.TransactionUUID)
+				if err != nil {
+					return fmt.Errorf("查询交易失败: %v", err)
+				}
+				s.cacheService.Remove(cache.TransactionPrefix + "uuid:" + op.TransactionUUID)
+				return s.afterConfirmTransactionStep(transaction, op.Step)
+			},
+		},
 	}
+}
 
-	// 清除交易缓存
-	s.cacheService.Remove(cache.TransactionPrefix + "uuid:" + completeTransactionDTO.TransactionUUID)
+// closeTransactionSteps 交易终止上链后的业务操作步骤：等待上链、同步数据库
+func closeTransactionSteps() []operationStep {
+	return []operationStep{
+		waitTransactionSyncCommit(),
+		{
+			name: "SyncDatabase",
+			action: func(payload string) error {
+				op, err := parseTransactionSyncOperation(payload)
+				if err != nil {
+					return err
+				}
+				s := GlobalTransactionService.(*transactionService)
+				transaction, err := s.txDAO.GetTransactionByTransactionUUID(op.TransactionUUID)
+				if err != nil {
+					return fmt.Errorf("查询交易失败: %v", err)
+				}
+				subContract, err := s.getSubContractByTransactionUUID(op.TransactionUUID, constants.InvestorOrganization)
+				if err != nil {
+					return err
+				}
+				s.cacheService.Remove(cache.TransactionPrefix + "uuid:" + op.TransactionUUID)
+				return s.syncClosedTransaction(subContract, transaction, op.Status)
+			},
+		},
+	}
+}
 
-	// 调用链码完成交易
+// afterConfirmTransactionStep 交易步骤上链后同步数据库
+func (s *transactionService) afterConfirmTransactionStep(transaction *models.Transaction, step string) error {
+	switch step {
+	case constants.TxStepGovernmentApproved:
+		transaction.Status = constants.TxStatusInProcess
+		return s.txDAO.UpdateTransaction(transaction)
+	case constants.TxStepTitleTransferred:
+		return s.syncCompletedTransaction(transaction)
+	}
+	return nil
+}
+
+// updateRealtyIndexOwner 过户后将主通道房产索引的所有人更新为买方，索引已更新时跳过
+func (s *transactionService) updateRealtyIndexOwner(transaction *models.Transaction) error {
 	mainContract, err := blockchain.GetMainContract(constants.InvestorOrganization)
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("获取合约失败: %v", err))
 		return fmt.Errorf("获取合约失败: %v", err)
 	}
-	transactionIndexBytes, err := mainContract.EvaluateTransaction(
-		"GetTransactionIndex",
-		completeTransactionDTO.TransactionUUID,
-	)
+
+	realtyIndexBytes, err := mainContract.EvaluateTransaction("GetRealtyIndex", transaction.RealtyCertHash)
 	if err != nil {
-		utils.Log.Error(fmt.Sprintf("查询交易索引失败: %v", err))
-		return fmt.Errorf("查询交易索引失败: %v", err)
+		utils.Log.Error(fmt.Sprintf("查询房产索引失败: %v", err))
+		return fmt.Errorf("查询房产索引失败: %v", err)
 	}
-	var transactionIndex blockDto.TransactionIndex
-	if err := json.Unmarshal(transactionIndexBytes, &transactionIndex); err != nil {
-		utils.Log.Error(fmt.Sprintf("解析交易索引失败: %v", err))
-		return fmt.Errorf("解析交易索引失败: %v", err)
+	var realtyIndex blockDto.RealtyIndex
+	if err := json.Unmarshal(realtyIndexBytes, &realtyIndex); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析房产索引失败: %v", err))
+		return fmt.Errorf("解析房产索引失败: %v", err)
 	}
-	subContract, err := blockchain.GetSubContract(transactionIndex.ChannelName, constants.InvestorOrganization)
-	if err != nil {
-		utils.Log.Error(fmt.Sprintf("获取子通道合约失败: %v", err))
-		return fmt.Errorf("获取子通道合约失败: %v", err)
+	if realtyIndex.CurrentOwnerCitizenIDHash == transaction.BuyerCitizenIDHash &&
+		realtyIndex.CurrentOwnerOrganization == transaction.BuyerOrganization {
+		return nil
 	}
-	_, err = subContract.SubmitTransaction(
-		"CompleteTransaction",
-		completeTransactionDTO.TransactionUUID,
+
+	// 调用主通道链码修改房产信息
+	_, err = mainContract.SubmitTransaction(
+		"UpdateRealtyIndex",
+		transaction.RealtyCertHash,
+		transaction.BuyerCitizenIDHash,
+		transaction.BuyerOrganization,
 	)
 	if err != nil {
-		utils.Log.Error(fmt.Sprintf("完成交易失败: %v", err))
-		return fmt.Errorf("完成交易失败: %v", err)
+		utils.Log.Error(fmt.Sprintf("修改房产信息失败: %v", err))
+		return abortOperation(fmt.Errorf("修改房产信息失败: %w", err))
 	}
+	return nil
+}
 
+// syncCompletedTransaction 过户完成后同步数据库中的交易、房产和合同状态
+func (s *transactionService) syncCompletedTransaction(transaction *models.Transaction) error {
 	// 调用DAO层完成交易
-	err = s.txDAO.CompleteTransaction(completeTransactionDTO.TransactionUUID)
+	err := s.txDAO.CompleteTransaction(transaction.TransactionUUID)
 	if err != nil {
 		utils.Log.Error(fmt.Sprintf("完成交易失败: %v", err))
 		return fmt.Errorf("完成交易失败: %v", err)
@@ -496,18 +922,6 @@ func (s *transactionService) CompleteTransaction(completeTransactionDTO *transac
 	s.cacheService.Remove(cache.RealtyPrefix + "cert:" + transaction.RealtyCertHash)
 	s.cacheService.Remove(cache.RealtyPrefix + "hash:" + transaction.RealtyCertHash)
 
-	// 调用主通道链码修改房产信息
-	_, err = mainContract.SubmitTransaction(
-		"UpdateRealtyIndex",
-		transaction.RealtyCertHash,
-		transaction.BuyerCitizenIDHash,
-		transaction.BuyerOrganization,
-	)
-	if err != nil {
-		utils.Log.Error(fmt.Sprintf("修改房产信息失败: %v", err))
-		return fmt.Errorf("修改房产信息失败: %v", err)
-	}
-
 	// 修改房产信息
 	realtyModel, err := dao.NewRealEstateDAO().GetRealtyByRealtyCertHash(transaction.RealtyCertHash)
 	if err != nil {
@@ -515,11 +929,32 @@ func (s *transactionService) CompleteTransaction(completeTransactionDTO *transac
 		return fmt.Errorf("获取房产信息失败: %v", err)
 	}
 
-	GlobalRealtyService.UpdateRealty(&realtyDto.UpdateRealtyDTO{
-		RealtyCert: realtyModel.RealtyCert,
-		IsNewHouse: &[]bool{false}[0],
-		Status:     constants.RealtyStatusNormal,
-	})
+	// 过户后的房产状态由链码决定（承接抵押的房产保持抵押中），从链上回读后同步数据库
+	subContract, err := s.getSubContractByTransactionUUID(transaction.TransactionUUID, constants.InvestorOrganization)
+	if err != nil {
+		return err
+	}
+	realtyBytes, err := subContract.EvaluateTransaction("QueryRealty", transaction.RealtyCertHash)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询房产信息失败: %v", err))
+		return fmt.Errorf("查询房产信息失败: %v", err)
+	}
+	var chaincodeRealty realtyDto.RealtyDTO
+	if err := json.Unmarshal(realtyBytes, &chaincodeRealty); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析房产信息失败: %v", err))
+		return fmt.Errorf("解析房产信息失败: %v", err)
+	}
+	realtyModel.Status = chaincodeRealty.Status
+	realtyModel.IsNewHouse = false
+	realtyModel.RelContractUUID = ""
+	if err := dao.NewRealEstateDAO().UpdateRealEstate(realtyModel); err != nil {
+		utils.Log.Error(fmt.Sprintf("更新房产信息失败: %v", err))
+		return fmt.Errorf("更新房产信息失败: %v", err)
+	}
+	s.cacheService.Remove(cache.RealtyPrefix + "cert:" + realtyModel.RealtyCert)
+	if realtyModel.ID > 0 {
+		s.cacheService.Remove(cache.RealtyPrefix + "id:" + fmt.Sprintf("%d", realtyModel.ID))
+	}
 
 	// 修改合同状态
 	contractModel, err := dao.NewContractDAO().GetContractByUUID(transaction.ContractUUID)
@@ -537,6 +972,220 @@ func (s *transactionService) CompleteTransaction(completeTransactionDTO *transac
 	return nil
 }
 
+// ConsentTransaction 共有人同意出售房产，链码校验调用者是否为房产共有人，异步提交并返回链上交易ID
+func (s *transactionService) ConsentTransaction(transactionUUID string, citizenID string, organization string) (string, error) {
+	subContract, err := s.getSubContractByTransactionUUID(transactionUUID, organization)
+	if err != nil {
+		return "", err
+	}
+	subContract, err = subContract.AsUser(organization, utils.GenerateHash(citizenID))
+	if err != nil {
+		return "", fmt.Errorf("获取用户身份失败: %v", err)
+	}
+
+	submitted, err := blockchain.SubmitAsync(subContract, "ConsentTransaction", s.removeTransactionCache(transactionUUID),
+		transactionUUID, utils.GenerateHash(citizenID))
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("共有人同意出售失败: %v", err))
+		return "", fmt.Errorf("共有人同意出售失败: %v", err)
+	}
+
+	return submitted.TxID, nil
+}
+
+// removeTransactionCache 交易上链后清除交易缓存
+func (s *transactionService) removeTransactionCache(transactionUUID string) func(status *blockchain.ChainTxStatus) {
+	return func(status *blockchain.ChainTxStatus) {
+		s.cacheService.Remove(cache.TransactionPrefix + "uuid:" + transactionUUID)
+	}
+}
+
+// ConsentTransactionOffline 以共有人自己签名的方式同意出售，链码校验签名者是否为房产共有人
+func (s *transactionService) ConsentTransactionOffline(transactionUUID string, citizenID string, organization string) (*blockchain.OfflineSession, error) {
+	publicKey, err := userDIDPublicKey(citizenID, organization)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户DID公钥失败: %v", err)
+	}
+
+	subContract, err := s.getSubContractByTransactionUUID(transactionUUID, organization)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := subContract.PrepareOffline(organization, utils.GenerateHash(citizenID), publicKey,
+		"ConsentTransaction", s.removeTransactionCache(transactionUUID), transactionUUID, utils.GenerateHash(citizenID))
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("创建共有人同意出售提案失败: %v", err))
+		return nil, fmt.Errorf("创建共有人同意出售提案失败: %v", err)
+	}
+	return session, nil
+}
+
+// GetTransactionTimeline 查询交易时间线
+func (s *transactionService) GetTransactionTimeline(transactionUUID string) ([]*transactionDto.TransactionRecordDTO, error) {
+	subContract, err := s.getSubContractByTransactionUUID(transactionUUID, constants.InvestorOrganization)
+	if err != nil {
+		return nil, err
+	}
+
+	timelineBytes, err := subContract.EvaluateTransaction("QueryTransactionTimeline", transactionUUID)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询交易时间线失败: %v", err))
+		return nil, fmt.Errorf("查询交易时间线失败: %v", err)
+	}
+
+	timeline := []*transactionDto.TransactionRecordDTO{}
+	if len(timelineBytes) == 0 {
+		return timeline, nil
+	}
+	if err := json.Unmarshal(timelineBytes, &timeline); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析交易时间线失败: %v", err))
+		return nil, fmt.Errorf("解析交易时间线失败: %v", err)
+	}
+
+	return timeline, nil
+}
+
+// getSubContractByTransactionUUID 根据交易索引获取指定组织在交易所在子通道的合约
+func (s *transactionService) getSubContractByTransactionUUID(transactionUUID string, organization string) (*blockchain.Contract, error) {
+	mainContract, err := blockchain.GetMainContract(constants.InvestorOrganization)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取合约失败: %v", err))
+		return nil, fmt.Errorf("获取合约失败: %v", err)
+	}
+
+	transactionIndexBytes, err := mainContract.EvaluateTransaction(
+		"GetTransactionIndex",
+		transactionUUID,
+	)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询交易索引失败: %v", err))
+		return nil, fmt.Errorf("查询交易索引失败: %v", err)
+	}
+
+	var transactionIndex blockDto.TransactionIndex
+	if err := json.Unmarshal(transactionIndexBytes, &transactionIndex); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析交易索引失败: %v", err))
+		return nil, fmt.Errorf("解析交易索引失败: %v", err)
+	}
+
+	subContract, err := blockchain.GetSubContract(transactionIndex.ChannelName, organization)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取子通道合约失败: %v", err))
+		return nil, fmt.Errorf("获取子通道合约失败: %v", err)
+	}
+
+	return subContract, nil
+}
+
+// RejectTransaction 拒绝交易，链上托管资金退还付款人，链码校验调用人是否为卖方，异步提交并返回链上交易ID
+func (s *transactionService) RejectTransaction(req *transactionDto.RejectTransactionDTO, citizenID string, organization string) (string, error) {
+	return s.closeTransaction(req.TransactionUUID, "RejectTransaction", constants.TxStatusRejected, citizenID, organization, nil)
+}
+
+// CancelTransaction 取消交易，链上托管资金退还付款人，链码校验调用人是否为买卖双方，异步提交并返回链上交易ID
+func (s *transactionService) CancelTransaction(req *transactionDto.CancelTransactionDTO, citizenID string, organization string) (string, error) {
+	return s.closeTransaction(req.TransactionUUID, "CancelTransaction", constants.TxStatusCancelled, citizenID, organization, nil)
+}
+
+// ExpireTransaction 终止超时未推进的交易，链上托管资金退还付款人，异步提交并返回链上交易ID
+func (s *transactionService) ExpireTransaction(req *transactionDto.ExpireTransactionDTO) (string, error) {
+	return s.closeTransaction(req.TransactionUUID, "ExpireTransaction", constants.TxStatusExpired, "", constants.InvestorOrganization, nil)
+}
+
+// closeTransaction 调用链码终止交易，交易上链后同步数据库状态并调用onCommit（可为nil）
+// citizenID为空时为系统调用（超时终止），否则以调用人本人的证书签名，链码从证书中识别调用人
+func (s *transactionService) closeTransaction(transactionUUID string, chaincodeFunc string, status string, citizenID string, organization string, onCommit func(status *blockchain.ChainTxStatus)) (string, error) {
+	// 查询交易
+	transaction, err := s.txDAO.GetTransactionByTransactionUUID(transactionUUID)
+	if err != nil {
+		return "", fmt.Errorf("查询交易失败: %v", err)
+	}
+
+	subContract, err := s.getSubContractByTransactionUUID(transactionUUID, organization)
+	if err != nil {
+		return "", err
+	}
+	signContract := subContract
+	if citizenID != "" {
+		signContract, err = subContract.AsUser(organization, utils.GenerateHash(citizenID))
+		if err != nil {
+			return "", fmt.Errorf("获取用户身份失败: %v", err)
+		}
+	}
+
+	afterCommit := func(commitStatus *blockchain.ChainTxStatus) {
+		s.cacheService.Remove(cache.TransactionPrefix + "uuid:" + transactionUUID)
+		s.cacheService.Remove(cache.RealtyPrefix + "hash:" + transaction.RealtyCertHash)
+		if onCommit != nil {
+			onCommit(commitStatus)
+		}
+	}
+	submitted, err := blockchain.SubmitAsync(signContract, chaincodeFunc, afterCommit, transactionUUID)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("终止交易失败: %v", err))
+		return "", fmt.Errorf("终止交易失败: %v", err)
+	}
+
+	// 上链后由业务操作同步房产和交易状态，链上交易已提交，登记失败时只记录日志
+	_, err = GlobalOperationService.Submit(constants.OperationCloseTransaction, transactionUUID, &transactionSyncOperation{
+		TxID:            submitted.TxID,
+		TransactionUUID: transactionUUID,
+		Status:          status,
+		Deadline:        time.Now().Add(constants.OperationWaitTimeout),
+	})
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("登记交易[%s]终止同步操作失败: %v", transactionUUID, err))
+	}
+
+	return submitted.TxID, nil
+}
+
+// syncClosedTransaction 交易终止上链后同步房产状态（挂牌或抵押中）和交易状态
+func (s *transactionService) syncClosedTransaction(subContract *blockchain.Contract, transaction *models.Transaction, status string) error {
+	s.cacheService.Remove(cache.RealtyPrefix + "hash:" + transaction.RealtyCertHash)
+	if err := s.syncRealtyStatus(subContract, transaction.RealtyCertHash); err != nil {
+		return err
+	}
+
+	// 调用DAO层更新交易
+	transaction.Status = status
+	return s.txDAO.UpdateTransaction(transaction)
+}
+
+// syncRealtyStatus 将链上房产状态同步到数据库，房产交易状态由链码维护
+func (s *transactionService) syncRealtyStatus(subContract *blockchain.Contract, realtyCertHash string) error {
+	realtyBytes, err := subContract.EvaluateTransaction("QueryRealty", realtyCertHash)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("查询房产信息失败: %v", err))
+		return fmt.Errorf("查询房产信息失败: %v", err)
+	}
+	var chaincodeRealty realtyDto.RealtyDTO
+	if err := json.Unmarshal(realtyBytes, &chaincodeRealty); err != nil {
+		utils.Log.Error(fmt.Sprintf("解析房产信息失败: %v", err))
+		return fmt.Errorf("解析房产信息失败: %v", err)
+	}
+
+	realtyDAO := dao.NewRealEstateDAO()
+	realty, err := realtyDAO.GetRealtyByRealtyCertHash(realtyCertHash)
+	if err != nil {
+		utils.Log.Error(fmt.Sprintf("获取房产信息失败: %v", err))
+		return fmt.Errorf("获取房产信息失败: %v", err)
+	}
+	if realty.Status == chaincodeRealty.Status {
+		return nil
+	}
+
+	realty.Status = chaincodeRealty.Status
+	if err := realtyDAO.UpdateRealEstate(realty); err != nil {
+		utils.Log.Error(fmt.Sprintf("更新房产状态失败: %v", err))
+		return fmt.Errorf("更新房产状态失败: %v", err)
+	}
+	s.cacheService.Remove(cache.RealtyPrefix + "cert:" + realty.RealtyCert)
+	s.cacheService.Remove(cache.RealtyPrefix + "hash:" + realtyCertHash)
+	return nil
+}
+
 // QueryTransactionStatistics 查询交易统计
 // 返回总交易量、总交易额、平均单价、税收总额
 func (s *transactionService) QueryTransactionStatistics(query *transactionDto.QueryTransactionStatisticsDTO) (
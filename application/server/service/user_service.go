@@ -196,6 +196,12 @@ func (s *userService) Register(req *userDto.RegisterDTO) error {
 		return fmt.Errorf("用户已存在")
 	}
 
+	// 为用户登记身份，注册交易以用户自己的身份签名
+	contract, err = contract.AsUser(req.Organization, utils.GenerateHash(req.CitizenID))
+	if err != nil {
+		return fmt.Errorf("登记用户身份失败: %v", err)
+	}
+
 	_, err = contract.SubmitTransaction(
 		"Register",
 		utils.GenerateHash(req.CitizenID),
@@ -32,6 +32,8 @@ export function createContract(data: {
   content: string
   contractType: string
   creatorCitizenID: string
+  buyerCitizenID: string
+  sellerCitizenID: string
 }) {
   return request({
     url: '/contracts/createContract',
@@ -43,6 +45,7 @@ export function createContract(data: {
 // 签署合同
 export function signContract(id: string, data: {
   signerType: string
+  signature: string
 }) {
   return request({
     url: `/contracts/${id}/sign`,
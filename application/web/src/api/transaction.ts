@@ -89,13 +89,29 @@ export function queryTransactionStatistics(data: {
   })
 }
 
-export function updateTransaction(data: {
-  transactionUUID: string,
-  status: string
-}){
+// 拒绝交易
+export function rejectTransaction(data: {
+  transactionUUID: string
+}) {
   return request({
-    url: '/transactions/updateTransaction',
+    url: '/transactions/rejectTransaction',
     method: 'post',
     data
   })
+}
+
+// 确认交易步骤（buyerSign、sellerSign、governmentApprove、confirmFundsEscrowed、confirmTaxPaid、transferTitle）
+export function confirmTransactionStep(transactionUUID: string, action: string) {
+  return request({
+    url: `/transactions/${transactionUUID}/${action}`,
+    method: 'post'
+  })
+}
+
+// 查询交易时间线
+export function getTransactionTimeline(transactionUUID: string) {
+  return request({
+    url: `/transactions/${transactionUUID}/timeline`,
+    method: 'get'
+  })
 }
\ No newline at end of file
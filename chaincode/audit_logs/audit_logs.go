@@ -41,6 +41,20 @@ type AuditLogsChaincode struct {
 	contractapi.Contract
 }
 
+// isAuditMSP 判断是否为审计组织
+func isAuditMSP(mspid string) bool {
+	return mspid == "AuditMSP" || mspid == "AuditOrg" || mspid == "audit-org"
+}
+
+// txTime 获取交易时间戳
+func txTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	return timestamp.AsTime(), nil
+}
+
 // 初始化账本
 func (a *AuditLogsChaincode) InitLedger(ctx contractapi.TransactionContextInterface) error {
 	log.Println("AuditLogsChaincode - 初始化账本成功")
@@ -78,7 +92,7 @@ func (a *AuditLogsChaincode) CreateAuditRecord(ctx contractapi.TransactionContex
 	}
 
 	// 只允许审计组织进行审计操作
-	if mspid != "AuditOrg" && mspid != "audit-org" {
+	if !isAuditMSP(mspid) {
 		return fmt.Errorf("只有审计组织可以创建审计记录")
 	}
 
@@ -119,7 +133,11 @@ func (a *AuditLogsChaincode) CreateAuditRecord(ctx contractapi.TransactionContex
 		}
 	}
 
-	now := time.Now()
+	// 使用交易时间戳，保证各背书节点的执行结果一致
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
 
 	// 创建审计记录
 	audit := AuditRecord{
@@ -215,7 +233,7 @@ func (a *AuditLogsChaincode) UpdateAuditRecord(ctx contractapi.TransactionContex
 	}
 
 	// 只允许审计组织进行审计操作
-	if mspid != "AuditOrg" && mspid != "audit-org" {
+	if !isAuditMSP(mspid) {
 		return fmt.Errorf("只有审计组织可以更新审计记录")
 	}
 
@@ -244,7 +262,10 @@ func (a *AuditLogsChaincode) UpdateAuditRecord(ctx contractapi.TransactionContex
 	audit.Result = result
 	audit.Comments = comments
 	audit.CurrentStatus = currentStatus
-	audit.LastModified = time.Now()
+	audit.LastModified, err = txTime(ctx)
+	if err != nil {
+		return err
+	}
 
 	// 解析违规项JSON
 	if violationsJSON != "" {
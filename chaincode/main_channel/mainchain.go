@@ -6,6 +6,8 @@ import (
 	"log"
 	"mainchain/models"
 	"mainchain/tools"
+	"strconv"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
 )
@@ -30,11 +32,28 @@ const (
 	TransactionIndexStatusInactive = "INACTIVE"
 )
 
+// 持有年限区间
+const (
+	HoldingPeriodLessThanTwoYears = "LT2Y"  // 不满两年
+	HoldingPeriodTwoToFiveYears   = "2Y_5Y" // 满两年不满五年
+	HoldingPeriodOverFiveYears    = "GE5Y"  // 满五年
+)
+
+// 链码事件类型，payload结构变更时递增EventVersion
+const (
+	EventVersion = 1
+
+	EventRealtyIndexRegistered = "RealtyIndexRegistered" // 房产索引注册，payload为RealtyIndex
+	EventChannelRegistered     = "ChannelRegistered"     // 子通道注册，payload为ChannelInfo
+)
+
 // 复合键类型
 const (
 	ChannelKeyType          = "channel"
 	RealtyIndexKeyType      = "realtyIndex"
 	TransactionIndexKeyType = "transactionIndex"
+	PaymentIndexKeyType     = "paymentIndex"
+	TaxRateKeyType          = "taxRate"
 )
 
 // InitLedger 初始化账本
@@ -73,6 +92,41 @@ func (s *MainChaincode) InitLedger(ctx contractapi.TransactionContextInterface)
 		return fmt.Errorf("[InitLedger]存储通道信息失败: %v", err)
 	}
 
+	// 初始化上海地区的默认税率表
+	realtyTypes := []string{"HOUSE", "SHOP", "OFFICE", "INDUSTRIAL", "OTHER"}
+	holdingPeriods := []string{HoldingPeriodLessThanTwoYears, HoldingPeriodTwoToFiveYears, HoldingPeriodOverFiveYears}
+	for _, realtyType := range realtyTypes {
+		for _, isFirstHome := range []bool{true, false} {
+			for _, holdingPeriod := range holdingPeriods {
+				taxRate := models.TaxRate{
+					ProvinceCode:   channelInfo.ProvinceCode,
+					RealtyType:     realtyType,
+					IsFirstHome:    isFirstHome,
+					HoldingPeriod:  holdingPeriod,
+					DeedTaxRate:    0.03,
+					VATRate:        0.053,
+					IncomeTaxRate:  0.01,
+					LastUpdateTime: timestamp.Seconds,
+				}
+				// 住宅：首套契税1%，满两年免征增值税，满五年免征个人所得税
+				if realtyType == "HOUSE" {
+					if isFirstHome {
+						taxRate.DeedTaxRate = 0.01
+					}
+					if holdingPeriod != HoldingPeriodLessThanTwoYears {
+						taxRate.VATRate = 0
+					}
+					if holdingPeriod == HoldingPeriodOverFiveYears {
+						taxRate.IncomeTaxRate = 0
+					}
+				}
+				if err := s.putTaxRate(ctx, &taxRate); err != nil {
+					return fmt.Errorf("[InitLedger]%v", err)
+				}
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -175,9 +229,9 @@ func (s *MainChaincode) RegisterRealtyIndex(
 		if err != nil {
 			return fmt.Errorf("[RegisterRealtyIndex]存储新房产索引失败: %v", err)
 		}
-	}
 
-	return nil
+		return s.emitEvent(ctx, EventRealtyIndexRegistered, newIndex)
+	}
 }
 
 // UpdateRealtyIndex 更新房产索引
@@ -250,6 +304,38 @@ func (s *MainChaincode) GetRealtyIndex(
 	return &index, nil
 }
 
+// QueryRealtyIndexPage 分页查询房产索引，用于对账，返回下一页书签
+func (s *MainChaincode) QueryRealtyIndexPage(
+	ctx contractapi.TransactionContextInterface,
+	pageSize int32,
+	bookmark string,
+) (*models.RealtyIndexPage, error) {
+	iter, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(RealtyIndexKeyType, []string{}, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("[QueryRealtyIndexPage]查询索引失败: %v", err)
+	}
+	defer iter.Close()
+
+	page := &models.RealtyIndexPage{RealtyIndexList: []*models.RealtyIndex{}}
+	for iter.HasNext() {
+		queryResponse, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("[QueryRealtyIndexPage]获取索引失败: %v", err)
+		}
+
+		var index models.RealtyIndex
+		if err := json.Unmarshal(queryResponse.Value, &index); err != nil {
+			return nil, fmt.Errorf("[QueryRealtyIndexPage]解析索引失败: %v", err)
+		}
+		page.RealtyIndexList = append(page.RealtyIndexList, &index)
+	}
+	if metadata != nil {
+		page.Bookmark = metadata.Bookmark
+	}
+
+	return page, nil
+}
+
 // RegisterTransactionIndex 注册交易索引
 func (s *MainChaincode) RegisterTransactionIndex(
 	ctx contractapi.TransactionContextInterface,
@@ -336,6 +422,109 @@ func (s *MainChaincode) GetTransactionIndex(
 	return &index, nil
 }
 
+// QueryTransactionIndexPage 分页查询交易索引，用于对账，返回下一页书签
+func (s *MainChaincode) QueryTransactionIndexPage(
+	ctx contractapi.TransactionContextInterface,
+	pageSize int32,
+	bookmark string,
+) (*models.TransactionIndexPage, error) {
+	iter, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(TransactionIndexKeyType, []string{}, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("[QueryTransactionIndexPage]查询索引失败: %v", err)
+	}
+	defer iter.Close()
+
+	page := &models.TransactionIndexPage{TransactionIndexList: []*models.TransactionIndex{}}
+	for iter.HasNext() {
+		queryResponse, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("[QueryTransactionIndexPage]获取索引失败: %v", err)
+		}
+
+		var index models.TransactionIndex
+		if err := json.Unmarshal(queryResponse.Value, &index); err != nil {
+			return nil, fmt.Errorf("[QueryTransactionIndexPage]解析索引失败: %v", err)
+		}
+		page.TransactionIndexList = append(page.TransactionIndexList, &index)
+	}
+	if metadata != nil {
+		page.Bookmark = metadata.Bookmark
+	}
+
+	return page, nil
+}
+
+// RegisterPaymentIndex 注册独立支付的索引，支付所在子通道由付款人身份证号的地区代码确定
+func (s *MainChaincode) RegisterPaymentIndex(
+	ctx contractapi.TransactionContextInterface,
+	paymentUUID string,
+	regionCode string,
+) error {
+	paymentIndexKey, err := ctx.GetStub().CreateCompositeKey(PaymentIndexKeyType, []string{paymentUUID})
+	if err != nil {
+		return fmt.Errorf("[RegisterPaymentIndex]创建复合键失败: %v", err)
+	}
+
+	// 检查该支付是否已注册
+	indexBytes, err := ctx.GetStub().GetState(paymentIndexKey)
+	if err != nil {
+		return fmt.Errorf("[RegisterPaymentIndex]查询支付索引失败: %v", err)
+	}
+	if indexBytes != nil {
+		return fmt.Errorf("[RegisterPaymentIndex]支付索引已存在: %s", paymentUUID)
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[RegisterPaymentIndex]获取当前时间失败: %v", err)
+	}
+
+	channelInfo, err := s.GetChannelInfoByRegionCode(ctx, regionCode)
+	if err != nil {
+		return fmt.Errorf("[RegisterPaymentIndex]查询通道信息失败: %v", err)
+	}
+
+	newIndexJSON, err := json.Marshal(models.PaymentIndex{
+		PaymentUUID: paymentUUID,
+		ChannelName: channelInfo.ChannelName,
+		CreateTime:  timestamp.Seconds,
+	})
+	if err != nil {
+		return fmt.Errorf("[RegisterPaymentIndex]转换新索引到JSON失败: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(paymentIndexKey, newIndexJSON); err != nil {
+		return fmt.Errorf("[RegisterPaymentIndex]存储新支付索引失败: %v", err)
+	}
+
+	return nil
+}
+
+// GetPaymentIndex 查询独立支付的索引
+func (s *MainChaincode) GetPaymentIndex(
+	ctx contractapi.TransactionContextInterface,
+	paymentUUID string,
+) (*models.PaymentIndex, error) {
+	indexKey, err := ctx.GetStub().CreateCompositeKey(PaymentIndexKeyType, []string{paymentUUID})
+	if err != nil {
+		return nil, fmt.Errorf("[GetPaymentIndex]创建复合键失败: %v", err)
+	}
+	indexBytes, err := ctx.GetStub().GetState(indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("[GetPaymentIndex]查询支付索引失败: %v", err)
+	}
+	if indexBytes == nil {
+		return nil, fmt.Errorf("[GetPaymentIndex]支付索引不存在: %s", paymentUUID)
+	}
+
+	var index models.PaymentIndex
+	if err := json.Unmarshal(indexBytes, &index); err != nil {
+		return nil, fmt.Errorf("[GetPaymentIndex]解析索引失败: %v", err)
+	}
+
+	return &index, nil
+}
+
 // RegisterChannel 注册新的子通道
 func (s *MainChaincode) RegisterChannel(
 	ctx contractapi.TransactionContextInterface,
@@ -385,6 +574,32 @@ func (s *MainChaincode) RegisterChannel(
 		return fmt.Errorf("[RegisterChannel]存储通道信息失败: %v", err)
 	}
 
+	return s.emitEvent(ctx, EventChannelRegistered, channelInfo)
+}
+
+// emitEvent 发送链码事件，Fabric每笔交易只保留最后一次SetEvent
+func (s *MainChaincode) emitEvent(ctx contractapi.TransactionContextInterface, eventType string, payload interface{}) error {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[emitEvent]获取当前时间失败: %v", err)
+	}
+
+	event := models.ChaincodeEvent{
+		EventType: eventType,
+		Version:   EventVersion,
+		TxID:      ctx.GetStub().GetTxID(),
+		Timestamp: time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC(),
+		Payload:   payload,
+	}
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("[emitEvent]转换链码事件到JSON失败: %v", err)
+	}
+
+	if err := ctx.GetStub().SetEvent(eventType, eventJSON); err != nil {
+		return fmt.Errorf("[emitEvent]发送链码事件失败: %v", err)
+	}
+
 	return nil
 }
 
@@ -520,6 +735,138 @@ func (s *MainChaincode) QueryRealtyIndicesByRegion(
 	return indices, nil
 }
 
+// SetTaxRate 设置税率（仅政府机构可调用）
+func (s *MainChaincode) SetTaxRate(
+	ctx contractapi.TransactionContextInterface,
+	provinceCode string,
+	realtyType string,
+	isFirstHome bool,
+	holdingPeriod string,
+	deedTaxRate float64,
+	vatRate float64,
+	incomeTaxRate float64,
+) error {
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("[SetTaxRate]获取客户端MSP ID失败: %v", err)
+	}
+	if clientMSPID != "GovernmentMSP" {
+		return fmt.Errorf("[SetTaxRate]只有政府机构可以设置税率")
+	}
+
+	if holdingPeriod != HoldingPeriodLessThanTwoYears &&
+		holdingPeriod != HoldingPeriodTwoToFiveYears &&
+		holdingPeriod != HoldingPeriodOverFiveYears {
+		return fmt.Errorf("[SetTaxRate]无效的持有年限区间: %s", holdingPeriod)
+	}
+
+	if deedTaxRate < 0 || vatRate < 0 || incomeTaxRate < 0 {
+		return fmt.Errorf("[SetTaxRate]税率不能为负数")
+	}
+
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[SetTaxRate]获取当前时间失败: %v", err)
+	}
+
+	taxRate := models.TaxRate{
+		ProvinceCode:   provinceCode,
+		RealtyType:     realtyType,
+		IsFirstHome:    isFirstHome,
+		HoldingPeriod:  holdingPeriod,
+		DeedTaxRate:    deedTaxRate,
+		VATRate:        vatRate,
+		IncomeTaxRate:  incomeTaxRate,
+		LastUpdateTime: timestamp.Seconds,
+	}
+
+	if err := s.putTaxRate(ctx, &taxRate); err != nil {
+		return fmt.Errorf("[SetTaxRate]%v", err)
+	}
+
+	return nil
+}
+
+// GetTaxRate 查询税率
+func (s *MainChaincode) GetTaxRate(
+	ctx contractapi.TransactionContextInterface,
+	provinceCode string,
+	realtyType string,
+	isFirstHome bool,
+	holdingPeriod string,
+) (*models.TaxRate, error) {
+	taxRateKey, err := ctx.GetStub().CreateCompositeKey(TaxRateKeyType, []string{
+		provinceCode,
+		realtyType,
+		strconv.FormatBool(isFirstHome),
+		holdingPeriod,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[GetTaxRate]创建复合键失败: %v", err)
+	}
+
+	taxRateBytes, err := ctx.GetStub().GetState(taxRateKey)
+	if err != nil {
+		return nil, fmt.Errorf("[GetTaxRate]查询税率失败: %v", err)
+	}
+
+	if taxRateBytes == nil {
+		return nil, fmt.Errorf("[GetTaxRate]未配置税率: %s/%s/%t/%s", provinceCode, realtyType, isFirstHome, holdingPeriod)
+	}
+
+	var taxRate models.TaxRate
+	err = json.Unmarshal(taxRateBytes, &taxRate)
+	if err != nil {
+		return nil, fmt.Errorf("[GetTaxRate]解析税率失败: %v", err)
+	}
+
+	return &taxRate, nil
+}
+
+// QueryTaxRatesByProvince 查询省份的全部税率
+func (s *MainChaincode) QueryTaxRatesByProvince(
+	ctx contractapi.TransactionContextInterface,
+	provinceCode string,
+) ([]*models.TaxRate, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(TaxRateKeyType, []string{provinceCode})
+	if err != nil {
+		return nil, fmt.Errorf("[QueryTaxRatesByProvince]查询税率失败: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	taxRates, err := tools.ConstructResultByIterator[models.TaxRate](resultsIterator)
+	if err != nil {
+		return nil, fmt.Errorf("[QueryTaxRatesByProvince]解析税率失败: %v", err)
+	}
+
+	return taxRates, nil
+}
+
+// putTaxRate 保存税率
+func (s *MainChaincode) putTaxRate(ctx contractapi.TransactionContextInterface, taxRate *models.TaxRate) error {
+	taxRateKey, err := ctx.GetStub().CreateCompositeKey(TaxRateKeyType, []string{
+		taxRate.ProvinceCode,
+		taxRate.RealtyType,
+		strconv.FormatBool(taxRate.IsFirstHome),
+		taxRate.HoldingPeriod,
+	})
+	if err != nil {
+		return fmt.Errorf("创建复合键失败: %v", err)
+	}
+
+	taxRateJSON, err := json.Marshal(taxRate)
+	if err != nil {
+		return fmt.Errorf("转换税率到JSON失败: %v", err)
+	}
+
+	err = ctx.GetStub().PutState(taxRateKey, taxRateJSON)
+	if err != nil {
+		return fmt.Errorf("存储税率失败: %v", err)
+	}
+
+	return nil
+}
+
 func main() {
 	chaincode, err := contractapi.NewChaincode(&MainChaincode{})
 	if err != nil {
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// ChaincodeEvent 链码事件，payload的结构由eventType和version决定
+type ChaincodeEvent struct {
+	EventType string      `json:"eventType"` // 事件类型
+	Version   int         `json:"version"`   // 事件结构版本
+	TxID      string      `json:"txID"`      // 交易ID
+	Timestamp time.Time   `json:"timestamp"` // 交易时间
+	Payload   interface{} `json:"payload"`   // 事件内容
+}
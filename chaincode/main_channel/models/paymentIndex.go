@@ -0,0 +1,8 @@
+package models
+
+// PaymentIndex 支付索引模型，记录不属于房产交易的独立支付所在的子通道
+type PaymentIndex struct {
+	PaymentUUID string `json:"paymentUUID"` // 支付UUID
+	ChannelName string `json:"channelName"` // 所在子通道名
+	CreateTime  int64  `json:"createTime"`  // 创建时间
+}
@@ -10,3 +10,9 @@ type RealtyIndex struct {
 	CurrentOwnerCitizenIDHash string `json:"currentOwnerCitizenIDHash"` // 当前房产所有人公民ID哈希
 	CurrentOwnerOrganization  string `json:"currentOwnerOrganization"`  // 当前房产所有人组织
 }
+
+// RealtyIndexPage 房产索引分页查询结果
+type RealtyIndexPage struct {
+	RealtyIndexList []*RealtyIndex `json:"realtyIndexList"` // 房产索引列表
+	Bookmark        string         `json:"bookmark"`        // 下一页书签，为空表示已到最后一页
+}
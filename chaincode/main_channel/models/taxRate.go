@@ -0,0 +1,13 @@
+package models
+
+// TaxRate 税率表结构，按省份、房产类型、是否首套、持有年限划分
+type TaxRate struct {
+	ProvinceCode   string  `json:"provinceCode"`   // 省份代码
+	RealtyType     string  `json:"realtyType"`     // 房产类型
+	IsFirstHome    bool    `json:"isFirstHome"`    // 是否首套
+	HoldingPeriod  string  `json:"holdingPeriod"`  // 持有年限区间
+	DeedTaxRate    float64 `json:"deedTaxRate"`    // 契税税率（买方缴纳）
+	VATRate        float64 `json:"vatRate"`        // 增值税税率（卖方缴纳）
+	IncomeTaxRate  float64 `json:"incomeTaxRate"`  // 个人所得税税率（卖方缴纳）
+	LastUpdateTime int64   `json:"lastUpdateTime"` // 最后更新时间
+}
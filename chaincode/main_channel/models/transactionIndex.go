@@ -8,3 +8,9 @@ type TransactionIndex struct {
 	Status          string `json:"status"`          // 状态
 	CreateTime      int64  `json:"createTime"`      // 创建时间
 }
+
+// TransactionIndexPage 交易索引分页查询结果
+type TransactionIndexPage struct {
+	TransactionIndexList []*TransactionIndex `json:"transactionIndexList"` // 交易索引列表
+	Bookmark             string              `json:"bookmark"`             // 下一页书签，为空表示已到最后一页
+}
@@ -1,5 +1,7 @@
 package constances
 
+import "time"
+
 // 房产状态枚举
 const (
 	RealtyStatusNormal      = "NORMAL"       // 正常
@@ -9,6 +11,13 @@ const (
 	RealtyStatusInMortgage  = "IN_MORTGAGE"  // 抵押中
 )
 
+// 司法冻结状态枚举
+const (
+	FreezeStatusActive   = "ACTIVE"   // 冻结中
+	FreezeStatusReleased = "RELEASED" // 已解冻
+	FreezeStatusExpired  = "EXPIRED"  // 到期解冻
+)
+
 // 房产类型枚举
 const (
 	RealtyTypeHouse      = "HOUSE"      // 住宅
@@ -24,6 +33,96 @@ const (
 	TxStatusInProgress = "IN_PROGRESS" // 已批准
 	TxStatusRejected   = "REJECTED"    // 已拒绝
 	TxStatusCompleted  = "COMPLETED"   // 已完成
+	TxStatusCancelled  = "CANCELLED"   // 已取消
+	TxStatusExpired    = "EXPIRED"     // 已超时
+)
+
+// TransactionTimeout 交易超时时间，超过该时间未推进的交易可被终止并释放房产
+const TransactionTimeout = 30 * 24 * time.Hour
+
+// 交易步骤枚举
+const (
+	TxStepBuyerSigned        = "BUYER_SIGNED"        // 买方签署
+	TxStepSellerSigned       = "SELLER_SIGNED"       // 卖方签署
+	TxStepGovernmentApproved = "GOVERNMENT_APPROVED" // 政府审批
+	TxStepFundsEscrowed      = "FUNDS_ESCROWED"      // 房款已托管
+	TxStepTaxPaid            = "TAX_PAID"            // 税费已缴清
+	TxStepTitleTransferred   = "TITLE_TRANSFERRED"   // 已过户
+)
+
+// 交易参与方枚举
+const (
+	TxPartyBuyer      = "BUYER"      // 买方
+	TxPartySeller     = "SELLER"     // 卖方
+	TxPartyGovernment = "GOVERNMENT" // 政府
+	TxPartyBank       = "BANK"       // 银行
+)
+
+// 抵押状态枚举
+const (
+	MortgageStatusPending  = "PENDING"  // 待审批
+	MortgageStatusActive   = "ACTIVE"   // 生效中
+	MortgageStatusAssumed  = "ASSUMED"  // 已同意买方承接
+	MortgageStatusReleased = "RELEASED" // 已解除
+)
+
+// 税费类型枚举
+const (
+	TaxTypeDeedTax   = "DEED_TAX"   // 契税（买方缴纳）
+	TaxTypeVAT       = "VAT"        // 增值税（卖方缴纳）
+	TaxTypeIncomeTax = "INCOME_TAX" // 个人所得税（卖方缴纳）
+)
+
+// 税费状态枚举
+const (
+	TaxStatusUnpaid   = "UNPAID"   // 未缴纳
+	TaxStatusPaid     = "PAID"     // 已缴纳
+	TaxStatusRefunded = "REFUNDED" // 已退还
+)
+
+// 资金托管状态枚举
+const (
+	EscrowStatusHolding  = "HOLDING"  // 托管中
+	EscrowStatusReleased = "RELEASED" // 已放款
+	EscrowStatusRefunded = "REFUNDED" // 已退款
+)
+
+// 链码事件类型，payload结构变更时递增EventVersion
+const (
+	EventVersion = 1
+
+	EventRealtyCreated        = "RealtyCreated"        // 房产登记
+	EventRealtyUpdated        = "RealtyUpdated"        // 房产信息变更
+	EventTransactionCreated   = "TransactionCreated"   // 交易创建
+	EventTransactionUpdated   = "TransactionUpdated"   // 交易步骤确认或终止
+	EventTransactionCompleted = "TransactionCompleted" // 交易完成过户
+	EventPaymentMade          = "PaymentMade"          // 支付发起
+	EventPaymentUpdated       = "PaymentUpdated"       // 支付核验、结算、冲正
+	EventContractUpdated      = "ContractUpdated"      // 合同变更（创建、更新、签署、审核、绑定交易）
+	EventMortgageUpdated      = "MortgageUpdated"      // 抵押变更（创建、批准、承接、解除），附带变更后的房产状态
+	EventDIDRegistered        = "DIDRegistered"        // DID注册
+	EventCredentialRevoked    = "CredentialRevoked"    // 凭证撤销
+)
+
+// 支付状态枚举
+const (
+	PaymentStatusInitiated    = "INITIATED"     // 已发起
+	PaymentStatusBankVerified = "BANK_VERIFIED" // 银行已核验
+	PaymentStatusSettled      = "SETTLED"       // 已结算
+	PaymentStatusReversed     = "REVERSED"      // 已冲正
+)
+
+// 纳税人角色枚举
+const (
+	TaxPayerBuyer  = "BUYER"  // 买方
+	TaxPayerSeller = "SELLER" // 卖方
+)
+
+// 持有年限区间（与主通道税率表保持一致）
+const (
+	HoldingPeriodLessThanTwoYears = "LT2Y"  // 不满两年
+	HoldingPeriodTwoToFiveYears   = "2Y_5Y" // 满两年不满五年
+	HoldingPeriodOverFiveYears    = "GE5Y"  // 满五年
 )
 
 // 组织MSP ID
@@ -35,6 +134,15 @@ const (
 	InvestorMSP   = "InvestorMSP"   // 投资者MSP ID
 )
 
+// 组织与MSP ID的对应关系
+var OrganizationMSPMap = map[string]string{
+	"government": GovernmentMSP,
+	"audit":      AuditMSP,
+	"thirdparty": ThirdpartyMSP,
+	"bank":       BankMSP,
+	"investor":   InvestorMSP,
+}
+
 // 文档类型常量（用于创建复合键）
 const (
 	DocTypeRealEstate  = "RE" // 房产信息
@@ -43,8 +151,9 @@ const (
 	DocTypeMortgage    = "MG" // 抵押信息
 	DocTypeAudit       = "AD" // 审计记录
 	DocTypeUser        = "US" // 用户信息
-	DocTypeTax         = "TX" // 税费信息
+	DocTypeTax         = "TA" // 税费信息
 	DocTypePayment     = "PT" // 支付信息
+	DocTypeEscrow      = "ES" // 资金托管
 )
 
 // 用户角色枚举
@@ -61,13 +170,28 @@ const (
 	PaymentTypeCash     = "CASH"     // 现金支付
 	PaymentTypeLoan     = "LOAN"     // 贷款支付
 	PaymentTypeTransfer = "TRANSFER" // 转账支付
+	PaymentTypeTax      = "TAX"      // 税费支付
 )
 
 // 合同状态枚举
 const (
-	ContractStatusNormal    = "NORMAL"    // 正常
-	ContractStatusFrozen    = "FROZEN"    // 冻结
-	ContractStatusCompleted = "COMPLETED" // 已完成
+	ContractStatusNormal     = "NORMAL"      // 正常
+	ContractStatusFrozen     = "FROZEN"      // 冻结
+	ContractStatusInProgress = "IN_PROGRESS" // 已绑定交易
+	ContractStatusCompleted  = "COMPLETED"   // 已完成
+)
+
+// 合同签署方
+const (
+	ContractPartyBuyer  = "buyer"  // 买方
+	ContractPartySeller = "seller" // 卖方
+)
+
+// 合同审核结果
+const (
+	ContractAuditApproved     = "approved"     // 通过
+	ContractAuditRejected     = "rejected"     // 拒绝
+	ContractAuditNeedRevision = "needRevision" // 需修改
 )
 
 // 用户状态枚举
@@ -84,3 +208,15 @@ const (
 	TransactionPrivateCollection = "TransactionPrivateCollection"
 	RealEstatePrivateCollection  = "RealEstatePrivateCollection"
 )
+
+// 政府默认账户（税费收款账户）
+const (
+	GovernmentDefaultCitizenID    = "GovernmentDefault"
+	GovernmentDefaultOrganization = "government"
+)
+
+// 主通道信息（用于跨通道查询房产索引和税率）
+const (
+	MainChannelName   = "mainchannel"
+	MainChaincodeName = "mainchaincode"
+)
@@ -7,14 +7,45 @@ import (
 
 // Contract 合同信息结构
 type Contract struct {
-	DocType              string    `json:"docType"`              // 文档类型
-	ContractUUID         string    `json:"contractUUID"`         // 合同UUID
-	DocHash              string    `json:"docHash"`              // 文档哈希
-	ContractType         string    `json:"contractType"`         // 合同类型
-	Status               string    `json:"status"`               // 合同状态
-	CreatorCitizenIDHash string    `json:"creatorCitizenIDHash"` // 创建人
-	CreateTime           time.Time `json:"createTime"`           // 创建时间
-	UpdateTime           time.Time `json:"updateTime"`           // 更新时间
+	DocType              string               `json:"docType"`              // 文档类型
+	ContractUUID         string               `json:"contractUUID"`         // 合同UUID
+	DocHash              string               `json:"docHash"`              // 文档哈希
+	ContractType         string               `json:"contractType"`         // 合同类型
+	Status               string               `json:"status"`               // 合同状态
+	CreatorCitizenIDHash string               `json:"creatorCitizenIDHash"` // 创建人
+	BuyerCitizenIDHash   string               `json:"buyerCitizenIDHash"`   // 买方签署人
+	SellerCitizenIDHash  string               `json:"sellerCitizenIDHash"`  // 卖方签署人
+	TransactionUUID      string               `json:"transactionUUID"`      // 绑定的交易UUID
+	RequiredPartyList    []string             `json:"requiredPartyList"`    // 须签署的合同方
+	SignatureList        []*ContractSignature `json:"signatureList"`        // 签署记录
+	AuditList            []*ContractAudit     `json:"auditList"`            // 审核记录
+	CreateTime           time.Time            `json:"createTime"`           // 创建时间
+	UpdateTime           time.Time            `json:"updateTime"`           // 更新时间
+}
+
+// ContractSignature 合同签署记录，签名为签署人DID密钥对文档哈希的签名
+type ContractSignature struct {
+	PartyRole           string    `json:"partyRole"`           // 签署方角色
+	SignerCitizenIDHash string    `json:"signerCitizenIDHash"` // 签署人
+	SignerOrganization  string    `json:"signerOrganization"`  // 签署人组织
+	DID                 string    `json:"did"`                 // 签署人DID
+	DocHash             string    `json:"docHash"`             // 签署时的文档哈希
+	Signature           string    `json:"signature"`           // 签名（r||s十六进制）
+	ClientID            string    `json:"clientID"`            // 提交人
+	MSPID               string    `json:"mspID"`               // 提交人所属MSP
+	SignTime            time.Time `json:"signTime"`            // 签署时间
+}
+
+// ContractAudit 合同审核记录
+type ContractAudit struct {
+	Result               string    `json:"result"`               // 审核结果
+	Comments             string    `json:"comments"`             // 审核意见
+	RevisionRequirements string    `json:"revisionRequirements"` // 修改要求
+	RejectionReason      string    `json:"rejectionReason"`      // 拒绝理由
+	DocHash              string    `json:"docHash"`              // 审核时的文档哈希
+	AuditorClientID      string    `json:"auditorClientID"`      // 审核人
+	AuditorMSPID         string    `json:"auditorMSPID"`         // 审核人所属MSP
+	AuditTime            time.Time `json:"auditTime"`            // 审核时间
 }
 
 func (c *Contract) IndexKey() string {
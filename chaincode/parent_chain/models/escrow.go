@@ -0,0 +1,41 @@
+package models
+
+import (
+	"parent_chain_chaincode/constances"
+	"time"
+)
+
+// Escrow 交易资金托管账户结构（按交易托管买卖双方支付的款项）
+type Escrow struct {
+	DocType             string           `json:"docType"`             // 文档类型
+	TransactionUUID     string           `json:"transactionUUID"`     // 关联交易UUID
+	BuyerCitizenIDHash  string           `json:"buyerCitizenIDHash"`  // 买方
+	BuyerOrganization   string           `json:"buyerOrganization"`   // 买方组织机构代码
+	SellerCitizenIDHash string           `json:"sellerCitizenIDHash"` // 卖方
+	SellerOrganization  string           `json:"sellerOrganization"`  // 卖方组织机构代码
+	TransferAmount      float64          `json:"transferAmount"`      // 托管房款
+	TaxAmount           float64          `json:"taxAmount"`           // 托管税费
+	DepositList         []*EscrowDeposit `json:"depositList"`         // 托管明细
+	Status              string           `json:"status"`              // 托管状态
+	CreateTime          time.Time        `json:"createTime"`          // 创建时间
+	SettleTime          time.Time        `json:"settleTime"`          // 放款/退款时间
+	LastUpdateTime      time.Time        `json:"lastUpdateTime"`      // 最后更新时间
+}
+
+// EscrowDeposit 托管明细，退款时按明细原路退回付款人
+type EscrowDeposit struct {
+	PaymentUUID        string    `json:"paymentUUID"`        // 支付ID
+	PaymentType        string    `json:"paymentType"`        // 支付类型
+	PayerCitizenIDHash string    `json:"payerCitizenIDHash"` // 付款人ID
+	PayerOrganization  string    `json:"payerOrganization"`  // 付款人组织机构代码
+	Amount             float64   `json:"amount"`             // 金额
+	CreateTime         time.Time `json:"createTime"`         // 托管时间
+}
+
+func (e *Escrow) IndexKey() string {
+	return "docType~transactionUUID"
+}
+
+func (e *Escrow) IndexAttr() []string {
+	return []string{constances.DocTypeEscrow, e.TransactionUUID}
+}
@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// ChaincodeEvent 链码事件，payload的结构由eventType和version决定
+type ChaincodeEvent struct {
+	EventType string      `json:"eventType"` // 事件类型
+	Version   int         `json:"version"`   // 事件结构版本
+	TxID      string      `json:"txID"`      // 交易ID
+	Timestamp time.Time   `json:"timestamp"` // 交易时间
+	Payload   interface{} `json:"payload"`   // 事件内容
+}
+
+// RealtyEvent 房产事件内容（RealtyCreated、RealtyUpdated）
+type RealtyEvent struct {
+	RealtyCertHash            string `json:"realtyCertHash"`            // 不动产证ID
+	RealtyCert                string `json:"realtyCert"`                // 不动产证号
+	RealtyType                string `json:"realtyType"`                // 建筑类型
+	Status                    string `json:"status"`                    // 房产状态
+	CurrentOwnerCitizenIDHash string `json:"currentOwnerCitizenIDHash"` // 当前所有者
+	CurrentOwnerOrganization  string `json:"currentOwnerOrganization"`  // 当前所有者组织
+	ActiveTransactionUUID     string `json:"activeTransactionUUID"`     // 锁定房产的交易UUID
+}
+
+// TransactionEvent 交易事件内容（TransactionCreated、TransactionUpdated、TransactionCompleted），不包含成交价等隐私数据
+// 同一笔链上交易只保留最后一个事件，交易流程同时变更的房产状态、所有者也记录在交易事件中，房产未变更时为空
+type TransactionEvent struct {
+	TransactionUUID          string `json:"transactionUUID"`                    // 交易UUID
+	RealtyCertHash           string `json:"realtyCertHash"`                     // 房产ID
+	SellerCitizenIDHash      string `json:"sellerCitizenIDHash"`                // 卖方
+	SellerOrganization       string `json:"sellerOrganization"`                 // 卖方组织机构代码
+	BuyerCitizenIDHash       string `json:"buyerCitizenIDHash"`                 // 买方
+	BuyerOrganization        string `json:"buyerOrganization"`                  // 买方组织机构代码
+	Status                   string `json:"status"`                             // 交易状态
+	Step                     string `json:"step"`                               // 本次确认的交易步骤
+	RealtyStatus             string `json:"realtyStatus,omitempty"`             // 变更后的房产状态
+	RealtyOwnerCitizenIDHash string `json:"realtyOwnerCitizenIDHash,omitempty"` // 变更后的房产所有者
+	RealtyOwnerOrganization  string `json:"realtyOwnerOrganization,omitempty"`  // 变更后的房产所有者组织
+}
+
+// PaymentEvent 支付事件内容（PaymentMade、PaymentUpdated）
+type PaymentEvent struct {
+	PaymentUUID           string  `json:"paymentUUID"`           // 支付ID
+	TransactionUUID       string  `json:"transactionUUID"`       // 关联交易ID
+	PaymentType           string  `json:"paymentType"`           // 支付类型
+	Amount                float64 `json:"amount"`                // 金额
+	PayerCitizenIDHash    string  `json:"payerCitizenIDHash"`    // 付款人ID
+	PayerOrganization     string  `json:"payerOrganization"`     // 付款人组织机构代码
+	ReceiverCitizenIDHash string  `json:"receiverCitizenIDHash"` // 收款人ID
+	ReceiverOrganization  string  `json:"receiverOrganization"`  // 收款人组织机构代码
+	Status                string  `json:"status"`                // 支付状态
+}
+
+// ContractEvent 合同事件内容（ContractUpdated）
+type ContractEvent struct {
+	ContractUUID    string `json:"contractUUID"`    // 合同UUID
+	DocHash         string `json:"docHash"`         // 文档哈希
+	Status          string `json:"status"`          // 合同状态
+	TransactionUUID string `json:"transactionUUID"` // 绑定的交易UUID
+	SignatureCount  int    `json:"signatureCount"`  // 签署数量
+	AuditCount      int    `json:"auditCount"`      // 审核数量
+}
+
+// MortgageEvent 抵押事件内容（MortgageUpdated），不包含贷款金额等隐私数据
+type MortgageEvent struct {
+	MortgageUUID           string `json:"mortgageUUID"`           // 抵押UUID
+	RealtyCertHash         string `json:"realtyCertHash"`         // 抵押房产ID
+	MortgagorCitizenIDHash string `json:"mortgagorCitizenIDHash"` // 抵押人
+	MortgagorOrganization  string `json:"mortgagorOrganization"`  // 抵押人组织机构代码
+	AssumeCitizenIDHash    string `json:"assumeCitizenIDHash"`    // 承接人
+	AssumeOrganization     string `json:"assumeOrganization"`     // 承接人组织机构代码
+	Status                 string `json:"status"`                 // 抵押状态
+	RealtyStatus           string `json:"realtyStatus"`           // 变更后的房产状态
+}
+
+// DIDEvent DID事件内容（DIDRegistered）
+type DIDEvent struct {
+	DID           string `json:"did"`           // DID
+	CitizenIDHash string `json:"citizenIDHash"` // 用户身份证号哈希
+	Organization  string `json:"organization"`  // 用户组织
+}
+
+// CredentialEvent 凭证事件内容（CredentialRevoked）
+type CredentialEvent struct {
+	CredentialID string `json:"credentialID"` // 凭证ID
+	IssuerDID    string `json:"issuerDID"`    // 颁发者DID
+	SubjectDID   string `json:"subjectDID"`   // 主体DID
+	RevokerDID   string `json:"revokerDID"`   // 撤销者DID
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// RealtyFreeze 房产司法冻结记录（保存在房产的冻结历史中）
+type RealtyFreeze struct {
+	FreezeID         string    `json:"freezeID"`         // 冻结记录ID（冻结时的链上交易ID）
+	CourtOrderHash   string    `json:"courtOrderHash"`   // 法院裁定书等法律文书哈希
+	Authority        string    `json:"authority"`        // 作出冻结决定的机关
+	PreviousStatus   string    `json:"previousStatus"`   // 冻结前的房产状态，解冻时恢复
+	Status           string    `json:"status"`           // 冻结状态
+	FreezeClientID   string    `json:"freezeClientID"`   // 冻结操作人
+	FreezeMSPID      string    `json:"freezeMSPID"`      // 冻结操作人所属MSP
+	FreezeTime       time.Time `json:"freezeTime"`       // 冻结时间
+	ExpireTime       time.Time `json:"expireTime"`       // 冻结到期时间
+	UnfreezeClientID string    `json:"unfreezeClientID"` // 解冻操作人
+	UnfreezeMSPID    string    `json:"unfreezeMSPID"`    // 解冻操作人所属MSP
+	UnfreezeReason   string    `json:"unfreezeReason"`   // 解冻原因
+	UnfreezeTime     time.Time `json:"unfreezeTime"`     // 解冻时间
+}
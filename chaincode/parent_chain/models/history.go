@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// HistoryMeta 键的历史版本信息
+type HistoryMeta struct {
+	TxID      string    `json:"txID"`      // 写入该版本的Fabric交易ID
+	Timestamp time.Time `json:"timestamp"` // 写入时间
+	IsDelete  bool      `json:"isDelete"`  // 该版本是否为删除
+}
+
+// RealtyHistory 房产信息历史版本
+type RealtyHistory struct {
+	HistoryMeta
+	Realty *Realty `json:"realty" metadata:"realty,optional"` // 该版本的房产公开信息，删除时为空
+}
+
+// TransactionHistory 交易信息历史版本
+type TransactionHistory struct {
+	HistoryMeta
+	Transaction *TransactionPublic `json:"transaction" metadata:"transaction,optional"` // 该版本的交易公开信息，删除时为空
+}
+
+// ContractHistory 合同信息历史版本
+type ContractHistory struct {
+	HistoryMeta
+	Contract *Contract `json:"contract" metadata:"contract,optional"` // 该版本的合同信息，删除时为空
+}
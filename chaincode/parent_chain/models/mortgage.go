@@ -0,0 +1,62 @@
+package models
+
+import (
+	"parent_chain_chaincode/constances"
+	"time"
+)
+
+// Mortgage 抵押信息结构
+type Mortgage struct {
+	DocType                string    `json:"docType"`                // 文档类型
+	MortgageUUID           string    `json:"mortgageUUID"`           // 抵押UUID
+	RealtyCertHash         string    `json:"realtyCertHash"`         // 抵押房产ID
+	MortgagorCitizenIDHash string    `json:"mortgagorCitizenIDHash"` // 抵押人
+	MortgagorOrganization  string    `json:"mortgagorOrganization"`  // 抵押人组织机构代码
+	AssumeCitizenIDHash    string    `json:"assumeCitizenIDHash"`    // 承接人（买方承接抵押时使用）
+	AssumeOrganization     string    `json:"assumeOrganization"`     // 承接人组织机构代码
+	LoanAmount             float64   `json:"loanAmount"`             // 贷款金额
+	InterestRate           float64   `json:"interestRate"`           // 利率
+	Term                   int       `json:"term"`                   // 期限(月)
+	CollateralValue        float64   `json:"collateralValue"`        // 抵押物估值
+	PaymentPlan            string    `json:"paymentPlan"`            // 还款计划
+	Status                 string    `json:"status"`                 // 抵押状态
+	PreviousRealtyStatus   string    `json:"previousRealtyStatus"`   // 房产抵押前的状态（全部抵押解除后恢复）
+	CreateTime             time.Time `json:"createTime"`             // 创建时间
+	ApprovedTime           time.Time `json:"approvedTime"`           // 批准时间
+	ReleasedTime           time.Time `json:"releasedTime"`           // 解除时间
+	LastUpdateTime         time.Time `json:"lastUpdateTime"`         // 最后更新时间
+}
+
+type MortgagePublic struct {
+	DocType                string    `json:"docType"`                // 文档类型
+	MortgageUUID           string    `json:"mortgageUUID"`           // 抵押UUID
+	RealtyCertHash         string    `json:"realtyCertHash"`         // 抵押房产ID
+	MortgagorCitizenIDHash string    `json:"mortgagorCitizenIDHash"` // 抵押人
+	MortgagorOrganization  string    `json:"mortgagorOrganization"`  // 抵押人组织机构代码
+	AssumeCitizenIDHash    string    `json:"assumeCitizenIDHash"`    // 承接人（买方承接抵押时使用）
+	AssumeOrganization     string    `json:"assumeOrganization"`     // 承接人组织机构代码
+	Status                 string    `json:"status"`                 // 抵押状态
+	PreviousRealtyStatus   string    `json:"previousRealtyStatus"`   // 房产抵押前的状态（全部抵押解除后恢复）
+	CreateTime             time.Time `json:"createTime"`             // 创建时间
+	ApprovedTime           time.Time `json:"approvedTime"`           // 批准时间
+	ReleasedTime           time.Time `json:"releasedTime"`           // 解除时间
+	LastUpdateTime         time.Time `json:"lastUpdateTime"`         // 最后更新时间
+}
+
+type MortgagePrivate struct {
+	DocType         string  `json:"docType"`         // 文档类型
+	MortgageUUID    string  `json:"mortgageUUID"`    // 抵押UUID
+	LoanAmount      float64 `json:"loanAmount"`      // 贷款金额
+	InterestRate    float64 `json:"interestRate"`    // 利率
+	Term            int     `json:"term"`            // 期限(月)
+	CollateralValue float64 `json:"collateralValue"` // 抵押物估值
+	PaymentPlan     string  `json:"paymentPlan"`     // 还款计划
+}
+
+func (m *Mortgage) IndexKey() string {
+	return "docType~mortgageUUID"
+}
+
+func (m *Mortgage) IndexAttr() []string {
+	return []string{constances.DocTypeMortgage, m.MortgageUUID}
+}
@@ -16,7 +16,13 @@ type Payment struct {
 	PayerOrganization     string    `json:"payerOrganization"`     // 付款人组织机构代码
 	ReceiverCitizenIDHash string    `json:"receiverCitizenIDHash"` // 收款人ID
 	ReceiverOrganization  string    `json:"receiverOrganization"`  // 收款人组织机构代码
+	Status                string    `json:"status"`                // 支付状态
+	ReverseReason         string    `json:"reverseReason"`         // 冲正原因
 	CreateTime            time.Time `json:"createTime"`            // 创建时间
+	VerifyTime            time.Time `json:"verifyTime"`            // 银行核验时间
+	SettleTime            time.Time `json:"settleTime"`            // 结算时间
+	ReverseTime           time.Time `json:"reverseTime"`           // 冲正时间
+	LastUpdateTime        time.Time `json:"lastUpdateTime"`        // 最后更新时间
 }
 
 func (p *Payment) IndexKey() string {
@@ -26,3 +32,9 @@ func (p *Payment) IndexKey() string {
 func (p *Payment) IndexAttr() []string {
 	return []string{constances.DocTypePayment, p.PaymentUUID}
 }
+
+// PaymentPage 支付分页查询结果
+type PaymentPage struct {
+	PaymentList []*Payment `json:"paymentList"` // 支付列表
+	Bookmark    string     `json:"bookmark"`    // 下一页书签，为空表示已到最后一页
+}
@@ -7,35 +7,50 @@ import (
 
 // Realty 房产信息结构
 type Realty struct {
-	DocType                         string    `json:"docType"`                         // 文档类型
-	RealtyCertHash                  string    `json:"realtyCertHash"`                  // 不动产证ID
-	RealtyCert                      string    `json:"realtyCert"`                      // 不动产证ID
-	RealtyType                      string    `json:"realtyType"`                      // 建筑类型
-	CurrentOwnerCitizenIDHash       string    `json:"currentOwnerCitizenIDHash"`       // 当前所有者
-	CurrentOwnerOrganization        string    `json:"currentOwnerOrganization"`        // 当前所有者的组织
-	PreviousOwnersCitizenIDHashList []string  `json:"previousOwnersCitizenIDHashList"` // 历史所有者
-	CreateTime                      time.Time `json:"createTime"`                      // 创建时间
-	Status                          string    `json:"status"`                          // 房产当前状态
-	LastUpdateTime                  time.Time `json:"lastUpdateTime"`                  // 最后更新时间
+	DocType                         string          `json:"docType"`                         // 文档类型
+	RealtyCertHash                  string          `json:"realtyCertHash"`                  // 不动产证ID
+	RealtyCert                      string          `json:"realtyCert"`                      // 不动产证ID
+	RealtyType                      string          `json:"realtyType"`                      // 建筑类型
+	CurrentOwnerCitizenIDHash       string          `json:"currentOwnerCitizenIDHash"`       // 当前所有者
+	CurrentOwnerOrganization        string          `json:"currentOwnerOrganization"`        // 当前所有者的组织
+	PreviousOwnersCitizenIDHashList []string        `json:"previousOwnersCitizenIDHashList"` // 历史所有者
+	OwnerList                       []*RealtyOwner  `json:"ownerList"`                       // 共有人及份额
+	CreateTime                      time.Time       `json:"createTime"`                      // 创建时间
+	AcquireTime                     time.Time       `json:"acquireTime"`                     // 当前所有者取得时间
+	Status                          string          `json:"status"`                          // 房产当前状态
+	ActiveTransactionUUID           string          `json:"activeTransactionUUID"`           // 进行中的交易UUID（交易中状态时有效）
+	FreezeHistory                   []*RealtyFreeze `json:"freezeHistory"`                   // 司法冻结历史
+	LastUpdateTime                  time.Time       `json:"lastUpdateTime"`                  // 最后更新时间
 }
 
 type RealtyPublic struct {
-	DocType        string    `json:"docType"`        // 文档类型
-	RealtyCertHash string    `json:"realtyCertHash"` // 不动产证ID
-	RealtyCert     string    `json:"realtyCert"`     // 不动产证ID
-	RealtyType     string    `json:"realtyType"`     // 建筑类型
-	CreateTime     time.Time `json:"createTime"`     // 创建时间
-	Status         string    `json:"status"`         // 房产当前状态
-	LastUpdateTime time.Time `json:"lastUpdateTime"` // 最后更新时间
+	DocType               string          `json:"docType"`               // 文档类型
+	RealtyCertHash        string          `json:"realtyCertHash"`        // 不动产证ID
+	RealtyCert            string          `json:"realtyCert"`            // 不动产证ID
+	RealtyType            string          `json:"realtyType"`            // 建筑类型
+	CreateTime            time.Time       `json:"createTime"`            // 创建时间
+	AcquireTime           time.Time       `json:"acquireTime"`           // 当前所有者取得时间
+	Status                string          `json:"status"`                // 房产当前状态
+	ActiveTransactionUUID string          `json:"activeTransactionUUID"` // 进行中的交易UUID（交易中状态时有效）
+	FreezeHistory         []*RealtyFreeze `json:"freezeHistory"`         // 司法冻结历史
+	LastUpdateTime        time.Time       `json:"lastUpdateTime"`        // 最后更新时间
 }
 
 type RealtyPrivate struct {
-	DocType                         string   `json:"docType"`                         // 文档类型
-	RealtyCertHash                  string   `json:"realtyCertHash"`                  // 不动产证ID
-	RealtyCert                      string   `json:"realtyCert"`                      // 不动产证ID
-	CurrentOwnerCitizenIDHash       string   `json:"currentOwnerCitizenIDHash"`       // 当前所有者
-	CurrentOwnerOrganization        string   `json:"currentOwnerOrganization"`        // 当前所有者的组织
-	PreviousOwnersCitizenIDHashList []string `json:"previousOwnersCitizenIDHashList"` // 历史所有者
+	DocType                         string         `json:"docType"`                         // 文档类型
+	RealtyCertHash                  string         `json:"realtyCertHash"`                  // 不动产证ID
+	RealtyCert                      string         `json:"realtyCert"`                      // 不动产证ID
+	CurrentOwnerCitizenIDHash       string         `json:"currentOwnerCitizenIDHash"`       // 当前所有者
+	CurrentOwnerOrganization        string         `json:"currentOwnerOrganization"`        // 当前所有者的组织
+	PreviousOwnersCitizenIDHashList []string       `json:"previousOwnersCitizenIDHashList"` // 历史所有者
+	OwnerList                       []*RealtyOwner `json:"ownerList"`                       // 共有人及份额
+}
+
+// RealtyOwner 房产共有人
+type RealtyOwner struct {
+	CitizenIDHash string  `json:"citizenIDHash"` // 共有人
+	Organization  string  `json:"organization"`  // 共有人的组织
+	Share         float64 `json:"share"`         // 所有权份额（百分比）
 }
 
 func (r *Realty) IndexKey() string {
@@ -45,3 +60,9 @@ func (r *Realty) IndexKey() string {
 func (r *Realty) IndexAttr() []string {
 	return []string{constances.DocTypeRealEstate, r.RealtyCertHash}
 }
+
+// RealtyPage 房产分页查询结果
+type RealtyPage struct {
+	RealtyList []*Realty `json:"realtyList"` // 房产列表（含私有数据）
+	Bookmark   string    `json:"bookmark"`   // 下一页书签，为空表示已到最后一页
+}
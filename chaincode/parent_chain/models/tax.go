@@ -0,0 +1,48 @@
+package models
+
+import (
+	"parent_chain_chaincode/constances"
+	"time"
+)
+
+// Tax 税费明细结构（按交易逐项核定）
+type Tax struct {
+	DocType         string    `json:"docType"`         // 文档类型
+	TransactionUUID string    `json:"transactionUUID"` // 关联交易UUID
+	TaxType         string    `json:"taxType"`         // 税费类型
+	PayerRole       string    `json:"payerRole"`       // 纳税人角色（买方/卖方）
+	TaxBase         float64   `json:"taxBase"`         // 计税依据
+	TaxRate         float64   `json:"taxRate"`         // 税率
+	TaxAmount       float64   `json:"taxAmount"`       // 税额
+	Status          string    `json:"status"`          // 状态（已缴/未缴）
+	PaymentUUID     string    `json:"paymentUUID"`     // 缴税支付ID
+	PaidTime        time.Time `json:"paidTime"`        // 缴纳时间
+	CreateTime      time.Time `json:"createTime"`      // 核定时间
+}
+
+// TaxRate 主通道税率表结构
+type TaxRate struct {
+	ProvinceCode   string  `json:"provinceCode"`   // 省份代码
+	RealtyType     string  `json:"realtyType"`     // 房产类型
+	IsFirstHome    bool    `json:"isFirstHome"`    // 是否首套
+	HoldingPeriod  string  `json:"holdingPeriod"`  // 持有年限区间
+	DeedTaxRate    float64 `json:"deedTaxRate"`    // 契税税率
+	VATRate        float64 `json:"vatRate"`        // 增值税税率
+	IncomeTaxRate  float64 `json:"incomeTaxRate"`  // 个人所得税税率
+	LastUpdateTime int64   `json:"lastUpdateTime"` // 最后更新时间
+}
+
+// RealtyIndex 主通道房产索引结构
+type RealtyIndex struct {
+	RealtyCertHash string `json:"realtyCertHash"` // 房产证书哈希
+	ChannelName    string `json:"channelName"`    // 所在子通道名
+	ProvinceCode   string `json:"provinceCode"`   // 省份代码
+}
+
+func (t *Tax) IndexKey() string {
+	return "docType~transactionUUID~taxType"
+}
+
+func (t *Tax) IndexAttr() []string {
+	return []string{constances.DocTypeTax, t.TransactionUUID, t.TaxType}
+}
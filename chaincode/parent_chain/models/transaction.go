@@ -20,6 +20,8 @@ type Transaction struct {
 	CreateTime             time.Time `json:"createTime"`             // 创建时间
 	UpdateTime             time.Time `json:"updateTime"`             // 更新时间
 	EstimatedCompletedTime time.Time `json:"estimatedCompletedTime"` // 预计完成时间
+	CompletedStepList      []string  `json:"completedStepList"`      // 已完成的交易步骤
+	SellerConsentList      []string  `json:"sellerConsentList"`      // 已同意出售的共有人
 	PaymentUUIDList        []string  `json:"paymentUUIDList"`        // 关联支付ID
 	ContractIDHash         string    `json:"contractIdHash"`         // 关联合同ID
 }
@@ -36,6 +38,8 @@ type TransactionPublic struct {
 	CreateTime             time.Time `json:"createTime"`             // 创建时间
 	UpdateTime             time.Time `json:"updateTime"`             // 更新时间
 	EstimatedCompletedTime time.Time `json:"estimatedCompletedTime"` // 预计完成时间
+	CompletedStepList      []string  `json:"completedStepList"`      // 已完成的交易步骤
+	SellerConsentList      []string  `json:"sellerConsentList"`      // 已同意出售的共有人
 }
 
 type TransactionPrivate struct {
@@ -47,6 +51,14 @@ type TransactionPrivate struct {
 	ContractUUID    string   `json:"contractUUID"`    // 关联合同ID
 }
 
+// TransactionRecord 交易操作记录（创建、步骤确认、终止等）
+type TransactionRecord struct {
+	ClientID string    `json:"clientID"`        // 操作人
+	MSPID    string    `json:"mspID,omitempty"` // 操作人所属MSP
+	Action   string    `json:"action"`          // 操作
+	Time     time.Time `json:"time"`            // 操作时间
+}
+
 func (t *Transaction) IndexKey() string {
 	return "docType~transactionUUID"
 }
@@ -54,3 +66,9 @@ func (t *Transaction) IndexKey() string {
 func (t *Transaction) IndexAttr() []string {
 	return []string{constances.DocTypeTransaction, t.TransactionUUID}
 }
+
+// TransactionPage 交易分页查询结果
+type TransactionPage struct {
+	TransactionList []*TransactionPublic `json:"transactionList"` // 交易公开信息列表
+	Bookmark        string               `json:"bookmark"`        // 下一页书签，为空表示已到最后一页
+}
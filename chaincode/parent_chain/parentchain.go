@@ -1,12 +1,18 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"parent_chain_chaincode/constances"
 	"parent_chain_chaincode/models"
 	"parent_chain_chaincode/tools"
+	"slices"
+	"sort"
+	"strconv"
 	"time"
 
 	"maps"
@@ -18,39 +24,6 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
-// 抵押信息结构
-type Mortgage struct {
-	MortgageID      string    `json:"mortgageId"`      // 抵押ID
-	RealEstateID    string    `json:"realEstateId"`    // 房产ID
-	BankID          string    `json:"bankId"`          // 银行ID
-	BorrowerID      string    `json:"borrowerId"`      // 借款人ID
-	LoanAmount      float64   `json:"loanAmount"`      // 贷款金额
-	InterestRate    float64   `json:"interestRate"`    // 利率
-	Term            int       `json:"term"`            // 期限(月)
-	StartDate       time.Time `json:"startDate"`       // 开始日期
-	EndDate         time.Time `json:"endDate"`         // 结束日期
-	Status          string    `json:"status"`          // 状态
-	ApprovedBy      string    `json:"approvedBy"`      // 批准人
-	ApprovedAt      time.Time `json:"approvedAt"`      // 批准时间
-	LastUpdateTime  time.Time `json:"lastUpdateTime"`  // 最后更新时间
-	PaymentPlan     string    `json:"paymentPlan"`     // 还款计划
-	CollateralValue float64   `json:"collateralValue"` // 抵押物估值
-}
-
-// 税费信息结构
-type Tax struct {
-	TaxID         string    `json:"taxId"`         // 税费ID
-	TransactionID string    `json:"transactionId"` // 关联交易ID
-	TaxType       string    `json:"taxType"`       // 税费类型
-	TaxRate       float64   `json:"taxRate"`       // 税率
-	TaxAmount     float64   `json:"taxAmount"`     // 税额
-	Status        string    `json:"status"`        // 状态（已缴/未缴）
-	DueDate       time.Time `json:"dueDate"`       // 截止日期
-	PaidAt        time.Time `json:"paidAt"`        // 缴纳时间
-	PaidBy        string    `json:"paidBy"`        // 缴纳人
-	ReceiptID     string    `json:"receiptId"`     // 收据ID
-}
-
 // 审计记录结构
 type AuditRecord struct {
 	AuditID         string    `json:"auditId"`         // 审计ID
@@ -160,6 +133,25 @@ func (s *SmartContract) getClientIdentityMSPID(ctx contractapi.TransactionContex
 
 }
 
+// 获取调用者的公民身份证号哈希
+// 个人用户证书的CN为登记时的公民身份证号哈希，组织身份的证书不是个人证书，返回空字符串
+func (s *SmartContract) getClientCitizenIDHash(ctx contractapi.TransactionContextInterface) (string, error) {
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return "", fmt.Errorf("[getClientCitizenIDHash] 获取客户端证书失败: %v", err)
+	}
+	if cert == nil {
+		return "", fmt.Errorf("[getClientCitizenIDHash] 客户端证书为空")
+	}
+
+	commonName := cert.Subject.CommonName
+	hashBytes, err := hex.DecodeString(commonName)
+	if err != nil || len(hashBytes) != sha256.Size {
+		return "", nil
+	}
+	return commonName, nil
+}
+
 // 创建复合键
 func (s *SmartContract) createCompositeKey(ctx contractapi.TransactionContextInterface, objectType string,
 	attributes ...string) (string, error) {
@@ -170,6 +162,197 @@ func (s *SmartContract) createCompositeKey(ctx contractapi.TransactionContextInt
 	return key, nil
 }
 
+// 发送链码事件
+// Fabric每笔交易只保留最后一次SetEvent，同一交易内后发送的事件会覆盖先发送的事件
+func (s *SmartContract) emitEvent(ctx contractapi.TransactionContextInterface, eventType string, payload interface{}) error {
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+
+	event := models.ChaincodeEvent{
+		EventType: eventType,
+		Version:   constances.EventVersion,
+		TxID:      ctx.GetStub().GetTxID(),
+		Timestamp: time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+		Payload:   payload,
+	}
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("序列化链码事件失败: %v", err)
+	}
+
+	if err := ctx.GetStub().SetEvent(eventType, eventJSON); err != nil {
+		return fmt.Errorf("发送链码事件失败: %v", err)
+	}
+
+	return nil
+}
+
+// 构造房产事件内容
+func newRealtyEvent(realty *models.Realty) *models.RealtyEvent {
+	return &models.RealtyEvent{
+		RealtyCertHash:            realty.RealtyCertHash,
+		RealtyCert:                realty.RealtyCert,
+		RealtyType:                realty.RealtyType,
+		Status:                    realty.Status,
+		CurrentOwnerCitizenIDHash: realty.CurrentOwnerCitizenIDHash,
+		CurrentOwnerOrganization:  realty.CurrentOwnerOrganization,
+		ActiveTransactionUUID:     realty.ActiveTransactionUUID,
+	}
+}
+
+// 构造交易事件内容，realty为本次交易变更后的房产，房产未变更时传nil
+func newTransactionEvent(transactionPublic *models.TransactionPublic, realty *models.Realty) *models.TransactionEvent {
+	event := &models.TransactionEvent{
+		TransactionUUID:     transactionPublic.TransactionUUID,
+		RealtyCertHash:      transactionPublic.RealtyCertHash,
+		SellerCitizenIDHash: transactionPublic.SellerCitizenIDHash,
+		SellerOrganization:  transactionPublic.SellerOrganization,
+		BuyerCitizenIDHash:  transactionPublic.BuyerCitizenIDHash,
+		BuyerOrganization:   transactionPublic.BuyerOrganization,
+		Status:              transactionPublic.Status,
+	}
+	if realty != nil {
+		event.RealtyStatus = realty.Status
+		event.RealtyOwnerCitizenIDHash = realty.CurrentOwnerCitizenIDHash
+		event.RealtyOwnerOrganization = realty.CurrentOwnerOrganization
+	}
+	return event
+}
+
+// 构造支付事件内容
+func newPaymentEvent(payment *models.Payment) *models.PaymentEvent {
+	return &models.PaymentEvent{
+		PaymentUUID:           payment.PaymentUUID,
+		TransactionUUID:       payment.TransactionUUID,
+		PaymentType:           payment.PaymentType,
+		Amount:                payment.Amount,
+		PayerCitizenIDHash:    payment.PayerCitizenIDHash,
+		PayerOrganization:     payment.PayerOrganization,
+		ReceiverCitizenIDHash: payment.ReceiverCitizenIDHash,
+		ReceiverOrganization:  payment.ReceiverOrganization,
+		Status:                payment.Status,
+	}
+}
+
+// 发送抵押事件，附带房产当前状态供链下同步
+// realEstate为本次交易变更后的房产，房产未变更时传nil，从账本读取（同一交易内读不到本交易的写入）
+func (s *SmartContract) emitMortgageEvent(ctx contractapi.TransactionContextInterface,
+	mortgagePublic *models.MortgagePublic,
+	realEstate *models.Realty,
+) error {
+	if realEstate == nil {
+		var err error
+		realEstate, err = s.QueryRealty(ctx, mortgagePublic.RealtyCertHash)
+		if err != nil {
+			return err
+		}
+	}
+	return s.emitEvent(ctx, constances.EventMortgageUpdated, &models.MortgageEvent{
+		MortgageUUID:           mortgagePublic.MortgageUUID,
+		RealtyCertHash:         mortgagePublic.RealtyCertHash,
+		MortgagorCitizenIDHash: mortgagePublic.MortgagorCitizenIDHash,
+		MortgagorOrganization:  mortgagePublic.MortgagorOrganization,
+		AssumeCitizenIDHash:    mortgagePublic.AssumeCitizenIDHash,
+		AssumeOrganization:     mortgagePublic.AssumeOrganization,
+		Status:                 mortgagePublic.Status,
+		RealtyStatus:           realEstate.Status,
+	})
+}
+
+// 构造合同事件内容
+func newContractEvent(contract *models.Contract) *models.ContractEvent {
+	return &models.ContractEvent{
+		ContractUUID:    contract.ContractUUID,
+		DocHash:         contract.DocHash,
+		Status:          contract.Status,
+		TransactionUUID: contract.TransactionUUID,
+		SignatureCount:  len(contract.SignatureList),
+		AuditCount:      len(contract.AuditList),
+	}
+}
+
+// 按写入时间从早到晚遍历键的全部历史版本（仅公开数据有历史记录）
+func (s *SmartContract) walkKeyHistory(ctx contractapi.TransactionContextInterface,
+	key string,
+	visit func(meta models.HistoryMeta, value []byte) error,
+) error {
+	iter, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return fmt.Errorf("[walkKeyHistory] 查询历史版本失败: %v", err)
+	}
+	defer iter.Close()
+
+	type version struct {
+		meta  models.HistoryMeta
+		value []byte
+	}
+	versionList := []version{}
+	for iter.HasNext() {
+		modification, err := iter.Next()
+		if err != nil {
+			return fmt.Errorf("[walkKeyHistory] 查询历史版本失败: %v", err)
+		}
+		meta := models.HistoryMeta{
+			TxID:     modification.TxId,
+			IsDelete: modification.IsDelete,
+		}
+		if modification.Timestamp != nil {
+			meta.Timestamp = modification.Timestamp.AsTime().UTC()
+		}
+		versionList = append(versionList, version{meta, modification.Value})
+	}
+
+	// Fabric 2.x 按从新到旧返回，这里统一为从旧到新
+	sort.SliceStable(versionList, func(i, j int) bool {
+		return versionList[i].meta.Timestamp.Before(versionList[j].meta.Timestamp)
+	})
+	for _, item := range versionList {
+		if err := visit(item.meta, item.value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 分页遍历某类文档的主记录（复合键只有一个属性），跳过同前缀下的交易记录等附属数据，返回下一页书签
+func (s *SmartContract) walkDocPage(ctx contractapi.TransactionContextInterface,
+	docType string,
+	pageSize int32,
+	bookmark string,
+	visit func(attrList []string, value []byte) error,
+) (string, error) {
+	iter, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(docType, []string{}, pageSize, bookmark)
+	if err != nil {
+		return "", fmt.Errorf("分页查询失败: %v", err)
+	}
+	defer iter.Close()
+
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		if err != nil {
+			return "", fmt.Errorf("分页查询失败: %v", err)
+		}
+		_, attrList, err := ctx.GetStub().SplitCompositeKey(kv.Key)
+		if err != nil {
+			return "", fmt.Errorf("解析复合键失败: %v", err)
+		}
+		if len(attrList) != 1 {
+			continue
+		}
+		if err := visit(attrList, kv.Value); err != nil {
+			return "", err
+		}
+	}
+
+	if metadata == nil {
+		return "", nil
+	}
+	return metadata.Bookmark, nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 //
 // 用户相关
@@ -529,6 +712,13 @@ func (s *SmartContract) CreateRealty(ctx contractapi.TransactionContextInterface
 		return fmt.Errorf("[CreateRealty] 获取交易时间戳失败: %v", err)
 	}
 
+	// 登记时当前所有者单独所有
+	ownerList := []*models.RealtyOwner{{
+		CitizenIDHash: currentOwnerCitizenIDHash,
+		Organization:  currentOwnerOrganization,
+		Share:         100,
+	}}
+
 	// 创建公开房产信息
 	realEstate := models.Realty{
 		DocType:                         constances.DocTypeRealEstate,
@@ -536,11 +726,13 @@ func (s *SmartContract) CreateRealty(ctx contractapi.TransactionContextInterface
 		RealtyCert:                      realtyCert,
 		RealtyType:                      realtyType,
 		CreateTime:                      time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+		AcquireTime:                     time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
 		Status:                          status,
 		LastUpdateTime:                  time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
 		CurrentOwnerCitizenIDHash:       currentOwnerCitizenIDHash,
 		CurrentOwnerOrganization:        currentOwnerOrganization,
 		PreviousOwnersCitizenIDHashList: previousOwnersCitizenIDHashList,
+		OwnerList:                       ownerList,
 	}
 
 	// 序列化并保存
@@ -559,6 +751,7 @@ func (s *SmartContract) CreateRealty(ctx contractapi.TransactionContextInterface
 		CurrentOwnerCitizenIDHash:       currentOwnerCitizenIDHash,
 		CurrentOwnerOrganization:        currentOwnerOrganization,
 		PreviousOwnersCitizenIDHashList: previousOwnersCitizenIDHashList,
+		OwnerList:                       ownerList,
 	}
 
 	// 序列化并保存
@@ -591,20 +784,36 @@ func (s *SmartContract) CreateRealty(ctx contractapi.TransactionContextInterface
 	if err != nil {
 		return fmt.Errorf("[CreateRealty] 序列化房产登记记录失败: %v", err)
 	}
+	err = ctx.GetStub().PutState(key, recordJSON)
+	if err != nil {
+		return fmt.Errorf("[CreateRealty] 保存房产登记记录失败: %v", err)
+	}
 
-	return ctx.GetStub().PutState(key, recordJSON)
+	return s.emitEvent(ctx, constances.EventRealtyCreated, newRealtyEvent(&realEstate))
 }
 
 func (s *SmartContract) QueryRealtyByOrganizationAndCitizenIDHash(ctx contractapi.TransactionContextInterface,
 	organization string,
 	citizenIDHash string,
 ) ([]*models.Realty, error) {
-	// 构建查询语句
+	// 构建查询语句，当前所有者或任一共有人匹配即可
 	queryString := fmt.Sprintf(`{
 		"selector": {
-			"docType": "%s",
-			"currentOwnerOrganization": "%s",
-			"currentOwnerCitizenIDHash": "%s"
+			"docType": "%[1]s",
+			"$or": [
+				{
+					"currentOwnerOrganization": "%[2]s",
+					"currentOwnerCitizenIDHash": "%[3]s"
+				},
+				{
+					"ownerList": {
+						"$elemMatch": {
+							"organization": "%[2]s",
+							"citizenIDHash": "%[3]s"
+						}
+					}
+				}
+			]
 		}
 	}`, constances.DocTypeRealEstate, organization, citizenIDHash)
 
@@ -713,6 +922,58 @@ func (s *SmartContract) QueryRealtyList(ctx contractapi.TransactionContextInterf
 	return realtyList, nil
 }
 
+// QueryRealtyPage 分页查询房产（含私有数据），用于对账，返回下一页书签
+func (s *SmartContract) QueryRealtyPage(ctx contractapi.TransactionContextInterface,
+	pageSize int32,
+	bookmark string,
+) (*models.RealtyPage, error) {
+	page := &models.RealtyPage{RealtyList: []*models.Realty{}}
+	nextBookmark, err := s.walkDocPage(ctx, constances.DocTypeRealEstate, pageSize, bookmark, func(attrList []string, value []byte) error {
+		realty, err := s.QueryRealty(ctx, attrList[0])
+		if err != nil {
+			return err
+		}
+		page.RealtyList = append(page.RealtyList, realty)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[QueryRealtyPage] %v", err)
+	}
+	page.Bookmark = nextBookmark
+	return page, nil
+}
+
+// QueryRealtyHistory 查询房产信息的全部历史版本
+func (s *SmartContract) QueryRealtyHistory(ctx contractapi.TransactionContextInterface,
+	realtyCertHash string,
+) ([]*models.RealtyHistory, error) {
+	key, err := s.createCompositeKey(ctx, constances.DocTypeRealEstate, []string{realtyCertHash}...)
+	if err != nil {
+		return nil, err
+	}
+
+	historyList := []*models.RealtyHistory{}
+	err = s.walkKeyHistory(ctx, key, func(meta models.HistoryMeta, value []byte) error {
+		history := &models.RealtyHistory{HistoryMeta: meta}
+		if !meta.IsDelete && len(value) > 0 {
+			history.Realty = &models.Realty{}
+			if err := json.Unmarshal(value, history.Realty); err != nil {
+				return fmt.Errorf("[QueryRealtyHistory] 解析房产信息失败: %v", err)
+			}
+		}
+		historyList = append(historyList, history)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(historyList) == 0 {
+		return nil, fmt.Errorf("[QueryRealtyHistory] 房产ID %s 不存在", realtyCertHash)
+	}
+
+	return historyList, nil
+}
+
 // UpdateRealty 更新房产信息（仅政府机构、投资者可调用）
 func (s *SmartContract) UpdateRealty(ctx contractapi.TransactionContextInterface,
 	realtyCertHash string,
@@ -722,50 +983,108 @@ func (s *SmartContract) UpdateRealty(ctx contractapi.TransactionContextInterface
 	currentOwnerOrganization string,
 	previousOwnersCitizenIDHashListJSON string,
 ) error {
+	realEstate, err := s.QueryRealty(ctx, realtyCertHash)
+	if err != nil {
+		return err
+	}
+
+	// 交易中状态由创建、终止交易加锁和解锁，不允许直接修改
+	if status != "" && status != realEstate.Status &&
+		(status == constances.RealtyStatusInSale || realEstate.Status == constances.RealtyStatusInSale) {
+		return fmt.Errorf("[UpdateRealty] 房产交易中状态只能随交易流程变更")
+	}
+
+	// 冻结状态只能通过司法冻结、解冻接口变更
+	if status != "" && status != realEstate.Status &&
+		(status == constances.RealtyStatusFrozen || realEstate.Status == constances.RealtyStatusFrozen) {
+		return fmt.Errorf("[UpdateRealty] 房产冻结状态只能通过冻结、解冻接口变更")
+	}
+
+	// 抵押中的房产挂牌或撤牌时保持抵押中，挂牌状态记录在抵押上，全部抵押解除后恢复
+	if realEstate.Status == constances.RealtyStatusInMortgage &&
+		(status == constances.RealtyStatusPendingSale || status == constances.RealtyStatusNormal) {
+		if err := s.setMortgagedRealtyListing(ctx, realtyCertHash, status); err != nil {
+			return fmt.Errorf("[UpdateRealty] %v", err)
+		}
+		status = ""
+	}
+
+	// 抵押中状态由抵押审批、解除和过户流程维护，不允许直接设置或清除
+	if status != "" && status != realEstate.Status &&
+		(status == constances.RealtyStatusInMortgage || realEstate.Status == constances.RealtyStatusInMortgage) {
+		return fmt.Errorf("[UpdateRealty] 房产抵押中状态只能随抵押流程变更")
+	}
+
+	updatedRealty, err := s.updateRealty(
+		ctx,
+		realtyCertHash,
+		realtyType,
+		status,
+		currentOwnerCitizenIDHash,
+		currentOwnerOrganization,
+		previousOwnersCitizenIDHashListJSON,
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.emitEvent(ctx, constances.EventRealtyUpdated, newRealtyEvent(updatedRealty))
+}
+
+// updateRealty 更新房产信息并返回更新后的房产，过户时由交易流程直接调用以释放交易锁
+// 不发送事件，由调用方发送（同一交易只保留最后一个事件，过户时房产变更记录在交易事件中）
+func (s *SmartContract) updateRealty(ctx contractapi.TransactionContextInterface,
+	realtyCertHash string,
+	realtyType string,
+	status string,
+	currentOwnerCitizenIDHash string,
+	currentOwnerOrganization string,
+	previousOwnersCitizenIDHashListJSON string,
+) (*models.Realty, error) {
 	// 检查调用者身份
 	clientID, err := ctx.GetClientIdentity().GetID()
 	if err != nil {
-		return fmt.Errorf("[UpdateRealty] 获取客户端ID失败: %v", err)
+		return nil, fmt.Errorf("[UpdateRealty] 获取客户端ID失败: %v", err)
 	}
 
 	clientMSPID, err := s.getClientIdentityMSPID(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if clientMSPID != constances.GovernmentMSP && clientMSPID != constances.InvestorMSP {
-		return fmt.Errorf("[UpdateRealty] 只有政府机构、投资者可以更新房产信息")
+		return nil, fmt.Errorf("[UpdateRealty] 只有政府机构、投资者可以更新房产信息")
 	}
 
 	// 查询现有房产信息
 	realEstatePublic, err := s.QueryRealty(ctx, realtyCertHash)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if realEstatePublic.Status == constances.RealtyStatusFrozen && status != constances.RealtyStatusNormal {
-		return fmt.Errorf("[UpdateRealty] 房产已被冻结，无法更新")
+		return nil, fmt.Errorf("[UpdateRealty] 房产已被冻结，无法更新")
 	}
 
 	// 获取复合键
 	key, err := s.createCompositeKey(ctx, constances.DocTypeRealEstate, []string{realEstatePublic.RealtyCertHash}...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// 查询房产私钥
 	realEstatePrivateBytes, err := ctx.GetStub().GetPrivateData(constances.RealEstatePrivateCollection, key)
 	if err != nil {
-		return fmt.Errorf("[UpdateRealty] 查询房产私钥失败: %v", err)
+		return nil, fmt.Errorf("[UpdateRealty] 查询房产私钥失败: %v", err)
 	}
 	if realEstatePrivateBytes == nil {
-		return fmt.Errorf("[UpdateRealty] 房产私钥不存在")
+		return nil, fmt.Errorf("[UpdateRealty] 房产私钥不存在")
 	}
 
 	var realEstatePrivate models.RealtyPrivate
 	err = json.Unmarshal(realEstatePrivateBytes, &realEstatePrivate)
 	if err != nil {
-		return fmt.Errorf("[UpdateRealty] 解析房产私钥失败: %v", err)
+		return nil, fmt.Errorf("[UpdateRealty] 解析房产私钥失败: %v", err)
 	}
 
 	var modifyFields []string
@@ -778,17 +1097,37 @@ func (s *SmartContract) UpdateRealty(ctx contractapi.TransactionContextInterface
 		realEstatePublic.Status = status
 		modifyFields = append(modifyFields, "status")
 	}
+	if realEstatePublic.Status != constances.RealtyStatusInSale && realEstatePublic.ActiveTransactionUUID != "" {
+		realEstatePublic.ActiveTransactionUUID = ""
+		modifyFields = append(modifyFields, "activeTransactionUUID")
+	}
+	ownerChanged := false
 	if currentOwnerCitizenIDHash != "" && currentOwnerCitizenIDHash != realEstatePrivate.CurrentOwnerCitizenIDHash {
 		realEstatePrivate.CurrentOwnerCitizenIDHash = currentOwnerCitizenIDHash
 		modifyFields = append(modifyFields, "currentOwnerCitizenIDHash")
+		ownerChanged = true
 	}
 	if currentOwnerOrganization != "" && currentOwnerOrganization != realEstatePrivate.CurrentOwnerOrganization {
 		realEstatePrivate.CurrentOwnerOrganization = currentOwnerOrganization
-	}
+		ownerChanged = true
+	}
+	if ownerChanged {
+		// 所有者变更后由新所有者单独所有
+		realEstatePrivate.OwnerList = []*models.RealtyOwner{{
+			CitizenIDHash: realEstatePrivate.CurrentOwnerCitizenIDHash,
+			Organization:  realEstatePrivate.CurrentOwnerOrganization,
+			Share:         100,
+		}}
+		modifyFields = append(modifyFields, "ownerList")
+	}
+	// 公开信息中的所有者与私有数据保持一致，供按所有者查询使用
+	realEstatePublic.CurrentOwnerCitizenIDHash = realEstatePrivate.CurrentOwnerCitizenIDHash
+	realEstatePublic.CurrentOwnerOrganization = realEstatePrivate.CurrentOwnerOrganization
+	realEstatePublic.OwnerList = realEstatePrivate.OwnerList
 	// 解析JSON字符串为字符串数组
 	var previousOwnersCitizenIDHashList []string
 	if err := json.Unmarshal([]byte(previousOwnersCitizenIDHashListJSON), &previousOwnersCitizenIDHashList); err != nil {
-		return fmt.Errorf("[UpdateRealty] 解析历史所有者列表失败: %v", err)
+		return nil, fmt.Errorf("[UpdateRealty] 解析历史所有者列表失败: %v", err)
 	}
 	if len(previousOwnersCitizenIDHashList) > len(realEstatePrivate.PreviousOwnersCitizenIDHashList) {
 		realEstatePrivate.PreviousOwnersCitizenIDHashList = previousOwnersCitizenIDHashList
@@ -797,14 +1136,19 @@ func (s *SmartContract) UpdateRealty(ctx contractapi.TransactionContextInterface
 
 	now, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-		return fmt.Errorf("[UpdateRealty] 获取交易时间戳失败: %v", err)
+		return nil, fmt.Errorf("[UpdateRealty] 获取交易时间戳失败: %v", err)
 	}
 	realEstatePublic.LastUpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+	if ownerChanged {
+		// 所有者变更时重新计算持有起始时间
+		realEstatePublic.AcquireTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+		modifyFields = append(modifyFields, "acquireTime")
+	}
 
 	// 序列化并保存
 	realEstatePublicJSON, err := json.Marshal(realEstatePublic)
 	if err != nil {
-		return fmt.Errorf("[UpdateRealty] 序列化房产信息失败: %v", err)
+		return nil, fmt.Errorf("[UpdateRealty] 序列化房产信息失败: %v", err)
 	}
 
 	ctx.GetStub().PutState(key, realEstatePublicJSON)
@@ -812,17 +1156,17 @@ func (s *SmartContract) UpdateRealty(ctx contractapi.TransactionContextInterface
 	// 私有数据序列化并保存
 	realEstatePrivateJSON, err := json.Marshal(realEstatePrivate)
 	if err != nil {
-		return fmt.Errorf("[UpdateRealty] 序列化房产私钥失败: %v", err)
+		return nil, fmt.Errorf("[UpdateRealty] 序列化房产私钥失败: %v", err)
 	}
 	err = ctx.GetStub().PutPrivateData(constances.RealEstatePrivateCollection, key, realEstatePrivateJSON)
 	if err != nil {
-		return fmt.Errorf("[UpdateRealty] 保存房产私钥失败: %v", err)
+		return nil, fmt.Errorf("[UpdateRealty] 保存房产私钥失败: %v", err)
 	}
 
 	// 创建房产登记记录
 	key, err = s.createCompositeKey(ctx, constances.DocTypeRealEstate, []string{realEstatePublic.RealtyCertHash, "updateRealty"}...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	type updateRealtyRecord struct {
@@ -839,130 +1183,496 @@ func (s *SmartContract) UpdateRealty(ctx contractapi.TransactionContextInterface
 	}
 	recordJSON, err := json.Marshal(record)
 	if err != nil {
-		return fmt.Errorf("[UpdateRealty] 序列化房产登记记录失败: %v", err)
+		return nil, fmt.Errorf("[UpdateRealty] 序列化房产登记记录失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, recordJSON)
+	if err != nil {
+		return nil, fmt.Errorf("[UpdateRealty] 保存房产登记记录失败: %v", err)
 	}
-	return ctx.GetStub().PutState(key, recordJSON)
-}
 
-////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-//
-// 交易相关
-//
-////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	return realEstatePublic, nil
+}
 
-// CreateTransaction 创建交易（投资者、政府可以调用）
-func (s *SmartContract) CreateTransaction(ctx contractapi.TransactionContextInterface,
+// SetRealtyOwnerList 登记房产共有人及份额（仅政府机构可以调用），列表第一位为主登记所有者
+func (s *SmartContract) SetRealtyOwnerList(ctx contractapi.TransactionContextInterface,
 	realtyCertHash string,
-	transactionUUID string,
-	sellerCitizenIDHash string,
-	sellerOrganization string,
-	buyerCitizenIDHash string,
-	buyerOrganization string,
-	contractUUID string,
-	paymentUUIDListJSON string,
-	tax float64,
-	price float64,
+	ownerListJSON string,
 ) error {
 	// 检查调用者身份
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("[SetRealtyOwnerList] 获取客户端ID失败: %v", err)
+	}
+
 	clientMSPID, err := s.getClientIdentityMSPID(ctx)
 	if err != nil {
 		return err
 	}
 
-	if clientMSPID != constances.InvestorMSP && clientMSPID != constances.GovernmentMSP {
-		return fmt.Errorf("[CreateTransaction] 只有投资者、政府可以创建交易")
+	if clientMSPID != constances.GovernmentMSP {
+		return fmt.Errorf("[SetRealtyOwnerList] 只有政府机构可以登记房产共有人")
+	}
+
+	var ownerList []*models.RealtyOwner
+	if err := json.Unmarshal([]byte(ownerListJSON), &ownerList); err != nil {
+		return fmt.Errorf("[SetRealtyOwnerList] 解析共有人列表失败: %v", err)
+	}
+	if len(ownerList) == 0 {
+		return fmt.Errorf("[SetRealtyOwnerList] 共有人列表不能为空")
+	}
+
+	totalShare := 0.0
+	ownerSet := map[string]bool{}
+	for _, owner := range ownerList {
+		if owner == nil || owner.CitizenIDHash == "" || owner.Organization == "" {
+			return fmt.Errorf("[SetRealtyOwnerList] 共有人信息不完整")
+		}
+		if _, ok := constances.OrganizationMSPMap[owner.Organization]; !ok {
+			return fmt.Errorf("[SetRealtyOwnerList] 未知的组织: %s", owner.Organization)
+		}
+		if owner.Share <= 0 {
+			return fmt.Errorf("[SetRealtyOwnerList] 共有人份额必须大于0")
+		}
+		ownerKey := owner.CitizenIDHash + "~" + owner.Organization
+		if ownerSet[ownerKey] {
+			return fmt.Errorf("[SetRealtyOwnerList] 共有人重复: %s", owner.CitizenIDHash)
+		}
+		ownerSet[ownerKey] = true
+		totalShare += owner.Share
+	}
+	if math.Abs(totalShare-100) > 0.0001 {
+		return fmt.Errorf("[SetRealtyOwnerList] 共有人份额合计必须为100%%，当前为%.4f%%", totalShare)
 	}
 
-	// 查询房产信息
 	realEstate, err := s.QueryRealty(ctx, realtyCertHash)
 	if err != nil {
 		return err
 	}
-
-	// 检查房产状态
-	if realEstate.Status != constances.RealtyStatusPendingSale {
-		return fmt.Errorf("[CreateTransaction] 房产状态不允许交易: %s", realEstate.Status)
+	if realEstate.Status == constances.RealtyStatusInSale {
+		return fmt.Errorf("[SetRealtyOwnerList] 房产交易中，不能变更共有人")
 	}
-
-	// 检查卖方是否为房产所有者
-	if realEstate.CurrentOwnerCitizenIDHash != sellerCitizenIDHash {
-		return fmt.Errorf("[CreateTransaction] 卖方不是房产所有者")
+	if err := s.checkRealtyFreeze(ctx, realtyCertHash); err != nil {
+		return fmt.Errorf("[SetRealtyOwnerList] %v", err)
 	}
 
-	now, err := ctx.GetStub().GetTxTimestamp()
+	key, err := s.createCompositeKey(ctx, constances.DocTypeRealEstate, []string{realtyCertHash}...)
 	if err != nil {
-		return fmt.Errorf("[CreateTransaction] 获取交易时间戳失败: %v", err)
+		return err
 	}
 
-	// 创建公开交易信息
-	transactionPublic := models.TransactionPublic{
-		DocType:             constances.DocTypeTransaction,
-		TransactionUUID:     transactionUUID,
-		RealtyCertHash:      realtyCertHash,
-		SellerCitizenIDHash: sellerCitizenIDHash,
-		SellerOrganization:  sellerOrganization,
-		BuyerCitizenIDHash:  buyerCitizenIDHash,
-		BuyerOrganization:   buyerOrganization,
-		Status:              constances.TxStatusPending,
-		CreateTime:          time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
-		UpdateTime:          time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+	realEstatePrivateBytes, err := ctx.GetStub().GetPrivateData(constances.RealEstatePrivateCollection, key)
+	if err != nil {
+		return fmt.Errorf("[SetRealtyOwnerList] 查询房产私钥失败: %v", err)
 	}
-
-	// 解析JSON字符串为字符串数组
-	var paymentUUIDList []string
-	if err := json.Unmarshal([]byte(paymentUUIDListJSON), &paymentUUIDList); err != nil {
-		return fmt.Errorf("[CreateTransaction] 解析支付UUID列表失败: %v", err)
+	if realEstatePrivateBytes == nil {
+		return fmt.Errorf("[SetRealtyOwnerList] 房产私钥不存在")
 	}
-
-	// 创建私有交易信息
-	transactionPrivate := models.TransactionPrivate{
-		DocType:         constances.DocTypeTransaction,
-		TransactionUUID: transactionUUID,
-		Price:           price,
-		Tax:             tax,
-		PaymentUUIDList: paymentUUIDList,
-		ContractUUID:    contractUUID,
+	var realEstatePrivate models.RealtyPrivate
+	err = json.Unmarshal(realEstatePrivateBytes, &realEstatePrivate)
+	if err != nil {
+		return fmt.Errorf("[SetRealtyOwnerList] 解析房产私钥失败: %v", err)
 	}
 
-	// 创建交易复合键
-	key, err := s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID}...)
+	now, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-		return err
+		return fmt.Errorf("[SetRealtyOwnerList] 获取交易时间戳失败: %v", err)
 	}
+	nowTime := time.Unix(now.Seconds, int64(now.Nanos)).UTC()
 
-	// 序列化并保存交易信息
-	transactionPublicJSON, err := json.Marshal(transactionPublic)
+	// 主登记所有者随列表第一位变更
+	realEstatePrivate.CurrentOwnerCitizenIDHash = ownerList[0].CitizenIDHash
+	realEstatePrivate.CurrentOwnerOrganization = ownerList[0].Organization
+	realEstatePrivate.OwnerList = ownerList
+
+	realEstate.CurrentOwnerCitizenIDHash = realEstatePrivate.CurrentOwnerCitizenIDHash
+	realEstate.CurrentOwnerOrganization = realEstatePrivate.CurrentOwnerOrganization
+	realEstate.OwnerList = ownerList
+	realEstate.LastUpdateTime = nowTime
+
+	realEstateJSON, err := json.Marshal(realEstate)
 	if err != nil {
-		return fmt.Errorf("[CreateTransaction] 序列化公开交易信息失败: %v", err)
+		return fmt.Errorf("[SetRealtyOwnerList] 序列化房产信息失败: %v", err)
 	}
-	err = ctx.GetStub().PutState(key, transactionPublicJSON)
+	err = ctx.GetStub().PutState(key, realEstateJSON)
 	if err != nil {
-		return fmt.Errorf("[CreateTransaction] 保存公开交易信息失败: %v", err)
+		return fmt.Errorf("[SetRealtyOwnerList] 保存房产信息失败: %v", err)
 	}
 
-	transactionPrivateJSON, err := json.Marshal(transactionPrivate)
+	realEstatePrivateJSON, err := json.Marshal(realEstatePrivate)
 	if err != nil {
-		return fmt.Errorf("[CreateTransaction] 序列化私有交易信息失败: %v", err)
+		return fmt.Errorf("[SetRealtyOwnerList] 序列化房产私钥失败: %v", err)
 	}
-	err = ctx.GetStub().PutPrivateData(constances.TransactionPrivateCollection, key, transactionPrivateJSON)
+	err = ctx.GetStub().PutPrivateData(constances.RealEstatePrivateCollection, key, realEstatePrivateJSON)
 	if err != nil {
-		return fmt.Errorf("[CreateTransaction] 保存私有交易信息失败: %v", err)
+		return fmt.Errorf("[SetRealtyOwnerList] 保存房产私钥失败: %v", err)
 	}
 
-	// 创建交易登记记录
-	clientID, err := ctx.GetClientIdentity().GetID()
+	// 创建房产登记记录
+	key, err = s.createCompositeKey(ctx, constances.DocTypeRealEstate, []string{realtyCertHash, "setRealtyOwnerList"}...)
 	if err != nil {
 		return err
 	}
-
-	key, err = s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID, "createTransaction"}...)
-	if err != nil {
-		return err
+	type setRealtyOwnerListRecord struct {
+		ClientID  string                `json:"clientID"`
+		Action    string                `json:"action"`
+		Time      time.Time             `json:"time"`
+		OwnerList []*models.RealtyOwner `json:"ownerList"`
 	}
-	type createTransactionRecord struct {
-		ClientID string    `json:"clientID"`
-		Action   string    `json:"action"`
-		Time     time.Time `json:"time"`
+	record := setRealtyOwnerListRecord{
+		ClientID:  clientID,
+		Action:    "setRealtyOwnerList",
+		Time:      nowTime,
+		OwnerList: ownerList,
+	}
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("[SetRealtyOwnerList] 序列化房产登记记录失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, recordJSON)
+	if err != nil {
+		return fmt.Errorf("[SetRealtyOwnerList] 保存房产登记记录失败: %v", err)
+	}
+
+	return s.emitEvent(ctx, constances.EventRealtyUpdated, newRealtyEvent(realEstate))
+}
+
+// realtyOwnerList 返回房产共有人列表，未登记共有人的历史数据视为当前所有者单独所有
+func realtyOwnerList(realty *models.Realty) []*models.RealtyOwner {
+	if len(realty.OwnerList) > 0 {
+		return realty.OwnerList
+	}
+	return []*models.RealtyOwner{{
+		CitizenIDHash: realty.CurrentOwnerCitizenIDHash,
+		Organization:  realty.CurrentOwnerOrganization,
+		Share:         100,
+	}}
+}
+
+// FreezeRealty 依据法律文书司法冻结房产（仅政府、审计机构可以调用）
+func (s *SmartContract) FreezeRealty(ctx contractapi.TransactionContextInterface,
+	realtyCertHash string,
+	courtOrderHash string,
+	authority string,
+	expireTime string,
+) error {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientMSPID != constances.GovernmentMSP && clientMSPID != constances.AuditMSP {
+		return fmt.Errorf("[FreezeRealty] 只有政府、审计机构可以冻结房产")
+	}
+
+	if courtOrderHash == "" || authority == "" {
+		return fmt.Errorf("[FreezeRealty] 法律文书哈希和冻结机关不能为空")
+	}
+
+	expire, err := time.Parse(time.RFC3339, expireTime)
+	if err != nil {
+		return fmt.Errorf("[FreezeRealty] 解析冻结到期时间失败: %v", err)
+	}
+
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[FreezeRealty] 获取交易时间戳失败: %v", err)
+	}
+	nowTime := time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+	if !expire.After(nowTime) {
+		return fmt.Errorf("[FreezeRealty] 冻结到期时间必须晚于当前时间")
+	}
+
+	realEstate, err := s.QueryRealty(ctx, realtyCertHash)
+	if err != nil {
+		return err
+	}
+	if realEstate.Status == constances.RealtyStatusFrozen {
+		return fmt.Errorf("[FreezeRealty] 房产已被冻结")
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("[FreezeRealty] 获取客户端ID失败: %v", err)
+	}
+
+	freeze := &models.RealtyFreeze{
+		FreezeID:       ctx.GetStub().GetTxID(),
+		CourtOrderHash: courtOrderHash,
+		Authority:      authority,
+		PreviousStatus: realEstate.Status,
+		Status:         constances.FreezeStatusActive,
+		FreezeClientID: clientID,
+		FreezeMSPID:    clientMSPID,
+		FreezeTime:     nowTime,
+		ExpireTime:     expire.UTC(),
+	}
+
+	var frozenRealty *models.Realty
+	err = s.modifyRealty(ctx, realtyCertHash, func(realty *models.Realty) {
+		realty.Status = constances.RealtyStatusFrozen
+		realty.FreezeHistory = append(realty.FreezeHistory, freeze)
+		frozenRealty = realty
+	})
+	if err != nil {
+		return fmt.Errorf("[FreezeRealty] %v", err)
+	}
+
+	return s.emitEvent(ctx, constances.EventRealtyUpdated, newRealtyEvent(frozenRealty))
+}
+
+// UnfreezeRealty 解除房产司法冻结并恢复冻结前状态（仅政府、审计机构可以调用）
+func (s *SmartContract) UnfreezeRealty(ctx contractapi.TransactionContextInterface,
+	realtyCertHash string,
+	reason string,
+) error {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientMSPID != constances.GovernmentMSP && clientMSPID != constances.AuditMSP {
+		return fmt.Errorf("[UnfreezeRealty] 只有政府、审计机构可以解冻房产")
+	}
+
+	realEstate, err := s.QueryRealty(ctx, realtyCertHash)
+	if err != nil {
+		return err
+	}
+	if activeRealtyFreeze(realEstate) == nil {
+		return fmt.Errorf("[UnfreezeRealty] 房产未被冻结")
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("[UnfreezeRealty] 获取客户端ID失败: %v", err)
+	}
+
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[UnfreezeRealty] 获取交易时间戳失败: %v", err)
+	}
+	nowTime := time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	var unfrozenRealty *models.Realty
+	err = s.modifyRealty(ctx, realtyCertHash, func(realty *models.Realty) {
+		freeze := activeRealtyFreeze(realty)
+		freeze.Status = constances.FreezeStatusReleased
+		if !nowTime.Before(freeze.ExpireTime) {
+			freeze.Status = constances.FreezeStatusExpired
+		}
+		freeze.UnfreezeClientID = clientID
+		freeze.UnfreezeMSPID = clientMSPID
+		freeze.UnfreezeReason = reason
+		freeze.UnfreezeTime = nowTime
+		realty.Status = freeze.PreviousStatus
+		unfrozenRealty = realty
+	})
+	if err != nil {
+		return fmt.Errorf("[UnfreezeRealty] %v", err)
+	}
+
+	return s.emitEvent(ctx, constances.EventRealtyUpdated, newRealtyEvent(unfrozenRealty))
+}
+
+// activeRealtyFreeze 返回房产当前生效的冻结记录，未冻结时返回nil
+func activeRealtyFreeze(realty *models.Realty) *models.RealtyFreeze {
+	if realty.Status != constances.RealtyStatusFrozen {
+		return nil
+	}
+	for i := len(realty.FreezeHistory) - 1; i >= 0; i-- {
+		if realty.FreezeHistory[i].Status == constances.FreezeStatusActive {
+			return realty.FreezeHistory[i]
+		}
+	}
+	return nil
+}
+
+// checkRealtyFreeze 检查房产是否处于司法冻结中
+func (s *SmartContract) checkRealtyFreeze(ctx contractapi.TransactionContextInterface,
+	realtyCertHash string,
+) error {
+	realEstate, err := s.QueryRealty(ctx, realtyCertHash)
+	if err != nil {
+		return err
+	}
+	if realEstate.Status != constances.RealtyStatusFrozen {
+		return nil
+	}
+
+	freeze := activeRealtyFreeze(realEstate)
+	if freeze == nil {
+		return fmt.Errorf("房产已被冻结")
+	}
+	return fmt.Errorf("房产已被%s司法冻结，法律文书哈希: %s，冻结至: %s",
+		freeze.Authority, freeze.CourtOrderHash, freeze.ExpireTime.Format(time.RFC3339))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// 交易相关
+//
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// CreateTransaction 创建交易（投资者、政府可以调用）
+func (s *SmartContract) CreateTransaction(ctx contractapi.TransactionContextInterface,
+	realtyCertHash string,
+	transactionUUID string,
+	sellerCitizenIDHash string,
+	sellerOrganization string,
+	buyerCitizenIDHash string,
+	buyerOrganization string,
+	contractUUID string,
+	paymentUUIDListJSON string,
+	price float64,
+) error {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientMSPID != constances.InvestorMSP && clientMSPID != constances.GovernmentMSP {
+		return fmt.Errorf("[CreateTransaction] 只有投资者、政府可以创建交易")
+	}
+
+	// 查询房产信息
+	realEstate, err := s.QueryRealty(ctx, realtyCertHash)
+	if err != nil {
+		return err
+	}
+
+	// 司法冻结中的房产不允许交易
+	if err := s.checkRealtyFreeze(ctx, realtyCertHash); err != nil {
+		return fmt.Errorf("[CreateTransaction] %v", err)
+	}
+
+	// 检查房产状态，交易中的房产不允许再次发起交易
+	// 抵押中的房产须在抵押期间挂牌，且全部未解除的抵押都已同意由买方承接，后者由下面的抵押检查保证
+	if realEstate.Status == constances.RealtyStatusInSale {
+		return fmt.Errorf("[CreateTransaction] 房产已有进行中的交易: %s", realEstate.ActiveTransactionUUID)
+	}
+	switch realEstate.Status {
+	case constances.RealtyStatusPendingSale:
+	case constances.RealtyStatusInMortgage:
+		mortgageList, err := s.queryMortgagePublicListByRealty(ctx, realtyCertHash)
+		if err != nil {
+			return fmt.Errorf("[CreateTransaction] %v", err)
+		}
+		if !isMortgagedRealtyListed(mortgageList) {
+			return fmt.Errorf("[CreateTransaction] 抵押中的房产未挂牌出售")
+		}
+	default:
+		return fmt.Errorf("[CreateTransaction] 房产状态不允许交易: %s", realEstate.Status)
+	}
+
+	// 检查卖方是否为房产所有者
+	if realEstate.CurrentOwnerCitizenIDHash != sellerCitizenIDHash ||
+		realEstate.CurrentOwnerOrganization != sellerOrganization {
+		return fmt.Errorf("[CreateTransaction] 卖方不是房产所有者")
+	}
+
+	// 检查房产抵押情况，未解除且未被买方承接的抵押不允许交易
+	if err := s.checkRealtyEncumbrance(ctx, realtyCertHash, buyerCitizenIDHash, buyerOrganization); err != nil {
+		return fmt.Errorf("[CreateTransaction] %v", err)
+	}
+
+	// 根据税率表核定税费
+	taxList, err := s.assessTax(ctx, realEstate, buyerCitizenIDHash, buyerOrganization, price)
+	if err != nil {
+		return fmt.Errorf("[CreateTransaction] 核定税费失败: %v", err)
+	}
+	tax := 0.0
+	for _, item := range taxList {
+		item.TransactionUUID = transactionUUID
+		if err := s.putTax(ctx, item); err != nil {
+			return fmt.Errorf("[CreateTransaction] %v", err)
+		}
+		tax += item.TaxAmount
+	}
+
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[CreateTransaction] 获取交易时间戳失败: %v", err)
+	}
+
+	// 创建公开交易信息
+	transactionPublic := models.TransactionPublic{
+		DocType:             constances.DocTypeTransaction,
+		TransactionUUID:     transactionUUID,
+		RealtyCertHash:      realtyCertHash,
+		SellerCitizenIDHash: sellerCitizenIDHash,
+		SellerOrganization:  sellerOrganization,
+		BuyerCitizenIDHash:  buyerCitizenIDHash,
+		BuyerOrganization:   buyerOrganization,
+		Status:              constances.TxStatusPending,
+		CreateTime:          time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+		UpdateTime:          time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+		CompletedStepList:   []string{},
+	}
+
+	// 解析JSON字符串为字符串数组
+	var paymentUUIDList []string
+	if err := json.Unmarshal([]byte(paymentUUIDListJSON), &paymentUUIDList); err != nil {
+		return fmt.Errorf("[CreateTransaction] 解析支付UUID列表失败: %v", err)
+	}
+
+	// 创建私有交易信息
+	transactionPrivate := models.TransactionPrivate{
+		DocType:         constances.DocTypeTransaction,
+		TransactionUUID: transactionUUID,
+		Price:           price,
+		Tax:             roundAmount(tax),
+		PaymentUUIDList: paymentUUIDList,
+		ContractUUID:    contractUUID,
+	}
+
+	// 创建交易复合键
+	key, err := s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID}...)
+	if err != nil {
+		return err
+	}
+
+	// 序列化并保存交易信息
+	transactionPublicJSON, err := json.Marshal(transactionPublic)
+	if err != nil {
+		return fmt.Errorf("[CreateTransaction] 序列化公开交易信息失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, transactionPublicJSON)
+	if err != nil {
+		return fmt.Errorf("[CreateTransaction] 保存公开交易信息失败: %v", err)
+	}
+
+	transactionPrivateJSON, err := json.Marshal(transactionPrivate)
+	if err != nil {
+		return fmt.Errorf("[CreateTransaction] 序列化私有交易信息失败: %v", err)
+	}
+	err = ctx.GetStub().PutPrivateData(constances.TransactionPrivateCollection, key, transactionPrivateJSON)
+	if err != nil {
+		return fmt.Errorf("[CreateTransaction] 保存私有交易信息失败: %v", err)
+	}
+
+	// 锁定房产，并发创建的交易会因读写冲突而失效
+	lockedRealty, err := s.setRealtyLock(ctx, realtyCertHash, constances.RealtyStatusInSale, transactionUUID)
+	if err != nil {
+		return fmt.Errorf("[CreateTransaction] 锁定房产失败: %v", err)
+	}
+
+	// 创建交易登记记录
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return err
+	}
+
+	key, err = s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID, "createTransaction"}...)
+	if err != nil {
+		return err
+	}
+	type createTransactionRecord struct {
+		ClientID string    `json:"clientID"`
+		Action   string    `json:"action"`
+		Time     time.Time `json:"time"`
 	}
 	record := createTransactionRecord{
 		ClientID: clientID,
@@ -978,7 +1688,7 @@ func (s *SmartContract) CreateTransaction(ctx contractapi.TransactionContextInte
 		return fmt.Errorf("[CreateTransaction] 保存交易登记记录失败: %v", err)
 	}
 
-	return nil
+	return s.emitEvent(ctx, constances.EventTransactionCreated, newTransactionEvent(&transactionPublic, lockedRealty))
 }
 
 // QueryTransaction 查询交易（投资者、政府可以调用）
@@ -1083,19 +1793,77 @@ func (s *SmartContract) QueryTransactionList(ctx contractapi.TransactionContextI
 	return transactionList, nil
 }
 
-// CheckTransaction 检查交易（投资者、政府可以调用）
-func (s *SmartContract) CheckTransaction(ctx contractapi.TransactionContextInterface,
+// QueryTransactionPage 分页查询交易公开信息，用于对账，返回下一页书签
+func (s *SmartContract) QueryTransactionPage(ctx contractapi.TransactionContextInterface,
+	pageSize int32,
+	bookmark string,
+) (*models.TransactionPage, error) {
+	page := &models.TransactionPage{TransactionList: []*models.TransactionPublic{}}
+	nextBookmark, err := s.walkDocPage(ctx, constances.DocTypeTransaction, pageSize, bookmark, func(attrList []string, value []byte) error {
+		var transactionPublic models.TransactionPublic
+		if err := json.Unmarshal(value, &transactionPublic); err != nil {
+			return fmt.Errorf("解析交易信息失败: %v", err)
+		}
+		page.TransactionList = append(page.TransactionList, &transactionPublic)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[QueryTransactionPage] %v", err)
+	}
+	page.Bookmark = nextBookmark
+	return page, nil
+}
+
+// 交易步骤规则：允许执行该步骤的参与方以及需要先完成的步骤
+type transactionStepRule struct {
+	partyList    []string // 允许执行的参与方
+	requiredList []string // 前置步骤
+}
+
+// 交易状态流转表
+var transactionStepTable = map[string]transactionStepRule{
+	constances.TxStepBuyerSigned: {
+		partyList: []string{constances.TxPartyBuyer},
+	},
+	constances.TxStepSellerSigned: {
+		partyList: []string{constances.TxPartySeller},
+	},
+	constances.TxStepGovernmentApproved: {
+		partyList:    []string{constances.TxPartyGovernment},
+		requiredList: []string{constances.TxStepBuyerSigned, constances.TxStepSellerSigned},
+	},
+	constances.TxStepFundsEscrowed: {
+		partyList:    []string{constances.TxPartyBuyer, constances.TxPartyBank},
+		requiredList: []string{constances.TxStepGovernmentApproved},
+	},
+	constances.TxStepTaxPaid: {
+		partyList:    []string{constances.TxPartyBuyer, constances.TxPartySeller, constances.TxPartyGovernment},
+		requiredList: []string{constances.TxStepGovernmentApproved},
+	},
+	constances.TxStepTitleTransferred: {
+		partyList:    []string{constances.TxPartyGovernment},
+		requiredList: []string{constances.TxStepFundsEscrowed, constances.TxStepTaxPaid},
+	},
+}
+
+// ConfirmTransactionStep 确认交易步骤（按状态流转表校验调用方和前置步骤）
+func (s *SmartContract) ConfirmTransactionStep(ctx contractapi.TransactionContextInterface,
 	transactionUUID string,
-	status string,
+	step string,
 ) error {
+	rule, ok := transactionStepTable[step]
+	if !ok {
+		return fmt.Errorf("[ConfirmTransactionStep] 未知的交易步骤: %s", step)
+	}
+
 	// 检查调用者身份
 	clientMSPID, err := s.getClientIdentityMSPID(ctx)
 	if err != nil {
 		return err
 	}
-
-	if clientMSPID != constances.InvestorMSP && clientMSPID != constances.GovernmentMSP {
-		return fmt.Errorf("[CheckTransaction] 只有投资者、政府可以检查交易")
+	clientCitizenIDHash, err := s.getClientCitizenIDHash(ctx)
+	if err != nil {
+		return err
 	}
 
 	// 查询交易信息
@@ -1106,89 +1874,137 @@ func (s *SmartContract) CheckTransaction(ctx contractapi.TransactionContextInter
 
 	transactionPublicBytes, err := ctx.GetStub().GetState(key)
 	if err != nil {
-		return fmt.Errorf("[CheckTransaction] 查询交易信息失败: %v", err)
+		return fmt.Errorf("[ConfirmTransactionStep] 查询交易信息失败: %v", err)
 	}
-
 	if transactionPublicBytes == nil {
-		return fmt.Errorf("[CheckTransaction] 交易不存在: %s", transactionUUID)
+		return fmt.Errorf("[ConfirmTransactionStep] 交易不存在: %s", transactionUUID)
 	}
 
 	var transactionPublic models.TransactionPublic
 	err = json.Unmarshal(transactionPublicBytes, &transactionPublic)
 	if err != nil {
-		return fmt.Errorf("[CheckTransaction] 解析交易信息失败: %v", err)
+		return fmt.Errorf("[ConfirmTransactionStep] 解析交易信息失败: %v", err)
 	}
 
-	now, err := ctx.GetStub().GetTxTimestamp()
-	if err != nil {
-		return fmt.Errorf("[CheckTransaction] 获取交易时间戳失败: %v", err)
+	if transactionPublic.Status != constances.TxStatusPending && transactionPublic.Status != constances.TxStatusInProgress {
+		return fmt.Errorf("[ConfirmTransactionStep] 交易状态不允许操作: %s", transactionPublic.Status)
 	}
 
-	// 检查交易状态
-	if transactionPublic.Status != constances.TxStatusPending {
-		return fmt.Errorf("[CheckTransaction] 交易状态不允许检查: %s", transactionPublic.Status)
+	// 房产司法冻结期间交易不能推进
+	if err := s.checkRealtyFreeze(ctx, transactionPublic.RealtyCertHash); err != nil {
+		return fmt.Errorf("[ConfirmTransactionStep] %v", err)
 	}
 
-	// 更新交易状态
-	transactionPublic.Status = status
-	transactionPublic.UpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
-	// 序列化交易信息
-	transactionPublicJSON, err := json.Marshal(transactionPublic)
-	if err != nil {
-		return fmt.Errorf("[CheckTransaction] 序列化交易信息失败: %v", err)
+	if slices.Contains(transactionPublic.CompletedStepList, step) {
+		return fmt.Errorf("[ConfirmTransactionStep] 交易步骤已完成: %s", step)
 	}
 
-	// 保存交易信息
+	for _, requiredStep := range rule.requiredList {
+		if !slices.Contains(transactionPublic.CompletedStepList, requiredStep) {
+			return fmt.Errorf("[ConfirmTransactionStep] 前置步骤未完成: %s", requiredStep)
+		}
+	}
+
+	if !s.isTransactionParty(clientMSPID, clientCitizenIDHash, &transactionPublic, rule.partyList) {
+		return fmt.Errorf("[ConfirmTransactionStep] 调用方 %s 无权执行步骤: %s", clientMSPID, step)
+	}
+
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[ConfirmTransactionStep] 获取交易时间戳失败: %v", err)
+	}
+	nowTime := time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	// 各步骤的业务校验，过户步骤记录过户后的房产
+	var transferredRealty *models.Realty
+	switch step {
+	case constances.TxStepSellerSigned:
+		if err := s.checkSellerConsent(ctx, &transactionPublic); err != nil {
+			return fmt.Errorf("[ConfirmTransactionStep] %v", err)
+		}
+	case constances.TxStepGovernmentApproved:
+		transactionPublic.Status = constances.TxStatusInProgress
+	case constances.TxStepFundsEscrowed:
+		if err := s.checkEscrowFunded(ctx, &transactionPublic); err != nil {
+			return fmt.Errorf("[ConfirmTransactionStep] %v", err)
+		}
+	case constances.TxStepTaxPaid:
+		taxList, err := s.queryTaxListByTransaction(ctx, transactionUUID)
+		if err != nil {
+			return fmt.Errorf("[ConfirmTransactionStep] %v", err)
+		}
+		for _, tax := range taxList {
+			if tax.Status != constances.TaxStatusPaid {
+				return fmt.Errorf("[ConfirmTransactionStep] 税费未缴清: %s", tax.TaxType)
+			}
+		}
+	case constances.TxStepTitleTransferred:
+		transferredRealty, err = s.transferTitle(ctx, &transactionPublic, nowTime)
+		if err != nil {
+			return fmt.Errorf("[ConfirmTransactionStep] %v", err)
+		}
+		transactionPublic.Status = constances.TxStatusCompleted
+	}
+
+	// 更新交易状态
+	transactionPublic.CompletedStepList = append(transactionPublic.CompletedStepList, step)
+	transactionPublic.UpdateTime = nowTime
+
+	transactionPublicJSON, err := json.Marshal(transactionPublic)
+	if err != nil {
+		return fmt.Errorf("[ConfirmTransactionStep] 序列化交易信息失败: %v", err)
+	}
 	err = ctx.GetStub().PutState(key, transactionPublicJSON)
 	if err != nil {
-		return fmt.Errorf("[CheckTransaction] 保存交易信息失败: %v", err)
+		return fmt.Errorf("[ConfirmTransactionStep] 保存交易信息失败: %v", err)
 	}
 
-	// 创建交易审核记录
+	// 创建交易步骤记录
 	clientID, err := ctx.GetClientIdentity().GetID()
 	if err != nil {
 		return err
 	}
-	key, err = s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID, "checkTransaction"}...)
+	key, err = s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID, step}...)
 	if err != nil {
 		return err
 	}
-	type checkTransactionRecord struct {
-		ClientID string    `json:"clientID"`
-		Action   string    `json:"action"`
-		Time     time.Time `json:"time"`
-	}
-	record := checkTransactionRecord{
+	record := models.TransactionRecord{
 		ClientID: clientID,
-		Action:   "checkTransaction",
-		Time:     time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+		MSPID:    clientMSPID,
+		Action:   step,
+		Time:     nowTime,
 	}
 	recordJSON, err := json.Marshal(record)
 	if err != nil {
-		return fmt.Errorf("[CheckTransaction] 序列化交易登记记录失败: %v", err)
+		return fmt.Errorf("[ConfirmTransactionStep] 序列化交易步骤记录失败: %v", err)
 	}
 	err = ctx.GetStub().PutState(key, recordJSON)
 	if err != nil {
-		return fmt.Errorf("[CheckTransaction] 保存交易登记记录失败: %v", err)
+		return fmt.Errorf("[ConfirmTransactionStep] 保存交易步骤记录失败: %v", err)
 	}
 
-	return nil
+	transactionEvent := newTransactionEvent(&transactionPublic, transferredRealty)
+	transactionEvent.Step = step
+	if step == constances.TxStepTitleTransferred {
+		return s.emitEvent(ctx, constances.EventTransactionCompleted, transactionEvent)
+	}
+
+	return s.emitEvent(ctx, constances.EventTransactionUpdated, transactionEvent)
 }
 
-// UpdateTransaction 更新交易（投资者、政府可以调用）
-func (s *SmartContract) UpdateTransaction(ctx contractapi.TransactionContextInterface,
+// ConsentTransaction 共有人同意出售房产（仅共有人本人可以调用），卖方签署前须取得全部共有人同意
+func (s *SmartContract) ConsentTransaction(ctx contractapi.TransactionContextInterface,
 	transactionUUID string,
-	status string,
+	ownerCitizenIDHash string,
 ) error {
-
 	// 检查调用者身份
 	clientMSPID, err := s.getClientIdentityMSPID(ctx)
 	if err != nil {
 		return err
 	}
-
-	if clientMSPID != constances.InvestorMSP && clientMSPID != constances.GovernmentMSP {
-		return fmt.Errorf("[UpdateTransaction] 只有投资者、政府可以更新交易")
+	clientCitizenIDHash, err := s.getClientCitizenIDHash(ctx)
+	if err != nil {
+		return err
 	}
 
 	// 查询交易信息
@@ -1199,161 +2015,1804 @@ func (s *SmartContract) UpdateTransaction(ctx contractapi.TransactionContextInte
 
 	transactionPublicBytes, err := ctx.GetStub().GetState(key)
 	if err != nil {
-		return fmt.Errorf("[UpdateTransaction] 查询交易信息失败: %v", err)
+		return fmt.Errorf("[ConsentTransaction] 查询交易信息失败: %v", err)
 	}
-
 	if transactionPublicBytes == nil {
-		return fmt.Errorf("[UpdateTransaction] 交易不存在: %s", transactionUUID)
+		return fmt.Errorf("[ConsentTransaction] 交易不存在: %s", transactionUUID)
 	}
 
 	var transactionPublic models.TransactionPublic
 	err = json.Unmarshal(transactionPublicBytes, &transactionPublic)
 	if err != nil {
-		return fmt.Errorf("[UpdateTransaction] 解析交易信息失败: %v", err)
+		return fmt.Errorf("[ConsentTransaction] 解析交易信息失败: %v", err)
 	}
 
 	if transactionPublic.Status != constances.TxStatusPending && transactionPublic.Status != constances.TxStatusInProgress {
-		return fmt.Errorf("[UpdateTransaction] 交易状态不允许更新: %s", transactionPublic.Status)
+		return fmt.Errorf("[ConsentTransaction] 交易状态不允许操作: %s", transactionPublic.Status)
+	}
+	if slices.Contains(transactionPublic.CompletedStepList, constances.TxStepSellerSigned) {
+		return fmt.Errorf("[ConsentTransaction] 卖方已签署，无需再征得共有人同意")
+	}
+	if slices.Contains(transactionPublic.SellerConsentList, ownerCitizenIDHash) {
+		return fmt.Errorf("[ConsentTransaction] 共有人已同意出售: %s", ownerCitizenIDHash)
+	}
+
+	realEstate, err := s.QueryRealty(ctx, transactionPublic.RealtyCertHash)
+	if err != nil {
+		return err
+	}
+	if err := s.checkRealtyFreeze(ctx, transactionPublic.RealtyCertHash); err != nil {
+		return fmt.Errorf("[ConsentTransaction] %v", err)
+	}
+
+	var owner *models.RealtyOwner
+	for _, item := range realtyOwnerList(realEstate) {
+		if item.CitizenIDHash == ownerCitizenIDHash {
+			owner = item
+			break
+		}
+	}
+	if owner == nil {
+		return fmt.Errorf("[ConsentTransaction] 不是房产共有人: %s", ownerCitizenIDHash)
+	}
+	if !isPartyCaller(clientMSPID, clientCitizenIDHash, owner.CitizenIDHash, owner.Organization) {
+		return fmt.Errorf("[ConsentTransaction] 调用方 %s 无权代表共有人同意出售", clientMSPID)
 	}
 
 	now, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-		return fmt.Errorf("[UpdateTransaction] 获取交易时间戳失败: %v", err)
+		return fmt.Errorf("[ConsentTransaction] 获取交易时间戳失败: %v", err)
 	}
+	nowTime := time.Unix(now.Seconds, int64(now.Nanos)).UTC()
 
-	// 更新交易状态
-	transactionPublic.Status = status
-	transactionPublic.UpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+	transactionPublic.SellerConsentList = append(transactionPublic.SellerConsentList, ownerCitizenIDHash)
+	transactionPublic.UpdateTime = nowTime
 
-	// 序列化交易信息
 	transactionPublicJSON, err := json.Marshal(transactionPublic)
 	if err != nil {
-		return fmt.Errorf("[UpdateTransaction] 序列化交易信息失败: %v", err)
+		return fmt.Errorf("[ConsentTransaction] 序列化交易信息失败: %v", err)
 	}
-
-	// 保存交易信息
 	err = ctx.GetStub().PutState(key, transactionPublicJSON)
 	if err != nil {
-		return fmt.Errorf("[UpdateTransaction] 保存交易信息失败: %v", err)
+		return fmt.Errorf("[ConsentTransaction] 保存交易信息失败: %v", err)
+	}
+
+	// 创建共有人同意记录
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return err
+	}
+	key, err = s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID, "ownerConsent", ownerCitizenIDHash}...)
+	if err != nil {
+		return err
+	}
+	record := models.TransactionRecord{
+		ClientID: clientID,
+		MSPID:    clientMSPID,
+		Action:   "ownerConsent",
+		Time:     nowTime,
+	}
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("[ConsentTransaction] 序列化共有人同意记录失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, recordJSON)
+	if err != nil {
+		return fmt.Errorf("[ConsentTransaction] 保存共有人同意记录失败: %v", err)
+	}
+
+	transactionEvent := newTransactionEvent(&transactionPublic, nil)
+	transactionEvent.Step = "ownerConsent"
+	return s.emitEvent(ctx, constances.EventTransactionUpdated, transactionEvent)
+}
+
+// 检查除卖方本人外的共有人是否均已同意出售，卖方签署视为其本人同意
+func (s *SmartContract) checkSellerConsent(ctx contractapi.TransactionContextInterface,
+	transactionPublic *models.TransactionPublic,
+) error {
+	realEstate, err := s.QueryRealty(ctx, transactionPublic.RealtyCertHash)
+	if err != nil {
+		return err
+	}
+
+	for _, owner := range realtyOwnerList(realEstate) {
+		if owner.CitizenIDHash == transactionPublic.SellerCitizenIDHash &&
+			owner.Organization == transactionPublic.SellerOrganization {
+			continue
+		}
+		if !slices.Contains(transactionPublic.SellerConsentList, owner.CitizenIDHash) {
+			return fmt.Errorf("共有人尚未同意出售: %s", owner.CitizenIDHash)
+		}
 	}
 
 	return nil
 }
 
-// CompleteTransaction 完成交易（投资者、政府可以调用）
+// CompleteTransaction 完成交易（仅政府可以调用），等同于确认过户步骤
 func (s *SmartContract) CompleteTransaction(ctx contractapi.TransactionContextInterface,
 	transactionUUID string,
 ) error {
+	return s.ConfirmTransactionStep(ctx, transactionUUID, constances.TxStepTitleTransferred)
+}
+
+// QueryTransactionTimeline 查询交易时间线（投资者、政府可以调用）
+func (s *SmartContract) QueryTransactionTimeline(ctx contractapi.TransactionContextInterface,
+	transactionUUID string,
+) ([]*models.TransactionRecord, error) {
 	// 检查调用者身份
 	clientMSPID, err := s.getClientIdentityMSPID(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if clientMSPID != constances.InvestorMSP && clientMSPID != constances.GovernmentMSP {
-		return fmt.Errorf("[CompleteTransaction] 只有投资者、政府可以完成交易")
+		return nil, fmt.Errorf("[QueryTransactionTimeline] 只有投资者、政府可以查询交易时间线")
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(constances.DocTypeTransaction, []string{transactionUUID})
+	if err != nil {
+		return nil, fmt.Errorf("[QueryTransactionTimeline] 查询交易记录失败: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	recordList, err := tools.ConstructResultByIterator[models.TransactionRecord](resultsIterator)
+	if err != nil {
+		return nil, fmt.Errorf("[QueryTransactionTimeline] 解析交易记录失败: %v", err)
+	}
+
+	// 交易本身也在该前缀下，只保留操作记录
+	timeline := []*models.TransactionRecord{}
+	for _, record := range recordList {
+		if record.Action != "" {
+			timeline = append(timeline, record)
+		}
+	}
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Time.Before(timeline[j].Time)
+	})
+
+	return timeline, nil
+}
+
+// QueryTransactionHistory 查询交易公开信息的全部历史版本（投资者、政府可以调用）
+func (s *SmartContract) QueryTransactionHistory(ctx contractapi.TransactionContextInterface,
+	transactionUUID string,
+) ([]*models.TransactionHistory, error) {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientMSPID != constances.InvestorMSP && clientMSPID != constances.GovernmentMSP {
+		return nil, fmt.Errorf("[QueryTransactionHistory] 只有投资者、政府可以查询交易历史")
 	}
 
-	// 查询交易信息
 	key, err := s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID}...)
+	if err != nil {
+		return nil, err
+	}
+
+	historyList := []*models.TransactionHistory{}
+	err = s.walkKeyHistory(ctx, key, func(meta models.HistoryMeta, value []byte) error {
+		history := &models.TransactionHistory{HistoryMeta: meta}
+		if !meta.IsDelete && len(value) > 0 {
+			history.Transaction = &models.TransactionPublic{}
+			if err := json.Unmarshal(value, history.Transaction); err != nil {
+				return fmt.Errorf("[QueryTransactionHistory] 解析交易信息失败: %v", err)
+			}
+		}
+		historyList = append(historyList, history)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(historyList) == 0 {
+		return nil, fmt.Errorf("[QueryTransactionHistory] 交易不存在: %s", transactionUUID)
+	}
+
+	return historyList, nil
+}
+
+// 检查调用人是否为允许的交易当事人，调用人由客户端证书确定：
+// 所属组织须与调用方MSP一致，个人当事人还须与证书中的公民身份证号哈希一致
+func (s *SmartContract) checkTransactionCaller(ctx contractapi.TransactionContextInterface,
+	transactionUUID string,
+	partyList []string,
+) error {
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return err
+	}
+	clientCitizenIDHash, err := s.getClientCitizenIDHash(ctx)
 	if err != nil {
 		return err
 	}
 
+	key, err := s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID}...)
+	if err != nil {
+		return err
+	}
 	transactionPublicBytes, err := ctx.GetStub().GetState(key)
 	if err != nil {
-		return fmt.Errorf("[CompleteTransaction] 查询交易信息失败: %v", err)
+		return fmt.Errorf("查询交易信息失败: %v", err)
 	}
 	if transactionPublicBytes == nil {
-		return fmt.Errorf("[CompleteTransaction] 交易不存在: %s", transactionUUID)
+		return fmt.Errorf("交易不存在: %s", transactionUUID)
 	}
-
 	var transactionPublic models.TransactionPublic
-	err = json.Unmarshal(transactionPublicBytes, &transactionPublic)
-	if err != nil {
-		return fmt.Errorf("[CompleteTransaction] 解析交易信息失败: %v", err)
+	if err := json.Unmarshal(transactionPublicBytes, &transactionPublic); err != nil {
+		return fmt.Errorf("解析交易信息失败: %v", err)
 	}
 
-	if transactionPublic.Status != constances.TxStatusInProgress {
-		return fmt.Errorf("[CompleteTransaction] 交易状态不允许完成: %s", transactionPublic.Status)
+	if !s.isTransactionParty(clientMSPID, clientCitizenIDHash, &transactionPublic, partyList) {
+		return fmt.Errorf("调用人不是允许的交易当事人: %v", partyList)
 	}
+	return nil
+}
 
-	now, err := ctx.GetStub().GetTxTimestamp()
-	if err != nil {
-		return fmt.Errorf("[CompleteTransaction] 获取交易时间戳失败: %v", err)
+// 判断调用方是否为指定的当事人
+// 政府机构作为当事人（新房出售）时由机构身份代表，其余当事人须为本人证书
+func isPartyCaller(clientMSPID string, clientCitizenIDHash string, citizenIDHash string, organization string) bool {
+	if constances.OrganizationMSPMap[organization] != clientMSPID {
+		return false
+	}
+	if organization == constances.GovernmentDefaultOrganization {
+		return true
 	}
+	return clientCitizenIDHash != "" && clientCitizenIDHash == citizenIDHash
+}
 
-	// 更新交易状态
-	transactionPublic.Status = constances.TxStatusCompleted
-	transactionPublic.UpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+// 判断调用方是否属于允许的交易参与方（买卖双方须为本人证书，政府和银行按MSP判断）
+func (s *SmartContract) isTransactionParty(clientMSPID string,
+	clientCitizenIDHash string,
+	transactionPublic *models.TransactionPublic,
+	partyList []string,
+) bool {
+	for _, party := range partyList {
+		switch party {
+		case constances.TxPartyBuyer:
+			if isPartyCaller(clientMSPID, clientCitizenIDHash, transactionPublic.BuyerCitizenIDHash, transactionPublic.BuyerOrganization) {
+				return true
+			}
+		case constances.TxPartySeller:
+			if isPartyCaller(clientMSPID, clientCitizenIDHash, transactionPublic.SellerCitizenIDHash, transactionPublic.SellerOrganization) {
+				return true
+			}
+		case constances.TxPartyGovernment:
+			if clientMSPID == constances.GovernmentMSP {
+				return true
+			}
+		case constances.TxPartyBank:
+			if clientMSPID == constances.BankMSP {
+				return true
+			}
+		}
+	}
+	return false
+}
 
-	// 序列化交易信息
-	transactionPublicJSON, err := json.Marshal(transactionPublic)
+// 检查托管房款是否已达到成交价
+func (s *SmartContract) checkEscrowFunded(ctx contractapi.TransactionContextInterface,
+	transactionPublic *models.TransactionPublic,
+) error {
+	key, err := s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionPublic.TransactionUUID}...)
+	if err != nil {
+		return err
+	}
+	transactionPrivateBytes, err := ctx.GetStub().GetPrivateData(constances.TransactionPrivateCollection, key)
+	if err != nil {
+		return fmt.Errorf("查询交易信息失败: %v", err)
+	}
+	var transactionPrivate models.TransactionPrivate
+	err = json.Unmarshal(transactionPrivateBytes, &transactionPrivate)
 	if err != nil {
-		return fmt.Errorf("[CompleteTransaction] 序列化交易信息失败: %v", err)
+		return fmt.Errorf("解析交易信息失败: %v", err)
 	}
 
-	// 保存交易信息
-	err = ctx.GetStub().PutState(key, transactionPublicJSON)
+	escrow, err := s.getEscrow(ctx, transactionPublic)
 	if err != nil {
-		return fmt.Errorf("[CompleteTransaction] 保存交易信息失败: %v", err)
+		return err
+	}
+	if escrow.TransferAmount < transactionPrivate.Price-0.01 {
+		return fmt.Errorf("托管房款不足: 已托管%.2f, 成交价%.2f", escrow.TransferAmount, transactionPrivate.Price)
 	}
 
+	return nil
+}
+
+// 过户：放款、转移承接的抵押并变更房产所有者，返回过户后的房产
+func (s *SmartContract) transferTitle(ctx contractapi.TransactionContextInterface,
+	transactionPublic *models.TransactionPublic,
+	nowTime time.Time,
+) (*models.Realty, error) {
 	// 更新房产信息
 	realtyIDHash := transactionPublic.RealtyCertHash
 	realEstate, err := s.QueryRealty(ctx, realtyIDHash)
 	if err != nil {
-		return fmt.Errorf("[CompleteTransaction] 查询房产信息失败: %v", err)
+		return nil, fmt.Errorf("查询房产信息失败: %v", err)
+	}
+
+	// 过户前再次检查房产抵押情况
+	if err := s.checkRealtyEncumbrance(ctx, realtyIDHash, transactionPublic.BuyerCitizenIDHash, transactionPublic.BuyerOrganization); err != nil {
+		return nil, err
+	}
+
+	// 买方承接的抵押随房产一并转移
+	mortgageList, err := s.queryMortgagePublicListByRealty(ctx, realtyIDHash)
+	if err != nil {
+		return nil, err
+	}
+	// 托管资金放款给卖方和政府税费账户
+	key, err := s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionPublic.TransactionUUID}...)
+	if err != nil {
+		return nil, err
+	}
+	transactionPrivateBytes, err := ctx.GetStub().GetPrivateData(constances.TransactionPrivateCollection, key)
+	if err != nil {
+		return nil, fmt.Errorf("查询交易信息失败: %v", err)
+	}
+	var transactionPrivate models.TransactionPrivate
+	err = json.Unmarshal(transactionPrivateBytes, &transactionPrivate)
+	if err != nil {
+		return nil, fmt.Errorf("解析交易信息失败: %v", err)
+	}
+	ownerList := realtyOwnerList(realEstate)
+	err = s.releaseEscrow(ctx, transactionPublic, ownerList, transactionPrivate.Price, nowTime)
+	if err != nil {
+		return nil, err
+	}
+
+	realtyStatus := constances.RealtyStatusNormal
+	for _, mortgage := range mortgageList {
+		if mortgage.Status != constances.MortgageStatusAssumed {
+			continue
+		}
+		mortgage.MortgagorCitizenIDHash = mortgage.AssumeCitizenIDHash
+		mortgage.MortgagorOrganization = mortgage.AssumeOrganization
+		mortgage.AssumeCitizenIDHash = ""
+		mortgage.AssumeOrganization = ""
+		mortgage.Status = constances.MortgageStatusActive
+		// 买方取得房产后未挂牌，抵押解除后恢复为正常状态
+		mortgage.PreviousRealtyStatus = constances.RealtyStatusNormal
+		mortgage.LastUpdateTime = nowTime
+
+		mortgageKey, err := s.createCompositeKey(ctx, constances.DocTypeMortgage, []string{mortgage.MortgageUUID}...)
+		if err != nil {
+			return nil, err
+		}
+		mortgageJSON, err := json.Marshal(mortgage)
+		if err != nil {
+			return nil, fmt.Errorf("序列化抵押信息失败: %v", err)
+		}
+		err = ctx.GetStub().PutState(mortgageKey, mortgageJSON)
+		if err != nil {
+			return nil, fmt.Errorf("保存抵押信息失败: %v", err)
+		}
+		realtyStatus = constances.RealtyStatusInMortgage
 	}
 
 	// 更新房产信息
-	previousOwnersCitizenIDHashList := append(realEstate.PreviousOwnersCitizenIDHashList, transactionPublic.SellerCitizenIDHash)
+	previousOwnersCitizenIDHashList := realEstate.PreviousOwnersCitizenIDHashList
+	for _, owner := range ownerList {
+		previousOwnersCitizenIDHashList = append(previousOwnersCitizenIDHashList, owner.CitizenIDHash)
+	}
 	previousOwnersCitizenIDHashListJSON, err := json.Marshal(previousOwnersCitizenIDHashList)
 	if err != nil {
-		return fmt.Errorf("[CompleteTransaction] 序列化历史所有者列表失败: %v", err)
+		return nil, fmt.Errorf("序列化历史所有者列表失败: %v", err)
 	}
-	err = s.UpdateRealty(
+	transferredRealty, err := s.updateRealty(
 		ctx,
 		realtyIDHash,
 		realEstate.RealtyType,
-		constances.RealtyStatusNormal,
+		realtyStatus,
 		transactionPublic.BuyerCitizenIDHash,
 		transactionPublic.BuyerOrganization,
 		string(previousOwnersCitizenIDHashListJSON),
 	)
 	if err != nil {
-		return fmt.Errorf("更新房产信息失败: %v", err)
+		return nil, fmt.Errorf("更新房产信息失败: %v", err)
+	}
+	return transferredRealty, nil
+}
+
+// RejectTransaction 拒绝交易并退还托管资金（仅卖方本人可以调用）
+func (s *SmartContract) RejectTransaction(ctx contractapi.TransactionContextInterface,
+	transactionUUID string,
+) error {
+	if err := s.checkTransactionCaller(ctx, transactionUUID, []string{constances.TxPartySeller}); err != nil {
+		return fmt.Errorf("[RejectTransaction] %v", err)
 	}
 
-	// 创建交易完成记录
-	clientID, err := ctx.GetClientIdentity().GetID()
+	if err := s.closeTransaction(ctx, transactionUUID, constances.TxStatusRejected, "rejectTransaction"); err != nil {
+		return fmt.Errorf("[RejectTransaction] %v", err)
+	}
+
+	return nil
+}
+
+// CancelTransaction 取消交易并退还托管资金（仅买卖双方本人可以调用）
+func (s *SmartContract) CancelTransaction(ctx contractapi.TransactionContextInterface,
+	transactionUUID string,
+) error {
+	if err := s.checkTransactionCaller(ctx, transactionUUID, []string{constances.TxPartyBuyer, constances.TxPartySeller}); err != nil {
+		return fmt.Errorf("[CancelTransaction] %v", err)
+	}
+
+	if err := s.closeTransaction(ctx, transactionUUID, constances.TxStatusCancelled, "cancelTransaction"); err != nil {
+		return fmt.Errorf("[CancelTransaction] %v", err)
+	}
+
+	return nil
+}
+
+// ExpireTransaction 终止超时未推进的交易并释放房产（投资者、政府可以调用）
+func (s *SmartContract) ExpireTransaction(ctx contractapi.TransactionContextInterface,
+	transactionUUID string,
+) error {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
 	if err != nil {
 		return err
 	}
-	key, err = s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID, "completeTransaction"}...)
+
+	if clientMSPID != constances.InvestorMSP && clientMSPID != constances.GovernmentMSP {
+		return fmt.Errorf("[ExpireTransaction] 只有投资者、政府可以终止超时交易")
+	}
+
+	transaction, err := s.QueryTransaction(ctx, transactionUUID)
 	if err != nil {
 		return err
 	}
-	type completeTransactionRecord struct {
-		ClientID string    `json:"clientID"`
-		Action   string    `json:"action"`
-		Time     time.Time `json:"time"`
+
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[ExpireTransaction] 获取交易时间戳失败: %v", err)
 	}
-	record := completeTransactionRecord{
-		ClientID: clientID,
-		Action:   "completeTransaction",
-		Time:     time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+	if now.AsTime().Before(transaction.UpdateTime.Add(constances.TransactionTimeout)) {
+		return fmt.Errorf("[ExpireTransaction] 交易尚未超时")
 	}
-	recordJSON, err := json.Marshal(record)
+
+	if err := s.closeTransaction(ctx, transactionUUID, constances.TxStatusExpired, "expireTransaction"); err != nil {
+		return fmt.Errorf("[ExpireTransaction] %v", err)
+	}
+
+	return nil
+}
+
+// 终止交易：退还托管资金，房产恢复挂牌状态
+func (s *SmartContract) closeTransaction(ctx contractapi.TransactionContextInterface,
+	transactionUUID string,
+	status string,
+	action string,
+) error {
+	key, err := s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID}...)
 	if err != nil {
-		return fmt.Errorf("[CompleteTransaction] 序列化交易完成记录失败: %v", err)
+		return err
 	}
-	err = ctx.GetStub().PutState(key, recordJSON)
+
+	transactionPublicBytes, err := ctx.GetStub().GetState(key)
 	if err != nil {
-		return fmt.Errorf("[CompleteTransaction] 保存交易完成记录失败: %v", err)
+		return fmt.Errorf("查询交易信息失败: %v", err)
+	}
+	if transactionPublicBytes == nil {
+		return fmt.Errorf("交易不存在: %s", transactionUUID)
 	}
 
-	return nil
+	var transactionPublic models.TransactionPublic
+	err = json.Unmarshal(transactionPublicBytes, &transactionPublic)
+	if err != nil {
+		return fmt.Errorf("解析交易信息失败: %v", err)
+	}
+
+	if transactionPublic.Status != constances.TxStatusPending && transactionPublic.Status != constances.TxStatusInProgress {
+		return fmt.Errorf("交易状态不允许终止: %s", transactionPublic.Status)
+	}
+
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	nowTime := time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	// 退还托管资金
+	if err := s.refundEscrow(ctx, &transactionPublic, nowTime); err != nil {
+		return err
+	}
+
+	// 更新交易状态
+	transactionPublic.Status = status
+	transactionPublic.UpdateTime = nowTime
+	transactionPublicJSON, err := json.Marshal(transactionPublic)
+	if err != nil {
+		return fmt.Errorf("序列化交易信息失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, transactionPublicJSON)
+	if err != nil {
+		return fmt.Errorf("保存交易信息失败: %v", err)
+	}
+
+	// 释放房产交易锁，房产恢复挂牌状态，仍有未解除的抵押时恢复为抵押中
+	realEstate, err := s.QueryRealty(ctx, transactionPublic.RealtyCertHash)
+	if err != nil {
+		return err
+	}
+	unlockedStatus, err := s.unlockedRealtyStatus(ctx, transactionPublic.RealtyCertHash)
+	if err != nil {
+		return err
+	}
+	var unlockedRealty *models.Realty
+	if realEstate.Status == constances.RealtyStatusInSale &&
+		(realEstate.ActiveTransactionUUID == "" || realEstate.ActiveTransactionUUID == transactionUUID) {
+		unlockedRealty, err = s.setRealtyLock(ctx, transactionPublic.RealtyCertHash, unlockedStatus, "")
+		if err != nil {
+			return err
+		}
+	}
+	// 冻结期间终止的交易，解冻后房产恢复为挂牌（或抵押中）状态
+	if realEstate.Status == constances.RealtyStatusFrozen && realEstate.ActiveTransactionUUID == transactionUUID {
+		err := s.modifyRealty(ctx, transactionPublic.RealtyCertHash, func(realty *models.Realty) {
+			realty.ActiveTransactionUUID = ""
+			if freeze := activeRealtyFreeze(realty); freeze != nil && freeze.PreviousStatus == constances.RealtyStatusInSale {
+				freeze.PreviousStatus = unlockedStatus
+			}
+			unlockedRealty = realty
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// 创建交易终止记录
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return err
+	}
+	key, err = s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID, action}...)
+	if err != nil {
+		return err
+	}
+	type closeTransactionRecord struct {
+		ClientID string    `json:"clientID"`
+		Action   string    `json:"action"`
+		Time     time.Time `json:"time"`
+	}
+	record := closeTransactionRecord{
+		ClientID: clientID,
+		Action:   action,
+		Time:     nowTime,
+	}
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("序列化交易终止记录失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, recordJSON)
+	if err != nil {
+		return fmt.Errorf("保存交易终止记录失败: %v", err)
+	}
+
+	return s.emitEvent(ctx, constances.EventTransactionUpdated, newTransactionEvent(&transactionPublic, unlockedRealty))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// 资金托管相关
+//
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// QueryEscrow 查询交易托管账户（投资者、银行、政府可以调用）
+func (s *SmartContract) QueryEscrow(ctx contractapi.TransactionContextInterface,
+	transactionUUID string,
+) (*models.Escrow, error) {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientMSPID != constances.InvestorMSP && clientMSPID != constances.BankMSP && clientMSPID != constances.GovernmentMSP {
+		return nil, fmt.Errorf("[QueryEscrow] 只有投资者、银行、政府可以查询托管账户")
+	}
+
+	key, err := s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID}...)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionPublicBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("[QueryEscrow] 查询交易信息失败: %v", err)
+	}
+	if transactionPublicBytes == nil {
+		return nil, fmt.Errorf("[QueryEscrow] 交易不存在: %s", transactionUUID)
+	}
+
+	var transactionPublic models.TransactionPublic
+	err = json.Unmarshal(transactionPublicBytes, &transactionPublic)
+	if err != nil {
+		return nil, fmt.Errorf("[QueryEscrow] 解析交易信息失败: %v", err)
+	}
+
+	escrow, err := s.getEscrow(ctx, &transactionPublic)
+	if err != nil {
+		return nil, fmt.Errorf("[QueryEscrow] %v", err)
+	}
+
+	return escrow, nil
+}
+
+// 查询交易托管账户，不存在时返回一个空的托管账户
+func (s *SmartContract) getEscrow(ctx contractapi.TransactionContextInterface,
+	transactionPublic *models.TransactionPublic,
+) (*models.Escrow, error) {
+	key, err := s.createCompositeKey(ctx, constances.DocTypeEscrow, []string{transactionPublic.TransactionUUID}...)
+	if err != nil {
+		return nil, err
+	}
+
+	escrowBytes, err := ctx.GetStub().GetPrivateData(constances.TransactionPrivateCollection, key)
+	if err != nil {
+		return nil, fmt.Errorf("查询托管账户失败: %v", err)
+	}
+
+	if escrowBytes == nil {
+		now, err := ctx.GetStub().GetTxTimestamp()
+		if err != nil {
+			return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+		}
+		return &models.Escrow{
+			DocType:             constances.DocTypeEscrow,
+			TransactionUUID:     transactionPublic.TransactionUUID,
+			BuyerCitizenIDHash:  transactionPublic.BuyerCitizenIDHash,
+			BuyerOrganization:   transactionPublic.BuyerOrganization,
+			SellerCitizenIDHash: transactionPublic.SellerCitizenIDHash,
+			SellerOrganization:  transactionPublic.SellerOrganization,
+			DepositList:         []*models.EscrowDeposit{},
+			Status:              constances.EscrowStatusHolding,
+			CreateTime:          time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+			LastUpdateTime:      time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+		}, nil
+	}
+
+	var escrow models.Escrow
+	err = json.Unmarshal(escrowBytes, &escrow)
+	if err != nil {
+		return nil, fmt.Errorf("解析托管账户失败: %v", err)
+	}
+
+	return &escrow, nil
+}
+
+// 保存交易托管账户
+func (s *SmartContract) putEscrow(ctx contractapi.TransactionContextInterface, escrow *models.Escrow) error {
+	key, err := s.createCompositeKey(ctx, constances.DocTypeEscrow, []string{escrow.TransactionUUID}...)
+	if err != nil {
+		return err
+	}
+
+	escrowJSON, err := json.Marshal(escrow)
+	if err != nil {
+		return fmt.Errorf("序列化托管账户失败: %v", err)
+	}
+
+	err = ctx.GetStub().PutPrivateData(constances.TransactionPrivateCollection, key, escrowJSON)
+	if err != nil {
+		return fmt.Errorf("保存托管账户失败: %v", err)
+	}
+
+	return nil
+}
+
+// 交易完成时放款：房款按份额付给各共有人（超出成交价的部分退还买方），税费付给政府账户
+func (s *SmartContract) releaseEscrow(ctx contractapi.TransactionContextInterface,
+	transactionPublic *models.TransactionPublic,
+	ownerList []*models.RealtyOwner,
+	price float64,
+	settleTime time.Time,
+) error {
+	escrow, err := s.getEscrow(ctx, transactionPublic)
+	if err != nil {
+		return err
+	}
+	if escrow.Status != constances.EscrowStatusHolding {
+		return fmt.Errorf("托管账户已结算: %s", escrow.Status)
+	}
+	if escrow.TransferAmount < price-0.01 {
+		return fmt.Errorf("托管房款不足: 已托管%.2f, 成交价%.2f", escrow.TransferAmount, price)
+	}
+
+	sellerAmount := math.Min(escrow.TransferAmount, price)
+	changes := []balanceChange{}
+	// 按份额分配房款，分配尾差计入最后一位共有人
+	remainAmount := sellerAmount
+	for i, owner := range ownerList {
+		amount := roundAmount(sellerAmount * owner.Share / 100)
+		if i == len(ownerList)-1 {
+			amount = remainAmount
+		}
+		remainAmount -= amount
+		changes = append(changes, balanceChange{owner.CitizenIDHash, owner.Organization, amount})
+	}
+	changes = append(changes,
+		balanceChange{escrow.BuyerCitizenIDHash, escrow.BuyerOrganization, escrow.TransferAmount - sellerAmount},
+		balanceChange{tools.GenerateHash(constances.GovernmentDefaultCitizenID), constances.GovernmentDefaultOrganization, escrow.TaxAmount},
+	)
+	if err := s.applyBalanceChanges(ctx, changes, settleTime); err != nil {
+		return err
+	}
+
+	if err := s.settleEscrowPayments(ctx, escrow, constances.PaymentStatusSettled, settleTime); err != nil {
+		return err
+	}
+
+	escrow.Status = constances.EscrowStatusReleased
+	escrow.SettleTime = settleTime
+	escrow.LastUpdateTime = settleTime
+	return s.putEscrow(ctx, escrow)
+}
+
+// 余额变动
+type balanceChange struct {
+	citizenIDHash string
+	organization  string
+	amount        float64
+}
+
+// 批量调整余额，同一账户的变动先合并，避免同一交易内重复读写私有数据导致覆盖
+func (s *SmartContract) applyBalanceChanges(ctx contractapi.TransactionContextInterface,
+	changes []balanceChange,
+	updateTime time.Time,
+) error {
+	merged := []*balanceChange{}
+	index := map[string]*balanceChange{}
+	for _, change := range changes {
+		accountKey := change.citizenIDHash + "~" + change.organization
+		if existing, ok := index[accountKey]; ok {
+			existing.amount += change.amount
+			continue
+		}
+		item := change
+		index[accountKey] = &item
+		merged = append(merged, &item)
+	}
+
+	for _, change := range merged {
+		if roundAmount(change.amount) == 0 {
+			continue
+		}
+		if err := s.adjustBalance(ctx, change.citizenIDHash, change.organization, change.amount, updateTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 交易终止时退款：托管资金按明细原路退回付款人，已缴税费标记为已退还
+func (s *SmartContract) refundEscrow(ctx contractapi.TransactionContextInterface,
+	transactionPublic *models.TransactionPublic,
+	settleTime time.Time,
+) error {
+	escrow, err := s.getEscrow(ctx, transactionPublic)
+	if err != nil {
+		return err
+	}
+	if escrow.Status != constances.EscrowStatusHolding {
+		return fmt.Errorf("托管账户已结算: %s", escrow.Status)
+	}
+
+	changes := []balanceChange{}
+	for _, deposit := range escrow.DepositList {
+		changes = append(changes, balanceChange{deposit.PayerCitizenIDHash, deposit.PayerOrganization, deposit.Amount})
+	}
+	if err := s.applyBalanceChanges(ctx, changes, settleTime); err != nil {
+		return err
+	}
+
+	taxList, err := s.queryTaxListByTransaction(ctx, transactionPublic.TransactionUUID)
+	if err != nil {
+		return err
+	}
+	for _, tax := range taxList {
+		if tax.Status != constances.TaxStatusPaid {
+			continue
+		}
+		tax.Status = constances.TaxStatusRefunded
+		if err := s.putTax(ctx, tax); err != nil {
+			return err
+		}
+	}
+
+	if err := s.settleEscrowPayments(ctx, escrow, constances.PaymentStatusReversed, settleTime); err != nil {
+		return err
+	}
+
+	escrow.Status = constances.EscrowStatusRefunded
+	escrow.SettleTime = settleTime
+	escrow.LastUpdateTime = settleTime
+	return s.putEscrow(ctx, escrow)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// 抵押相关
+//
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// CreateMortgage 创建抵押申请（仅银行可调用）
+func (s *SmartContract) CreateMortgage(ctx contractapi.TransactionContextInterface,
+	mortgageUUID string,
+	realtyCertHash string,
+	mortgagorCitizenIDHash string,
+	mortgagorOrganization string,
+	loanAmount float64,
+	interestRate float64,
+	term int,
+	collateralValue float64,
+	paymentPlan string,
+) error {
+	// 检查调用者身份
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("[CreateMortgage] 获取客户端ID失败: %v", err)
+	}
+
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientMSPID != constances.BankMSP {
+		return fmt.Errorf("[CreateMortgage] 只有银行可以创建抵押")
+	}
+
+	// 检查抵押是否已存在
+	key, err := s.createCompositeKey(ctx, constances.DocTypeMortgage, []string{mortgageUUID}...)
+	if err != nil {
+		return err
+	}
+	exists, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("[CreateMortgage] 查询抵押信息失败: %v", err)
+	}
+	if exists != nil {
+		return fmt.Errorf("[CreateMortgage] 抵押ID %s 已存在", mortgageUUID)
+	}
+
+	// 查询房产信息
+	realEstate, err := s.QueryRealty(ctx, realtyCertHash)
+	if err != nil {
+		return err
+	}
+
+	if realEstate.Status == constances.RealtyStatusFrozen || realEstate.Status == constances.RealtyStatusInSale {
+		return fmt.Errorf("[CreateMortgage] 房产状态不允许抵押: %s", realEstate.Status)
+	}
+
+	// 检查抵押人是否为房产所有者
+	if realEstate.CurrentOwnerCitizenIDHash != mortgagorCitizenIDHash ||
+		realEstate.CurrentOwnerOrganization != mortgagorOrganization {
+		return fmt.Errorf("[CreateMortgage] 抵押人不是房产所有者")
+	}
+
+	if loanAmount <= 0 {
+		return fmt.Errorf("[CreateMortgage] 贷款金额必须大于0")
+	}
+
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[CreateMortgage] 获取交易时间戳失败: %v", err)
+	}
+
+	// 创建公开抵押信息
+	mortgagePublic := models.MortgagePublic{
+		DocType:                constances.DocTypeMortgage,
+		MortgageUUID:           mortgageUUID,
+		RealtyCertHash:         realtyCertHash,
+		MortgagorCitizenIDHash: mortgagorCitizenIDHash,
+		MortgagorOrganization:  mortgagorOrganization,
+		Status:                 constances.MortgageStatusPending,
+		CreateTime:             time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+		LastUpdateTime:         time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+	}
+
+	mortgagePublicJSON, err := json.Marshal(mortgagePublic)
+	if err != nil {
+		return fmt.Errorf("[CreateMortgage] 序列化公开抵押信息失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, mortgagePublicJSON)
+	if err != nil {
+		return fmt.Errorf("[CreateMortgage] 保存公开抵押信息失败: %v", err)
+	}
+
+	// 创建私有抵押信息
+	mortgagePrivate := models.MortgagePrivate{
+		DocType:         constances.DocTypeMortgage,
+		MortgageUUID:    mortgageUUID,
+		LoanAmount:      loanAmount,
+		InterestRate:    interestRate,
+		Term:            term,
+		CollateralValue: collateralValue,
+		PaymentPlan:     paymentPlan,
+	}
+
+	mortgagePrivateJSON, err := json.Marshal(mortgagePrivate)
+	if err != nil {
+		return fmt.Errorf("[CreateMortgage] 序列化私有抵押信息失败: %v", err)
+	}
+	err = ctx.GetStub().PutPrivateData(constances.MortgageDataCollection, key, mortgagePrivateJSON)
+	if err != nil {
+		return fmt.Errorf("[CreateMortgage] 保存私有抵押信息失败: %v", err)
+	}
+
+	// 创建抵押登记记录
+	key, err = s.createCompositeKey(ctx, constances.DocTypeMortgage, []string{mortgageUUID, "createMortgage"}...)
+	if err != nil {
+		return err
+	}
+	type createMortgageRecord struct {
+		ClientID string    `json:"clientID"`
+		Action   string    `json:"action"`
+		Time     time.Time `json:"time"`
+	}
+	record := createMortgageRecord{
+		ClientID: clientID,
+		Action:   "createMortgage",
+		Time:     time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+	}
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("[CreateMortgage] 序列化抵押登记记录失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, recordJSON)
+	if err != nil {
+		return fmt.Errorf("[CreateMortgage] 保存抵押登记记录失败: %v", err)
+	}
+
+	return s.emitMortgageEvent(ctx, &mortgagePublic, realEstate)
+}
+
+// ApproveMortgage 批准抵押，房产进入抵押状态（仅银行可调用）
+func (s *SmartContract) ApproveMortgage(ctx contractapi.TransactionContextInterface,
+	mortgageUUID string,
+) error {
+	// 检查调用者身份
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("[ApproveMortgage] 获取客户端ID失败: %v", err)
+	}
+
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientMSPID != constances.BankMSP {
+		return fmt.Errorf("[ApproveMortgage] 只有银行可以批准抵押")
+	}
+
+	mortgagePublic, key, err := s.getMortgagePublic(ctx, mortgageUUID)
+	if err != nil {
+		return fmt.Errorf("[ApproveMortgage] %v", err)
+	}
+
+	if mortgagePublic.Status != constances.MortgageStatusPending {
+		return fmt.Errorf("[ApproveMortgage] 抵押状态不允许批准: %s", mortgagePublic.Status)
+	}
+
+	// 批准前再次确认房产状态
+	realEstate, err := s.QueryRealty(ctx, mortgagePublic.RealtyCertHash)
+	if err != nil {
+		return err
+	}
+	if realEstate.Status == constances.RealtyStatusFrozen || realEstate.Status == constances.RealtyStatusInSale {
+		return fmt.Errorf("[ApproveMortgage] 房产状态不允许抵押: %s", realEstate.Status)
+	}
+
+	// 记录房产抵押前的状态，全部抵押解除后恢复
+	mortgageList, err := s.queryMortgagePublicListByRealty(ctx, mortgagePublic.RealtyCertHash)
+	if err != nil {
+		return fmt.Errorf("[ApproveMortgage] %v", err)
+	}
+
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[ApproveMortgage] 获取交易时间戳失败: %v", err)
+	}
+
+	mortgagePublic.PreviousRealtyStatus = previousRealtyStatus(realEstate, mortgageList)
+	mortgagePublic.Status = constances.MortgageStatusActive
+	mortgagePublic.ApprovedTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+	mortgagePublic.LastUpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	mortgagePublicJSON, err := json.Marshal(mortgagePublic)
+	if err != nil {
+		return fmt.Errorf("[ApproveMortgage] 序列化抵押信息失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, mortgagePublicJSON)
+	if err != nil {
+		return fmt.Errorf("[ApproveMortgage] 保存抵押信息失败: %v", err)
+	}
+
+	// 房产设置为抵押中
+	mortgagedRealty, err := s.setRealtyStatus(ctx, mortgagePublic.RealtyCertHash, constances.RealtyStatusInMortgage)
+	if err != nil {
+		return fmt.Errorf("[ApproveMortgage] %v", err)
+	}
+
+	// 创建抵押批准记录
+	key, err = s.createCompositeKey(ctx, constances.DocTypeMortgage, []string{mortgageUUID, "approveMortgage"}...)
+	if err != nil {
+		return err
+	}
+	type approveMortgageRecord struct {
+		ClientID string    `json:"clientID"`
+		Action   string    `json:"action"`
+		Time     time.Time `json:"time"`
+	}
+	record := approveMortgageRecord{
+		ClientID: clientID,
+		Action:   "approveMortgage",
+		Time:     time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+	}
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("[ApproveMortgage] 序列化抵押批准记录失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, recordJSON)
+	if err != nil {
+		return fmt.Errorf("[ApproveMortgage] 保存抵押批准记录失败: %v", err)
+	}
+
+	return s.emitMortgageEvent(ctx, mortgagePublic, mortgagedRealty)
+}
+
+// AssumeMortgage 同意买方承接抵押（仅银行可调用）
+func (s *SmartContract) AssumeMortgage(ctx contractapi.TransactionContextInterface,
+	mortgageUUID string,
+	assumeCitizenIDHash string,
+	assumeOrganization string,
+) error {
+	// 检查调用者身份
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("[AssumeMortgage] 获取客户端ID失败: %v", err)
+	}
+
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientMSPID != constances.BankMSP {
+		return fmt.Errorf("[AssumeMortgage] 只有银行可以同意承接抵押")
+	}
+
+	mortgagePublic, key, err := s.getMortgagePublic(ctx, mortgageUUID)
+	if err != nil {
+		return fmt.Errorf("[AssumeMortgage] %v", err)
+	}
+
+	if mortgagePublic.Status != constances.MortgageStatusActive && mortgagePublic.Status != constances.MortgageStatusAssumed {
+		return fmt.Errorf("[AssumeMortgage] 抵押状态不允许承接: %s", mortgagePublic.Status)
+	}
+
+	if assumeCitizenIDHash == mortgagePublic.MortgagorCitizenIDHash && assumeOrganization == mortgagePublic.MortgagorOrganization {
+		return fmt.Errorf("[AssumeMortgage] 承接人不能为抵押人本人")
+	}
+
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[AssumeMortgage] 获取交易时间戳失败: %v", err)
+	}
+
+	mortgagePublic.Status = constances.MortgageStatusAssumed
+	mortgagePublic.AssumeCitizenIDHash = assumeCitizenIDHash
+	mortgagePublic.AssumeOrganization = assumeOrganization
+	mortgagePublic.LastUpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	mortgagePublicJSON, err := json.Marshal(mortgagePublic)
+	if err != nil {
+		return fmt.Errorf("[AssumeMortgage] 序列化抵押信息失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, mortgagePublicJSON)
+	if err != nil {
+		return fmt.Errorf("[AssumeMortgage] 保存抵押信息失败: %v", err)
+	}
+
+	// 创建抵押承接记录
+	key, err = s.createCompositeKey(ctx, constances.DocTypeMortgage, []string{mortgageUUID, "assumeMortgage"}...)
+	if err != nil {
+		return err
+	}
+	type assumeMortgageRecord struct {
+		ClientID string    `json:"clientID"`
+		Action   string    `json:"action"`
+		Time     time.Time `json:"time"`
+	}
+	record := assumeMortgageRecord{
+		ClientID: clientID,
+		Action:   "assumeMortgage",
+		Time:     time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+	}
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("[AssumeMortgage] 序列化抵押承接记录失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, recordJSON)
+	if err != nil {
+		return fmt.Errorf("[AssumeMortgage] 保存抵押承接记录失败: %v", err)
+	}
+
+	return s.emitMortgageEvent(ctx, mortgagePublic, nil)
+}
+
+// ReleaseMortgage 解除抵押（仅银行可调用）
+func (s *SmartContract) ReleaseMortgage(ctx contractapi.TransactionContextInterface,
+	mortgageUUID string,
+) error {
+	// 检查调用者身份
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("[ReleaseMortgage] 获取客户端ID失败: %v", err)
+	}
+
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientMSPID != constances.BankMSP {
+		return fmt.Errorf("[ReleaseMortgage] 只有银行可以解除抵押")
+	}
+
+	mortgagePublic, key, err := s.getMortgagePublic(ctx, mortgageUUID)
+	if err != nil {
+		return fmt.Errorf("[ReleaseMortgage] %v", err)
+	}
+
+	if mortgagePublic.Status == constances.MortgageStatusReleased {
+		return fmt.Errorf("[ReleaseMortgage] 抵押已解除: %s", mortgageUUID)
+	}
+
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[ReleaseMortgage] 获取交易时间戳失败: %v", err)
+	}
+
+	mortgagePublic.Status = constances.MortgageStatusReleased
+	mortgagePublic.ReleasedTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+	mortgagePublic.LastUpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	mortgagePublicJSON, err := json.Marshal(mortgagePublic)
+	if err != nil {
+		return fmt.Errorf("[ReleaseMortgage] 序列化抵押信息失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, mortgagePublicJSON)
+	if err != nil {
+		return fmt.Errorf("[ReleaseMortgage] 保存抵押信息失败: %v", err)
+	}
+
+	// 房产上没有其他生效的抵押时，恢复为抵押前的状态
+	mortgageList, err := s.queryMortgagePublicListByRealty(ctx, mortgagePublic.RealtyCertHash)
+	if err != nil {
+		return fmt.Errorf("[ReleaseMortgage] %v", err)
+	}
+	stillEncumbered := false
+	for _, mortgage := range mortgageList {
+		if mortgage.MortgageUUID != mortgageUUID && isMortgageEffective(mortgage) {
+			stillEncumbered = true
+			break
+		}
+	}
+	realEstate, err := s.QueryRealty(ctx, mortgagePublic.RealtyCertHash)
+	if err != nil {
+		return err
+	}
+	if !stillEncumbered && realEstate.Status == constances.RealtyStatusInMortgage {
+		restoreStatus := mortgagePublic.PreviousRealtyStatus
+		if restoreStatus == "" {
+			restoreStatus = constances.RealtyStatusNormal
+		}
+		realEstate, err = s.setRealtyStatus(ctx, mortgagePublic.RealtyCertHash, restoreStatus)
+		if err != nil {
+			return fmt.Errorf("[ReleaseMortgage] %v", err)
+		}
+	}
+
+	// 创建抵押解除记录
+	key, err = s.createCompositeKey(ctx, constances.DocTypeMortgage, []string{mortgageUUID, "releaseMortgage"}...)
+	if err != nil {
+		return err
+	}
+	type releaseMortgageRecord struct {
+		ClientID string    `json:"clientID"`
+		Action   string    `json:"action"`
+		Time     time.Time `json:"time"`
+	}
+	record := releaseMortgageRecord{
+		ClientID: clientID,
+		Action:   "releaseMortgage",
+		Time:     time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+	}
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("[ReleaseMortgage] 序列化抵押解除记录失败: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, recordJSON)
+	if err != nil {
+		return fmt.Errorf("[ReleaseMortgage] 保存抵押解除记录失败: %v", err)
+	}
+
+	return s.emitMortgageEvent(ctx, mortgagePublic, realEstate)
+}
+
+// QueryMortgagesByRealty 查询房产上的全部抵押（仅银行可调用）
+func (s *SmartContract) QueryMortgagesByRealty(ctx contractapi.TransactionContextInterface,
+	realtyCertHash string,
+) ([]*models.Mortgage, error) {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientMSPID != constances.BankMSP {
+		return nil, fmt.Errorf("[QueryMortgagesByRealty] 只有银行可以查询抵押信息")
+	}
+
+	mortgagePublicList, err := s.queryMortgagePublicListByRealty(ctx, realtyCertHash)
+	if err != nil {
+		return nil, fmt.Errorf("[QueryMortgagesByRealty] %v", err)
+	}
+
+	mortgageList := []*models.Mortgage{}
+	for _, mortgagePublic := range mortgagePublicList {
+		key, err := s.createCompositeKey(ctx, constances.DocTypeMortgage, []string{mortgagePublic.MortgageUUID}...)
+		if err != nil {
+			return nil, err
+		}
+
+		mortgage := models.Mortgage{
+			DocType:                mortgagePublic.DocType,
+			MortgageUUID:           mortgagePublic.MortgageUUID,
+			RealtyCertHash:         mortgagePublic.RealtyCertHash,
+			MortgagorCitizenIDHash: mortgagePublic.MortgagorCitizenIDHash,
+			MortgagorOrganization:  mortgagePublic.MortgagorOrganization,
+			AssumeCitizenIDHash:    mortgagePublic.AssumeCitizenIDHash,
+			AssumeOrganization:     mortgagePublic.AssumeOrganization,
+			Status:                 mortgagePublic.Status,
+			PreviousRealtyStatus:   mortgagePublic.PreviousRealtyStatus,
+			CreateTime:             mortgagePublic.CreateTime,
+			ApprovedTime:           mortgagePublic.ApprovedTime,
+			ReleasedTime:           mortgagePublic.ReleasedTime,
+			LastUpdateTime:         mortgagePublic.LastUpdateTime,
+		}
+
+		mortgagePrivateBytes, err := ctx.GetStub().GetPrivateData(constances.MortgageDataCollection, key)
+		if err != nil {
+			return nil, fmt.Errorf("[QueryMortgagesByRealty] 查询私有抵押信息失败: %v", err)
+		}
+		if mortgagePrivateBytes != nil {
+			var mortgagePrivate models.MortgagePrivate
+			err = json.Unmarshal(mortgagePrivateBytes, &mortgagePrivate)
+			if err != nil {
+				return nil, fmt.Errorf("[QueryMortgagesByRealty] 解析私有抵押信息失败: %v", err)
+			}
+			mortgage.LoanAmount = mortgagePrivate.LoanAmount
+			mortgage.InterestRate = mortgagePrivate.InterestRate
+			mortgage.Term = mortgagePrivate.Term
+			mortgage.CollateralValue = mortgagePrivate.CollateralValue
+			mortgage.PaymentPlan = mortgagePrivate.PaymentPlan
+		}
+
+		mortgageList = append(mortgageList, &mortgage)
+	}
+
+	return mortgageList, nil
+}
+
+// 查询公开抵押信息
+func (s *SmartContract) getMortgagePublic(ctx contractapi.TransactionContextInterface,
+	mortgageUUID string,
+) (*models.MortgagePublic, string, error) {
+	key, err := s.createCompositeKey(ctx, constances.DocTypeMortgage, []string{mortgageUUID}...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	mortgagePublicBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, "", fmt.Errorf("查询抵押信息失败: %v", err)
+	}
+	if mortgagePublicBytes == nil {
+		return nil, "", fmt.Errorf("抵押不存在: %s", mortgageUUID)
+	}
+
+	var mortgagePublic models.MortgagePublic
+	err = json.Unmarshal(mortgagePublicBytes, &mortgagePublic)
+	if err != nil {
+		return nil, "", fmt.Errorf("解析抵押信息失败: %v", err)
+	}
+
+	return &mortgagePublic, key, nil
+}
+
+// 查询房产上的公开抵押信息列表
+func (s *SmartContract) queryMortgagePublicListByRealty(ctx contractapi.TransactionContextInterface,
+	realtyCertHash string,
+) ([]*models.MortgagePublic, error) {
+	queryString := fmt.Sprintf(`{
+		"selector": {
+			"docType": "%s",
+			"realtyCertHash": "%s"
+		}
+	}`, constances.DocTypeMortgage, realtyCertHash)
+
+	mortgageList, err := tools.SelectByQueryString[models.MortgagePublic](ctx, queryString)
+	if err != nil {
+		return nil, fmt.Errorf("查询抵押信息失败: %v", err)
+	}
+
+	return mortgageList, nil
+}
+
+// 检查房产是否存在未解除的抵押，买方已获银行同意承接的抵押视为可转移
+func (s *SmartContract) checkRealtyEncumbrance(ctx contractapi.TransactionContextInterface,
+	realtyCertHash string,
+	buyerCitizenIDHash string,
+	buyerOrganization string,
+) error {
+	mortgageList, err := s.queryMortgagePublicListByRealty(ctx, realtyCertHash)
+	if err != nil {
+		return err
+	}
+
+	for _, mortgage := range mortgageList {
+		switch mortgage.Status {
+		case constances.MortgageStatusReleased:
+			continue
+		case constances.MortgageStatusAssumed:
+			if mortgage.AssumeCitizenIDHash == buyerCitizenIDHash && mortgage.AssumeOrganization == buyerOrganization {
+				continue
+			}
+			return fmt.Errorf("房产抵押 %s 未被买方承接", mortgage.MortgageUUID)
+		default:
+			return fmt.Errorf("房产存在未解除的抵押: %s", mortgage.MortgageUUID)
+		}
+	}
+
+	return nil
+}
+
+// unlockedRealtyStatus 交易终止后房产应恢复的状态：存在未解除的抵押时为抵押中，否则为挂牌
+func (s *SmartContract) unlockedRealtyStatus(ctx contractapi.TransactionContextInterface, realtyCertHash string) (string, error) {
+	mortgageList, err := s.queryMortgagePublicListByRealty(ctx, realtyCertHash)
+	if err != nil {
+		return "", err
+	}
+	for _, mortgage := range mortgageList {
+		if isMortgageEffective(mortgage) {
+			return constances.RealtyStatusInMortgage, nil
+		}
+	}
+	return constances.RealtyStatusPendingSale, nil
+}
+
+// 判断抵押是否生效（已批准且未解除）
+func isMortgageEffective(mortgage *models.MortgagePublic) bool {
+	return mortgage.Status == constances.MortgageStatusActive || mortgage.Status == constances.MortgageStatusAssumed
+}
+
+// previousRealtyStatus 新批准的抵押应记录的房产抵押前状态，房产已在抵押中时沿用已生效抵押的记录
+func previousRealtyStatus(realEstate *models.Realty, mortgageList []*models.MortgagePublic) string {
+	if realEstate.Status != constances.RealtyStatusInMortgage {
+		return realEstate.Status
+	}
+	for _, mortgage := range mortgageList {
+		if isMortgageEffective(mortgage) && mortgage.PreviousRealtyStatus != "" {
+			return mortgage.PreviousRealtyStatus
+		}
+	}
+	return constances.RealtyStatusNormal
+}
+
+// isMortgagedRealtyListed 抵押中的房产是否已挂牌：生效抵押记录的抵押前状态均为挂牌
+func isMortgagedRealtyListed(mortgageList []*models.MortgagePublic) bool {
+	listed := false
+	for _, mortgage := range mortgageList {
+		if !isMortgageEffective(mortgage) {
+			continue
+		}
+		if mortgage.PreviousRealtyStatus != constances.RealtyStatusPendingSale {
+			return false
+		}
+		listed = true
+	}
+	return listed
+}
+
+// setMortgagedRealtyListing 抵押中的房产挂牌或撤牌，房产保持抵押中，挂牌状态记录在生效的抵押上，全部抵押解除后恢复
+func (s *SmartContract) setMortgagedRealtyListing(ctx contractapi.TransactionContextInterface,
+	realtyCertHash string,
+	status string,
+) error {
+	mortgageList, err := s.queryMortgagePublicListByRealty(ctx, realtyCertHash)
+	if err != nil {
+		return err
+	}
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+
+	for _, mortgage := range mortgageList {
+		if !isMortgageEffective(mortgage) || mortgage.PreviousRealtyStatus == status {
+			continue
+		}
+		mortgage.PreviousRealtyStatus = status
+		mortgage.LastUpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+		key, err := s.createCompositeKey(ctx, constances.DocTypeMortgage, []string{mortgage.MortgageUUID}...)
+		if err != nil {
+			return err
+		}
+		mortgageJSON, err := json.Marshal(mortgage)
+		if err != nil {
+			return fmt.Errorf("序列化抵押信息失败: %v", err)
+		}
+		if err := ctx.GetStub().PutState(key, mortgageJSON); err != nil {
+			return fmt.Errorf("保存抵押信息失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// 更新房产公开状态（供抵押等流程内部调用，不做调用者身份检查），返回更新后的房产供调用方发送事件
+func (s *SmartContract) setRealtyStatus(ctx contractapi.TransactionContextInterface,
+	realtyCertHash string,
+	status string,
+) (*models.Realty, error) {
+	var updatedRealty *models.Realty
+	err := s.modifyRealty(ctx, realtyCertHash, func(realty *models.Realty) {
+		realty.Status = status
+		updatedRealty = realty
+	})
+	return updatedRealty, err
+}
+
+// setRealtyLock 修改房产状态并记录进行中的交易，transactionUUID为空表示释放交易锁，返回更新后的房产供调用方发送事件
+func (s *SmartContract) setRealtyLock(ctx contractapi.TransactionContextInterface,
+	realtyCertHash string,
+	status string,
+	transactionUUID string,
+) (*models.Realty, error) {
+	var updatedRealty *models.Realty
+	err := s.modifyRealty(ctx, realtyCertHash, func(realty *models.Realty) {
+		realty.Status = status
+		realty.ActiveTransactionUUID = transactionUUID
+		updatedRealty = realty
+	})
+	return updatedRealty, err
+}
+
+// modifyRealty 读取房产公开信息，修改后写回
+func (s *SmartContract) modifyRealty(ctx contractapi.TransactionContextInterface,
+	realtyCertHash string,
+	modify func(realty *models.Realty),
+) error {
+	key, err := s.createCompositeKey(ctx, constances.DocTypeRealEstate, []string{realtyCertHash}...)
+	if err != nil {
+		return err
+	}
+
+	realEstatePublicBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("查询房产信息失败: %v", err)
+	}
+	if realEstatePublicBytes == nil {
+		return fmt.Errorf("房产ID %s 不存在", realtyCertHash)
+	}
+
+	var realEstatePublic models.Realty
+	err = json.Unmarshal(realEstatePublicBytes, &realEstatePublic)
+	if err != nil {
+		return fmt.Errorf("解析房产信息失败: %v", err)
+	}
+
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+
+	modify(&realEstatePublic)
+	realEstatePublic.LastUpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	realEstatePublicJSON, err := json.Marshal(realEstatePublic)
+	if err != nil {
+		return fmt.Errorf("序列化房产信息失败: %v", err)
+	}
+
+	return ctx.GetStub().PutState(key, realEstatePublicJSON)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// 税费相关
+//
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// AssessTransactionTax 预估交易税费（投资者、政府可以调用），结果与创建交易时核定的税费一致
+func (s *SmartContract) AssessTransactionTax(ctx contractapi.TransactionContextInterface,
+	realtyCertHash string,
+	buyerCitizenIDHash string,
+	buyerOrganization string,
+	price float64,
+) ([]*models.Tax, error) {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientMSPID != constances.InvestorMSP && clientMSPID != constances.GovernmentMSP {
+		return nil, fmt.Errorf("[AssessTransactionTax] 只有投资者、政府可以核定税费")
+	}
+
+	if price <= 0 {
+		return nil, fmt.Errorf("[AssessTransactionTax] 成交价格必须大于0")
+	}
+
+	realEstate, err := s.QueryRealty(ctx, realtyCertHash)
+	if err != nil {
+		return nil, err
+	}
+
+	taxList, err := s.assessTax(ctx, realEstate, buyerCitizenIDHash, buyerOrganization, price)
+	if err != nil {
+		return nil, fmt.Errorf("[AssessTransactionTax] %v", err)
+	}
+
+	return taxList, nil
+}
+
+// QueryTransactionTaxList 查询交易的税费明细（投资者、政府可以调用）
+func (s *SmartContract) QueryTransactionTaxList(ctx contractapi.TransactionContextInterface,
+	transactionUUID string,
+) ([]*models.Tax, error) {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientMSPID != constances.InvestorMSP && clientMSPID != constances.GovernmentMSP {
+		return nil, fmt.Errorf("[QueryTransactionTaxList] 只有投资者、政府可以查询税费")
+	}
+
+	taxList, err := s.queryTaxListByTransaction(ctx, transactionUUID)
+	if err != nil {
+		return nil, fmt.Errorf("[QueryTransactionTaxList] %v", err)
+	}
+
+	return taxList, nil
+}
+
+// 根据主通道税率表核定税费明细（契税由买方缴纳，增值税和个人所得税由卖方缴纳）
+func (s *SmartContract) assessTax(ctx contractapi.TransactionContextInterface,
+	realEstate *models.Realty,
+	buyerCitizenIDHash string,
+	buyerOrganization string,
+	price float64,
+) ([]*models.Tax, error) {
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("获取交易时间戳失败: %v", err)
+	}
+	nowTime := time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	// 从主通道获取房产所在省份
+	realtyIndex, err := tools.InvokeChaincode[models.RealtyIndex](ctx,
+		constances.MainChaincodeName,
+		constances.MainChannelName,
+		"GetRealtyIndex",
+		realEstate.RealtyCertHash,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询房产索引失败: %v", err)
+	}
+
+	// 首套认定：仅住宅适用，买方在本通道名下无其他住宅
+	isFirstHome := false
+	if realEstate.RealtyType == constances.RealtyTypeHouse {
+		queryString := fmt.Sprintf(`{
+			"selector": {
+				"docType": "%s",
+				"realtyType": "%s",
+				"currentOwnerOrganization": "%s",
+				"currentOwnerCitizenIDHash": "%s"
+			}
+		}`, constances.DocTypeRealEstate, constances.RealtyTypeHouse, buyerOrganization, buyerCitizenIDHash)
+		ownedHouses, err := tools.SelectByQueryString[models.RealtyPublic](ctx, queryString)
+		if err != nil {
+			return nil, fmt.Errorf("查询买方名下房产失败: %v", err)
+		}
+		isFirstHome = len(ownedHouses) == 0
+	}
+
+	// 持有年限从卖方取得房产时起算，历史数据缺失时以登记时间为准
+	acquireTime := realEstate.AcquireTime
+	if acquireTime.IsZero() {
+		acquireTime = realEstate.CreateTime
+	}
+	holdingPeriod := constances.HoldingPeriodOverFiveYears
+	if nowTime.Before(acquireTime.AddDate(2, 0, 0)) {
+		holdingPeriod = constances.HoldingPeriodLessThanTwoYears
+	} else if nowTime.Before(acquireTime.AddDate(5, 0, 0)) {
+		holdingPeriod = constances.HoldingPeriodTwoToFiveYears
+	}
+
+	// 从主通道获取税率
+	taxRate, err := tools.InvokeChaincode[models.TaxRate](ctx,
+		constances.MainChaincodeName,
+		constances.MainChannelName,
+		"GetTaxRate",
+		realtyIndex.ProvinceCode,
+		realEstate.RealtyType,
+		strconv.FormatBool(isFirstHome),
+		holdingPeriod,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询税率失败: %v", err)
+	}
+
+	taxItems := []struct {
+		taxType   string
+		payerRole string
+		rate      float64
+	}{
+		{constances.TaxTypeDeedTax, constances.TaxPayerBuyer, taxRate.DeedTaxRate},
+		{constances.TaxTypeVAT, constances.TaxPayerSeller, taxRate.VATRate},
+		{constances.TaxTypeIncomeTax, constances.TaxPayerSeller, taxRate.IncomeTaxRate},
+	}
+
+	taxList := []*models.Tax{}
+	for _, item := range taxItems {
+		taxAmount := roundAmount(price * item.rate)
+		if taxAmount <= 0 {
+			continue
+		}
+		taxList = append(taxList, &models.Tax{
+			DocType:    constances.DocTypeTax,
+			TaxType:    item.taxType,
+			PayerRole:  item.payerRole,
+			TaxBase:    price,
+			TaxRate:    item.rate,
+			TaxAmount:  taxAmount,
+			Status:     constances.TaxStatusUnpaid,
+			CreateTime: nowTime,
+		})
+	}
+
+	return taxList, nil
+}
+
+// 查询交易的税费明细
+func (s *SmartContract) queryTaxListByTransaction(ctx contractapi.TransactionContextInterface,
+	transactionUUID string,
+) ([]*models.Tax, error) {
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(
+		constances.TransactionPrivateCollection,
+		constances.DocTypeTax,
+		[]string{transactionUUID},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询税费明细失败: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	taxList, err := tools.ConstructResultByIterator[models.Tax](resultsIterator)
+	if err != nil {
+		return nil, fmt.Errorf("解析税费明细失败: %v", err)
+	}
+	if taxList == nil {
+		taxList = []*models.Tax{}
+	}
+
+	return taxList, nil
+}
+
+// 保存税费明细
+func (s *SmartContract) putTax(ctx contractapi.TransactionContextInterface, tax *models.Tax) error {
+	key, err := s.createCompositeKey(ctx, constances.DocTypeTax, []string{tax.TransactionUUID, tax.TaxType}...)
+	if err != nil {
+		return err
+	}
+
+	taxJSON, err := json.Marshal(tax)
+	if err != nil {
+		return fmt.Errorf("序列化税费明细失败: %v", err)
+	}
+
+	err = ctx.GetStub().PutPrivateData(constances.TransactionPrivateCollection, key, taxJSON)
+	if err != nil {
+		return fmt.Errorf("保存税费明细失败: %v", err)
+	}
+
+	return nil
+}
+
+// 核对税费支付金额并将对应税费标记为已缴纳
+// 支付金额须等于付款人（买方/卖方）名下未缴税费之和，或等于全部未缴税费之和
+func (s *SmartContract) settleTax(ctx contractapi.TransactionContextInterface,
+	transactionPublic *models.TransactionPublic,
+	paymentUUID string,
+	amount float64,
+	payerCitizenIDHash string,
+	payerOrganization string,
+	paidTime time.Time,
+) error {
+	taxList, err := s.queryTaxListByTransaction(ctx, transactionPublic.TransactionUUID)
+	if err != nil {
+		return err
+	}
+
+	payerRole := ""
+	if payerCitizenIDHash == transactionPublic.BuyerCitizenIDHash && payerOrganization == transactionPublic.BuyerOrganization {
+		payerRole = constances.TaxPayerBuyer
+	} else if payerCitizenIDHash == transactionPublic.SellerCitizenIDHash && payerOrganization == transactionPublic.SellerOrganization {
+		payerRole = constances.TaxPayerSeller
+	}
+
+	var unpaidList, payerUnpaidList []*models.Tax
+	unpaidAmount, payerUnpaidAmount := 0.0, 0.0
+	for _, tax := range taxList {
+		if tax.Status != constances.TaxStatusUnpaid {
+			continue
+		}
+		unpaidList = append(unpaidList, tax)
+		unpaidAmount += tax.TaxAmount
+		if tax.PayerRole == payerRole {
+			payerUnpaidList = append(payerUnpaidList, tax)
+			payerUnpaidAmount += tax.TaxAmount
+		}
+	}
+
+	if len(unpaidList) == 0 {
+		return fmt.Errorf("交易不存在未缴纳的税费")
+	}
+
+	expectedAmount := unpaidAmount
+	if len(payerUnpaidList) > 0 {
+		expectedAmount = payerUnpaidAmount
+	}
+
+	var settleList []*models.Tax
+	switch {
+	case len(payerUnpaidList) > 0 && math.Abs(amount-payerUnpaidAmount) < 0.01:
+		settleList = payerUnpaidList
+	case math.Abs(amount-unpaidAmount) < 0.01:
+		settleList = unpaidList
+	default:
+		return fmt.Errorf("税费金额与核定金额不符: 支付%.2f, 应缴%.2f", amount, expectedAmount)
+	}
+
+	for _, tax := range settleList {
+		tax.Status = constances.TaxStatusPaid
+		tax.PaymentUUID = paymentUUID
+		tax.PaidTime = paidTime
+		if err := s.putTax(ctx, tax); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 金额保留两位小数
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -1362,7 +3821,7 @@ func (s *SmartContract) CompleteTransaction(ctx contractapi.TransactionContextIn
 //
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-// CreatePayment 创建支付信息（仅银行和投资者可调用）
+// CreatePayment 创建支付信息（仅银行和投资者可调用），付款人余额先行扣除，银行结算后收款人入账
 func (s *SmartContract) CreatePayment(ctx contractapi.TransactionContextInterface,
 	paymentUUID string,
 	amount float64,
@@ -1382,6 +3841,10 @@ func (s *SmartContract) CreatePayment(ctx contractapi.TransactionContextInterfac
 		return fmt.Errorf("[CreatePayment] 只有银行和投资者可以创建支付信息")
 	}
 
+	if amount <= 0 {
+		return fmt.Errorf("[CreatePayment] 支付金额必须大于0")
+	}
+
 	// 检查支付信息是否已存在
 	paymentKey, err := s.createCompositeKey(ctx, constances.DocTypePayment, []string{paymentUUID}...)
 	if err != nil {
@@ -1399,194 +3862,310 @@ func (s *SmartContract) CreatePayment(ctx contractapi.TransactionContextInterfac
 	if err != nil {
 		return fmt.Errorf("[CreatePayment] 获取交易时间戳失败: %v", err)
 	}
+	nowTime := time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	// 检查收款人是否存在
+	toUserKey, err := s.createCompositeKey(ctx, constances.DocTypeUser, []string{toCitizenIDHash, toOrganization}...)
+	if err != nil {
+		return fmt.Errorf("[CreatePayment] 创建复合键失败: %v", err)
+	}
+	toUserBytes, err := ctx.GetStub().GetState(toUserKey)
+	if err != nil {
+		return fmt.Errorf("[CreatePayment] 查询用户信息失败: %v", err)
+	}
+	if toUserBytes == nil {
+		return fmt.Errorf("[CreatePayment] 目标用户不存在: %s", toCitizenIDHash)
+	}
 
 	// 创建支付信息
-	payment := models.Payment{
+	payment := &models.Payment{
 		DocType:               constances.DocTypePayment,
 		PaymentUUID:           paymentUUID,
 		Amount:                amount,
+		PaymentType:           paymentType,
 		PayerCitizenIDHash:    fromCitizenIDHash,
+		PayerOrganization:     fromOrganization,
 		ReceiverCitizenIDHash: toCitizenIDHash,
-		PaymentType:           paymentType,
-		CreateTime:            time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+		ReceiverOrganization:  toOrganization,
+		Status:                constances.PaymentStatusInitiated,
+		CreateTime:            nowTime,
+		LastUpdateTime:        nowTime,
+	}
+	if err := s.putPayment(ctx, payment); err != nil {
+		return fmt.Errorf("[CreatePayment] %v", err)
 	}
 
-	// 序列化支付信息
-	paymentJSON, err := json.Marshal(payment)
-	if err != nil {
-		return fmt.Errorf("[CreatePayment] 序列化支付信息失败: %v", err)
+	// 从付款人余额中扣除，结算前款项处于在途状态
+	if err := s.adjustBalance(ctx, fromCitizenIDHash, fromOrganization, -amount, nowTime); err != nil {
+		return fmt.Errorf("[CreatePayment] %v", err)
 	}
 
-	// 保存支付信息
-	err = ctx.GetStub().PutState(paymentKey, paymentJSON)
+	return s.emitEvent(ctx, constances.EventPaymentMade, newPaymentEvent(payment))
+}
+
+// QueryPayment 查询支付信息
+func (s *SmartContract) QueryPayment(ctx contractapi.TransactionContextInterface,
+	paymentUUID string,
+) (*models.Payment, error) {
+	paymentKey, err := s.createCompositeKey(ctx, constances.DocTypePayment, []string{paymentUUID}...)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 保存支付信息失败: %v", err)
+		return nil, fmt.Errorf("[QueryPayment] 创建复合键失败: %v", err)
 	}
-
-	fromUserKey, err := s.createCompositeKey(ctx, constances.DocTypeUser, []string{fromCitizenIDHash, fromOrganization}...)
+	paymentBytes, err := ctx.GetStub().GetState(paymentKey)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 创建复合键失败: %v", err)
+		return nil, fmt.Errorf("[QueryPayment] 查询支付信息失败: %v", err)
+	}
+	if paymentBytes == nil {
+		return nil, fmt.Errorf("[QueryPayment] 支付信息不存在: %s", paymentUUID)
 	}
 
-	// 查询fromCitizenIDHash的余额
-	fromCitizenPrivateBytes, err := ctx.GetStub().GetPrivateData(constances.UserDataCollection, fromUserKey)
+	var payment models.Payment
+	err = json.Unmarshal(paymentBytes, &payment)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 查询用户余额失败: %v", err)
+		return nil, fmt.Errorf("[QueryPayment] 解析支付信息失败: %v", err)
 	}
-	if fromCitizenPrivateBytes == nil {
-		return fmt.Errorf("[CreatePayment] 来源用户不存在: %s", fromCitizenIDHash)
+
+	return &payment, nil
+}
+
+// QueryPaymentPage 分页查询支付信息，用于对账，返回下一页书签
+func (s *SmartContract) QueryPaymentPage(ctx contractapi.TransactionContextInterface,
+	pageSize int32,
+	bookmark string,
+) (*models.PaymentPage, error) {
+	page := &models.PaymentPage{PaymentList: []*models.Payment{}}
+	nextBookmark, err := s.walkDocPage(ctx, constances.DocTypePayment, pageSize, bookmark, func(attrList []string, value []byte) error {
+		var payment models.Payment
+		if err := json.Unmarshal(value, &payment); err != nil {
+			return fmt.Errorf("解析支付信息失败: %v", err)
+		}
+		page.PaymentList = append(page.PaymentList, &payment)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[QueryPaymentPage] %v", err)
 	}
+	page.Bookmark = nextBookmark
+	return page, nil
+}
 
-	var fromCitizenPrivate models.UserPrivate
-	err = json.Unmarshal(fromCitizenPrivateBytes, &fromCitizenPrivate)
+// PayForTransaction 支付房产交易（仅银行和投资者可调用），款项先进入交易托管账户，交易完成时放款
+func (s *SmartContract) PayForTransaction(ctx contractapi.TransactionContextInterface,
+	transactionUUID string,
+	paymentUUID string,
+	paymentType string,
+	amount float64,
+	fromCitizenIDHash string,
+	fromOrganization string,
+	toCitizenIDHash string,
+	toOrganization string,
+) error {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 解析用户余额失败: %v", err)
+		return err
 	}
 
-	if fromCitizenPrivate.Balance < amount {
-		return fmt.Errorf("[CreatePayment] 余额不足: %s", fromCitizenIDHash)
+	if clientMSPID != constances.BankMSP && clientMSPID != constances.InvestorMSP {
+		return fmt.Errorf("[PayForTransaction] 只有银行和投资者可以支付交易")
 	}
 
-	// 更新fromCitizenIDHash的余额
-	fromCitizenPrivate.Balance -= amount
+	if amount <= 0 {
+		return fmt.Errorf("[PayForTransaction] 支付金额必须大于0")
+	}
 
-	// 序列化fromCitizenIDHash的余额
-	fromCitizenPrivateJSON, err := json.Marshal(fromCitizenPrivate)
+	// 检查交易是否已存在
+	transactionKey, err := s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID}...)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 序列化用户余额失败: %v", err)
+		return fmt.Errorf("[PayForTransaction] 创建复合键失败: %v", err)
 	}
-
-	// 保存fromCitizenIDHash的余额
-	err = ctx.GetStub().PutPrivateData(constances.UserDataCollection, fromUserKey, fromCitizenPrivateJSON)
+	transactionPublicBytes, err := ctx.GetStub().GetState(transactionKey)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 保存用户余额失败: %v", err)
+		return fmt.Errorf("[PayForTransaction] 查询交易信息失败: %v", err)
+	}
+	if transactionPublicBytes == nil {
+		return fmt.Errorf("[PayForTransaction] 交易不存在: %s", transactionUUID)
 	}
 
-	// 更新fromCitizenIDHash的
-	fromCitizenPublicBytes, err := ctx.GetStub().GetState(fromUserKey)
+	var transactionPublic models.TransactionPublic
+	err = json.Unmarshal(transactionPublicBytes, &transactionPublic)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 查询用户信息失败: %v", err)
+		return fmt.Errorf("[PayForTransaction] 解析交易信息失败: %v", err)
 	}
 
-	var fromCitizenPublic models.UserPublic
-	err = json.Unmarshal(fromCitizenPublicBytes, &fromCitizenPublic)
-	if err != nil {
-		return fmt.Errorf("[CreatePayment] 解析用户信息失败: %v", err)
+	if transactionPublic.Status != constances.TxStatusPending && transactionPublic.Status != constances.TxStatusInProgress {
+		return fmt.Errorf("[PayForTransaction] 交易状态不允许支付: %s", transactionPublic.Status)
 	}
 
-	fromCitizenPublic.LastUpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+	// 房产司法冻结期间不能支付
+	if err := s.checkRealtyFreeze(ctx, transactionPublic.RealtyCertHash); err != nil {
+		return fmt.Errorf("[PayForTransaction] %v", err)
+	}
 
-	// 序列化fromCitizenIDHash的
-	fromCitizenPublicJSON, err := json.Marshal(fromCitizenPublic)
+	// 检查支付信息是否已存在
+	paymentKey, err := s.createCompositeKey(ctx, constances.DocTypePayment, []string{paymentUUID}...)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 序列化用户信息失败: %v", err)
+		return fmt.Errorf("[PayForTransaction] 创建复合键失败: %v", err)
 	}
-
-	// 保存fromCitizenIDHash的
-	err = ctx.GetStub().PutState(fromUserKey, fromCitizenPublicJSON)
+	paymentBytes, err := ctx.GetStub().GetState(paymentKey)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 保存用户信息失败: %v", err)
+		return fmt.Errorf("[PayForTransaction] 查询支付信息失败: %v", err)
+	}
+	if paymentBytes != nil {
+		return fmt.Errorf("[PayForTransaction] 支付信息已存在: %s", paymentUUID)
 	}
 
-	toUserKey, err := s.createCompositeKey(ctx, constances.DocTypeUser, []string{toCitizenIDHash, toOrganization}...)
+	now, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 创建复合键失败: %v", err)
+		return fmt.Errorf("[PayForTransaction] 获取交易时间戳失败: %v", err)
+	}
+	nowTime := time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	// 税费支付须与链上核定的税费一致
+	if paymentType == constances.PaymentTypeTax {
+		err = s.settleTax(ctx, &transactionPublic, paymentUUID, amount, fromCitizenIDHash, fromOrganization, nowTime)
+		if err != nil {
+			return fmt.Errorf("[PayForTransaction] %v", err)
+		}
 	}
 
-	// 查询toCitizenIDHash的余额
-	toCitizenBytes, err := ctx.GetStub().GetPrivateData(constances.UserDataCollection, toUserKey)
+	// 查询交易托管账户
+	escrow, err := s.getEscrow(ctx, &transactionPublic)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 查询用户余额失败: %v", err)
+		return fmt.Errorf("[PayForTransaction] %v", err)
 	}
-	if toCitizenBytes == nil {
-		return fmt.Errorf("[CreatePayment] 目标用户不存在: %s", toCitizenIDHash)
+	if escrow.Status != constances.EscrowStatusHolding {
+		return fmt.Errorf("[PayForTransaction] 托管账户已结算: %s", escrow.Status)
 	}
 
-	var toCitizenPrivate models.UserPrivate
-	err = json.Unmarshal(toCitizenBytes, &toCitizenPrivate)
-	if err != nil {
-		return fmt.Errorf("[CreatePayment] 解析用户余额失败: %v", err)
+	// 创建支付信息
+	payment := models.Payment{
+		DocType:               constances.DocTypePayment,
+		PaymentUUID:           paymentUUID,
+		TransactionUUID:       transactionUUID,
+		Amount:                amount,
+		PaymentType:           paymentType,
+		PayerCitizenIDHash:    fromCitizenIDHash,
+		PayerOrganization:     fromOrganization,
+		ReceiverCitizenIDHash: toCitizenIDHash,
+		ReceiverOrganization:  toOrganization,
+		Status:                constances.PaymentStatusInitiated,
+		CreateTime:            nowTime,
+		LastUpdateTime:        nowTime,
+	}
+	if err := s.putPayment(ctx, &payment); err != nil {
+		return fmt.Errorf("[PayForTransaction] %v", err)
+	}
+
+	// 从付款人余额中扣除，转入托管账户
+	if err := s.adjustBalance(ctx, fromCitizenIDHash, fromOrganization, -amount, nowTime); err != nil {
+		return fmt.Errorf("[PayForTransaction] %v", err)
 	}
 
-	// 更新toCitizenIDHash的余额
-	toCitizenPrivate.Balance += amount
+	escrow.DepositList = append(escrow.DepositList, &models.EscrowDeposit{
+		PaymentUUID:        paymentUUID,
+		PaymentType:        paymentType,
+		PayerCitizenIDHash: fromCitizenIDHash,
+		PayerOrganization:  fromOrganization,
+		Amount:             amount,
+		CreateTime:         nowTime,
+	})
+	if paymentType == constances.PaymentTypeTax {
+		escrow.TaxAmount = roundAmount(escrow.TaxAmount + amount)
+	} else {
+		escrow.TransferAmount = roundAmount(escrow.TransferAmount + amount)
+	}
+	escrow.LastUpdateTime = nowTime
+	if err := s.putEscrow(ctx, escrow); err != nil {
+		return fmt.Errorf("[PayForTransaction] %v", err)
+	}
 
-	// 序列化toCitizenIDHash的余额
-	toCitizenPrivateJSON, err := json.Marshal(toCitizenPrivate)
+	// 将该笔支付纳入交易
+	transactionPrivateBytes, err := ctx.GetStub().GetPrivateData(constances.TransactionPrivateCollection, transactionKey)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 序列化用户余额失败: %v", err)
+		return fmt.Errorf("[PayForTransaction] 查询交易信息失败: %v", err)
 	}
 
-	// 保存toCitizenIDHash的余额
-	err = ctx.GetStub().PutPrivateData(constances.UserDataCollection, toUserKey, toCitizenPrivateJSON)
+	var transactionPrivate models.TransactionPrivate
+	err = json.Unmarshal(transactionPrivateBytes, &transactionPrivate)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 保存用户余额失败: %v", err)
+		return fmt.Errorf("[PayForTransaction] 解析交易信息失败: %v", err)
 	}
 
-	// 更新toCitizenIDHash的
-	toCitizenPublicBytes, err := ctx.GetStub().GetState(toUserKey)
+	transactionPrivate.PaymentUUIDList = append(transactionPrivate.PaymentUUIDList, paymentUUID)
+
+	// 序列化交易
+	transactionPrivateJSON, err := json.Marshal(transactionPrivate)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 查询用户信息失败: %v", err)
+		return fmt.Errorf("[PayForTransaction] 序列化交易信息失败: %v", err)
 	}
 
-	var toCitizenPublic models.UserPublic
-	err = json.Unmarshal(toCitizenPublicBytes, &toCitizenPublic)
+	// 保存交易
+	err = ctx.GetStub().PutPrivateData(constances.TransactionPrivateCollection, transactionKey, transactionPrivateJSON)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 解析用户信息失败: %v", err)
+		return fmt.Errorf("[PayForTransaction] 保存交易信息失败: %v", err)
 	}
 
-	toCitizenPublic.LastUpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+	// 更新交易状态
+	transactionPublic.UpdateTime = nowTime
 
-	// 序列化toCitizenIDHash的
-	toCitizenPublicJSON, err := json.Marshal(toCitizenPublic)
+	// 序列化交易
+	transactionPublicJSON, err := json.Marshal(transactionPublic)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 序列化用户信息失败: %v", err)
+		return fmt.Errorf("[PayForTransaction] 序列化交易信息失败: %v", err)
 	}
 
-	// 保存toCitizenIDHash的
-	err = ctx.GetStub().PutState(toUserKey, toCitizenPublicJSON)
+	// 保存交易
+	err = ctx.GetStub().PutState(transactionKey, transactionPublicJSON)
 	if err != nil {
-		return fmt.Errorf("[CreatePayment] 保存用户信息失败: %v", err)
+		return fmt.Errorf("[PayForTransaction] 保存交易信息失败: %v", err)
 	}
 
-	return nil
+	return s.emitEvent(ctx, constances.EventPaymentMade, newPaymentEvent(&payment))
 }
 
-// QueryPayment 查询支付信息
-func (s *SmartContract) QueryPayment(ctx contractapi.TransactionContextInterface,
+// VerifyPayment 银行核验支付（仅银行可调用）
+func (s *SmartContract) VerifyPayment(ctx contractapi.TransactionContextInterface,
 	paymentUUID string,
-) (*models.Payment, error) {
-	paymentKey, err := s.createCompositeKey(ctx, constances.DocTypePayment, []string{paymentUUID}...)
+) error {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("[QueryPayment] 创建复合键失败: %v", err)
+		return err
 	}
-	paymentBytes, err := ctx.GetStub().GetState(paymentKey)
+
+	if clientMSPID != constances.BankMSP {
+		return fmt.Errorf("[VerifyPayment] 只有银行可以核验支付")
+	}
+
+	payment, err := s.getPayment(ctx, paymentUUID)
 	if err != nil {
-		return nil, fmt.Errorf("[QueryPayment] 查询支付信息失败: %v", err)
+		return fmt.Errorf("[VerifyPayment] %v", err)
 	}
-	if paymentBytes == nil {
-		return nil, fmt.Errorf("[QueryPayment] 支付信息不存在: %s", paymentUUID)
+	if payment.Status != constances.PaymentStatusInitiated {
+		return fmt.Errorf("[VerifyPayment] 支付状态不允许核验: %s", payment.Status)
 	}
 
-	var payment models.Payment
-	err = json.Unmarshal(paymentBytes, &payment)
+	now, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-		return nil, fmt.Errorf("[QueryPayment] 解析支付信息失败: %v", err)
+		return fmt.Errorf("[VerifyPayment] 获取交易时间戳失败: %v", err)
 	}
+	nowTime := time.Unix(now.Seconds, int64(now.Nanos)).UTC()
 
-	return &payment, nil
+	payment.Status = constances.PaymentStatusBankVerified
+	payment.VerifyTime = nowTime
+	payment.LastUpdateTime = nowTime
+	if err := s.putPayment(ctx, payment); err != nil {
+		return fmt.Errorf("[VerifyPayment] %v", err)
+	}
+
+	return s.emitEvent(ctx, constances.EventPaymentUpdated, newPaymentEvent(payment))
 }
 
-// PayForTransaction 支付房产交易（仅银行和投资者可调用）
-func (s *SmartContract) PayForTransaction(ctx contractapi.TransactionContextInterface,
-	transactionUUID string,
+// SettlePayment 结算已核验的支付，款项计入收款人余额（仅银行可调用）
+// 交易支付的款项在托管账户中，由过户放款时统一结算
+func (s *SmartContract) SettlePayment(ctx contractapi.TransactionContextInterface,
 	paymentUUID string,
-	paymentType string,
-	amount float64,
-	fromCitizenIDHash string,
-	fromOrganization string,
-	toCitizenIDHash string,
-	toOrganization string,
 ) error {
 	// 检查调用者身份
 	clientMSPID, err := s.getClientIdentityMSPID(ctx)
@@ -1594,240 +4173,319 @@ func (s *SmartContract) PayForTransaction(ctx contractapi.TransactionContextInte
 		return err
 	}
 
-	if clientMSPID != constances.BankMSP && clientMSPID != constances.InvestorMSP {
-		return fmt.Errorf("[PayForTransaction] 只有银行和投资者可以支付交易")
+	if clientMSPID != constances.BankMSP {
+		return fmt.Errorf("[SettlePayment] 只有银行可以结算支付")
 	}
 
-	// 检查交易是否已存在
-	transactionKey, err := s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{transactionUUID}...)
+	payment, err := s.getPayment(ctx, paymentUUID)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 创建复合键失败: %v", err)
+		return fmt.Errorf("[SettlePayment] %v", err)
 	}
-	transactionPublicBytes, err := ctx.GetStub().GetState(transactionKey)
+	if payment.Status != constances.PaymentStatusBankVerified {
+		return fmt.Errorf("[SettlePayment] 支付未经银行核验: %s", payment.Status)
+	}
+	if payment.TransactionUUID != "" {
+		return fmt.Errorf("[SettlePayment] 交易支付由托管账户在过户时结算: %s", payment.TransactionUUID)
+	}
+
+	now, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 查询交易信息失败: %v", err)
+		return fmt.Errorf("[SettlePayment] 获取交易时间戳失败: %v", err)
 	}
-	if transactionPublicBytes == nil {
-		return fmt.Errorf("[PayForTransaction] 交易不存在: %s", transactionUUID)
+	nowTime := time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	if err := s.adjustBalance(ctx, payment.ReceiverCitizenIDHash, payment.ReceiverOrganization, payment.Amount, nowTime); err != nil {
+		return fmt.Errorf("[SettlePayment] %v", err)
 	}
 
-	// 检查支付信息是否已存在
-	paymentKey, err := s.createCompositeKey(ctx, constances.DocTypePayment, []string{paymentUUID}...)
+	payment.Status = constances.PaymentStatusSettled
+	payment.SettleTime = nowTime
+	payment.LastUpdateTime = nowTime
+	if err := s.putPayment(ctx, payment); err != nil {
+		return fmt.Errorf("[SettlePayment] %v", err)
+	}
+
+	return s.emitEvent(ctx, constances.EventPaymentUpdated, newPaymentEvent(payment))
+}
+
+// ReversePayment 冲正未结算的支付，款项退回付款人（仅银行可调用）
+// 交易支付须在托管账户结算前、且对应的交易步骤尚未确认时才能冲正
+func (s *SmartContract) ReversePayment(ctx contractapi.TransactionContextInterface,
+	paymentUUID string,
+	reason string,
+) error {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 创建复合键失败: %v", err)
+		return err
 	}
-	paymentBytes, err := ctx.GetStub().GetState(paymentKey)
+
+	if clientMSPID != constances.BankMSP {
+		return fmt.Errorf("[ReversePayment] 只有银行可以冲正支付")
+	}
+
+	if reason == "" {
+		return fmt.Errorf("[ReversePayment] 冲正原因不能为空")
+	}
+
+	payment, err := s.getPayment(ctx, paymentUUID)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 查询支付信息失败: %v", err)
+		return fmt.Errorf("[ReversePayment] %v", err)
 	}
-	if paymentBytes != nil {
-		return fmt.Errorf("[PayForTransaction] 支付信息已存在: %s", paymentUUID)
+	if payment.Status != constances.PaymentStatusInitiated && payment.Status != constances.PaymentStatusBankVerified {
+		return fmt.Errorf("[ReversePayment] 支付状态不允许冲正: %s", payment.Status)
 	}
 
 	now, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 获取交易时间戳失败: %v", err)
+		return fmt.Errorf("[ReversePayment] 获取交易时间戳失败: %v", err)
 	}
+	nowTime := time.Unix(now.Seconds, int64(now.Nanos)).UTC()
 
-	// 创建支付信息
-	payment := models.Payment{
-		DocType:               constances.DocTypePayment,
-		PaymentUUID:           paymentUUID,
-		TransactionUUID:       transactionUUID,
-		Amount:                amount,
-		PaymentType:           paymentType,
-		PayerCitizenIDHash:    fromCitizenIDHash,
-		PayerOrganization:     fromOrganization,
-		ReceiverCitizenIDHash: toCitizenIDHash,
-		ReceiverOrganization:  toOrganization,
-		CreateTime:            time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
+	// 交易支付须从托管账户中移出
+	if payment.TransactionUUID != "" {
+		if err := s.withdrawEscrowDeposit(ctx, payment); err != nil {
+			return fmt.Errorf("[ReversePayment] %v", err)
+		}
 	}
 
-	// 序列化支付信息
-	paymentJSON, err := json.Marshal(payment)
-	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 序列化支付信息失败: %v", err)
+	if err := s.adjustBalance(ctx, payment.PayerCitizenIDHash, payment.PayerOrganization, payment.Amount, nowTime); err != nil {
+		return fmt.Errorf("[ReversePayment] %v", err)
 	}
 
-	// 保存支付信息
-	err = ctx.GetStub().PutState(paymentKey, paymentJSON)
-	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 保存支付信息失败: %v", err)
+	payment.Status = constances.PaymentStatusReversed
+	payment.ReverseReason = reason
+	payment.ReverseTime = nowTime
+	payment.LastUpdateTime = nowTime
+	if err := s.putPayment(ctx, payment); err != nil {
+		return fmt.Errorf("[ReversePayment] %v", err)
 	}
 
-	fromUserKey, err := s.createCompositeKey(ctx, constances.DocTypeUser, []string{fromCitizenIDHash, fromOrganization}...)
+	return s.emitEvent(ctx, constances.EventPaymentUpdated, newPaymentEvent(payment))
+}
+
+// 从托管账户中移出一笔交易支付，税费支付对应的税费恢复为未缴纳
+func (s *SmartContract) withdrawEscrowDeposit(ctx contractapi.TransactionContextInterface,
+	payment *models.Payment,
+) error {
+	transactionKey, err := s.createCompositeKey(ctx, constances.DocTypeTransaction, []string{payment.TransactionUUID}...)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 创建复合键失败: %v", err)
+		return err
 	}
-	// 查询fromCitizenIDHash的余额
-	fromCitizenPrivateBytes, err := ctx.GetStub().GetPrivateData(constances.UserDataCollection, fromUserKey)
+	transactionPublicBytes, err := ctx.GetStub().GetState(transactionKey)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 查询用户余额失败: %v", err)
+		return fmt.Errorf("查询交易信息失败: %v", err)
 	}
-	if fromCitizenPrivateBytes == nil {
-		return fmt.Errorf("[PayForTransaction] 来源用户不存在: %s", fromCitizenIDHash)
+	if transactionPublicBytes == nil {
+		return fmt.Errorf("交易不存在: %s", payment.TransactionUUID)
 	}
 
-	var fromCitizenPrivate models.UserPrivate
-	err = json.Unmarshal(fromCitizenPrivateBytes, &fromCitizenPrivate)
+	var transactionPublic models.TransactionPublic
+	err = json.Unmarshal(transactionPublicBytes, &transactionPublic)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 解析用户余额失败: %v", err)
+		return fmt.Errorf("解析交易信息失败: %v", err)
 	}
 
-	if fromCitizenPrivate.Balance < amount {
-		return fmt.Errorf("[PayForTransaction] 余额不足: %s", fromCitizenIDHash)
+	// 款项已被交易步骤确认的不能冲正
+	confirmedStep := constances.TxStepFundsEscrowed
+	if payment.PaymentType == constances.PaymentTypeTax {
+		confirmedStep = constances.TxStepTaxPaid
+	}
+	for _, step := range transactionPublic.CompletedStepList {
+		if step == confirmedStep {
+			return fmt.Errorf("交易步骤已确认，支付不能冲正: %s", confirmedStep)
+		}
 	}
 
-	// 更新fromCitizenIDHash的余额
-	fromCitizenPrivate.Balance -= amount
-
-	// 序列化fromCitizenIDHash的余额
-	fromCitizenPrivateJSON, err := json.Marshal(fromCitizenPrivate)
+	escrow, err := s.getEscrow(ctx, &transactionPublic)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 序列化用户余额失败: %v", err)
+		return err
+	}
+	if escrow.Status != constances.EscrowStatusHolding {
+		return fmt.Errorf("托管账户已结算: %s", escrow.Status)
 	}
 
-	// 保存fromCitizenIDHash的余额
-	err = ctx.GetStub().PutPrivateData(constances.UserDataCollection, fromUserKey, fromCitizenPrivateJSON)
-	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 保存用户余额失败: %v", err)
+	depositList := []*models.EscrowDeposit{}
+	found := false
+	for _, deposit := range escrow.DepositList {
+		if deposit.PaymentUUID == payment.PaymentUUID {
+			found = true
+			continue
+		}
+		depositList = append(depositList, deposit)
 	}
+	if !found {
+		return fmt.Errorf("托管账户中不存在该支付: %s", payment.PaymentUUID)
+	}
+	escrow.DepositList = depositList
 
-	// 更新fromCitizenIDHash的
-	fromCitizenPublicBytes, err := ctx.GetStub().GetState(fromUserKey)
-	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 查询用户信息失败: %v", err)
+	if payment.PaymentType == constances.PaymentTypeTax {
+		escrow.TaxAmount = roundAmount(escrow.TaxAmount - payment.Amount)
+
+		taxList, err := s.queryTaxListByTransaction(ctx, payment.TransactionUUID)
+		if err != nil {
+			return err
+		}
+		for _, tax := range taxList {
+			if tax.PaymentUUID != payment.PaymentUUID || tax.Status != constances.TaxStatusPaid {
+				continue
+			}
+			tax.Status = constances.TaxStatusUnpaid
+			tax.PaymentUUID = ""
+			tax.PaidTime = time.Time{}
+			if err := s.putTax(ctx, tax); err != nil {
+				return err
+			}
+		}
+	} else {
+		escrow.TransferAmount = roundAmount(escrow.TransferAmount - payment.Amount)
 	}
 
-	var fromCitizenPublic models.UserPublic
-	err = json.Unmarshal(fromCitizenPublicBytes, &fromCitizenPublic)
+	now, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 解析用户信息失败: %v", err)
+		return fmt.Errorf("获取交易时间戳失败: %v", err)
 	}
+	escrow.LastUpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	return s.putEscrow(ctx, escrow)
+}
 
-	fromCitizenPublic.LastUpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+// 托管账户结算时同步更新托管明细对应支付的状态
+func (s *SmartContract) settleEscrowPayments(ctx contractapi.TransactionContextInterface,
+	escrow *models.Escrow,
+	status string,
+	settleTime time.Time,
+) error {
+	for _, deposit := range escrow.DepositList {
+		payment, err := s.getPayment(ctx, deposit.PaymentUUID)
+		if err != nil {
+			return err
+		}
+		if payment.Status == constances.PaymentStatusSettled || payment.Status == constances.PaymentStatusReversed {
+			continue
+		}
 
-	// 序列化fromCitizenIDHash的
-	fromCitizenPublicJSON, err := json.Marshal(fromCitizenPublic)
-	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 序列化用户信息失败: %v", err)
+		payment.Status = status
+		if status == constances.PaymentStatusSettled {
+			payment.SettleTime = settleTime
+		} else {
+			payment.ReverseReason = "交易终止，托管资金退回"
+			payment.ReverseTime = settleTime
+		}
+		payment.LastUpdateTime = settleTime
+		if err := s.putPayment(ctx, payment); err != nil {
+			return err
+		}
 	}
 
-	// 保存fromCitizenIDHash的
-	err = ctx.GetStub().PutState(fromUserKey, fromCitizenPublicJSON)
-	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 保存用户信息失败: %v", err)
-	}
+	return nil
+}
 
-	toUserKey, err := s.createCompositeKey(ctx, constances.DocTypeUser, []string{toCitizenIDHash, toOrganization}...)
+// 查询支付信息
+func (s *SmartContract) getPayment(ctx contractapi.TransactionContextInterface,
+	paymentUUID string,
+) (*models.Payment, error) {
+	paymentKey, err := s.createCompositeKey(ctx, constances.DocTypePayment, []string{paymentUUID}...)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 创建复合键失败: %v", err)
+		return nil, err
 	}
-
-	// 查询toCitizenIDHash的余额
-	toCitizenBytes, err := ctx.GetStub().GetPrivateData(constances.UserDataCollection, toUserKey)
+	paymentBytes, err := ctx.GetStub().GetState(paymentKey)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 查询用户余额失败: %v", err)
+		return nil, fmt.Errorf("查询支付信息失败: %v", err)
 	}
-	if toCitizenBytes == nil {
-		return fmt.Errorf("[PayForTransaction] 目标用户不存在: %s", toCitizenIDHash)
+	if paymentBytes == nil {
+		return nil, fmt.Errorf("支付信息不存在: %s", paymentUUID)
 	}
 
-	var toCitizenPrivate models.UserPrivate
-	err = json.Unmarshal(toCitizenBytes, &toCitizenPrivate)
+	var payment models.Payment
+	err = json.Unmarshal(paymentBytes, &payment)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 解析用户余额失败: %v", err)
+		return nil, fmt.Errorf("解析支付信息失败: %v", err)
 	}
 
-	// 更新toCitizenIDHash的余额
-	toCitizenPrivate.Balance += amount
-
-	// 序列化toCitizenIDHash的余额
-	toCitizenPrivateJSON, err := json.Marshal(toCitizenPrivate)
-	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 序列化用户余额失败: %v", err)
-	}
+	return &payment, nil
+}
 
-	// 保存toCitizenIDHash的余额
-	err = ctx.GetStub().PutPrivateData(constances.UserDataCollection, toUserKey, toCitizenPrivateJSON)
+// 保存支付信息
+func (s *SmartContract) putPayment(ctx contractapi.TransactionContextInterface, payment *models.Payment) error {
+	paymentKey, err := s.createCompositeKey(ctx, constances.DocTypePayment, []string{payment.PaymentUUID}...)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 保存用户余额失败: %v", err)
+		return err
 	}
 
-	// 更新toCitizenIDHash
-	toCitizenPublicBytes, err := ctx.GetStub().GetState(toUserKey)
+	paymentJSON, err := json.Marshal(payment)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 查询用户信息失败: %v", err)
+		return fmt.Errorf("序列化支付信息失败: %v", err)
 	}
 
-	var toCitizenPublic models.UserPublic
-	err = json.Unmarshal(toCitizenPublicBytes, &toCitizenPublic)
+	err = ctx.GetStub().PutState(paymentKey, paymentJSON)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 解析用户信息失败: %v", err)
+		return fmt.Errorf("保存支付信息失败: %v", err)
 	}
 
-	toCitizenPublic.LastUpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+	return nil
+}
 
-	// 序列化toCitizenIDHash
-	toCitizenPublicJSON, err := json.Marshal(toCitizenPublic)
+// 调整用户余额（delta为负数时扣款，余额不足则拒绝）
+func (s *SmartContract) adjustBalance(ctx contractapi.TransactionContextInterface,
+	citizenIDHash string,
+	organization string,
+	delta float64,
+	updateTime time.Time,
+) error {
+	userKey, err := s.createCompositeKey(ctx, constances.DocTypeUser, []string{citizenIDHash, organization}...)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 序列化用户信息失败: %v", err)
+		return fmt.Errorf("创建复合键失败: %v", err)
 	}
 
-	// 保存toCitizenIDHash
-	err = ctx.GetStub().PutState(toUserKey, toCitizenPublicJSON)
+	userPrivateBytes, err := ctx.GetStub().GetPrivateData(constances.UserDataCollection, userKey)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 保存用户信息失败: %v", err)
+		return fmt.Errorf("查询用户余额失败: %v", err)
 	}
-
-	// 将该笔支付纳入交易
-	transactionPrivateBytes, err := ctx.GetStub().GetPrivateData(constances.TransactionPrivateCollection, transactionKey)
-	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 查询交易信息失败: %v", err)
+	if userPrivateBytes == nil {
+		return fmt.Errorf("用户不存在: %s", citizenIDHash)
 	}
 
-	var transactionPrivate models.TransactionPrivate
-	err = json.Unmarshal(transactionPrivateBytes, &transactionPrivate)
+	var userPrivate models.UserPrivate
+	err = json.Unmarshal(userPrivateBytes, &userPrivate)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 解析交易信息失败: %v", err)
+		return fmt.Errorf("解析用户余额失败: %v", err)
 	}
 
-	transactionPrivate.PaymentUUIDList = append(transactionPrivate.PaymentUUIDList, paymentUUID)
+	if userPrivate.Balance+delta < 0 {
+		return fmt.Errorf("余额不足: %s", citizenIDHash)
+	}
+	userPrivate.Balance = roundAmount(userPrivate.Balance + delta)
 
-	// 序列化交易
-	transactionPrivateJSON, err := json.Marshal(transactionPrivate)
+	userPrivateJSON, err := json.Marshal(userPrivate)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 序列化交易信息失败: %v", err)
+		return fmt.Errorf("序列化用户余额失败: %v", err)
 	}
-
-	// 保存交易
+	err = ctx.GetStub().PutPrivateData(constances.UserDataCollection, userKey, userPrivateJSON)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 创建复合键失败: %v", err)
+		return fmt.Errorf("保存用户余额失败: %v", err)
 	}
-	err = ctx.GetStub().PutPrivateData(constances.TransactionPrivateCollection, transactionKey, transactionPrivateJSON)
+
+	userPublicBytes, err := ctx.GetStub().GetState(userKey)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 保存交易信息失败: %v", err)
+		return fmt.Errorf("查询用户信息失败: %v", err)
 	}
 
-	// 更新交易状态
-	var transactionPublic models.TransactionPublic
-	err = json.Unmarshal(transactionPublicBytes, &transactionPublic)
+	var userPublic models.UserPublic
+	err = json.Unmarshal(userPublicBytes, &userPublic)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 解析交易信息失败: %v", err)
+		return fmt.Errorf("解析用户信息失败: %v", err)
 	}
 
-	transactionPublic.UpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+	userPublic.LastUpdateTime = updateTime
 
-	// 序列化交易
-	transactionPublicJSON, err := json.Marshal(transactionPublic)
+	userPublicJSON, err := json.Marshal(userPublic)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 序列化交易信息失败: %v", err)
+		return fmt.Errorf("序列化用户信息失败: %v", err)
 	}
-
-	// 保存交易
-	err = ctx.GetStub().PutState(transactionKey, transactionPublicJSON)
+	err = ctx.GetStub().PutState(userKey, userPublicJSON)
 	if err != nil {
-		return fmt.Errorf("[PayForTransaction] 保存交易信息失败: %v", err)
+		return fmt.Errorf("保存用户信息失败: %v", err)
 	}
 
 	return nil
@@ -1991,12 +4649,14 @@ func (s *SmartContract) Hello(ctx contractapi.TransactionContextInterface) (stri
 //
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-// CreateContract 创建合同（仅投资者和政府机构可以调用）
+// CreateContract 创建合同并登记买卖双方签署人（仅投资者和政府机构可以调用）
 func (s *SmartContract) CreateContract(ctx contractapi.TransactionContextInterface,
 	contractUUID string,
 	docHash string,
 	contractType string,
 	creatorCitizenIDHash string,
+	buyerCitizenIDHash string,
+	sellerCitizenIDHash string,
 ) error {
 	// 检查调用者身份
 	clientMSPID, err := s.getClientIdentityMSPID(ctx)
@@ -2008,6 +4668,13 @@ func (s *SmartContract) CreateContract(ctx contractapi.TransactionContextInterfa
 		return fmt.Errorf("[CreateContract] 只有投资者和政府机构可以创建合同")
 	}
 
+	if buyerCitizenIDHash == "" || sellerCitizenIDHash == "" {
+		return fmt.Errorf("[CreateContract] 须登记买方和卖方签署人")
+	}
+	if buyerCitizenIDHash == sellerCitizenIDHash {
+		return fmt.Errorf("[CreateContract] 买方和卖方签署人不能为同一人")
+	}
+
 	// 创建合同信息复合键
 	key, err := s.createCompositeKey(ctx, constances.DocTypeContract, []string{contractUUID}...)
 	if err != nil {
@@ -2029,23 +4696,25 @@ func (s *SmartContract) CreateContract(ctx contractapi.TransactionContextInterfa
 	}
 
 	// 创建合同信息
-	contract := models.Contract{
+	contract := &models.Contract{
+		DocType:              constances.DocTypeContract,
 		ContractUUID:         contractUUID,
 		CreateTime:           time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
 		UpdateTime:           time.Unix(now.Seconds, int64(now.Nanos)).UTC(),
 		DocHash:              docHash,
 		CreatorCitizenIDHash: creatorCitizenIDHash,
+		BuyerCitizenIDHash:   buyerCitizenIDHash,
+		SellerCitizenIDHash:  sellerCitizenIDHash,
 		ContractType:         contractType,
 		Status:               constances.ContractStatusNormal,
+		RequiredPartyList:    []string{constances.ContractPartyBuyer, constances.ContractPartySeller},
 	}
 
-	// 序列化并保存合同信息
-	contractJSON, err := json.Marshal(contract)
-	if err != nil {
-		return fmt.Errorf("[CreateContract] 序列化合同信息失败: %v", err)
+	if err := s.putContract(ctx, contract); err != nil {
+		return fmt.Errorf("[CreateContract] %v", err)
 	}
 
-	return ctx.GetStub().PutState(key, contractJSON)
+	return s.emitEvent(ctx, constances.EventContractUpdated, newContractEvent(contract))
 }
 
 // QueryContract 查询合同信息（仅投资者、政府机构和审计机构可以调用）
@@ -2082,6 +4751,328 @@ func (s *SmartContract) QueryContract(ctx contractapi.TransactionContextInterfac
 	return &contract, nil
 }
 
+// SignContract 合同方使用DID密钥签署合同（签署人所属组织可以调用）
+func (s *SmartContract) SignContract(ctx contractapi.TransactionContextInterface,
+	contractUUID string,
+	partyRole string,
+	signerCitizenIDHash string,
+	signerOrganization string,
+	signature string,
+) error {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if constances.OrganizationMSPMap[signerOrganization] != clientMSPID {
+		return fmt.Errorf("[SignContract] 调用方 %s 无权代表组织 %s 签署合同", clientMSPID, signerOrganization)
+	}
+
+	contract, err := s.QueryContract(ctx, contractUUID)
+	if err != nil {
+		return fmt.Errorf("[SignContract] %v", err)
+	}
+	if contract.Status != constances.ContractStatusNormal {
+		return fmt.Errorf("[SignContract] 合同状态不允许签署: %s", contract.Status)
+	}
+	if !slices.Contains(contractRequiredPartyList(contract), partyRole) {
+		return fmt.Errorf("[SignContract] 合同不需要该签署方: %s", partyRole)
+	}
+	for _, item := range contract.SignatureList {
+		if item.PartyRole == partyRole && item.DocHash == contract.DocHash {
+			return fmt.Errorf("[SignContract] 签署方已签署: %s", partyRole)
+		}
+	}
+
+	// 签署人须为合同登记的该签署方，且买卖双方不能由同一人签署
+	partyCitizenIDHash, err := s.contractPartyCitizenIDHash(ctx, contract, partyRole)
+	if err != nil {
+		return fmt.Errorf("[SignContract] %v", err)
+	}
+	if signerCitizenIDHash != partyCitizenIDHash {
+		return fmt.Errorf("[SignContract] 签署人不是合同登记的%s", partyRole)
+	}
+	for _, item := range contract.SignatureList {
+		if item.PartyRole != partyRole && item.DocHash == contract.DocHash && item.SignerCitizenIDHash == signerCitizenIDHash {
+			return fmt.Errorf("[SignContract] 同一签署人不能同时签署买卖双方")
+		}
+	}
+
+	// 使用签署人链上登记的DID公钥验证其对文档哈希的签名
+	did, err := s.GetDIDByUser(ctx, signerCitizenIDHash, signerOrganization)
+	if err != nil {
+		return fmt.Errorf("[SignContract] %v", err)
+	}
+	publicKey, err := s.GetPublicKeyByDID(ctx, did)
+	if err != nil {
+		return fmt.Errorf("[SignContract] %v", err)
+	}
+	if err := tools.VerifySignature(publicKey, contract.DocHash, signature); err != nil {
+		return fmt.Errorf("[SignContract] %v", err)
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("[SignContract] 获取客户端ID失败: %v", err)
+	}
+
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[SignContract] 获取交易时间戳失败: %v", err)
+	}
+	nowTime := time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	contract.SignatureList = append(contract.SignatureList, &models.ContractSignature{
+		PartyRole:           partyRole,
+		SignerCitizenIDHash: signerCitizenIDHash,
+		SignerOrganization:  signerOrganization,
+		DID:                 did,
+		DocHash:             contract.DocHash,
+		Signature:           signature,
+		ClientID:            clientID,
+		MSPID:               clientMSPID,
+		SignTime:            nowTime,
+	})
+	contract.UpdateTime = nowTime
+
+	if err := s.putContract(ctx, contract); err != nil {
+		return fmt.Errorf("[SignContract] %v", err)
+	}
+
+	return s.emitEvent(ctx, constances.EventContractUpdated, newContractEvent(contract))
+}
+
+// AuditContract 审核合同（仅审计机构可以调用）
+func (s *SmartContract) AuditContract(ctx contractapi.TransactionContextInterface,
+	contractUUID string,
+	result string,
+	comments string,
+	revisionRequirements string,
+	rejectionReason string,
+) error {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientMSPID != constances.AuditMSP {
+		return fmt.Errorf("[AuditContract] 只有审计机构可以审核合同")
+	}
+
+	switch result {
+	case constances.ContractAuditApproved:
+	case constances.ContractAuditRejected:
+		if rejectionReason == "" {
+			return fmt.Errorf("[AuditContract] 拒绝合同须填写拒绝理由")
+		}
+	case constances.ContractAuditNeedRevision:
+		if revisionRequirements == "" {
+			return fmt.Errorf("[AuditContract] 要求修改须填写修改要求")
+		}
+	default:
+		return fmt.Errorf("[AuditContract] 未知的审核结果: %s", result)
+	}
+
+	contract, err := s.QueryContract(ctx, contractUUID)
+	if err != nil {
+		return fmt.Errorf("[AuditContract] %v", err)
+	}
+	if contract.Status != constances.ContractStatusNormal {
+		return fmt.Errorf("[AuditContract] 合同状态不允许审核: %s", contract.Status)
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("[AuditContract] 获取客户端ID失败: %v", err)
+	}
+
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[AuditContract] 获取交易时间戳失败: %v", err)
+	}
+	nowTime := time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	contract.AuditList = append(contract.AuditList, &models.ContractAudit{
+		Result:               result,
+		Comments:             comments,
+		RevisionRequirements: revisionRequirements,
+		RejectionReason:      rejectionReason,
+		DocHash:              contract.DocHash,
+		AuditorClientID:      clientID,
+		AuditorMSPID:         clientMSPID,
+		AuditTime:            nowTime,
+	})
+	contract.UpdateTime = nowTime
+
+	if err := s.putContract(ctx, contract); err != nil {
+		return fmt.Errorf("[AuditContract] %v", err)
+	}
+
+	return s.emitEvent(ctx, constances.EventContractUpdated, newContractEvent(contract))
+}
+
+// BindContractTransaction 合同绑定交易（仅投资者、政府机构可以调用），须全部合同方签署且审计机构审核通过
+func (s *SmartContract) BindContractTransaction(ctx contractapi.TransactionContextInterface,
+	contractUUID string,
+	transactionUUID string,
+) error {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return err
+	}
+
+	if clientMSPID != constances.InvestorMSP && clientMSPID != constances.GovernmentMSP {
+		return fmt.Errorf("[BindContractTransaction] 只有投资者和政府机构可以绑定交易")
+	}
+
+	contract, err := s.QueryContract(ctx, contractUUID)
+	if err != nil {
+		return fmt.Errorf("[BindContractTransaction] %v", err)
+	}
+	if contract.Status != constances.ContractStatusNormal || contract.TransactionUUID != "" {
+		return fmt.Errorf("[BindContractTransaction] 合同已绑定交易或状态不可用: %s", contract.Status)
+	}
+	if err := checkContractReady(contract); err != nil {
+		return fmt.Errorf("[BindContractTransaction] %v", err)
+	}
+
+	now, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[BindContractTransaction] 获取交易时间戳失败: %v", err)
+	}
+
+	contract.TransactionUUID = transactionUUID
+	contract.Status = constances.ContractStatusInProgress
+	contract.UpdateTime = time.Unix(now.Seconds, int64(now.Nanos)).UTC()
+
+	if err := s.putContract(ctx, contract); err != nil {
+		return fmt.Errorf("[BindContractTransaction] %v", err)
+	}
+
+	return s.emitEvent(ctx, constances.EventContractUpdated, newContractEvent(contract))
+}
+
+// contractPartyCitizenIDHash 返回合同签署方对应的签署人，未登记签署人的历史合同以绑定交易的买卖双方为准
+func (s *SmartContract) contractPartyCitizenIDHash(ctx contractapi.TransactionContextInterface,
+	contract *models.Contract,
+	partyRole string,
+) (string, error) {
+	buyerCitizenIDHash, sellerCitizenIDHash := contract.BuyerCitizenIDHash, contract.SellerCitizenIDHash
+	if (buyerCitizenIDHash == "" || sellerCitizenIDHash == "") && contract.TransactionUUID != "" {
+		transaction, err := s.QueryTransaction(ctx, contract.TransactionUUID)
+		if err != nil {
+			return "", err
+		}
+		buyerCitizenIDHash, sellerCitizenIDHash = transaction.BuyerCitizenIDHash, transaction.SellerCitizenIDHash
+	}
+
+	var citizenIDHash string
+	switch partyRole {
+	case constances.ContractPartyBuyer:
+		citizenIDHash = buyerCitizenIDHash
+	case constances.ContractPartySeller:
+		citizenIDHash = sellerCitizenIDHash
+	}
+	if citizenIDHash == "" {
+		return "", fmt.Errorf("合同未登记签署方: %s", partyRole)
+	}
+	return citizenIDHash, nil
+}
+
+// contractRequiredPartyList 返回合同须签署的合同方，未登记的历史合同默认为买卖双方
+func contractRequiredPartyList(contract *models.Contract) []string {
+	if len(contract.RequiredPartyList) > 0 {
+		return contract.RequiredPartyList
+	}
+	return []string{constances.ContractPartyBuyer, constances.ContractPartySeller}
+}
+
+// checkContractReady 检查合同当前文档是否已由全部合同方签署并经审计机构审核通过
+func checkContractReady(contract *models.Contract) error {
+	for _, partyRole := range contractRequiredPartyList(contract) {
+		signed := false
+		for _, item := range contract.SignatureList {
+			if item.PartyRole == partyRole && item.DocHash == contract.DocHash {
+				signed = true
+				break
+			}
+		}
+		if !signed {
+			return fmt.Errorf("合同方尚未签署: %s", partyRole)
+		}
+	}
+
+	if len(contract.AuditList) == 0 {
+		return fmt.Errorf("合同尚未审核")
+	}
+	audit := contract.AuditList[len(contract.AuditList)-1]
+	if audit.DocHash != contract.DocHash {
+		return fmt.Errorf("合同文档已变更，尚未重新审核")
+	}
+	if audit.Result != constances.ContractAuditApproved {
+		return fmt.Errorf("合同审核未通过: %s", audit.Result)
+	}
+
+	return nil
+}
+
+// putContract 保存合同信息
+func (s *SmartContract) putContract(ctx contractapi.TransactionContextInterface, contract *models.Contract) error {
+	key, err := s.createCompositeKey(ctx, constances.DocTypeContract, []string{contract.ContractUUID}...)
+	if err != nil {
+		return err
+	}
+	contractJSON, err := json.Marshal(contract)
+	if err != nil {
+		return fmt.Errorf("序列化合同信息失败: %v", err)
+	}
+	return ctx.GetStub().PutState(key, contractJSON)
+}
+
+// QueryContractHistory 查询合同信息的全部历史版本（仅投资者、政府机构和审计机构可以调用）
+func (s *SmartContract) QueryContractHistory(ctx contractapi.TransactionContextInterface,
+	contractUUID string,
+) ([]*models.ContractHistory, error) {
+	// 检查调用者身份
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientMSPID != constances.InvestorMSP && clientMSPID != constances.GovernmentMSP && clientMSPID != constances.AuditMSP {
+		return nil, fmt.Errorf("[QueryContractHistory] 只有投资者、政府机构和审计机构可以查询合同历史")
+	}
+
+	key, err := s.createCompositeKey(ctx, constances.DocTypeContract, []string{contractUUID}...)
+	if err != nil {
+		return nil, err
+	}
+
+	historyList := []*models.ContractHistory{}
+	err = s.walkKeyHistory(ctx, key, func(meta models.HistoryMeta, value []byte) error {
+		history := &models.ContractHistory{HistoryMeta: meta}
+		if !meta.IsDelete && len(value) > 0 {
+			history.Contract = &models.Contract{}
+			if err := json.Unmarshal(value, history.Contract); err != nil {
+				return fmt.Errorf("[QueryContractHistory] 解析合同信息失败: %v", err)
+			}
+		}
+		historyList = append(historyList, history)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(historyList) == 0 {
+		return nil, fmt.Errorf("[QueryContractHistory] 合同UUID %s 不存在", contractUUID)
+	}
+
+	return historyList, nil
+}
+
 // UpdateContract 更新合同状态（仅投资者、政府机构、审计机构可以调用）
 func (s *SmartContract) UpdateContract(ctx contractapi.TransactionContextInterface,
 	contractUUID string,
@@ -2110,7 +5101,17 @@ func (s *SmartContract) UpdateContract(ctx contractapi.TransactionContextInterfa
 		return fmt.Errorf("[UpdateContractStatus] 合同已冻结，无法更新状态")
 	}
 
-	// 更新合同
+	// 绑定交易须经过签署和审核检查
+	if status == constances.ContractStatusInProgress && contract.Status != constances.ContractStatusInProgress {
+		return fmt.Errorf("[UpdateContractStatus] 合同须通过绑定交易进入进行中状态")
+	}
+
+	// 已绑定交易的合同文档不能再修改
+	if docHash != "" && docHash != contract.DocHash && contract.TransactionUUID != "" {
+		return fmt.Errorf("[UpdateContractStatus] 合同已绑定交易，不能修改文档")
+	}
+
+	// 更新合同，文档变更后原有签署和审核不再对新文档生效
 	if docHash != "" {
 		contract.DocHash = docHash
 	}
@@ -2136,7 +5137,12 @@ func (s *SmartContract) UpdateContract(ctx contractapi.TransactionContextInterfa
 	if err != nil {
 		return fmt.Errorf("[UpdateContractStatus] 构造复合键失败: %v", err)
 	}
-	return ctx.GetStub().PutState(key, contractJSON)
+	err = ctx.GetStub().PutState(key, contractJSON)
+	if err != nil {
+		return fmt.Errorf("[UpdateContractStatus] 保存合同信息失败: %v", err)
+	}
+
+	return s.emitEvent(ctx, constances.EventContractUpdated, newContractEvent(contract))
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -2235,7 +5241,11 @@ func (s *SmartContract) RegisterDID(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("[RegisterDID] 保存公钥信息失败: %v", err)
 	}
 
-	return nil
+	return s.emitEvent(ctx, constances.EventDIDRegistered, &models.DIDEvent{
+		DID:           did,
+		CitizenIDHash: mapping.CitizenIDHash,
+		Organization:  organization,
+	})
 }
 
 // ResolveDID 解析DID文档
@@ -2568,7 +5578,12 @@ func (s *SmartContract) RevokeCredential(ctx contractapi.TransactionContextInter
 		}
 	}
 
-	return nil
+	return s.emitEvent(ctx, constances.EventCredentialRevoked, &models.CredentialEvent{
+		CredentialID: credentialID,
+		IssuerDID:    credential.Issuer,
+		SubjectDID:   subjectDID,
+		RevokerDID:   revokerDID,
+	})
 }
 
 // GetPublicKeyByDID 根据DID获取公钥
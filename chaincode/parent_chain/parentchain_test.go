@@ -0,0 +1,717 @@
+package main
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"math"
+	"parent_chain_chaincode/constances"
+	"parent_chain_chaincode/models"
+	"parent_chain_chaincode/tools"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// testStub 内存中的链码桩，只实现税费核定和托管放款用到的接口
+type testStub struct {
+	shim.ChaincodeStubInterface
+	txTime      time.Time
+	state       map[string][]byte
+	privateData map[string]map[string][]byte
+	// invoke 处理跨通道调用，参数为函数名和参数列表
+	invoke func(args []string) (interface{}, error)
+	// queryResult 富查询返回的记录
+	queryResult []interface{}
+	queryList   []string
+	// eventList 按发送顺序记录的链码事件
+	eventList []*testEvent
+}
+
+// testEvent 链码发送的事件
+type testEvent struct {
+	name    string
+	payload []byte
+}
+
+func newTestStub(txTime time.Time) *testStub {
+	return &testStub{
+		txTime:      txTime,
+		state:       map[string][]byte{},
+		privateData: map[string]map[string][]byte{},
+	}
+}
+
+func newTestContext(stub *testStub) *contractapi.TransactionContext {
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(stub)
+	return ctx
+}
+
+// testIdentity 调用方身份，commonName为个人证书中的身份证号哈希，组织身份为空
+type testIdentity struct {
+	cid.ClientIdentity
+	mspID      string
+	commonName string
+}
+
+func (identity *testIdentity) GetID() (string, error) {
+	return "x509::CN=" + identity.commonName, nil
+}
+
+func (identity *testIdentity) GetMSPID() (string, error) {
+	return identity.mspID, nil
+}
+
+func (identity *testIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return &x509.Certificate{Subject: pkix.Name{CommonName: identity.commonName}}, nil
+}
+
+func (stub *testStub) SetEvent(name string, payload []byte) error {
+	stub.eventList = append(stub.eventList, &testEvent{name: name, payload: payload})
+	return nil
+}
+
+func (stub *testStub) GetTxID() string {
+	return "test-tx"
+}
+
+func (stub *testStub) GetTxTimestamp() (*timestamppb.Timestamp, error) {
+	return timestamppb.New(stub.txTime), nil
+}
+
+func (stub *testStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (stub *testStub) GetState(key string) ([]byte, error) {
+	return stub.state[key], nil
+}
+
+func (stub *testStub) PutState(key string, value []byte) error {
+	stub.state[key] = value
+	return nil
+}
+
+func (stub *testStub) GetPrivateData(collection string, key string) ([]byte, error) {
+	return stub.privateData[collection][key], nil
+}
+
+func (stub *testStub) PutPrivateData(collection string, key string, value []byte) error {
+	if stub.privateData[collection] == nil {
+		stub.privateData[collection] = map[string][]byte{}
+	}
+	stub.privateData[collection][key] = value
+	return nil
+}
+
+func (stub *testStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) *peer.Response {
+	argList := make([]string, 0, len(args))
+	for _, arg := range args {
+		argList = append(argList, string(arg))
+	}
+	result, err := stub.invoke(argList)
+	if err != nil {
+		return &peer.Response{Status: shim.ERROR, Message: err.Error()}
+	}
+	payload, err := json.Marshal(result)
+	if err != nil {
+		return &peer.Response{Status: shim.ERROR, Message: err.Error()}
+	}
+	return &peer.Response{Status: shim.OK, Payload: payload}
+}
+
+func (stub *testStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	stub.queryList = append(stub.queryList, query)
+	iterator := &testIterator{}
+	for i, record := range stub.queryResult {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		iterator.kvList = append(iterator.kvList, &queryresult.KV{Key: fmt.Sprintf("record-%d", i), Value: value})
+	}
+	return iterator, nil
+}
+
+func (stub *testStub) GetPrivateDataByPartialCompositeKey(collection string, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	iterator := &testIterator{}
+	for key, value := range stub.privateData[collection] {
+		if strings.HasPrefix(key, prefix) {
+			iterator.kvList = append(iterator.kvList, &queryresult.KV{Key: key, Value: value})
+		}
+	}
+	sort.Slice(iterator.kvList, func(i, j int) bool { return iterator.kvList[i].Key < iterator.kvList[j].Key })
+	return iterator, nil
+}
+
+// testIterator 富查询结果迭代器
+type testIterator struct {
+	kvList []*queryresult.KV
+}
+
+func (iterator *testIterator) HasNext() bool {
+	return len(iterator.kvList) > 0
+}
+
+func (iterator *testIterator) Next() (*queryresult.KV, error) {
+	kv := iterator.kvList[0]
+	iterator.kvList = iterator.kvList[1:]
+	return kv, nil
+}
+
+func (iterator *testIterator) Close() error {
+	return nil
+}
+
+func TestAssessTax(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	testCaseList := []struct {
+		name          string
+		realty        *models.Realty
+		ownedHouses   int
+		isFirstHome   string
+		holdingPeriod string
+		taxRate       models.TaxRate
+		taxList       []models.Tax
+	}{
+		{
+			name:          "首套住宅持有不满两年",
+			realty:        &models.Realty{RealtyCertHash: "realty-1", RealtyType: constances.RealtyTypeHouse, AcquireTime: now.AddDate(-1, 0, 0)},
+			isFirstHome:   "true",
+			holdingPeriod: constances.HoldingPeriodLessThanTwoYears,
+			taxRate:       models.TaxRate{DeedTaxRate: 0.01, VATRate: 0.053, IncomeTaxRate: 0.01},
+			taxList: []models.Tax{
+				{TaxType: constances.TaxTypeDeedTax, PayerRole: constances.TaxPayerBuyer, TaxRate: 0.01, TaxAmount: 10000},
+				{TaxType: constances.TaxTypeVAT, PayerRole: constances.TaxPayerSeller, TaxRate: 0.053, TaxAmount: 53000},
+				{TaxType: constances.TaxTypeIncomeTax, PayerRole: constances.TaxPayerSeller, TaxRate: 0.01, TaxAmount: 10000},
+			},
+		},
+		{
+			name:          "二套住宅持有满两年不满五年",
+			realty:        &models.Realty{RealtyCertHash: "realty-1", RealtyType: constances.RealtyTypeHouse, AcquireTime: now.AddDate(-3, 0, 0)},
+			ownedHouses:   1,
+			isFirstHome:   "false",
+			holdingPeriod: constances.HoldingPeriodTwoToFiveYears,
+			taxRate:       models.TaxRate{DeedTaxRate: 0.03, IncomeTaxRate: 0.01},
+			taxList: []models.Tax{
+				{TaxType: constances.TaxTypeDeedTax, PayerRole: constances.TaxPayerBuyer, TaxRate: 0.03, TaxAmount: 30000},
+				{TaxType: constances.TaxTypeIncomeTax, PayerRole: constances.TaxPayerSeller, TaxRate: 0.01, TaxAmount: 10000},
+			},
+		},
+		{
+			name:          "缺少取得时间时按登记时间计算持有年限",
+			realty:        &models.Realty{RealtyCertHash: "realty-1", RealtyType: constances.RealtyTypeHouse, CreateTime: now.AddDate(-6, 0, 0)},
+			isFirstHome:   "true",
+			holdingPeriod: constances.HoldingPeriodOverFiveYears,
+			taxRate:       models.TaxRate{DeedTaxRate: 0.01},
+			taxList: []models.Tax{
+				{TaxType: constances.TaxTypeDeedTax, PayerRole: constances.TaxPayerBuyer, TaxRate: 0.01, TaxAmount: 10000},
+			},
+		},
+		{
+			name:          "持有刚满两年",
+			realty:        &models.Realty{RealtyCertHash: "realty-1", RealtyType: constances.RealtyTypeHouse, AcquireTime: now.AddDate(-2, 0, 0)},
+			isFirstHome:   "true",
+			holdingPeriod: constances.HoldingPeriodTwoToFiveYears,
+			taxRate:       models.TaxRate{DeedTaxRate: 0.01},
+			taxList: []models.Tax{
+				{TaxType: constances.TaxTypeDeedTax, PayerRole: constances.TaxPayerBuyer, TaxRate: 0.01, TaxAmount: 10000},
+			},
+		},
+		{
+			name:          "非住宅不适用首套认定",
+			realty:        &models.Realty{RealtyCertHash: "realty-1", RealtyType: constances.RealtyTypeShop, AcquireTime: now.AddDate(-1, 0, 0)},
+			isFirstHome:   "false",
+			holdingPeriod: constances.HoldingPeriodLessThanTwoYears,
+			taxRate:       models.TaxRate{DeedTaxRate: 0.03, VATRate: 0.053, IncomeTaxRate: 0.015},
+			taxList: []models.Tax{
+				{TaxType: constances.TaxTypeDeedTax, PayerRole: constances.TaxPayerBuyer, TaxRate: 0.03, TaxAmount: 30000},
+				{TaxType: constances.TaxTypeVAT, PayerRole: constances.TaxPayerSeller, TaxRate: 0.053, TaxAmount: 53000},
+				{TaxType: constances.TaxTypeIncomeTax, PayerRole: constances.TaxPayerSeller, TaxRate: 0.015, TaxAmount: 15000},
+			},
+		},
+	}
+
+	for _, testCase := range testCaseList {
+		t.Run(testCase.name, func(t *testing.T) {
+			stub := newTestStub(now)
+			for i := 0; i < testCase.ownedHouses; i++ {
+				stub.queryResult = append(stub.queryResult, &models.RealtyPublic{RealtyType: constances.RealtyTypeHouse})
+			}
+			var taxRateArgs []string
+			stub.invoke = func(args []string) (interface{}, error) {
+				switch args[0] {
+				case "GetRealtyIndex":
+					return &models.RealtyIndex{RealtyCertHash: args[1], ProvinceCode: "31"}, nil
+				case "GetTaxRate":
+					taxRateArgs = args[1:]
+					return &testCase.taxRate, nil
+				}
+				return nil, fmt.Errorf("未知函数: %s", args[0])
+			}
+
+			taxList, err := (&SmartContract{}).assessTax(newTestContext(stub), testCase.realty, "buyer", "investor", 1000000)
+			if err != nil {
+				t.Fatalf("核定税费失败: %v", err)
+			}
+
+			expectedArgs := []string{"31", testCase.realty.RealtyType, testCase.isFirstHome, testCase.holdingPeriod}
+			if fmt.Sprint(taxRateArgs) != fmt.Sprint(expectedArgs) {
+				t.Errorf("查询税率参数 = %v, 期望 %v", taxRateArgs, expectedArgs)
+			}
+			if testCase.realty.RealtyType != constances.RealtyTypeHouse && len(stub.queryList) > 0 {
+				t.Errorf("非住宅不应查询买方名下住宅")
+			}
+
+			if len(taxList) != len(testCase.taxList) {
+				t.Fatalf("税费明细数量 = %d, 期望 %d", len(taxList), len(testCase.taxList))
+			}
+			for i, expected := range testCase.taxList {
+				tax := taxList[i]
+				if tax.TaxType != expected.TaxType || tax.PayerRole != expected.PayerRole {
+					t.Errorf("第%d项税费 = %s/%s, 期望 %s/%s", i+1, tax.TaxType, tax.PayerRole, expected.TaxType, expected.PayerRole)
+				}
+				if tax.TaxRate != expected.TaxRate || tax.TaxAmount != expected.TaxAmount {
+					t.Errorf("%s 税率 = %v, 税额 = %.2f, 期望 %v, %.2f", tax.TaxType, tax.TaxRate, tax.TaxAmount, expected.TaxRate, expected.TaxAmount)
+				}
+				if tax.TaxBase != 1000000 || tax.Status != constances.TaxStatusUnpaid || !tax.CreateTime.Equal(now) {
+					t.Errorf("%s 计税依据、状态或核定时间不正确", tax.TaxType)
+				}
+			}
+		})
+	}
+}
+
+// putTestUser 保存余额为0的测试用户
+func putTestUser(t *testing.T, stub *testStub, citizenIDHash string, organization string) {
+	t.Helper()
+	key, err := shim.CreateCompositeKey(constances.DocTypeUser, []string{citizenIDHash, organization})
+	if err != nil {
+		t.Fatalf("创建复合键失败: %v", err)
+	}
+	userPublic, _ := json.Marshal(&models.UserPublic{Organization: organization})
+	userPrivate, _ := json.Marshal(&models.UserPrivate{})
+	stub.state[key] = userPublic
+	if err := stub.PutPrivateData(constances.UserDataCollection, key, userPrivate); err != nil {
+		t.Fatalf("保存用户失败: %v", err)
+	}
+}
+
+func getTestBalance(t *testing.T, stub *testStub, citizenIDHash string, organization string) float64 {
+	t.Helper()
+	key, _ := shim.CreateCompositeKey(constances.DocTypeUser, []string{citizenIDHash, organization})
+	var userPrivate models.UserPrivate
+	if err := json.Unmarshal(stub.privateData[constances.UserDataCollection][key], &userPrivate); err != nil {
+		t.Fatalf("解析用户余额失败: %v", err)
+	}
+	return userPrivate.Balance
+}
+
+func TestReleaseEscrow(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	governmentHash := tools.GenerateHash(constances.GovernmentDefaultCitizenID)
+
+	testCaseList := []struct {
+		name           string
+		ownerList      []*models.RealtyOwner
+		transferAmount float64
+		price          float64
+		balanceList    map[string]float64
+	}{
+		{
+			name:           "单一所有人",
+			ownerList:      []*models.RealtyOwner{{CitizenIDHash: "seller-1", Organization: "investor", Share: 100}},
+			transferAmount: 1000000,
+			price:          1000000,
+			balanceList:    map[string]float64{"seller-1": 1000000, "buyer": 0},
+		},
+		{
+			name: "按份额分配，尾差计入最后一位共有人",
+			ownerList: []*models.RealtyOwner{
+				{CitizenIDHash: "seller-1", Organization: "investor", Share: 100.0 / 3},
+				{CitizenIDHash: "seller-2", Organization: "investor", Share: 100.0 / 3},
+				{CitizenIDHash: "seller-3", Organization: "investor", Share: 100.0 / 3},
+			},
+			transferAmount: 100.01,
+			price:          100.01,
+			balanceList:    map[string]float64{"seller-1": 33.34, "seller-2": 33.34, "seller-3": 33.33, "buyer": 0},
+		},
+		{
+			name: "超出成交价的房款退还买方",
+			ownerList: []*models.RealtyOwner{
+				{CitizenIDHash: "seller-1", Organization: "investor", Share: 60},
+				{CitizenIDHash: "seller-2", Organization: "investor", Share: 40},
+			},
+			transferAmount: 1000500,
+			price:          1000000,
+			balanceList:    map[string]float64{"seller-1": 600000, "seller-2": 400000, "buyer": 500},
+		},
+		{
+			name: "买方是共有人时合并入账",
+			ownerList: []*models.RealtyOwner{
+				{CitizenIDHash: "seller-1", Organization: "investor", Share: 50},
+				{CitizenIDHash: "buyer", Organization: "investor", Share: 50},
+			},
+			transferAmount: 1000100,
+			price:          1000000,
+			balanceList:    map[string]float64{"seller-1": 500000, "buyer": 500100},
+		},
+	}
+
+	for _, testCase := range testCaseList {
+		t.Run(testCase.name, func(t *testing.T) {
+			stub := newTestStub(now)
+			ctx := newTestContext(stub)
+			for citizenIDHash := range testCase.balanceList {
+				putTestUser(t, stub, citizenIDHash, "investor")
+			}
+			putTestUser(t, stub, governmentHash, constances.GovernmentDefaultOrganization)
+
+			s := &SmartContract{}
+			transactionPublic := &models.TransactionPublic{
+				TransactionUUID:     "transaction-1",
+				BuyerCitizenIDHash:  "buyer",
+				BuyerOrganization:   "investor",
+				SellerCitizenIDHash: testCase.ownerList[0].CitizenIDHash,
+				SellerOrganization:  "investor",
+			}
+			payment := &models.Payment{PaymentUUID: "payment-1", Amount: testCase.transferAmount, Status: constances.PaymentStatusBankVerified}
+			if err := s.putPayment(ctx, payment); err != nil {
+				t.Fatalf("保存支付失败: %v", err)
+			}
+			escrow, err := s.getEscrow(ctx, transactionPublic)
+			if err != nil {
+				t.Fatalf("查询托管账户失败: %v", err)
+			}
+			escrow.TransferAmount = testCase.transferAmount
+			escrow.TaxAmount = 3000
+			escrow.DepositList = []*models.EscrowDeposit{{PaymentUUID: "payment-1", PayerCitizenIDHash: "buyer", PayerOrganization: "investor", Amount: testCase.transferAmount}}
+			if err := s.putEscrow(ctx, escrow); err != nil {
+				t.Fatalf("保存托管账户失败: %v", err)
+			}
+
+			if err := s.releaseEscrow(ctx, transactionPublic, testCase.ownerList, testCase.price, now); err != nil {
+				t.Fatalf("放款失败: %v", err)
+			}
+
+			total := 0.0
+			for citizenIDHash, expected := range testCase.balanceList {
+				balance := getTestBalance(t, stub, citizenIDHash, "investor")
+				if math.Abs(balance-expected) > 0.001 {
+					t.Errorf("%s 余额 = %.2f, 期望 %.2f", citizenIDHash, balance, expected)
+				}
+				total += balance
+			}
+			if math.Abs(total-testCase.transferAmount) > 0.001 {
+				t.Errorf("放款总额 = %.2f, 期望等于托管房款 %.2f", total, testCase.transferAmount)
+			}
+			if balance := getTestBalance(t, stub, governmentHash, constances.GovernmentDefaultOrganization); balance != 3000 {
+				t.Errorf("政府账户余额 = %.2f, 期望 3000.00", balance)
+			}
+
+			escrow, err = s.getEscrow(ctx, transactionPublic)
+			if err != nil {
+				t.Fatalf("查询托管账户失败: %v", err)
+			}
+			if escrow.Status != constances.EscrowStatusReleased || !escrow.SettleTime.Equal(now) {
+				t.Errorf("托管状态 = %s, 期望 %s", escrow.Status, constances.EscrowStatusReleased)
+			}
+			payment, err = s.getPayment(ctx, "payment-1")
+			if err != nil {
+				t.Fatalf("查询支付失败: %v", err)
+			}
+			if payment.Status != constances.PaymentStatusSettled {
+				t.Errorf("支付状态 = %s, 期望 %s", payment.Status, constances.PaymentStatusSettled)
+			}
+
+			// 已放款的托管账户不能再次放款
+			if err := s.releaseEscrow(ctx, transactionPublic, testCase.ownerList, testCase.price, now); err == nil {
+				t.Errorf("期望重复放款失败")
+			}
+		})
+	}
+}
+
+func TestReleaseEscrowInsufficient(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	stub := newTestStub(now)
+	ctx := newTestContext(stub)
+	s := &SmartContract{}
+
+	transactionPublic := &models.TransactionPublic{TransactionUUID: "transaction-1", BuyerCitizenIDHash: "buyer", BuyerOrganization: "investor"}
+	escrow, err := s.getEscrow(ctx, transactionPublic)
+	if err != nil {
+		t.Fatalf("查询托管账户失败: %v", err)
+	}
+	escrow.TransferAmount = 999999.98
+	if err := s.putEscrow(ctx, escrow); err != nil {
+		t.Fatalf("保存托管账户失败: %v", err)
+	}
+
+	ownerList := []*models.RealtyOwner{{CitizenIDHash: "seller-1", Organization: "investor", Share: 100}}
+	if err := s.releaseEscrow(ctx, transactionPublic, ownerList, 1000000, now); err == nil {
+		t.Errorf("期望托管房款不足时放款失败")
+	}
+}
+
+func TestIsTransactionParty(t *testing.T) {
+	buyerHash := tools.GenerateHash("buyer")
+	sellerHash := tools.GenerateHash("seller")
+	transactionPublic := &models.TransactionPublic{
+		BuyerCitizenIDHash:  buyerHash,
+		BuyerOrganization:   "investor",
+		SellerCitizenIDHash: sellerHash,
+		SellerOrganization:  "investor",
+	}
+	newHouseTransaction := &models.TransactionPublic{
+		BuyerCitizenIDHash:  buyerHash,
+		BuyerOrganization:   "investor",
+		SellerCitizenIDHash: tools.GenerateHash("government"),
+		SellerOrganization:  constances.GovernmentDefaultOrganization,
+	}
+
+	testCaseList := []struct {
+		name                string
+		clientMSPID         string
+		clientCitizenIDHash string
+		transactionPublic   *models.TransactionPublic
+		step                string
+		allowed             bool
+	}{
+		{"买方签署", constances.InvestorMSP, buyerHash, transactionPublic, constances.TxStepBuyerSigned, true},
+		{"卖方代替买方签署", constances.InvestorMSP, sellerHash, transactionPublic, constances.TxStepBuyerSigned, false},
+		{"买方代替卖方签署", constances.InvestorMSP, buyerHash, transactionPublic, constances.TxStepSellerSigned, false},
+		{"投资者组织身份签署", constances.InvestorMSP, "", transactionPublic, constances.TxStepBuyerSigned, false},
+		{"其他组织的同一用户签署", constances.BankMSP, buyerHash, transactionPublic, constances.TxStepBuyerSigned, false},
+		{"政府代表卖方签署新房", constances.GovernmentMSP, "", newHouseTransaction, constances.TxStepSellerSigned, true},
+		{"政府审批", constances.GovernmentMSP, "", transactionPublic, constances.TxStepGovernmentApproved, true},
+		{"政府代替卖方签署", constances.GovernmentMSP, "", transactionPublic, constances.TxStepSellerSigned, false},
+		{"银行确认托管", constances.BankMSP, "", transactionPublic, constances.TxStepFundsEscrowed, true},
+		{"卖方确认托管", constances.InvestorMSP, sellerHash, transactionPublic, constances.TxStepFundsEscrowed, false},
+	}
+
+	contract := &SmartContract{}
+	for _, testCase := range testCaseList {
+		t.Run(testCase.name, func(t *testing.T) {
+			allowed := contract.isTransactionParty(testCase.clientMSPID, testCase.clientCitizenIDHash,
+				testCase.transactionPublic, transactionStepTable[testCase.step].partyList)
+			if allowed != testCase.allowed {
+				t.Errorf("允许执行步骤 = %t, 期望 %t", allowed, testCase.allowed)
+			}
+		})
+	}
+}
+
+func TestMortgagedRealtyListing(t *testing.T) {
+	activeListed := &models.MortgagePublic{Status: constances.MortgageStatusActive, PreviousRealtyStatus: constances.RealtyStatusPendingSale}
+	assumedListed := &models.MortgagePublic{Status: constances.MortgageStatusAssumed, PreviousRealtyStatus: constances.RealtyStatusPendingSale}
+	activeNormal := &models.MortgagePublic{Status: constances.MortgageStatusActive, PreviousRealtyStatus: constances.RealtyStatusNormal}
+	releasedNormal := &models.MortgagePublic{Status: constances.MortgageStatusReleased, PreviousRealtyStatus: constances.RealtyStatusNormal}
+
+	listedCaseList := []struct {
+		name         string
+		mortgageList []*models.MortgagePublic
+		listed       bool
+	}{
+		{"抵押前已挂牌", []*models.MortgagePublic{activeListed}, true},
+		{"已解除的抵押不影响挂牌", []*models.MortgagePublic{activeListed, assumedListed, releasedNormal}, true},
+		{"抵押前未挂牌", []*models.MortgagePublic{activeNormal}, false},
+		{"部分抵押记录未挂牌", []*models.MortgagePublic{activeListed, activeNormal}, false},
+		{"没有生效的抵押", []*models.MortgagePublic{releasedNormal}, false},
+	}
+	for _, testCase := range listedCaseList {
+		if listed := isMortgagedRealtyListed(testCase.mortgageList); listed != testCase.listed {
+			t.Errorf("%s: 已挂牌 = %t, 期望 %t", testCase.name, listed, testCase.listed)
+		}
+	}
+
+	previousCaseList := []struct {
+		name         string
+		realtyStatus string
+		mortgageList []*models.MortgagePublic
+		previous     string
+	}{
+		{"挂牌房产抵押", constances.RealtyStatusPendingSale, nil, constances.RealtyStatusPendingSale},
+		{"正常房产抵押", constances.RealtyStatusNormal, nil, constances.RealtyStatusNormal},
+		{"再次抵押沿用首次抵押前的状态", constances.RealtyStatusInMortgage, []*models.MortgagePublic{releasedNormal, activeListed}, constances.RealtyStatusPendingSale},
+	}
+	for _, testCase := range previousCaseList {
+		realty := &models.Realty{Status: testCase.realtyStatus}
+		if previous := previousRealtyStatus(realty, testCase.mortgageList); previous != testCase.previous {
+			t.Errorf("%s: 抵押前状态 = %s, 期望 %s", testCase.name, previous, testCase.previous)
+		}
+	}
+}
+
+// putTestTransaction 保存交易中的房产和交易，房产由唯一所有者（卖方）持有
+func putTestTransaction(t *testing.T, stub *testStub, transactionPublic *models.TransactionPublic, price float64) {
+	t.Helper()
+	realtyKey, _ := shim.CreateCompositeKey(constances.DocTypeRealEstate, []string{transactionPublic.RealtyCertHash})
+	ownerList := []*models.RealtyOwner{{CitizenIDHash: transactionPublic.SellerCitizenIDHash, Organization: transactionPublic.SellerOrganization, Share: 100}}
+	realty, _ := json.Marshal(&models.Realty{
+		DocType:                   constances.DocTypeRealEstate,
+		RealtyCertHash:            transactionPublic.RealtyCertHash,
+		RealtyType:                "HOUSE",
+		CurrentOwnerCitizenIDHash: transactionPublic.SellerCitizenIDHash,
+		CurrentOwnerOrganization:  transactionPublic.SellerOrganization,
+		OwnerList:                 ownerList,
+		Status:                    constances.RealtyStatusInSale,
+		ActiveTransactionUUID:     transactionPublic.TransactionUUID,
+	})
+	realtyPrivate, _ := json.Marshal(&models.RealtyPrivate{
+		RealtyCertHash:            transactionPublic.RealtyCertHash,
+		CurrentOwnerCitizenIDHash: transactionPublic.SellerCitizenIDHash,
+		CurrentOwnerOrganization:  transactionPublic.SellerOrganization,
+		OwnerList:                 ownerList,
+	})
+	stub.state[realtyKey] = realty
+	if err := stub.PutPrivateData(constances.RealEstatePrivateCollection, realtyKey, realtyPrivate); err != nil {
+		t.Fatalf("保存房产失败: %v", err)
+	}
+
+	transactionKey, _ := shim.CreateCompositeKey(constances.DocTypeTransaction, []string{transactionPublic.TransactionUUID})
+	transaction, _ := json.Marshal(transactionPublic)
+	transactionPrivate, _ := json.Marshal(&models.TransactionPrivate{TransactionUUID: transactionPublic.TransactionUUID, Price: price})
+	stub.state[transactionKey] = transaction
+	if err := stub.PutPrivateData(constances.TransactionPrivateCollection, transactionKey, transactionPrivate); err != nil {
+		t.Fatalf("保存交易失败: %v", err)
+	}
+}
+
+// onlyTestEvent 检查交易只发送了一个事件并解析其内容
+func onlyTestEvent(t *testing.T, stub *testStub, eventType string) *models.TransactionEvent {
+	t.Helper()
+	if len(stub.eventList) != 1 {
+		nameList := []string{}
+		for _, event := range stub.eventList {
+			nameList = append(nameList, event.name)
+		}
+		t.Fatalf("发送的事件 = %v, 期望只发送 %s（Fabric只保留最后一个事件）", nameList, eventType)
+	}
+	if stub.eventList[0].name != eventType {
+		t.Fatalf("事件类型 = %s, 期望 %s", stub.eventList[0].name, eventType)
+	}
+	var event struct {
+		EventType string                  `json:"eventType"`
+		Payload   models.TransactionEvent `json:"payload"`
+	}
+	if err := json.Unmarshal(stub.eventList[0].payload, &event); err != nil {
+		t.Fatalf("解析事件失败: %v", err)
+	}
+	return &event.Payload
+}
+
+func TestTransactionEventCarriesRealty(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	sellerHash := tools.GenerateHash("seller")
+	buyerHash := tools.GenerateHash("buyer")
+	newTransaction := func() *models.TransactionPublic {
+		return &models.TransactionPublic{
+			DocType:             constances.DocTypeTransaction,
+			TransactionUUID:     "transaction-1",
+			RealtyCertHash:      tools.GenerateHash("realty"),
+			SellerCitizenIDHash: sellerHash,
+			SellerOrganization:  "investor",
+			BuyerCitizenIDHash:  buyerHash,
+			BuyerOrganization:   "investor",
+			Status:              constances.TxStatusInProgress,
+			CompletedStepList: []string{
+				constances.TxStepBuyerSigned,
+				constances.TxStepSellerSigned,
+				constances.TxStepGovernmentApproved,
+				constances.TxStepFundsEscrowed,
+				constances.TxStepTaxPaid,
+			},
+		}
+	}
+
+	t.Run("过户事件包含新所有者", func(t *testing.T) {
+		stub := newTestStub(now)
+		ctx := newTestContext(stub)
+		ctx.SetClientIdentity(&testIdentity{mspID: constances.GovernmentMSP})
+		s := &SmartContract{}
+
+		transactionPublic := newTransaction()
+		putTestTransaction(t, stub, transactionPublic, 1000000)
+		putTestUser(t, stub, sellerHash, "investor")
+		putTestUser(t, stub, buyerHash, "investor")
+		putTestUser(t, stub, tools.GenerateHash(constances.GovernmentDefaultCitizenID), constances.GovernmentDefaultOrganization)
+		escrow, err := s.getEscrow(ctx, transactionPublic)
+		if err != nil {
+			t.Fatalf("查询托管账户失败: %v", err)
+		}
+		escrow.TransferAmount = 1000000
+		if err := s.putEscrow(ctx, escrow); err != nil {
+			t.Fatalf("保存托管账户失败: %v", err)
+		}
+
+		if err := s.ConfirmTransactionStep(ctx, transactionPublic.TransactionUUID, constances.TxStepTitleTransferred); err != nil {
+			t.Fatalf("过户失败: %v", err)
+		}
+
+		event := onlyTestEvent(t, stub, constances.EventTransactionCompleted)
+		if event.Status != constances.TxStatusCompleted || event.Step != constances.TxStepTitleTransferred {
+			t.Errorf("交易状态 = %s, 步骤 = %s", event.Status, event.Step)
+		}
+		if event.RealtyStatus != constances.RealtyStatusNormal {
+			t.Errorf("房产状态 = %s, 期望 %s", event.RealtyStatus, constances.RealtyStatusNormal)
+		}
+		if event.RealtyOwnerCitizenIDHash != buyerHash || event.RealtyOwnerOrganization != "investor" {
+			t.Errorf("房产所有者 = %s/%s, 期望为买方", event.RealtyOwnerCitizenIDHash, event.RealtyOwnerOrganization)
+		}
+	})
+
+	t.Run("取消交易事件包含解锁后的房产状态", func(t *testing.T) {
+		stub := newTestStub(now)
+		ctx := newTestContext(stub)
+		ctx.SetClientIdentity(&testIdentity{mspID: constances.InvestorMSP, commonName: buyerHash})
+		s := &SmartContract{}
+
+		transactionPublic := newTransaction()
+		putTestTransaction(t, stub, transactionPublic, 1000000)
+
+		if err := s.CancelTransaction(ctx, transactionPublic.TransactionUUID); err != nil {
+			t.Fatalf("取消交易失败: %v", err)
+		}
+
+		event := onlyTestEvent(t, stub, constances.EventTransactionUpdated)
+		if event.Status != constances.TxStatusCancelled {
+			t.Errorf("交易状态 = %s, 期望 %s", event.Status, constances.TxStatusCancelled)
+		}
+		if event.RealtyStatus != constances.RealtyStatusPendingSale || event.RealtyOwnerCitizenIDHash != sellerHash {
+			t.Errorf("房产状态 = %s, 所有者 = %s, 期望卖方持有的挂牌房产", event.RealtyStatus, event.RealtyOwnerCitizenIDHash)
+		}
+	})
+
+	t.Run("非当事人不能取消交易", func(t *testing.T) {
+		stub := newTestStub(now)
+		ctx := newTestContext(stub)
+		ctx.SetClientIdentity(&testIdentity{mspID: constances.InvestorMSP, commonName: tools.GenerateHash("other")})
+		s := &SmartContract{}
+
+		transactionPublic := newTransaction()
+		putTestTransaction(t, stub, transactionPublic, 1000000)
+
+		if err := s.CancelTransaction(ctx, transactionPublic.TransactionUUID); err == nil {
+			t.Fatalf("期望非当事人取消交易失败")
+		}
+		if len(stub.eventList) != 0 {
+			t.Errorf("取消失败时不应发送事件")
+		}
+	})
+}
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
@@ -34,3 +35,22 @@ func SelectByQueryString[T interface{}](ctx contractapi.TransactionContextInterf
 
 	return ConstructResultByIterator[T](resultsIterator)
 }
+
+// 跨通道调用链码（只读查询），并将返回结果解析为指定类型
+func InvokeChaincode[T interface{}](ctx contractapi.TransactionContextInterface, chaincodeName string, channelName string, args ...string) (*T, error) {
+	invokeArgs := make([][]byte, 0, len(args))
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+
+	response := ctx.GetStub().InvokeChaincode(chaincodeName, invokeArgs, channelName)
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("调用链码%s失败: %s", chaincodeName, response.Message)
+	}
+
+	var result T
+	if err := json.Unmarshal(response.Payload, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+)
+
+// 验证DID密钥签名：公钥为04开头的未压缩P-256公钥，签名为32字节r和32字节s拼接，均为十六进制，消息先做SHA-256
+func VerifySignature(publicKeyHex string, message string, signatureHex string) error {
+	keyBytes, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return fmt.Errorf("解码公钥失败: %v", err)
+	}
+	if len(keyBytes) != 65 || keyBytes[0] != 0x04 {
+		return fmt.Errorf("无效的公钥格式")
+	}
+	publicKey := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(keyBytes[1:33]),
+		Y:     new(big.Int).SetBytes(keyBytes[33:65]),
+	}
+	if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+		return fmt.Errorf("公钥不在曲线上")
+	}
+
+	signatureBytes, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return fmt.Errorf("解码签名失败: %v", err)
+	}
+	if len(signatureBytes) != 64 {
+		return fmt.Errorf("无效的签名长度")
+	}
+	r := new(big.Int).SetBytes(signatureBytes[:32])
+	s := new(big.Int).SetBytes(signatureBytes[32:])
+
+	hash := sha256.Sum256([]byte(message))
+	if !ecdsa.Verify(publicKey, hash[:], r, s) {
+		return fmt.Errorf("签名验证失败")
+	}
+	return nil
+}
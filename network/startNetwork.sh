@@ -54,12 +54,16 @@ MainChainCodeName="mainchaincode"
 SubChannelName="${SUB_CHANNEL_NAME}"
 SubChainCodeName="shanghaigretschaincode"
 
+# 审计日志链码（部署在主通道）
+AuditChainCodeName="auditlogs"
+
 # 共用配置
 Version="1.0.0"
 Sequence="1"
 CHAINCODE_PATH="/opt/gopath/src/chaincode"
 MAIN_CHAINCODE_PACKAGE="${CHAINCODE_PATH}/main_channel/mainchain_${Version}.tar.gz"
 SUB_CHAINCODE_PACKAGE="${CHAINCODE_PATH}/parent_chain/parentchain_${Version}.tar.gz"
+AUDIT_CHAINCODE_PACKAGE="${CHAINCODE_PATH}/audit_logs/auditlogs_${Version}.tar.gz"
 
 # Order 配置
 ORDERER1_ADDRESS="orderer1.${DOMAIN}:7050"
@@ -389,6 +393,9 @@ main() {
     # 打包子通道链码 (使用parent_chain的链码)
     execute_with_timer "打包子通道链码" "$CLI_CMD \"peer lifecycle chaincode package ${SUB_CHAINCODE_PACKAGE} --path ${CHAINCODE_PATH}/parent_chain --lang golang --label parentchain_${Version}\""
 
+    # 打包审计日志链码
+    execute_with_timer "打包审计日志链码" "$CLI_CMD \"peer lifecycle chaincode package ${AUDIT_CHAINCODE_PACKAGE} --path ${CHAINCODE_PATH}/audit_logs --lang golang --label auditlogs_${Version}\""
+
     # 安装主通道和子通道链码
     show_progress 13 "安装链码" $start_time
     
@@ -416,6 +423,18 @@ main() {
         done
     done
 
+    # 所有组织安装审计日志链码
+    log_info "所有组织安装审计日志链码"
+    for org in ${OrganizationList[@]}; do
+        for ((i=0; i < $peerNumber; i++)); do
+            local org_cap="$(tr '[:lower:]' '[:upper:]' <<< ${org:0:1})${org:1}"
+            local OrgPeerCli="${org_cap}Peer${i}Cli"
+            local cli_value=$(eval echo "\$${OrgPeerCli}")
+            
+            execute_with_timer "${org_cap}Peer${i}安装审计日志链码" "$CLI_CMD \"${cli_value} peer lifecycle chaincode install ${AUDIT_CHAINCODE_PACKAGE}\""
+        done
+    done
+
     # 批准和提交主子通道链码
     show_progress 14 "批准和提交链码" $start_time
 
@@ -462,6 +481,28 @@ main() {
     --peerAddresses $INVESTOR_PEER0_ADDRESS --tlsRootCertFiles $INVESTOR_PEER0_TLS_ROOTCERT_FILE \
     --peerAddresses $AUDIT_PEER0_ADDRESS --tlsRootCertFiles $AUDIT_PEER0_TLS_ROOTCERT_FILE --waitForEvent --waitForEventTimeout ${PEER_OPERATION_TIMEOUT}\""
 
+    # 处理审计日志链码
+    log_info "处理审计日志链码"
+    AuditPackageID=$($CLI_CMD "${GovernmentPeer0Cli} peer lifecycle chaincode calculatepackageid ${AUDIT_CHAINCODE_PACKAGE}")
+    # 批准审计日志链码
+    for org in ${OrganizationList[@]}; do
+        for ((i=0; i < $peerNumber; i++)); do
+            local org_cap="$(tr '[:lower:]' '[:upper:]' <<< ${org:0:1})${org:1}"
+            local OrgPeerCli="${org_cap}Peer${i}Cli"
+            local cli_value=$(eval echo "\$${OrgPeerCli}")
+            
+            execute_with_timer "${org_cap}批准审计日志链码" "$CLI_CMD \"${cli_value} peer lifecycle chaincode approveformyorg -o $ORDERER1_ADDRESS --channelID $MainChannelName --name $AuditChainCodeName --version $Version --package-id $AuditPackageID --sequence $Sequence --tls --cafile $ORDERER1_CA --waitForEvent --waitForEventTimeout ${PEER_OPERATION_TIMEOUT}\""
+        done
+    done
+
+    # 提交审计日志链码
+    execute_with_timer "提交审计日志链码定义" "$CLI_CMD \"${GovernmentPeer0Cli} peer lifecycle chaincode commit -o $ORDERER1_ADDRESS --channelID $MainChannelName --name $AuditChainCodeName --version $Version --sequence $Sequence --tls --cafile $ORDERER1_CA \
+    --peerAddresses $GOVERNMENT_PEER0_ADDRESS --tlsRootCertFiles $GOVERNMENT_PEER0_TLS_ROOTCERT_FILE \
+    --peerAddresses $THIRDPARTY_PEER0_ADDRESS --tlsRootCertFiles $THIRDPARTY_PEER0_TLS_ROOTCERT_FILE \
+    --peerAddresses $BANK_PEER0_ADDRESS --tlsRootCertFiles $BANK_PEER0_TLS_ROOTCERT_FILE \
+    --peerAddresses $INVESTOR_PEER0_ADDRESS --tlsRootCertFiles $INVESTOR_PEER0_TLS_ROOTCERT_FILE \
+    --peerAddresses $AUDIT_PEER0_ADDRESS --tlsRootCertFiles $AUDIT_PEER0_TLS_ROOTCERT_FILE --waitForEvent --waitForEventTimeout ${PEER_OPERATION_TIMEOUT}\""
+
     # 初始化并验证所有链码
     show_progress 16 "初始化并验证所有链码" $start_time
 